@@ -8,12 +8,25 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/danieljhkim/monodev/internal/engine"
+	"github.com/danieljhkim/monodev/internal/planner"
 )
 
 var (
-	applyMode   string = "copy"
-	applyForce  bool
-	applyDryRun bool
+	applyForce     bool
+	applyDryRun    bool
+	applyVerbose   bool
+	applyPins      map[string]string
+	applyStrict    bool
+	applyScript    bool
+	applyConfirm   string
+	applyStaged    bool
+	applyOnlyNew   bool
+	applyNoOverlap bool
+	applyPrefix    string
+	applyResume    bool
+	applyWriteLock bool
+	applyLocked    bool
+	applyVars      map[string]string
 )
 
 var applyCmd = &cobra.Command{
@@ -37,17 +50,37 @@ This command applies only a single store - use 'stack apply' to apply the stack.
 		}
 
 		req := &engine.ApplyRequest{
-			CWD:    cwd,
-			Mode:   applyMode,
-			Force:  applyForce,
-			DryRun: applyDryRun,
+			CWD:              cwd,
+			Force:            applyForce,
+			DryRun:           applyDryRun,
+			StorePins:        applyPins,
+			WarningsAsErrors: applyStrict,
+			StagedInstall:    applyStaged,
+			OnlyNew:          applyOnlyNew,
+			RefuseOverlap:    applyNoOverlap,
+			DestPrefix:       applyPrefix,
+			Resume:           applyResume,
+			WriteLock:        applyWriteLock,
+			Locked:           applyLocked,
+			Vars:             applyVars,
+		}
+		if applyScript {
+			if !applyDryRun {
+				return fmt.Errorf("--script requires --dry-run")
+			}
+			req.EmitScript = os.Stdout
 		}
 
 		if len(args) > 0 {
 			req.StoreID = args[0]
 		}
 
-		result, err := eng.Apply(ctx, req)
+		var result *engine.ApplyResult
+		if applyConfirm != "" {
+			result, err = eng.ApplyConfirmed(ctx, &engine.ApplyConfirmedRequest{ApplyRequest: *req, Fingerprint: applyConfirm})
+		} else {
+			result, err = eng.Apply(ctx, req)
+		}
 		if err != nil {
 			if result != nil && result.Plan != nil && result.Plan.HasConflicts() {
 				if jsonOutput {
@@ -63,6 +96,11 @@ This command applies only a single store - use 'stack apply' to apply the stack.
 			return err
 		}
 
+		// The script was written to stdout; keep the output runnable
+		if applyScript {
+			return nil
+		}
+
 		if jsonOutput {
 			return outputJSON(result)
 		}
@@ -85,10 +123,12 @@ This command applies only a single store - use 'stack apply' to apply the stack.
 					default:
 						opType = op.Type
 					}
-					ops = append(ops, fmt.Sprintf("%s: %s", opType, op.RelPath))
+					ops = append(ops, describeOperation(opType, op))
 				}
 				PrintList(ops, 1)
 			}
+			PrintLabelValue("Plan Fingerprint", result.Plan.Fingerprint)
+			PrintInfo("Run with --confirm <fingerprint> to apply exactly this plan")
 			return nil
 		}
 
@@ -99,13 +139,44 @@ This command applies only a single store - use 'stack apply' to apply the stack.
 			}
 		}
 
+		if len(result.Resumed) > 0 {
+			PrintInfo(fmt.Sprintf("Resumed: %s already in place", PrintCount(len(result.Resumed), "path", "paths")))
+		}
 		PrintSuccess(fmt.Sprintf("Applied %s successfully", PrintCount(len(result.Applied), "operation", "operations")))
+		if applyVerbose && len(result.Applied) > 0 {
+			ops := make([]string, 0, len(result.Applied))
+			for _, op := range result.Applied {
+				ops = append(ops, describeOperation(op.Type, op))
+			}
+			PrintList(ops, 1)
+		}
 		PrintLabelValue("Workspace ID", result.WorkspaceID)
 		return nil
 	},
 }
 
+// describeOperation formats an operation for display, with its reason if set.
+func describeOperation(opType string, op planner.Operation) string {
+	if op.Reason == "" {
+		return fmt.Sprintf("%s: %s", opType, op.RelPath)
+	}
+	return fmt.Sprintf("%s: %s (%s)", opType, op.RelPath, op.Reason)
+}
+
 func init() {
 	applyCmd.Flags().BoolVarP(&applyForce, "force", "f", false, "Force apply, overriding conflicts")
 	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Show what would be applied without applying")
+	applyCmd.Flags().BoolVarP(&applyVerbose, "verbose", "v", false, "List each applied operation and why it was needed")
+	applyCmd.Flags().StringToStringVar(&applyPins, "pin", nil, "Apply a store as committed at a sync repo ref (store=ref)")
+	applyCmd.Flags().BoolVar(&applyScript, "script", false, "With --dry-run, print the plan as shell commands")
+	applyCmd.Flags().StringVar(&applyConfirm, "confirm", "", "Apply only if the plan still matches this fingerprint from --dry-run")
+	applyCmd.Flags().BoolVar(&applyStrict, "warnings-as-errors", false, "Fail without changes if planning produces warnings")
+	applyCmd.Flags().BoolVar(&applyStaged, "staged", false, "Build the result in a staging directory, then swap it into place")
+	applyCmd.Flags().BoolVar(&applyNoOverlap, "refuse-overlap", false, "Fail if a nested or enclosing workspace already manages a planned path")
+	applyCmd.Flags().BoolVar(&applyOnlyNew, "only-new", false, "Install only tracked paths not already applied, leaving existing ones untouched")
+	applyCmd.Flags().BoolVar(&applyResume, "resume", false, "Finish an interrupted apply, adopting paths already placed as planned")
+	applyCmd.Flags().StringVar(&applyPrefix, "dest-prefix", "", "Install every tracked path under this workspace-relative directory")
+	applyCmd.Flags().BoolVar(&applyWriteLock, "write-lock", false, "Record the applied store's content hash in monodev.lock")
+	applyCmd.Flags().StringToStringVar(&applyVars, "var", nil, "Set a variable for tracked paths' when conditions (name=value)")
+	applyCmd.Flags().BoolVar(&applyLocked, "locked", false, "Fail without changes unless the store matches monodev.lock")
 }
@@ -40,15 +40,19 @@ Use -n to create a new store if it doesn't exist.`,
 		if createNew {
 			owner, _ := cmd.Flags().GetString("owner")
 			taskID, _ := cmd.Flags().GetString("task-id")
+			anchor, _ := cmd.Flags().GetBool("anchor")
+			template, _ := cmd.Flags().GetString("from-template")
 
 			createReq := &engine.CreateStoreRequest{
-				CWD:         cwd,
-				StoreID:     storeID,
-				Name:        storeID,
-				Scope:       storeScope,
-				Description: storeDesc,
-				Owner:       owner,
-				TaskID:      taskID,
+				CWD:          cwd,
+				StoreID:      storeID,
+				Name:         storeID,
+				Scope:        storeScope,
+				Description:  storeDesc,
+				Owner:        owner,
+				TaskID:       taskID,
+				AnchorToCWD:  anchor,
+				FromTemplate: template,
 			}
 			if err := eng.CreateStore(ctx, createReq); err != nil {
 				return fmt.Errorf("failed to create store: %w", err)
@@ -107,4 +111,6 @@ func init() {
 	checkoutCmd.Flags().String("description", "", "Store description")
 	checkoutCmd.Flags().String("owner", "", "Store owner")
 	checkoutCmd.Flags().String("task-id", "", "External task ID")
+	checkoutCmd.Flags().Bool("anchor", false, "Resolve tracked paths relative to the current directory (with -n)")
+	checkoutCmd.Flags().String("from-template", "", "Seed the new store's overlay and tracked paths from an existing store (with -n)")
 }
@@ -11,8 +11,9 @@ import (
 )
 
 var (
-	clearForce  bool
-	clearDryRun bool
+	clearForce   bool
+	clearDryRun  bool
+	clearUnapply bool
 )
 
 // clearCmd deletes the current workspace state file.
@@ -24,8 +25,8 @@ var clearCmd = &cobra.Command{
 This command auto-discovers the current workspace and deletes it.
 If the workspace has applied overlays, you'll need to use --force to proceed.
 
-IMPORTANT: This only deletes the state file, not the actual workspace files.
-Use 'monodev unapply' first to remove applied overlays from the workspace.`,
+IMPORTANT: This only deletes the state file, not the actual workspace files,
+unless --unapply is set to remove every managed path first.`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		eng, err := newEngine()
@@ -54,6 +55,8 @@ Use 'monodev unapply' first to remove applied overlays from the workspace.`,
 			WorkspaceID: workspaceID,
 			Force:       clearForce,
 			DryRun:      clearDryRun,
+			Unapply:     clearUnapply,
+			CWD:         cwd,
 		}
 
 		result, err := eng.DeleteWorkspace(ctx, req)
@@ -80,6 +83,9 @@ Use 'monodev unapply' first to remove applied overlays from the workspace.`,
 
 		// Success output
 		PrintSection("Clear Workspace")
+		if clearUnapply {
+			PrintSuccess(fmt.Sprintf("Removed %s", PrintCount(len(result.Unapplied), "managed path", "managed paths")))
+		}
 		PrintSuccess(fmt.Sprintf("Cleared workspace: %s", result.WorkspacePath))
 
 		return nil
@@ -89,4 +95,5 @@ Use 'monodev unapply' first to remove applied overlays from the workspace.`,
 func init() {
 	clearCmd.Flags().BoolVarP(&clearForce, "force", "f", false, "Force deletion even if workspace has applied paths")
 	clearCmd.Flags().BoolVar(&clearDryRun, "dry-run", false, "Show what would be deleted without deleting")
+	clearCmd.Flags().BoolVar(&clearUnapply, "unapply", false, "Remove managed files from disk before deleting the state")
 }
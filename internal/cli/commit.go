@@ -13,6 +13,7 @@ import (
 var (
 	commitAll    bool
 	commitDryRun bool
+	commitPin    bool
 )
 
 var commitCmd = &cobra.Command{
@@ -42,6 +43,7 @@ var commitCmd = &cobra.Command{
 			Paths:  args,
 			All:    commitAll,
 			DryRun: commitDryRun,
+			Pin:    commitPin,
 		}
 
 		result, err := eng.Commit(ctx, req)
@@ -92,4 +94,5 @@ var commitCmd = &cobra.Command{
 func init() {
 	commitCmd.Flags().BoolVar(&commitAll, "all", false, "Commit all tracked paths")
 	commitCmd.Flags().BoolVar(&commitDryRun, "dry-run", false, "Show what would be committed without committing")
+	commitCmd.Flags().BoolVar(&commitPin, "pin", false, "Pin committed files' checksums so apply rejects sources changed outside commit")
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/danieljhkim/monodev/internal/clock"
 	"github.com/danieljhkim/monodev/internal/config"
@@ -19,7 +20,7 @@ import (
 )
 
 // newEngine creates a new engine with real implementations of all dependencies.
-func newEngine() (*engine.Engine, error) {
+func newEngine(opts ...engine.Option) (*engine.Engine, error) {
 	// Get scoped paths (global + component)
 	scopedPaths, err := config.NewScopedPaths()
 	if err != nil {
@@ -38,11 +39,32 @@ func newEngine() (*engine.Engine, error) {
 	clk := &clock.RealClock{}
 
 	// Create engine with dual-scope support
-	return engine.NewScoped(gitRepo, scopedPaths, fs, hasher, clk), nil
+	eng := engine.NewScoped(gitRepo, scopedPaths, fs, hasher, clk, opts...)
+	eng.SetGitPersistence(remote.NewRealGitPersistence())
+	eng.SetVersion(rootCmd.Version)
+	settings, err := config.EffectiveSettings()
+	if err != nil {
+		return nil, err
+	}
+	eng.SetSettings(settings)
+	userSettings, err := config.EffectiveUserSettings()
+	if err != nil {
+		return nil, err
+	}
+	eng.SetMaxStoreBytes(stores.ScopeGlobal, userSettings.MaxStoreBytes)
+	if scopedPaths.RepoRoot != "" {
+		settings, err := config.LoadRepoSettings(filepath.Join(scopedPaths.RepoRoot, ".monodev"))
+		if err != nil {
+			return nil, err
+		}
+		eng.SetRepoSettings(settings)
+		eng.SetMaxStoreBytes(stores.ScopeComponent, settings.MaxStoreBytes)
+	}
+	return eng, nil
 }
 
 // newSyncer creates a new syncer with real implementations of all dependencies.
-func newSyncer() (*sync.Syncer, error) {
+func newSyncer(opts ...sync.Option) (*sync.Syncer, error) {
 	// Get default paths
 	paths, err := config.DefaultPaths()
 	if err != nil {
@@ -58,14 +80,15 @@ func newSyncer() (*sync.Syncer, error) {
 	fs := fsops.NewRealFS()
 	hasher := hash.NewSHA256Hasher()
 	clk := &clock.RealClock{}
-	stateStore := state.NewFileStateStore(fs, paths.Workspaces)
+	stateStore := state.NewFileStateStore(fs, paths.Workspaces, state.WithClock(clk))
+	stateStore.SetAuditSink(state.NewFileAuditSink(filepath.Join(paths.Root, state.AuditLogFileName)))
 	storeRepo := stores.NewFileStoreRepo(fs, paths.Stores)
 	gitPersist := remote.NewRealGitPersistence()
 	configStore := remote.NewFileRemoteConfigStore(fs)
 	snapshotMgr := persist.NewSnapshotManager(fs)
 
 	// Create syncer
-	return sync.New(gitPersist, storeRepo, stateStore, snapshotMgr, configStore, fs, hasher, clk), nil
+	return sync.New(gitPersist, storeRepo, stateStore, snapshotMgr, configStore, fs, hasher, clk, opts...), nil
 }
 
 // formatJSON formats a value as JSON.
@@ -17,6 +17,12 @@ var (
 	diffPatch      bool
 	diffNameOnly   bool
 	diffNameStatus bool
+	diffExcluded   bool
+	diffHashCache  bool
+	diffSinceApply bool
+	diffExitCode   bool
+	diffRef        string
+	diffRenames    bool
 )
 
 var diffCmd = &cobra.Command{
@@ -25,7 +31,7 @@ var diffCmd = &cobra.Command{
 	Long:  `Display which tracked files have been modified, added, or removed compared to the store overlay.`,
 	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		eng, err := newEngine()
+		eng, err := newEngine(engine.WithHashCache(diffHashCache))
 		if err != nil {
 			return err
 		}
@@ -37,11 +43,15 @@ var diffCmd = &cobra.Command{
 		}
 
 		req := &engine.DiffRequest{
-			CWD:         cwd,
-			StoreID:     diffStoreID,
-			ShowContent: diffPatch || (!diffNameOnly && !diffNameStatus),
-			NameOnly:    diffNameOnly,
-			NameStatus:  diffNameStatus,
+			CWD:             cwd,
+			StoreID:         diffStoreID,
+			ShowContent:     diffPatch || (!diffNameOnly && !diffNameStatus),
+			NameOnly:        diffNameOnly,
+			NameStatus:      diffNameStatus,
+			IncludeExcluded: diffExcluded,
+			SinceApply:      diffSinceApply,
+			StoreRef:        diffRef,
+			DetectRenames:   diffRenames,
 		}
 
 		result, err := eng.Diff(ctx, req)
@@ -50,10 +60,18 @@ var diffCmd = &cobra.Command{
 		}
 
 		if jsonOutput {
-			return outputJSON(result)
+			err = outputJSON(result)
+		} else {
+			err = formatDiffOutput(result)
+		}
+		if err != nil {
+			return err
 		}
 
-		return formatDiffOutput(result)
+		if diffExitCode && result.HasChanges() {
+			return fmt.Errorf("%s differ from store '%s'", PrintCount(len(result.ChangedPaths()), "file", "files"), result.StoreID)
+		}
+		return nil
 	},
 }
 
@@ -62,6 +80,12 @@ func init() {
 	diffCmd.Flags().BoolVarP(&diffPatch, "patch", "p", false, "Show unified diff content")
 	diffCmd.Flags().BoolVar(&diffNameOnly, "name-only", false, "Show only file names")
 	diffCmd.Flags().BoolVar(&diffNameStatus, "name-status", false, "Show file names with status")
+	diffCmd.Flags().BoolVar(&diffHashCache, "hash-cache", false, "Reuse cached hashes of unchanged files (stored in the store directory)")
+	diffCmd.Flags().BoolVar(&diffExcluded, "include-excluded", false, "Include tracked paths marked as excluded from diff")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with a non-zero status if any file differs")
+	diffCmd.Flags().BoolVar(&diffSinceApply, "since-apply", false, "Compare against the content last applied instead of the current store")
+	diffCmd.Flags().BoolVarP(&diffRenames, "find-renames", "M", false, "Report files moved with unchanged content as renames")
+	diffCmd.Flags().StringVar(&diffRef, "ref", "", "Compare against the store as committed at this ref of the sync repository")
 }
 
 // formatDiffOutput formats the diff result for display.
@@ -87,11 +111,14 @@ func formatNameOnly(result *engine.DiffResult) error {
 	return nil
 }
 
-// formatNameStatus outputs filenames with status indicators (M, A, D).
+// formatNameStatus outputs filenames with status indicators (M, A, D, R).
+// Renames list the store path before the workspace path.
 func formatNameStatus(result *engine.DiffResult) error {
 	for _, file := range changedFiles(result) {
 		statusChar := getStatusChar(file.Status)
 		switch file.Status {
+		case "renamed":
+			_, _ = infoColor.Printf("%s\t%s\t%s\n", statusChar, file.OldPath, file.Path)
 		case "added":
 			_, _ = successColor.Printf("%s\t%s\n", statusChar, file.Path)
 		case "removed":
@@ -175,6 +202,8 @@ func getStatusChar(status string) string {
 		return "A"
 	case "removed":
 		return "D"
+	case "renamed":
+		return "R"
 	case "unchanged":
 		return "U"
 	default:
@@ -199,10 +228,15 @@ func printDiffFileHeader(file engine.DiffFileInfo) {
 		statusClr = errorColor
 	case "modified":
 		statusClr = warningColor
+	case "renamed":
+		statusClr = infoColor
 	}
 
 	// Status badge + file path + stats
 	_, _ = statusClr.Printf("  %s ", statusChar)
+	if file.OldPath != "" {
+		_, _ = headerColor.Printf("%s → ", file.OldPath)
+	}
 	_, _ = headerColor.Printf("%s", file.Path)
 
 	// Insertion/deletion counts, colored individually
@@ -212,6 +246,9 @@ func printDiffFileHeader(file engine.DiffFileInfo) {
 	if file.Deletions > 0 {
 		_, _ = errorColor.Printf("  -%d", file.Deletions)
 	}
+	if file.StoreChangedSinceApply {
+		_, _ = dimColor.Printf("  (store changed since apply)")
+	}
 	fmt.Println()
 
 	// Thin separator under the file header
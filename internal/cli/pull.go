@@ -33,21 +33,33 @@ Examples:
   monodev pull my-store --verify
 
   # Force pull (overwrite local changes)
-  monodev pull my-store --force`,
+  monodev pull my-store --force
+
+  # Merge remote changes, keeping local edits to files the remote didn't touch
+  monodev pull my-store --merge
+
+  # Pull only part of a large store's overlay
+  monodev pull my-store --path scripts --path docs/setup.md`,
 	Args: cobra.ArbitraryArgs,
 	RunE: runPull,
 }
 
 var (
-	pullRemote string
-	pullForce  bool
-	pullVerify bool
+	pullRemote    string
+	pullForce     bool
+	pullVerify    bool
+	pullHashCache bool
+	pullMerge     bool
+	pullPaths     []string
 )
 
 func init() {
 	pullCmd.Flags().StringVar(&pullRemote, "remote", "", "Git remote to pull from (defaults to configured remote)")
 	pullCmd.Flags().BoolVar(&pullForce, "force", false, "Force pull (overwrite local stores)")
 	pullCmd.Flags().BoolVar(&pullVerify, "verify", false, "Verify store integrity with checksums after pulling")
+	pullCmd.Flags().BoolVar(&pullHashCache, "hash-cache", false, "With --verify, reuse cached hashes of unchanged files (stored in the store directory)")
+	pullCmd.Flags().BoolVar(&pullMerge, "merge", false, "Merge per file against the last-synced state instead of overwriting")
+	pullCmd.Flags().StringSliceVar(&pullPaths, "path", nil, "Restore only these overlay paths of the named stores (repeatable)")
 }
 
 func runPull(cmd *cobra.Command, args []string) error {
@@ -61,7 +73,7 @@ func runPull(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create syncer
-	syncer, err := newSyncer()
+	syncer, err := newSyncer(sync.WithHashCache(pullHashCache))
 	if err != nil {
 		return fmt.Errorf("failed to create syncer: %w", err)
 	}
@@ -73,6 +85,16 @@ func runPull(cmd *cobra.Command, args []string) error {
 		Remote:   pullRemote,
 		Force:    pullForce,
 		Verify:   pullVerify,
+		Merge:    pullMerge,
+	}
+	if len(pullPaths) > 0 {
+		if len(args) == 0 {
+			return fmt.Errorf("--path requires store IDs to pull")
+		}
+		req.PathFilters = make(map[string][]string, len(args))
+		for _, storeID := range args {
+			req.PathFilters[storeID] = pullPaths
+		}
 	}
 
 	// Execute pull
@@ -85,6 +107,10 @@ func runPull(cmd *cobra.Command, args []string) error {
 		return outputJSON(result)
 	}
 
+	for _, w := range result.Warnings {
+		PrintWarning(w)
+	}
+
 	// Display result
 	if len(result.PulledStores) > 0 {
 		if len(args) == 0 {
@@ -100,6 +126,14 @@ func runPull(cmd *cobra.Command, args []string) error {
 		PrintInfo("No stores found in remote")
 	}
 
+	if len(result.Conflicts) > 0 {
+		PrintWarning(fmt.Sprintf("Kept local version of %d conflicting file(s):", len(result.Conflicts)))
+		for _, c := range result.Conflicts {
+			fmt.Printf("  - %s\n", c)
+		}
+		PrintInfo("")
+	}
+
 	if result.Verified {
 		PrintSuccess("All stores verified successfully")
 		PrintInfo("")
@@ -83,12 +83,20 @@ func runPush(cmd *cobra.Command, args []string) error {
 
 	// Execute push
 	result, err := syncer.PushStore(ctx, req)
-	if err != nil {
+	if err != nil && (result == nil || len(result.Failures) == 0) {
 		return err
 	}
+	// Per-store failures still report what was pushed before returning err
 
 	if jsonOutput {
-		return outputJSON(result)
+		if jsonErr := outputJSON(result); jsonErr != nil {
+			return jsonErr
+		}
+		return err
+	}
+
+	for _, w := range result.Warnings {
+		PrintWarning(w)
 	}
 
 	// Display result
@@ -130,7 +138,31 @@ func runPush(cmd *cobra.Command, args []string) error {
 		PrintInfo(fmt.Sprintf("Remote: %s", result.Remote))
 		PrintInfo(fmt.Sprintf("Branch: %s", result.Branch))
 		PrintInfo(fmt.Sprintf("Commit: %s", result.CommitMessage))
+	} else if len(result.PlannedGitActions) > 0 {
+		PrintInfo("Planned git actions:")
+		for _, action := range result.PlannedGitActions {
+			fmt.Printf("  - %s\n", formatGitAction(action))
+		}
 	}
 
-	return nil
+	return err
+}
+
+// formatGitAction describes a planned git action on one line.
+func formatGitAction(action sync.GitAction) string {
+	switch action.Kind {
+	case sync.GitActionEnsureRepo:
+		return fmt.Sprintf("ensure persistence repo on branch %s", action.Branch)
+	case sync.GitActionSetRemote:
+		return fmt.Sprintf("configure remote %s", action.Remote)
+	case sync.GitActionCommit:
+		return fmt.Sprintf("commit %q on %s", action.Message, action.Branch)
+	case sync.GitActionPush:
+		if action.Force {
+			return fmt.Sprintf("force push %s to %s", action.Branch, action.Remote)
+		}
+		return fmt.Sprintf("push %s to %s", action.Branch, action.Remote)
+	default:
+		return action.Kind
+	}
 }
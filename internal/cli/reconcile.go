@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danieljhkim/monodev/internal/engine"
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile [store-id]",
+	Short: "Adopt existing workspace files that match a store",
+	Long: `Record tracked paths that already exist in the workspace as managed by the
+active store (or specified store), without changing any files.
+
+A path is adopted when its content matches the store overlay, or when it is a
+symlink to the overlay path. Paths that differ are reported as conflicts and
+left unmanaged.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		req := &engine.ReconcileRequest{CWD: cwd}
+		if len(args) > 0 {
+			req.StoreID = args[0]
+		}
+
+		result, err := eng.Reconcile(context.Background(), req)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return outputJSON(result)
+		}
+
+		PrintSuccess(fmt.Sprintf("Adopted %s from store '%s'", PrintCount(len(result.Adopted), "path", "paths"), result.StoreID))
+		if len(result.Missing) > 0 {
+			PrintInfo(fmt.Sprintf("%s not present in the workspace", PrintCount(len(result.Missing), "tracked path", "tracked paths")))
+		}
+		if len(result.Conflicts) > 0 {
+			PrintSection("Conflicts Detected")
+			for _, conflict := range result.Conflicts {
+				PrintError(fmt.Sprintf("%s: %s", conflict.Path, conflict.Reason))
+			}
+			fmt.Println()
+			PrintWarning("Conflicting paths were left unmanaged; use 'apply --force' to replace them.")
+		}
+		return nil
+	},
+}
@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/danieljhkim/monodev/internal/fsops"
 	"github.com/danieljhkim/monodev/internal/gitx"
@@ -36,6 +39,22 @@ Examples:
 	RunE: runRemoteUse,
 }
 
+var remoteUseDirCmd = &cobra.Command{
+	Use:   "use-dir <path>",
+	Short: "Sync stores with a local directory instead of Git",
+	Long: `Select a local directory to push stores to and pull stores from.
+
+Stores are copied into <path>/persist without involving git, which is
+useful for shared network drives or sandboxes without remote access.
+Run "monodev remote use <remote-name>" to switch back to a Git remote.
+
+Examples:
+  # Sync through a shared drive
+  monodev remote use-dir /mnt/shared/monodev`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRemoteUseDir,
+}
+
 var remoteSetBranchCmd = &cobra.Command{
 	Use:   "set-branch <branch>",
 	Short: "Set the persistence branch name",
@@ -54,6 +73,24 @@ Examples:
 	RunE: runRemoteSetBranch,
 }
 
+var remoteSetSyncStoresCmd = &cobra.Command{
+	Use:   "set-sync-stores [store-id...]",
+	Short: "Limit push/pull of all stores to an allowlist",
+	Long: `Set the stores that "push" and "pull" without arguments will sync.
+
+Stores named explicitly on the command line are always synced, with a
+warning if they are not in the allowlist. Run without arguments to clear
+the allowlist and sync every store again.
+
+Examples:
+  # Only sync two stores by default
+  monodev remote set-sync-stores frontend-config shared-scripts
+
+  # Sync all stores again
+  monodev remote set-sync-stores`,
+	RunE: runRemoteSetSyncStores,
+}
+
 var remoteShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Display current remote configuration",
@@ -64,10 +101,35 @@ Shows the configured Git remote, branch name, and last update time.`,
 	RunE: runRemoteShow,
 }
 
+var remoteGCAggressive bool
+
+var remoteGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Compact the sync repository",
+	Long: `Run git garbage collection on the persistence repository at .monodev/.git.
+
+Repeated pushes and pulls accumulate history and loose objects. This prunes
+unreachable objects and repacks the rest. It does not contact the remote.
+
+Examples:
+  # Compact the sync repository
+  monodev remote gc
+
+  # Spend longer to compact it further
+  monodev remote gc --aggressive`,
+	Args: cobra.NoArgs,
+	RunE: runRemoteGC,
+}
+
 func init() {
+	remoteGCCmd.Flags().BoolVar(&remoteGCAggressive, "aggressive", false, "Optimize the repository more thoroughly at the cost of time")
+
 	remoteCmd.AddCommand(remoteUseCmd)
+	remoteCmd.AddCommand(remoteUseDirCmd)
 	remoteCmd.AddCommand(remoteSetBranchCmd)
+	remoteCmd.AddCommand(remoteSetSyncStoresCmd)
 	remoteCmd.AddCommand(remoteShowCmd)
+	remoteCmd.AddCommand(remoteGCCmd)
 }
 
 func runRemoteUse(cmd *cobra.Command, args []string) error {
@@ -103,6 +165,8 @@ func runRemoteUse(cmd *cobra.Command, args []string) error {
 
 	// Update remote
 	config.Remote = remoteName
+	config.Kind = remote.RemoteKindGit
+	config.Dir = ""
 
 	// Save config
 	if err := configStore.Save(repoRoot, config); err != nil {
@@ -129,6 +193,54 @@ func runRemoteUse(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runRemoteUseDir(cmd *cobra.Command, args []string) error {
+	dir, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	// Get the repository root
+	gitRepo := gitx.NewRealGitRepo()
+	repoRoot, err := gitRepo.Discover(".")
+	if err != nil {
+		return fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	// Load or create config
+	fs := fsops.NewRealFS()
+	configStore := remote.NewFileRemoteConfigStore(fs)
+
+	config, err := configStore.Load(repoRoot)
+	if err != nil {
+		if err == remote.ErrRemoteNotConfigured {
+			config = remote.DefaultRemoteConfig()
+		} else {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+	}
+
+	config.Kind = remote.RemoteKindDir
+	config.Dir = dir
+
+	if err := configStore.Save(repoRoot, config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if jsonOutput {
+		result := struct {
+			Kind string `json:"kind"`
+			Dir  string `json:"dir"`
+		}{
+			Kind: config.Kind,
+			Dir:  dir,
+		}
+		return outputJSON(result)
+	}
+
+	PrintSuccess(fmt.Sprintf("Remote set to directory %s", dir))
+	return nil
+}
+
 func runRemoteSetBranch(cmd *cobra.Command, args []string) error {
 	branchName := args[0]
 
@@ -178,6 +290,51 @@ func runRemoteSetBranch(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runRemoteSetSyncStores(cmd *cobra.Command, args []string) error {
+	// Get the repository root
+	gitRepo := gitx.NewRealGitRepo()
+	repoRoot, err := gitRepo.Discover(".")
+	if err != nil {
+		return fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	// Load or create config
+	fs := fsops.NewRealFS()
+	configStore := remote.NewFileRemoteConfigStore(fs)
+
+	config, err := configStore.Load(repoRoot)
+	if err != nil {
+		if err == remote.ErrRemoteNotConfigured {
+			config = remote.DefaultRemoteConfig()
+		} else {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+	}
+
+	config.SyncStores = args
+
+	if err := configStore.Save(repoRoot, config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if jsonOutput {
+		result := struct {
+			SyncStores []string `json:"syncStores"`
+		}{
+			SyncStores: config.SyncStores,
+		}
+		return outputJSON(result)
+	}
+
+	if len(args) == 0 {
+		PrintSuccess("Sync allowlist cleared; all stores will be synced")
+	} else {
+		PrintSuccess(fmt.Sprintf("Sync allowlist set to: %s", strings.Join(args, ", ")))
+	}
+
+	return nil
+}
+
 func runRemoteShow(cmd *cobra.Command, args []string) error {
 	// Get the repository root
 	gitRepo := gitx.NewRealGitRepo()
@@ -210,36 +367,83 @@ func runRemoteShow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	kind := config.Kind
+	if kind == "" {
+		kind = remote.RemoteKindGit
+	}
+
 	// Get remote URL
-	gitPersist := remote.NewRealGitPersistence()
-	remoteURL, err := gitPersist.GetRemoteURL(repoRoot, config.Remote)
-	if err != nil {
-		PrintWarning(fmt.Sprintf("Remote %q not found in repository", config.Remote))
-		remoteURL = "(not found)"
+	var remoteURL string
+	if kind == remote.RemoteKindDir {
+		remoteURL = config.Dir
+	} else {
+		gitPersist := remote.NewRealGitPersistence()
+		remoteURL, err = gitPersist.GetRemoteURL(repoRoot, config.Remote)
+		if err != nil {
+			PrintWarning(fmt.Sprintf("Remote %q not found in repository", config.Remote))
+			remoteURL = "(not found)"
+		}
 	}
 
 	if jsonOutput {
 		result := struct {
-			Configured bool   `json:"configured"`
-			Remote     string `json:"remote"`
-			URL        string `json:"url"`
-			Branch     string `json:"branch"`
-			UpdatedAt  string `json:"updatedAt"`
+			Configured bool     `json:"configured"`
+			Kind       string   `json:"kind"`
+			Remote     string   `json:"remote"`
+			URL        string   `json:"url"`
+			Branch     string   `json:"branch"`
+			UpdatedAt  string   `json:"updatedAt"`
+			SyncStores []string `json:"syncStores,omitempty"`
 		}{
 			Configured: true,
+			Kind:       kind,
 			Remote:     config.Remote,
 			URL:        remoteURL,
 			Branch:     config.Branch,
 			UpdatedAt:  config.UpdatedAt.Format("2006-01-02 15:04:05"),
+			SyncStores: config.SyncStores,
 		}
 		return outputJSON(result)
 	}
 
 	// Display config
+	fmt.Printf("Kind:    %s\n", kind)
 	fmt.Printf("Remote:  %s\n", config.Remote)
 	fmt.Printf("URL:     %s\n", remoteURL)
 	fmt.Printf("Branch:  %s\n", config.Branch)
 	fmt.Printf("Updated: %s\n", config.UpdatedAt.Format("2006-01-02 15:04:05"))
+	if len(config.SyncStores) > 0 {
+		fmt.Printf("Sync:    %s\n", strings.Join(config.SyncStores, ", "))
+	}
+
+	return nil
+}
+
+func runRemoteGC(cmd *cobra.Command, args []string) error {
+	// Get the repository root
+	gitRepo := gitx.NewRealGitRepo()
+	repoRoot, err := gitRepo.Discover(".")
+	if err != nil {
+		return fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	syncer, err := newSyncer()
+	if err != nil {
+		return err
+	}
+	if err := syncer.GC(context.Background(), repoRoot, remoteGCAggressive); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		result := struct {
+			Aggressive bool `json:"aggressive"`
+		}{
+			Aggressive: remoteGCAggressive,
+		}
+		return outputJSON(result)
+	}
 
+	PrintSuccess("Sync repository compacted")
 	return nil
 }
@@ -204,12 +204,16 @@ See each sub-command's help for details on how to use the generated script.`,
 	statusCmd.GroupID = "workspace-lifecycle"
 	workspaceCmd.GroupID = "workspace-lifecycle"
 	diffCmd.GroupID = "workspace-lifecycle"
+	reconcileCmd.GroupID = "workspace-lifecycle"
+	whoOwnsCmd.GroupID = "workspace-lifecycle"
 	rootCmd.AddCommand(applyCmd)
 	rootCmd.AddCommand(unapplyCmd)
 	rootCmd.AddCommand(clearCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(workspaceCmd)
 	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(reconcileCmd)
+	rootCmd.AddCommand(whoOwnsCmd)
 
 	// Store Operations commands
 	storeCmd.GroupID = "store-operations"
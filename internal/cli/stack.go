@@ -21,10 +21,20 @@ func init() {
 	stackCmd.AddCommand(stackClearCmd)
 	stackCmd.AddCommand(stackApplyCmd)
 	stackCmd.AddCommand(stackUnapplyCmd)
+	stackCmd.AddCommand(stackExportCmd)
+	stackCmd.AddCommand(stackImportCmd)
 
+	// Flags for stack add
+	stackAddCmd.Flags().Int("position", -1, "Stack index to insert at (0 = lowest precedence, -1 = append)")
+	stackAddCmd.Flags().Bool("apply", false, "Apply the stack after adding the store")
+	stackAddCmd.Flags().BoolP("force", "f", false, "Force apply, overwriting conflicts (with --apply)")
 	// Flags for stack apply
 	stackApplyCmd.Flags().BoolP("force", "f", false, "Force apply, overwriting conflicts")
 	stackApplyCmd.Flags().Bool("dry-run", false, "Show what would be applied without making changes")
+	stackApplyCmd.Flags().Bool("with-active", false, "Also apply the active store, last, in the same plan")
+	stackApplyCmd.Flags().Bool("write-lock", false, "With --with-active, record every applied store's content hash in monodev.lock")
+	stackApplyCmd.Flags().Bool("locked", false, "With --with-active, fail without changes unless every store matches monodev.lock")
+	stackApplyCmd.Flags().StringToString("var", nil, "Set a variable for tracked paths' when conditions (name=value)")
 	// Flags for stack unapply
 	stackUnapplyCmd.Flags().BoolP("force", "f", false, "Force removal even if validation fails")
 	stackUnapplyCmd.Flags().Bool("dry-run", false, "Show what would be removed without making changes")
@@ -16,7 +16,8 @@ var stackApplyCmd = &cobra.Command{
 	Short: "Apply the stack (multiple stores) in dependency order ",
 	Long: `Apply all stores in the stack to the current workspace.
 Stores are applied in order, with later stores taking precedence on path conflicts.
-The active store is not affected - use 'monodev apply' separately for that.`,
+The active store is not affected - use 'monodev apply' separately for that, or
+--with-active to apply it last in the same plan so it takes precedence.`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		eng, err := newEngine()
@@ -32,16 +33,32 @@ The active store is not affected - use 'monodev apply' separately for that.`,
 
 		force, _ := cmd.Flags().GetBool("force")
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
-		applyMode := "copy" // cmd.Flags().GetString("mode")
-
-		req := &engine.StackApplyRequest{
-			CWD:    cwd,
-			Mode:   applyMode,
-			Force:  force,
-			DryRun: dryRun,
+		withActive, _ := cmd.Flags().GetBool("with-active")
+		writeLock, _ := cmd.Flags().GetBool("write-lock")
+		locked, _ := cmd.Flags().GetBool("locked")
+		vars, _ := cmd.Flags().GetStringToString("var")
+		if (writeLock || locked) && !withActive {
+			return fmt.Errorf("--write-lock and --locked require --with-active")
 		}
 
-		result, err := eng.StackApply(ctx, req)
+		var result *engine.StackApplyResult
+		if withActive {
+			result, err = eng.ApplyAll(ctx, &engine.ApplyAllRequest{
+				CWD:       cwd,
+				Force:     force,
+				DryRun:    dryRun,
+				WriteLock: writeLock,
+				Locked:    locked,
+				Vars:      vars,
+			})
+		} else {
+			result, err = eng.StackApply(ctx, &engine.StackApplyRequest{
+				CWD:    cwd,
+				Force:  force,
+				DryRun: dryRun,
+				Vars:   vars,
+			})
+		}
 		if err != nil {
 			if result != nil && result.Plan != nil && result.Plan.HasConflicts() {
 				PrintSection("Conflicts Detected")
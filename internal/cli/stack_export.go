@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danieljhkim/monodev/internal/engine"
+	"github.com/danieljhkim/monodev/internal/fsops"
+)
+
+var (
+	stackImportApply bool
+	stackImportForce bool
+)
+
+// stackExportCmd writes the stack to a shareable file.
+var stackExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Export the stack to a shareable file",
+	Long: `Export the current stack, active store, and mode to a JSON file that can be
+committed and imported by teammates. Defaults to ` + engine.StackExportFileName + `.
+Use "-" to write to stdout.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := engine.StackExportFileName
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		// Export into memory first so a failed export never leaves a
+		// truncated file behind
+		var buf bytes.Buffer
+		if err := eng.ExportStack(context.Background(), &engine.ExportStackRequest{CWD: cwd, Writer: &buf}); err != nil {
+			return fmt.Errorf("failed to export stack: %w", err)
+		}
+
+		if path == "-" {
+			_, err := os.Stdout.Write(buf.Bytes())
+			return err
+		}
+		if err := fsops.NewRealFS().AtomicWrite(path, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+
+		PrintSuccess(fmt.Sprintf("Exported stack to %s", path))
+		return nil
+	},
+}
+
+// stackImportCmd restores the stack from an exported file.
+var stackImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import a stack from a shared file",
+	Long: `Import a stack previously written by 'stack export' into the current workspace.
+Defaults to ` + engine.StackExportFileName + `. All referenced stores must exist.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := engine.StackExportFileName
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+
+		result, err := eng.ImportStack(context.Background(), &engine.ImportStackRequest{
+			CWD:    cwd,
+			Reader: f,
+			Apply:  stackImportApply,
+			Force:  stackImportForce,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import stack: %w", err)
+		}
+
+		if jsonOutput {
+			return outputJSON(result)
+		}
+
+		PrintSuccess(fmt.Sprintf("Imported stack (%s)", PrintCount(len(result.Stack), "store", "stores")))
+		if len(result.Stack) > 0 {
+			PrintList(result.Stack, 1)
+		}
+		if result.Applied != nil {
+			PrintSuccess(fmt.Sprintf("Applied %s", PrintCount(len(result.Applied.Applied), "operation", "operations")))
+		}
+		return nil
+	},
+}
+
+func init() {
+	stackImportCmd.Flags().BoolVar(&stackImportApply, "apply", false, "Apply the imported stack")
+	stackImportCmd.Flags().BoolVarP(&stackImportForce, "force", "f", false, "Force apply, overwriting conflicts")
+}
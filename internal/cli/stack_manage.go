@@ -82,16 +82,42 @@ var stackAddCmd = &cobra.Command{
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 
+		position, _ := cmd.Flags().GetInt("position")
+		apply, _ := cmd.Flags().GetBool("apply")
+		force, _ := cmd.Flags().GetBool("force")
+
 		req := &engine.StackAddRequest{
-			CWD:     cwd,
-			StoreID: storeID,
+			CWD:      cwd,
+			StoreID:  storeID,
+			Position: &position,
+			Apply:    apply,
+			Force:    force,
 		}
 
-		if err := eng.StackAdd(ctx, req); err != nil {
-			return fmt.Errorf("failed to add store to stack: %w", err)
+		result, err := eng.StackAdd(ctx, req)
+		if err != nil {
+			if result == nil {
+				return fmt.Errorf("failed to add store to stack: %w", err)
+			}
+			PrintWarning(fmt.Sprintf("Added store to stack at position %d, but applying the stack failed", result.Position))
+			if result.Applied != nil && result.Applied.Plan != nil && result.Applied.Plan.HasConflicts() {
+				for _, conflict := range result.Applied.Plan.Conflicts {
+					PrintError(fmt.Sprintf("%s: %s", conflict.Path, conflict.Reason))
+				}
+				PrintWarning("Use --force to override conflicts.")
+			}
+			return err
 		}
 
-		PrintSuccess(fmt.Sprintf("Added store to stack: %s", storeID))
+		if jsonOutput {
+			return outputJSON(result)
+		}
+
+		PrintSuccess(fmt.Sprintf("Added store to stack at position %d: %s", result.Position, storeID))
+		if result.Applied != nil {
+			PrintInfo(fmt.Sprintf("Applied stack: %s", PrintCount(len(result.Applied.Applied), "operation", "operations")))
+			return nil
+		}
 		PrintInfo(fmt.Sprintf("Store '%s' will be applied in the order of the stack.", storeID))
 		return nil
 	},
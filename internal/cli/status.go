@@ -9,6 +9,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/danieljhkim/monodev/internal/config"
 	"github.com/danieljhkim/monodev/internal/engine"
 )
 
@@ -57,6 +58,15 @@ var statusCmd = &cobra.Command{
 		} else {
 			PrintLabelValue("Stack", "[]")
 		}
+		// Environment overrides change what requests default to
+		if settings, err := config.EffectiveSettings(); err == nil {
+			if settings.Mode != "" {
+				PrintLabelValue("Mode Override", fmt.Sprintf("%s (%s)", settings.Mode, config.EnvMode))
+			}
+			if settings.Scope != "" {
+				PrintLabelValue("Scope Override", fmt.Sprintf("%s (%s)", settings.Scope, config.EnvScope))
+			}
+		}
 
 		fmt.Println()
 		// existing paths in the workspace
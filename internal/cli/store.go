@@ -16,4 +16,7 @@ func init() {
 	storeCmd.AddCommand(storeRmCmd)
 	storeCmd.AddCommand(storeDescribeCmd)
 	storeCmd.AddCommand(storeUpdateCmd)
+	storeCmd.AddCommand(storeAuditCmd)
+	storeCmd.AddCommand(storeDupesCmd)
+	storeCmd.AddCommand(storeMergeCmd)
 }
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var storeAuditCmd = &cobra.Command{
+	Use:   "audit <store-id>",
+	Short: "Check a store's overlay for symlinks leading outside it",
+	Long: `Walk a store's overlay and report every symlink with an absolute target
+or a target outside the overlay. Applying such a store could read or link
+files outside it, so audit stores pulled from untrusted sources before
+applying them.
+
+Exits with an error when any unsafe symlink is found.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		scope, _ := cmd.Flags().GetString("scope")
+		result, err := eng.AuditStore(context.Background(), args[0], scope)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			if err := outputJSON(result); err != nil {
+				return err
+			}
+		} else if len(result.Findings) == 0 {
+			PrintSuccess(fmt.Sprintf("Store %s has no unsafe symlinks", result.StoreID))
+		} else {
+			rows := make([][]string, 0, len(result.Findings))
+			for _, finding := range result.Findings {
+				rows = append(rows, []string{finding.Path, finding.Target, finding.Reason})
+			}
+			PrintTable([]string{"Path", "Target", "Reason"}, rows)
+		}
+
+		if len(result.Findings) > 0 {
+			return fmt.Errorf("store '%s' has %s", result.StoreID, PrintCount(len(result.Findings), "unsafe symlink", "unsafe symlinks"))
+		}
+		return nil
+	},
+}
+
+func init() {
+	storeAuditCmd.Flags().String("scope", "", "Store scope to disambiguate (global or component)")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -60,12 +61,26 @@ var storeDescribeCmd = &cobra.Command{
 			PrintLabelValue("Created", details.Meta.CreatedAt.Format("2006-01-02 15:04:05"))
 			PrintLabelValue("Updated", details.Meta.UpdatedAt.Format("2006-01-02 15:04:05"))
 
+			PrintLabelValue("Size", fmt.Sprintf("%d bytes", details.SizeBytes))
+
 			if details.Meta.Owner != "" {
 				PrintLabelValue("Owner", details.Meta.Owner)
 			}
 			if details.Meta.TaskID != "" {
 				PrintLabelValue("Task ID", details.Meta.TaskID)
 			}
+			if details.Meta.Type != "" {
+				PrintLabelValue("Type", details.Meta.Type)
+			}
+			if details.Meta.Frozen {
+				PrintLabelValue("Frozen", "yes")
+			}
+			if len(details.Meta.Requires) > 0 {
+				PrintLabelValue("Requires", strings.Join(details.Meta.Requires, ", "))
+			}
+			if len(details.RequiredBy) > 0 {
+				PrintLabelValue("Required By", strings.Join(details.RequiredBy, ", "))
+			}
 
 			if len(details.TrackedPaths) > 0 {
 				PrintSubsection(fmt.Sprintf("\nTracked Paths (%s)", PrintCount(len(details.TrackedPaths), "path", "paths")))
@@ -76,6 +91,9 @@ var storeDescribeCmd = &cobra.Command{
 					} else {
 						pathStrs[i] = tp.Path
 					}
+					if tp.Note != "" {
+						pathStrs[i] += " - " + tp.Note
+					}
 				}
 				PrintList(pathStrs, 1)
 			} else {
@@ -83,6 +101,20 @@ var storeDescribeCmd = &cobra.Command{
 				PrintEmptyState("No paths tracked")
 			}
 
+			if len(details.Ignore) > 0 {
+				PrintSubsection(fmt.Sprintf("\nIgnore (%s)", PrintCount(len(details.Ignore), "pattern", "patterns")))
+				PrintList(details.Ignore, 1)
+			}
+
+			if len(details.Changelog) > 0 {
+				PrintSubsection(fmt.Sprintf("\nChangelog (%s)", PrintCount(len(details.Changelog), "entry", "entries")))
+				entries := make([]string, len(details.Changelog))
+				for i, entry := range details.Changelog {
+					entries[i] = fmt.Sprintf("%s %s", entry.At.Format("2006-01-02 15:04:05"), entry.Message)
+				}
+				PrintList(entries, 1)
+			}
+
 			if i < len(detailsList)-1 {
 				fmt.Println()
 			}
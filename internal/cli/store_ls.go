@@ -2,10 +2,12 @@ package cli
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/danieljhkim/monodev/internal/engine"
 	"github.com/danieljhkim/monodev/internal/stores"
 )
 
@@ -14,7 +16,8 @@ var storeLsCmd = &cobra.Command{
 	Short: "List all stores",
 	Long: `Display all available stores.
 
-Use filter flags to narrow results.`,
+Use filter flags to narrow results, and --group-by to list stores in
+sections by owner or scope.`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		eng, err := newEngine()
@@ -24,7 +27,12 @@ Use filter flags to narrow results.`,
 
 		ctx := context.Background()
 
-		storeList, err := eng.ListStores(ctx)
+		sortBy, _ := cmd.Flags().GetString("sort")
+		health, _ := cmd.Flags().GetBool("health")
+		storeList, err := eng.ListStoresWith(ctx, &engine.ListStoresRequest{
+			SortBy:        sortBy,
+			IncludeHealth: health,
+		})
 		if err != nil {
 			return err
 		}
@@ -32,6 +40,19 @@ Use filter flags to narrow results.`,
 		// Apply filters
 		storeList = filterStores(cmd, storeList)
 
+		groupBy, _ := cmd.Flags().GetString("group-by")
+		if groupBy != "" {
+			groups, err := engine.GroupStores(storeList, groupBy)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				return outputJSON(groups)
+			}
+			printStoreGroups(groups)
+			return nil
+		}
+
 		if jsonOutput {
 			return outputJSON(storeList)
 		}
@@ -56,16 +77,60 @@ func orDash(s string) string {
 }
 
 func printStoreTable(storeList []stores.ScopedStore) {
+	headers := []string{"Name", "Scope", "Owner", "Description"}
+	withHealth := len(storeList) > 0 && storeList[0].Health != nil
+	if withHealth {
+		headers = append(headers, "Health")
+	}
+
 	rows := make([][]string, 0, len(storeList))
 	for _, store := range storeList {
-		rows = append(rows, []string{
+		row := []string{
 			store.Meta.Name,
 			store.Scope,
 			orDash(store.Meta.Owner),
 			orDash(store.Meta.Description),
-		})
+		}
+		if withHealth {
+			row = append(row, formatStoreHealth(store.Health))
+		}
+		rows = append(rows, row)
+	}
+	PrintTable(headers, rows)
+}
+
+// formatStoreHealth summarizes a store's health flags, or "ok" if none are raised.
+func formatStoreHealth(health *stores.StoreHealth) string {
+	var problems []string
+	if health.AuditFindings > 0 {
+		problems = append(problems, fmt.Sprintf("%d audit findings", health.AuditFindings))
+	}
+	if health.TrackMismatch {
+		problems = append(problems, "track mismatch")
+	}
+	if health.Orphaned {
+		problems = append(problems, "orphaned")
+	}
+	if len(problems) == 0 {
+		return "ok"
+	}
+	return strings.Join(problems, ", ")
+}
+
+func printStoreGroups(groups *engine.StoreGroups) {
+	if len(groups.Keys) == 0 {
+		PrintSection("Stores")
+		PrintEmptyState("No stores found")
+		return
+	}
+	for _, key := range groups.Keys {
+		title := key
+		if title == "" {
+			title = "(no " + groups.GroupBy + ")"
+		}
+		PrintSection(title)
+		printStoreTable(groups.Groups[key])
 	}
-	PrintTable([]string{"Name", "Scope", "Owner", "Description"}, rows)
 }
 
 func filterStores(cmd *cobra.Command, storeList []stores.ScopedStore) []stores.ScopedStore {
@@ -75,6 +140,7 @@ func filterStores(cmd *cobra.Command, storeList []stores.ScopedStore) []stores.S
 	}{
 		{"scope", func(s stores.ScopedStore, v string) bool { return strings.EqualFold(s.Scope, v) }},
 		{"owner", func(s stores.ScopedStore, v string) bool { return strings.EqualFold(s.Meta.Owner, v) }},
+		{"type", func(s stores.ScopedStore, v string) bool { return strings.EqualFold(s.Meta.Type, v) }},
 	}
 
 	for _, f := range filters {
@@ -96,4 +162,8 @@ func filterStores(cmd *cobra.Command, storeList []stores.ScopedStore) []stores.S
 func init() {
 	storeLsCmd.Flags().String("scope", "", "Filter by scope (global, component)")
 	storeLsCmd.Flags().String("owner", "", "Filter by owner")
+	storeLsCmd.Flags().String("type", "", "Filter by store type")
+	storeLsCmd.Flags().String("sort", "", "Sort order (recent: most recently used first)")
+	storeLsCmd.Flags().String("group-by", "", "Group stores into sections (owner, scope)")
+	storeLsCmd.Flags().Bool("health", false, "Check each store for lint findings, track mismatches and missing references (slow)")
 }
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var storeDupesCmd = &cobra.Command{
+	Use:   "dupes",
+	Short: "List stores whose overlays have identical content",
+	Long: `Group stores, across both scopes, whose overlays hold exactly the same
+files. Stores in a group can be folded together with 'monodev store merge'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		groups, err := eng.FindDuplicateStores(context.Background())
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return outputJSON(groups)
+		}
+		if len(groups) == 0 {
+			PrintSection("Duplicate Stores")
+			PrintEmptyState("No duplicate stores found")
+			return nil
+		}
+
+		PrintSection("Duplicate Stores")
+		rows := make([][]string, 0, len(groups))
+		for i, group := range groups {
+			rows = append(rows, []string{fmt.Sprintf("%d", i+1), strings.Join(group, ", ")})
+		}
+		PrintTable([]string{"Group", "Stores"}, rows)
+		return nil
+	},
+}
+
+var storeMergeCmd = &cobra.Command{
+	Use:   "merge <keep-id> <merge-id>",
+	Short: "Fold a duplicate store into another and delete it",
+	Long: `Rewrite every workspace that references <merge-id> (in its stack, active
+store or applied paths) to reference <keep-id>, then delete <merge-id>.
+
+Both stores must have identical overlays. Either ID may be scope-qualified,
+such as global/foo.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		result, err := eng.MergeStores(context.Background(), args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return outputJSON(result)
+		}
+		PrintSuccess(fmt.Sprintf("Merged store %s into %s", result.MergedID, result.KeepID))
+		if len(result.Workspaces) > 0 {
+			PrintInfo(fmt.Sprintf("Updated %s", PrintCount(len(result.Workspaces), "workspace", "workspaces")))
+		}
+		return nil
+	},
+}
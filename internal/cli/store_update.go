@@ -63,23 +63,49 @@ var storeUpdateCmd = &cobra.Command{
 			v, _ := cmd.Flags().GetString("task-id")
 			req.TaskID = &v
 		}
+		if cmd.Flags().Changed("tags") {
+			v, _ := cmd.Flags().GetStringSlice("tags")
+			req.Tags = &v
+		}
+		if cmd.Flags().Changed("requires") {
+			v, _ := cmd.Flags().GetStringSlice("requires")
+			req.Requires = &v
+		}
+		if cmd.Flags().Changed("type") {
+			v, _ := cmd.Flags().GetString("type")
+			req.Type = &v
+		}
+		if cmd.Flags().Changed("frozen") {
+			v, _ := cmd.Flags().GetBool("frozen")
+			req.Frozen = &v
+		}
 
-		if err := eng.UpdateStore(ctx, req); err != nil {
+		result, err := eng.UpdateStore(ctx, req)
+		if err != nil {
 			return err
 		}
 
 		if jsonOutput {
-			result := struct {
-				StoreID string `json:"storeId"`
-				Updated bool   `json:"updated"`
+			output := struct {
+				StoreID string               `json:"storeId"`
+				Updated bool                 `json:"updated"`
+				Changed []engine.FieldChange `json:"changed,omitempty"`
 			}{
 				StoreID: storeID,
-				Updated: true,
+				Updated: !result.NoOp,
+				Changed: result.Changed,
 			}
-			return outputJSON(result)
+			return outputJSON(output)
 		}
 
+		if result.NoOp {
+			PrintInfo(fmt.Sprintf("No changes to store: %s", storeID))
+			return nil
+		}
 		PrintSuccess(fmt.Sprintf("Updated store: %s", storeID))
+		for _, change := range result.Changed {
+			PrintInfo(fmt.Sprintf("%s: %q -> %q", change.Field, change.Old, change.New))
+		}
 		return nil
 	},
 }
@@ -89,4 +115,8 @@ func init() {
 	storeUpdateCmd.Flags().String("description", "", "Store description")
 	storeUpdateCmd.Flags().String("owner", "", "Store owner")
 	storeUpdateCmd.Flags().String("task-id", "", "External task ID")
+	storeUpdateCmd.Flags().StringSlice("tags", nil, "Comma-separated store tags (replaces existing tags)")
+	storeUpdateCmd.Flags().StringSlice("requires", nil, "Comma-separated IDs of stores this store depends on (replaces existing requirements)")
+	storeUpdateCmd.Flags().String("type", "", "Store type, such as config or toolchain")
+	storeUpdateCmd.Flags().Bool("frozen", false, "Exclude the store from bulk metadata updates (--frozen=false to unfreeze)")
 }
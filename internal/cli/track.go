@@ -30,13 +30,23 @@ var trackCmd = &cobra.Command{
 		role, _ := cmd.Flags().GetString("role")
 		description, _ := cmd.Flags().GetString("description")
 		origin, _ := cmd.Flags().GetString("origin")
+		note, _ := cmd.Flags().GetString("note")
+		excludeFromDiff, _ := cmd.Flags().GetBool("exclude-from-diff")
+		linkContents, _ := cmd.Flags().GetBool("link-contents")
+		mirror, _ := cmd.Flags().GetBool("mirror")
+		when, _ := cmd.Flags().GetString("when")
 
 		req := &engine.TrackRequest{
-			CWD:         cwd,
-			Paths:       args,
-			Role:        role,
-			Description: description,
-			Origin:      origin,
+			CWD:             cwd,
+			Paths:           args,
+			Role:            role,
+			Description:     description,
+			Origin:          origin,
+			Note:            note,
+			ExcludeFromDiff: excludeFromDiff,
+			LinkContents:    linkContents,
+			Mirror:          mirror,
+			When:            when,
 		}
 
 		result, err := eng.Track(ctx, req)
@@ -87,4 +97,9 @@ func init() {
 	trackCmd.Flags().String("role", "", "Path role (script, docs, style, config, other)")
 	trackCmd.Flags().String("description", "", "Description of the tracked path")
 	trackCmd.Flags().String("origin", "", "Origin of the tracked path (user, agent, other)")
+	trackCmd.Flags().String("note", "", "Why the path is in the store, shown to teammates in store describe")
+	trackCmd.Flags().Bool("exclude-from-diff", false, "Hide the tracked path from diff output by default")
+	trackCmd.Flags().Bool("link-contents", false, "Apply a tracked directory entry by entry, keeping the workspace directory real")
+	trackCmd.Flags().String("when", "", "Only apply the paths when a variable condition holds (NAME, !NAME or NAME==value; see apply --var)")
+	trackCmd.Flags().Bool("mirror", false, "Remove workspace files in a tracked directory that are not in the store (copy mode)")
 }
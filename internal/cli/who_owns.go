@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danieljhkim/monodev/internal/engine"
+)
+
+var whoOwnsVars map[string]string
+
+var whoOwnsCmd = &cobra.Command{
+	Use:   "who-owns <path>",
+	Short: "Show which store owns a workspace path",
+	Long: `Show the store recorded as owning a workspace path, every store in the stack
+(and the active store) that tracks it, and which of them would own it if the
+stack were reapplied now.
+
+When the two owners differ, the next apply will hand the path to the new
+winner.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		result, err := eng.WhoOwns(context.Background(), &engine.WhoOwnsRequest{CWD: cwd, RelPath: args[0], Vars: whoOwnsVars})
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return outputJSON(result)
+		}
+
+		PrintSection(result.RelPath)
+		if result.Recorded != nil {
+			PrintLabelValue("Recorded Owner", fmt.Sprintf("%s (%s)", result.Recorded.Store, result.Recorded.Type))
+		} else {
+			PrintLabelValue("Recorded Owner", "none")
+		}
+		if len(result.Candidates) > 0 {
+			PrintLabelValue("Tracked By", strings.Join(result.Candidates, ", "))
+			PrintLabelValue("Winner", result.Winner)
+		} else {
+			PrintLabelValue("Tracked By", "none")
+		}
+
+		if result.Pending {
+			fmt.Println()
+			if result.Winner == "" {
+				PrintWarning("No store tracks this path anymore; it would not be reapplied")
+			} else {
+				PrintWarning(fmt.Sprintf("Ownership would change to '%s' on the next apply", result.Winner))
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	whoOwnsCmd.Flags().StringToStringVar(&whoOwnsVars, "var", nil, "Set a variable for tracked paths' when conditions (name=value)")
+}
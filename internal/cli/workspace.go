@@ -15,4 +15,10 @@ func init() {
 	workspaceCmd.AddCommand(workspaceLsCmd)
 	workspaceCmd.AddCommand(workspaceDescribeCmd)
 	workspaceCmd.AddCommand(workspaceRmCmd)
+	workspaceCmd.AddCommand(workspaceMvCmd)
+	workspaceCmd.AddCommand(workspaceExportCmd)
+	workspaceCmd.AddCommand(workspaceImportCmd)
+	workspaceCmd.AddCommand(workspaceCompactCmd)
+	workspaceCmd.AddCommand(workspaceCloneCmd)
+	workspaceCmd.AddCommand(workspaceTagCmd)
 }
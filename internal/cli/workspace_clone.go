@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danieljhkim/monodev/internal/engine"
+	"github.com/spf13/cobra"
+)
+
+var (
+	workspaceCloneApply bool
+	workspaceCloneForce bool
+)
+
+// workspaceCloneCmd copies one workspace's configuration to another.
+var workspaceCloneCmd = &cobra.Command{
+	Use:   "clone <source-dir> [target-dir]",
+	Short: "Copy a workspace's stack, active store and mode to another workspace",
+	Long: `Copy the stack, active store and mode of the workspace at <source-dir>
+to the workspace at [target-dir] (default: current directory).
+
+Applied paths are not copied. Use --apply to apply the cloned configuration
+to the target.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		source, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to resolve source directory: %w", err)
+		}
+		target, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		if len(args) == 2 {
+			if target, err = filepath.Abs(args[1]); err != nil {
+				return fmt.Errorf("failed to resolve target directory: %w", err)
+			}
+		}
+
+		result, err := eng.CloneWorkspaceConfig(ctx, &engine.CloneWorkspaceConfigRequest{
+			SourceCWD: source,
+			TargetCWD: target,
+			Apply:     workspaceCloneApply,
+			Force:     workspaceCloneForce,
+		})
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return outputJSON(result)
+		}
+
+		PrintSuccess(fmt.Sprintf("Cloned configuration from workspace %s", result.SourceWorkspaceID))
+		PrintInfo(fmt.Sprintf("Stack: %s", strings.Join(result.Stack, ", ")))
+		if result.ActiveStore != "" {
+			PrintInfo(fmt.Sprintf("Active store: %s", result.ActiveStore))
+		}
+		if result.StackApplied != nil || result.Applied != nil {
+			PrintInfo("Applied to target workspace")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	workspaceCloneCmd.Flags().BoolVar(&workspaceCloneApply, "apply", false, "Apply the cloned configuration to the target")
+	workspaceCloneCmd.Flags().BoolVarP(&workspaceCloneForce, "force", "f", false, "With --apply, override conflicts")
+}
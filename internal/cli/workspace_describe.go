@@ -3,18 +3,32 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/danieljhkim/monodev/internal/engine"
 	"github.com/spf13/cobra"
 )
 
+var (
+	workspaceDescribeRepo string
+	workspaceDescribePath string
+)
+
 // workspaceDescribeCmd shows detailed information about a workspace.
 var workspaceDescribeCmd = &cobra.Command{
-	Use:   "describe <workspace-id>",
+	Use:   "describe [workspace-id]",
 	Short: "Show workspace details",
-	Long:  `Display detailed information about a workspace.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Display detailed information about a workspace.
+
+The workspace is named by its ID, or by --repo (the repo fingerprint) and
+--path (the workspace path relative to the repo root). Neither form needs
+the current directory to be inside the repository.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		workspaceID := args[0]
+		byPath := workspaceDescribeRepo != "" || cmd.Flags().Changed("path")
+		if byPath == (len(args) == 1) {
+			return fmt.Errorf("specify either a workspace ID or --repo with --path")
+		}
 
 		eng, err := newEngine()
 		if err != nil {
@@ -23,7 +37,12 @@ var workspaceDescribeCmd = &cobra.Command{
 
 		ctx := context.Background()
 
-		result, err := eng.DescribeWorkspace(ctx, workspaceID)
+		var result *engine.DescribeWorkspaceResult
+		if byPath {
+			result, err = eng.DescribeWorkspaceByPath(ctx, workspaceDescribeRepo, workspaceDescribePath)
+		} else {
+			result, err = eng.DescribeWorkspace(ctx, args[0])
+		}
 		if err != nil {
 			return err
 		}
@@ -40,6 +59,9 @@ var workspaceDescribeCmd = &cobra.Command{
 		PrintLabelValue("Applied", fmt.Sprintf("%t", result.Applied))
 		PrintLabelValue("Mode", result.Mode)
 		PrintLabelValue("Active Store", result.ActiveStore)
+		if len(result.Tags) > 0 {
+			PrintLabelValue("Tags", strings.Join(result.Tags, ", "))
+		}
 
 		if len(result.Stack) > 0 {
 			PrintSubsection(fmt.Sprintf("\nStack (%s)", PrintCount(len(result.Stack), "store", "stores")))
@@ -58,11 +80,18 @@ var workspaceDescribeCmd = &cobra.Command{
 			PrintList(storesList, 1)
 		}
 
-		if len(result.Paths) > 0 {
-			PrintSubsection(fmt.Sprintf("\nApplied Paths (%s)", PrintCount(len(result.Paths), "path", "paths")))
-			pathsList := make([]string, 0, len(result.Paths))
-			for path, ownership := range result.Paths {
-				pathsList = append(pathsList, fmt.Sprintf("%s (from %s, %s)", path, ownership.Store, ownership.Type))
+		if len(result.AppliedPaths) > 0 {
+			PrintSubsection(fmt.Sprintf("\nApplied Paths (%s)", PrintCount(len(result.AppliedPaths), "path", "paths")))
+			pathsList := make([]string, 0, len(result.AppliedPaths))
+			for _, p := range result.AppliedPaths {
+				details := fmt.Sprintf("from %s, %s, applied %s", p.Store, p.Type, p.AppliedAt.Format("2006-01-02 15:04:05"))
+				if p.Checksum != "" {
+					details += ", sha " + p.Checksum
+				}
+				if p.AppliedVersion != "" {
+					details += ", monodev " + p.AppliedVersion
+				}
+				pathsList = append(pathsList, fmt.Sprintf("%s (%s)", p.Path, details))
 			}
 			PrintList(pathsList, 1)
 		} else {
@@ -73,3 +102,8 @@ var workspaceDescribeCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func init() {
+	workspaceDescribeCmd.Flags().StringVar(&workspaceDescribeRepo, "repo", "", "Repo fingerprint of the workspace (use with --path)")
+	workspaceDescribeCmd.Flags().StringVar(&workspaceDescribePath, "path", "", "Workspace path relative to the repo root (use with --repo)")
+}
@@ -3,15 +3,19 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/danieljhkim/monodev/internal/engine"
 	"github.com/spf13/cobra"
 )
 
+var workspaceLsTag string
+
 // workspaceLsCmd lists all workspaces.
 var workspaceLsCmd = &cobra.Command{
 	Use:   "ls",
 	Short: "List all workspaces",
-	Long:  `Display all workspaces with their current state.`,
+	Long:  `Display all workspaces with their current state. Use --tag to list only workspaces carrying a tag.`,
 	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		eng, err := newEngine()
@@ -21,7 +25,12 @@ var workspaceLsCmd = &cobra.Command{
 
 		ctx := context.Background()
 
-		result, err := eng.ListWorkspaces(ctx)
+		var result *engine.ListWorkspacesResult
+		if workspaceLsTag != "" {
+			result, err = eng.ListWorkspacesByTag(ctx, workspaceLsTag)
+		} else {
+			result, err = eng.ListWorkspaces(ctx)
+		}
 		if err != nil {
 			return err
 		}
@@ -49,9 +58,14 @@ var workspaceLsCmd = &cobra.Command{
 				ws.ActiveStore,
 				appliedMark,
 				fmt.Sprintf("%d", ws.AppliedPathCount),
+				orDash(strings.Join(ws.Tags, ",")),
 			})
 		}
-		PrintTable([]string{"Workspace ID", "Absolute Path", "Active Store", "Applied", "Paths"}, rows)
+		PrintTable([]string{"Workspace ID", "Absolute Path", "Active Store", "Applied", "Paths", "Tags"}, rows)
 		return nil
 	},
 }
+
+func init() {
+	workspaceLsCmd.Flags().StringVar(&workspaceLsTag, "tag", "", "Only list workspaces with this tag")
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/danieljhkim/monodev/internal/engine"
+	"github.com/spf13/cobra"
+)
+
+// workspaceMvCmd moves workspace state to a new workspace path.
+var workspaceMvCmd = &cobra.Command{
+	Use:   "mv <old-path> <new-path>",
+	Short: "Move workspace state after a directory is moved",
+	Long: `Move a workspace's state to a new workspace path.
+
+Use this after moving a component directory within the repository so its
+applied-path tracking follows it. Paths may be repo-relative or absolute.
+Fails if state already exists for the new path.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		result, err := eng.RehomeWorkspace(ctx, &engine.RehomeRequest{
+			CWD:     cwd,
+			OldPath: args[0],
+			NewPath: args[1],
+		})
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return outputJSON(result)
+		}
+
+		PrintSuccess(fmt.Sprintf("Moved workspace %s -> %s", result.OldWorkspacePath, result.NewWorkspacePath))
+		PrintInfo(fmt.Sprintf("Workspace ID: %s", result.NewWorkspaceID))
+
+		return nil
+	},
+}
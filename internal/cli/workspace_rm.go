@@ -3,14 +3,16 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/danieljhkim/monodev/internal/engine"
 	"github.com/spf13/cobra"
 )
 
 var (
-	workspaceRmForce  bool
-	workspaceRmDryRun bool
+	workspaceRmForce   bool
+	workspaceRmDryRun  bool
+	workspaceRmUnapply bool
 )
 
 // workspaceRmCmd deletes a workspace state file.
@@ -22,8 +24,9 @@ var workspaceRmCmd = &cobra.Command{
 This command will check if the workspace has applied overlays before deletion.
 If overlays are applied, you'll need to use --force to proceed.
 
-IMPORTANT: This only deletes the state file, not the actual workspace files.
-Use 'monodev unapply' first to remove applied overlays from the workspace.`,
+IMPORTANT: This only deletes the state file, not the actual workspace files,
+unless --unapply is set. --unapply removes every managed path first and must
+be run from within the workspace's repository.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		workspaceID := args[0]
@@ -39,6 +42,13 @@ Use 'monodev unapply' first to remove applied overlays from the workspace.`,
 			WorkspaceID: workspaceID,
 			Force:       workspaceRmForce,
 			DryRun:      workspaceRmDryRun,
+			Unapply:     workspaceRmUnapply,
+		}
+		if workspaceRmUnapply {
+			req.CWD, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
 		}
 
 		result, err := eng.DeleteWorkspace(ctx, req)
@@ -65,6 +75,9 @@ Use 'monodev unapply' first to remove applied overlays from the workspace.`,
 
 		// Success output
 		PrintSection("Delete Workspace")
+		if workspaceRmUnapply {
+			PrintSuccess(fmt.Sprintf("Removed %s", PrintCount(len(result.Unapplied), "managed path", "managed paths")))
+		}
 		PrintSuccess(fmt.Sprintf("Deleted workspace state: %s", result.WorkspaceID))
 		PrintInfo(fmt.Sprintf("Workspace path: %s", result.WorkspacePath))
 
@@ -75,4 +88,5 @@ Use 'monodev unapply' first to remove applied overlays from the workspace.`,
 func init() {
 	workspaceRmCmd.Flags().BoolVarP(&workspaceRmForce, "force", "f", false, "Force deletion even if workspace has applied paths")
 	workspaceRmCmd.Flags().BoolVar(&workspaceRmDryRun, "dry-run", false, "Show what would be deleted without deleting")
+	workspaceRmCmd.Flags().BoolVar(&workspaceRmUnapply, "unapply", false, "Remove managed files from disk before deleting the state")
 }
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var workspaceImportOverwrite bool
+
+// workspaceExportCmd writes every workspace's state to a bundle file.
+var workspaceExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export all workspace state to a bundle file",
+	Long: `Export the state of every workspace to a single JSON bundle, for backups or
+moving to another machine. Use "-" to write to stdout.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if path != "-" {
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", path, err)
+			}
+			defer func() {
+				_ = f.Close()
+			}()
+			out = f
+		}
+
+		if err := eng.ExportState(context.Background(), out); err != nil {
+			return fmt.Errorf("failed to export workspace state: %w", err)
+		}
+
+		if path != "-" {
+			PrintSuccess(fmt.Sprintf("Exported workspace state to %s", path))
+		}
+		return nil
+	},
+}
+
+// workspaceImportCmd restores workspace state from a bundle file.
+var workspaceImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import workspace state from a bundle file",
+	Long: `Import workspace state previously written by 'workspace export'.
+Fails without changes if any workspace in the bundle already has state,
+unless --overwrite is set.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", args[0], err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+
+		result, err := eng.ImportState(context.Background(), f, workspaceImportOverwrite)
+		if err != nil {
+			return fmt.Errorf("failed to import workspace state: %w", err)
+		}
+
+		if jsonOutput {
+			return outputJSON(result)
+		}
+
+		PrintSuccess(fmt.Sprintf("Imported %s", PrintCount(len(result.Imported), "workspace", "workspaces")))
+		if len(result.Overwritten) > 0 {
+			PrintWarning(fmt.Sprintf("Overwrote %s", PrintCount(len(result.Overwritten), "existing workspace", "existing workspaces")))
+		}
+		return nil
+	},
+}
+
+// workspaceCompactCmd collapses fully covered directories in workspace state.
+var workspaceCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Shrink the current workspace's state file",
+	Long: `Collapse directories whose contents are all applied from the same store
+into single entries. The next apply records the paths it touches individually
+again.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		result, err := eng.CompactWorkspace(context.Background(), cwd)
+		if err != nil {
+			return fmt.Errorf("failed to compact workspace state: %w", err)
+		}
+
+		if jsonOutput {
+			return outputJSON(result)
+		}
+
+		if result.Removed == 0 {
+			PrintInfo("Nothing to compact")
+			return nil
+		}
+		PrintSuccess(fmt.Sprintf("Removed %s (%d remaining)", PrintCount(result.Removed, "path entry", "path entries"), result.Remaining))
+		return nil
+	},
+}
+
+func init() {
+	workspaceImportCmd.Flags().BoolVar(&workspaceImportOverwrite, "overwrite", false, "Replace existing workspace state")
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danieljhkim/monodev/internal/engine"
+	"github.com/spf13/cobra"
+)
+
+var workspaceTagRemove []string
+
+// workspaceTagCmd adds and removes workspace tags.
+var workspaceTagCmd = &cobra.Command{
+	Use:   "tag <workspace-id> [tag...]",
+	Short: "Add or remove workspace tags",
+	Long: `Label a workspace with free-form tags, independent of its repo and path.
+
+Tags given as arguments are added; tags given with --remove are removed.
+List the workspaces carrying a tag with 'workspace ls --tag <tag>'.
+
+Examples:
+  # Tag a workspace
+  monodev workspace tag 3f2a9c experiment
+
+  # Replace one tag with another
+  monodev workspace tag 3f2a9c prod-like --remove experiment`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 && len(workspaceTagRemove) == 0 {
+			return fmt.Errorf("specify tags to add or --remove")
+		}
+
+		eng, err := newEngine()
+		if err != nil {
+			return err
+		}
+
+		result, err := eng.TagWorkspace(context.Background(), &engine.TagWorkspaceRequest{
+			WorkspaceID: args[0],
+			Add:         args[1:],
+			Remove:      workspaceTagRemove,
+		})
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return outputJSON(result)
+		}
+
+		if len(result.Tags) == 0 {
+			PrintSuccess(fmt.Sprintf("Workspace %s has no tags", result.WorkspaceID))
+		} else {
+			PrintSuccess(fmt.Sprintf("Workspace %s tags: %s", result.WorkspaceID, strings.Join(result.Tags, ", ")))
+		}
+		return nil
+	},
+}
+
+func init() {
+	workspaceTagCmd.Flags().StringSliceVar(&workspaceTagRemove, "remove", nil, "Tags to remove")
+}
@@ -2,13 +2,16 @@
 //
 // Configuration includes the locations of monodev data directories, which can
 // be customized via environment variables. The default root is ~/.monodev/
-// containing stores/, workspaces/, and config files.
+// containing stores/, workspaces/, and config files. When XDG_DATA_HOME or
+// XDG_CONFIG_HOME are set and ~/.monodev does not already exist, data and
+// config live under their monodev subdirectories instead.
 package config
 
 import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // Paths contains all the filesystem paths used by monodev.
@@ -29,30 +32,97 @@ type Paths struct {
 // DefaultPaths returns the default paths for monodev.
 // Path resolution priority:
 // 1. MONODEV_ROOT environment variable (highest priority)
-// 2. Repo-local .monodev (if exists and we're in a git repo)
-// 3. ~/.monodev (fallback - existing behavior)
+// 2. Repo-local .monodev (if exists, in a git repo, and not disabled by settings)
+// 3. The root named by MONODEV_PROFILE in the user settings (if set)
+// 4. ~/.monodev (if it already exists)
+// 5. $XDG_DATA_HOME/monodev and $XDG_CONFIG_HOME/monodev (if set)
+// 6. ~/.monodev (fallback)
 func DefaultPaths() (*Paths, error) {
 	// Priority 1: MONODEV_ROOT env var
 	if root := os.Getenv("MONODEV_ROOT"); root != "" {
 		return buildPaths(root), nil
 	}
 
-	// Priority 2: Repo-local .monodev
+	// Priority 2: Repo-local .monodev, unless its settings disable it
 	if cwd, err := os.Getwd(); err == nil {
 		if repoRoot, err := discoverGitRoot(cwd); err == nil {
-			repoLocalPath := filepath.Join(repoRoot, ".monodev")
-			if pathExists(repoLocalPath) {
+			repoLocalPath, err := componentRoot(repoRoot)
+			if err != nil {
+				return nil, err
+			}
+			if repoLocalPath != "" {
 				return buildPaths(repoLocalPath), nil
 			}
 		}
 	}
 
-	// Priority 3: Global ~/.monodev (fallback)
-	home, err := os.UserHomeDir()
+	// Priority 3 to 6: a named profile, then the per-user paths
+	return globalPaths()
+}
+
+// globalPaths returns the paths of the root named by MONODEV_PROFILE, or the
+// per-user paths when no profile is selected.
+func globalPaths() (*Paths, error) {
+	user, err := userPaths()
+	if err != nil {
+		return nil, err
+	}
+	name := os.Getenv(EnvProfile)
+	if name == "" {
+		return user, nil
+	}
+
+	settingsDir := filepath.Dir(user.Config)
+	settings, err := LoadUserSettings(settingsDir)
 	if err != nil {
+		return nil, err
+	}
+	root, ok := settings.Roots[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s %q: no such root in %s", EnvProfile, name, filepath.Join(settingsDir, UserSettingsFileName))
+	}
+	if rest, found := strings.CutPrefix(root, "~/"); found {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		root = filepath.Join(home, rest)
+	}
+	if !filepath.IsAbs(root) {
+		return nil, fmt.Errorf("root %q for %s %q must be an absolute path", root, EnvProfile, name)
+	}
+	return buildPaths(root), nil
+}
+
+// userPaths returns the per-user paths, honoring XDG_DATA_HOME for data and
+// XDG_CONFIG_HOME for the config file, and falling back to ~/.monodev for
+// whichever is unset. An existing ~/.monodev is kept even when XDG variables
+// are set, so the stores and workspaces already in it are not orphaned.
+func userPaths() (*Paths, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+
+	home, err := os.UserHomeDir()
+	if err != nil && (dataHome == "" || configHome == "") {
 		return nil, fmt.Errorf("failed to get user home directory: %w", err)
 	}
-	return buildPaths(filepath.Join(home, ".monodev")), nil
+	if err == nil {
+		legacyRoot := filepath.Join(home, ".monodev")
+		if info, statErr := os.Stat(legacyRoot); statErr == nil && info.IsDir() {
+			return buildPaths(legacyRoot), nil
+		}
+	}
+
+	root := filepath.Join(home, ".monodev")
+	if dataHome != "" {
+		root = filepath.Join(dataHome, "monodev")
+	}
+
+	paths := buildPaths(root)
+	if configHome != "" {
+		paths.Config = filepath.Join(configHome, "monodev", "config.yaml")
+	}
+	return paths, nil
 }
 
 // buildPaths constructs a Paths struct from a root directory.
@@ -97,7 +167,7 @@ func pathExists(path string) bool {
 
 // ScopedPaths provides dual-scope path resolution for global and component stores.
 type ScopedPaths struct {
-	// Global points to ~/.monodev (or MONODEV_ROOT)
+	// Global points to ~/.monodev (or MONODEV_ROOT, or the XDG directories)
 	Global *Paths
 
 	// Component points to repo_root/.monodev (nil if no repo context)
@@ -111,28 +181,34 @@ type ScopedPaths struct {
 }
 
 // NewScopedPaths resolves both global and component paths.
-// Global always resolves to ~/.monodev (or MONODEV_ROOT).
-// Component resolves to repo_root/.monodev if we're in a git repo that has it.
+// Global resolves to MONODEV_ROOT if set, then the MONODEV_PROFILE root, then
+// the XDG directories, then ~/.monodev.
+// Component resolves to repo_root/.monodev if we're in a git repo that has it,
+// unless .monodev/settings.json sets disable_component_scope.
 func NewScopedPaths() (*ScopedPaths, error) {
 	sp := &ScopedPaths{}
 
-	// Global: MONODEV_ROOT or ~/.monodev
+	// Global: MONODEV_ROOT, a named profile, XDG directories, or ~/.monodev
 	if root := os.Getenv("MONODEV_ROOT"); root != "" {
 		sp.Global = buildPaths(root)
 	} else {
-		home, err := os.UserHomeDir()
+		global, err := globalPaths()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get user home directory: %w", err)
+			return nil, err
 		}
-		sp.Global = buildPaths(filepath.Join(home, ".monodev"))
+		sp.Global = global
 	}
 
-	// Component: repo_root/.monodev (if in a git repo)
+	// Component: repo_root/.monodev (if in a git repo and not disabled by
+	// the repo's settings)
 	if cwd, err := os.Getwd(); err == nil {
 		if repoRoot, err := discoverGitRoot(cwd); err == nil {
 			sp.RepoRoot = repoRoot
-			repoLocalPath := filepath.Join(repoRoot, ".monodev")
-			if pathExists(repoLocalPath) {
+			repoLocalPath, err := componentRoot(repoRoot)
+			if err != nil {
+				return nil, err
+			}
+			if repoLocalPath != "" {
 				sp.Component = buildPaths(repoLocalPath)
 				sp.HasRepoContext = true
 			}
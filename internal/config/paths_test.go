@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -18,6 +19,8 @@ func TestDefaultPaths(t *testing.T) {
 		if err := os.Unsetenv("MONODEV_ROOT"); err != nil {
 			t.Fatalf("failed to unset MONODEV_ROOT: %v", err)
 		}
+		t.Setenv("XDG_DATA_HOME", "")
+		t.Setenv("XDG_CONFIG_HOME", "")
 
 		paths, err := DefaultPaths()
 		if err != nil {
@@ -278,6 +281,8 @@ func TestNewScopedPaths(t *testing.T) {
 		if err := os.Unsetenv("MONODEV_ROOT"); err != nil {
 			t.Fatalf("failed to unset MONODEV_ROOT: %v", err)
 		}
+		t.Setenv("XDG_DATA_HOME", "")
+		t.Setenv("XDG_CONFIG_HOME", "")
 
 		sp, err := NewScopedPaths()
 		if err != nil {
@@ -447,6 +452,252 @@ func TestNewScopedPaths(t *testing.T) {
 	})
 }
 
+func TestNewScopedPaths_DisableComponentScope(t *testing.T) {
+	t.Setenv("MONODEV_ROOT", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git: %v", err)
+	}
+	monodevDir := filepath.Join(tmpDir, ".monodev")
+	if err := os.MkdirAll(filepath.Join(monodevDir, "stores"), 0755); err != nil {
+		t.Fatalf("failed to create .monodev: %v", err)
+	}
+	settings := []byte(`{"disable_component_scope": true}`)
+	if err := os.WriteFile(filepath.Join(monodevDir, RepoSettingsFileName), settings, 0644); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get cwd: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(oldWd); err != nil {
+			t.Errorf("failed to restore working directory: %v", err)
+		}
+	}()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+
+	sp, err := NewScopedPaths()
+	if err != nil {
+		t.Fatalf("NewScopedPaths failed: %v", err)
+	}
+	if sp.HasRepoContext {
+		t.Error("expected HasRepoContext to be false when the component scope is disabled")
+	}
+	if sp.Component != nil {
+		t.Errorf("expected Component to be nil, got %+v", sp.Component)
+	}
+	if sp.RepoRoot == "" {
+		t.Error("expected RepoRoot to still be set")
+	}
+
+	paths, err := DefaultPaths()
+	if err != nil {
+		t.Fatalf("DefaultPaths failed: %v", err)
+	}
+	if paths.Root != sp.Global.Root {
+		t.Errorf("DefaultPaths root = %s, want global root %s", paths.Root, sp.Global.Root)
+	}
+
+	t.Run("malformed settings are reported", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(monodevDir, RepoSettingsFileName), []byte("{"), 0644); err != nil {
+			t.Fatalf("failed to write settings: %v", err)
+		}
+		if _, err := NewScopedPaths(); err == nil {
+			t.Error("expected an error for malformed settings")
+		}
+	})
+}
+
+func TestDefaultPaths_XDG(t *testing.T) {
+	// Run outside any repo so repo-local .monodev does not take priority
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get cwd: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(oldWd); err != nil {
+			t.Errorf("failed to restore working directory: %v", err)
+		}
+	}()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	t.Setenv("MONODEV_ROOT", "")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Run("uses XDG data and config homes when set", func(t *testing.T) {
+		t.Setenv("XDG_DATA_HOME", "/xdg/data")
+		t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+
+		paths, err := DefaultPaths()
+		if err != nil {
+			t.Fatalf("DefaultPaths failed: %v", err)
+		}
+		if paths.Root != filepath.Join("/xdg/data", "monodev") {
+			t.Errorf("Root = %s, want /xdg/data/monodev", paths.Root)
+		}
+		if paths.Stores != filepath.Join("/xdg/data", "monodev", "stores") {
+			t.Errorf("Stores = %s", paths.Stores)
+		}
+		if paths.Config != filepath.Join("/xdg/config", "monodev", "config.yaml") {
+			t.Errorf("Config = %s, want /xdg/config/monodev/config.yaml", paths.Config)
+		}
+	})
+
+	t.Run("falls back to ~/.monodev when XDG is unset", func(t *testing.T) {
+		t.Setenv("XDG_DATA_HOME", "")
+		t.Setenv("XDG_CONFIG_HOME", "")
+
+		paths, err := DefaultPaths()
+		if err != nil {
+			t.Fatalf("DefaultPaths failed: %v", err)
+		}
+		if paths.Root != filepath.Join(home, ".monodev") {
+			t.Errorf("Root = %s, want ~/.monodev", paths.Root)
+		}
+		if paths.Config != filepath.Join(home, ".monodev", "config.yaml") {
+			t.Errorf("Config = %s, want ~/.monodev/config.yaml", paths.Config)
+		}
+	})
+
+	t.Run("MONODEV_ROOT takes priority over XDG", func(t *testing.T) {
+		t.Setenv("MONODEV_ROOT", "/custom/root")
+		t.Setenv("XDG_DATA_HOME", "/xdg/data")
+		t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+
+		paths, err := DefaultPaths()
+		if err != nil {
+			t.Fatalf("DefaultPaths failed: %v", err)
+		}
+		if paths.Root != "/custom/root" || paths.Config != filepath.Join("/custom/root", "config.yaml") {
+			t.Errorf("expected MONODEV_ROOT paths, got %+v", paths)
+		}
+	})
+
+	t.Run("NewScopedPaths global uses XDG", func(t *testing.T) {
+		t.Setenv("XDG_DATA_HOME", "/xdg/data")
+		t.Setenv("XDG_CONFIG_HOME", "")
+
+		sp, err := NewScopedPaths()
+		if err != nil {
+			t.Fatalf("NewScopedPaths failed: %v", err)
+		}
+		if sp.Global.Root != filepath.Join("/xdg/data", "monodev") {
+			t.Errorf("Global.Root = %s, want /xdg/data/monodev", sp.Global.Root)
+		}
+		if sp.Global.Config != filepath.Join("/xdg/data", "monodev", "config.yaml") {
+			t.Errorf("Global.Config = %s", sp.Global.Config)
+		}
+	})
+
+	t.Run("keeps an existing ~/.monodev over XDG", func(t *testing.T) {
+		t.Setenv("XDG_DATA_HOME", "/xdg/data")
+		t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+		legacyRoot := filepath.Join(home, ".monodev")
+		if err := os.Mkdir(legacyRoot, 0755); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			_ = os.Remove(legacyRoot)
+		}()
+
+		paths, err := DefaultPaths()
+		if err != nil {
+			t.Fatalf("DefaultPaths failed: %v", err)
+		}
+		if paths.Root != legacyRoot || paths.Config != filepath.Join(legacyRoot, "config.yaml") {
+			t.Errorf("expected the existing ~/.monodev paths, got %+v", paths)
+		}
+	})
+}
+
+func TestDefaultPaths_Profile(t *testing.T) {
+	// Run outside any repo so repo-local .monodev does not take priority
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get cwd: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(oldWd); err != nil {
+			t.Errorf("failed to restore working directory: %v", err)
+		}
+	}()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+
+	configHome := t.TempDir()
+	t.Setenv("MONODEV_ROOT", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("HOME", t.TempDir())
+
+	settingsDir := filepath.Join(configHome, "monodev")
+	if err := os.MkdirAll(settingsDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	settings := []byte(`{"roots": {"work": "/roots/work", "personal": "/roots/personal"}}`)
+	if err := os.WriteFile(filepath.Join(settingsDir, UserSettingsFileName), settings, 0644); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	t.Run("selects the named root", func(t *testing.T) {
+		t.Setenv(EnvProfile, "work")
+
+		paths, err := DefaultPaths()
+		if err != nil {
+			t.Fatalf("DefaultPaths failed: %v", err)
+		}
+		if paths.Root != "/roots/work" {
+			t.Errorf("Root = %s, want /roots/work", paths.Root)
+		}
+
+		sp, err := NewScopedPaths()
+		if err != nil {
+			t.Fatalf("NewScopedPaths failed: %v", err)
+		}
+		if sp.Global.Root != "/roots/work" {
+			t.Errorf("Global.Root = %s, want /roots/work", sp.Global.Root)
+		}
+	})
+
+	t.Run("MONODEV_ROOT takes priority", func(t *testing.T) {
+		t.Setenv(EnvProfile, "personal")
+		t.Setenv("MONODEV_ROOT", "/override")
+
+		paths, err := DefaultPaths()
+		if err != nil {
+			t.Fatalf("DefaultPaths failed: %v", err)
+		}
+		if paths.Root != "/override" {
+			t.Errorf("Root = %s, want /override", paths.Root)
+		}
+	})
+
+	t.Run("unknown profile errors", func(t *testing.T) {
+		t.Setenv(EnvProfile, "missing")
+
+		_, err := DefaultPaths()
+		if err == nil {
+			t.Fatal("expected an error for an unknown profile")
+		}
+		if !strings.Contains(err.Error(), `"missing"`) {
+			t.Errorf("error = %v, want it to name the profile", err)
+		}
+		if _, err := NewScopedPaths(); err == nil {
+			t.Error("expected NewScopedPaths to fail for an unknown profile")
+		}
+	})
+}
+
 func TestPaths_EnsureDirectories(t *testing.T) {
 	t.Run("creates all necessary directories", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "config-test-*")
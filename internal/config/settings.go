@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RepoSettingsFileName is the name of the repo-local settings file inside
+// repo_root/.monodev.
+const RepoSettingsFileName = "settings.json"
+
+// RepoSettings holds per-repository options, stored repo-locally at
+// .monodev/settings.json.
+type RepoSettings struct {
+	// DisableComponentScope ignores repo_root/.monodev as a store and
+	// workspace root, so monodev runs global-only in this repo (e.g. a
+	// vendored copy whose .monodev belongs to another project)
+	DisableComponentScope bool `json:"disable_component_scope,omitempty"`
+
+	// Ignore lists ignore patterns added to every store's own when
+	// expanding tracked directories in this repo
+	Ignore []string `json:"ignore,omitempty"`
+
+	// DefaultRole is the role given to tracked paths that set none
+	DefaultRole string `json:"default_role,omitempty"`
+
+	// MaxStoreBytes caps the overlay size of each component store in this
+	// repo (0 = unlimited). The global limit is in UserSettings.
+	MaxStoreBytes int64 `json:"max_store_bytes,omitempty"`
+}
+
+// LoadRepoSettings reads the settings file in repoLocalPath (a repo's
+// .monodev directory). A missing file yields the zero settings.
+func LoadRepoSettings(repoLocalPath string) (*RepoSettings, error) {
+	data, err := os.ReadFile(filepath.Join(repoLocalPath, RepoSettingsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &RepoSettings{}, nil
+		}
+		return nil, fmt.Errorf("failed to read repo settings: %w", err)
+	}
+
+	var settings RepoSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse repo settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// UserSettingsFileName is the name of the per-user settings file, kept in
+// the directory of the global config file (~/.monodev by default).
+const UserSettingsFileName = "settings.json"
+
+// UserSettings holds per-user options that apply across every root.
+type UserSettings struct {
+	// Roots maps profile names to monodev roots, selected with
+	// MONODEV_PROFILE. A leading "~/" refers to the home directory.
+	Roots map[string]string `json:"roots,omitempty"`
+
+	// MaxStoreBytes caps the overlay size of each global store
+	// (0 = unlimited)
+	MaxStoreBytes int64 `json:"max_store_bytes,omitempty"`
+}
+
+// LoadUserSettings reads the user settings file in dir. A missing file
+// yields the zero settings.
+func LoadUserSettings(dir string) (*UserSettings, error) {
+	data, err := os.ReadFile(filepath.Join(dir, UserSettingsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &UserSettings{}, nil
+		}
+		return nil, fmt.Errorf("failed to read user settings: %w", err)
+	}
+
+	var settings UserSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse user settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// EffectiveUserSettings reads the user settings file next to the per-user
+// config file, whichever root or profile is selected.
+func EffectiveUserSettings() (*UserSettings, error) {
+	user, err := userPaths()
+	if err != nil {
+		return nil, err
+	}
+	return LoadUserSettings(filepath.Dir(user.Config))
+}
+
+// componentRoot returns repo_root/.monodev when it exists and its settings
+// leave the component scope enabled, or "" otherwise.
+func componentRoot(repoRoot string) (string, error) {
+	repoLocalPath := filepath.Join(repoRoot, ".monodev")
+	if !pathExists(repoLocalPath) {
+		return "", nil
+	}
+	settings, err := LoadRepoSettings(repoLocalPath)
+	if err != nil {
+		return "", err
+	}
+	if settings.DisableComponentScope {
+		return "", nil
+	}
+	return repoLocalPath, nil
+}
+
+// Environment variables forcing defaults, e.g. for deterministic CI runs.
+const (
+	// EnvMode sets the apply mode ("copy" or "symlink") for requests that
+	// leave it unset
+	EnvMode = "MONODEV_MODE"
+
+	// EnvScope sets the store scope ("global" or "component") for requests
+	// that leave it unset
+	EnvScope = "MONODEV_SCOPE"
+
+	// EnvProfile selects a named root from the user settings' roots. It
+	// ranks below MONODEV_ROOT and above the per-user default root.
+	EnvProfile = "MONODEV_PROFILE"
+)
+
+// Settings are the defaults in force for requests that leave mode or scope
+// unset. They rank above repo and global settings but below values given
+// explicitly in a request. An empty field means no override.
+type Settings struct {
+	// Mode is the forced apply mode, from MONODEV_MODE
+	Mode string `json:"mode,omitempty"`
+
+	// Scope is the forced store scope, from MONODEV_SCOPE
+	Scope string `json:"scope,omitempty"`
+}
+
+// EffectiveSettings reads the overrides from the environment, rejecting
+// values that are not a valid mode or scope.
+func EffectiveSettings() (*Settings, error) {
+	settings := &Settings{
+		Mode:  os.Getenv(EnvMode),
+		Scope: os.Getenv(EnvScope),
+	}
+	switch settings.Mode {
+	case "", "copy", "symlink":
+	default:
+		return nil, fmt.Errorf("invalid %s %q: must be copy or symlink", EnvMode, settings.Mode)
+	}
+	switch settings.Scope {
+	case "", "global", "component":
+	default:
+		return nil, fmt.Errorf("invalid %s %q: must be global or component", EnvScope, settings.Scope)
+	}
+	return settings, nil
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEffectiveSettings(t *testing.T) {
+	t.Run("unset variables leave no override", func(t *testing.T) {
+		t.Setenv(EnvMode, "")
+		t.Setenv(EnvScope, "")
+
+		settings, err := EffectiveSettings()
+		if err != nil {
+			t.Fatalf("EffectiveSettings failed: %v", err)
+		}
+		if *settings != (Settings{}) {
+			t.Errorf("settings = %+v, want none", settings)
+		}
+	})
+
+	t.Run("reads mode and scope", func(t *testing.T) {
+		t.Setenv(EnvMode, "symlink")
+		t.Setenv(EnvScope, "global")
+
+		settings, err := EffectiveSettings()
+		if err != nil {
+			t.Fatalf("EffectiveSettings failed: %v", err)
+		}
+		if settings.Mode != "symlink" || settings.Scope != "global" {
+			t.Errorf("settings = %+v, want symlink/global", settings)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		for _, tc := range []struct{ mode, scope, want string }{
+			{"hardlink", "", EnvMode},
+			{"", "team", EnvScope},
+		} {
+			t.Setenv(EnvMode, tc.mode)
+			t.Setenv(EnvScope, tc.scope)
+			if _, err := EffectiveSettings(); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("EffectiveSettings(%q, %q) error = %v, want invalid %s", tc.mode, tc.scope, err, tc.want)
+			}
+		}
+	})
+}
+
+func TestEffectiveUserSettings(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("MONODEV_ROOT", "/custom/root")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("HOME", t.TempDir())
+
+	t.Run("missing file yields zero settings", func(t *testing.T) {
+		settings, err := EffectiveUserSettings()
+		if err != nil {
+			t.Fatalf("EffectiveUserSettings failed: %v", err)
+		}
+		if settings.MaxStoreBytes != 0 || len(settings.Roots) != 0 {
+			t.Errorf("settings = %+v, want zero", settings)
+		}
+	})
+
+	t.Run("reads the file next to the user config", func(t *testing.T) {
+		settingsDir := filepath.Join(configHome, "monodev")
+		if err := os.MkdirAll(settingsDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		data := []byte(`{"max_store_bytes": 1024}`)
+		if err := os.WriteFile(filepath.Join(settingsDir, UserSettingsFileName), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		settings, err := EffectiveUserSettings()
+		if err != nil {
+			t.Fatalf("EffectiveUserSettings failed: %v", err)
+		}
+		if settings.MaxStoreBytes != 1024 {
+			t.Errorf("MaxStoreBytes = %d, want 1024", settings.MaxStoreBytes)
+		}
+	})
+}
@@ -2,7 +2,15 @@ package engine
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/danieljhkim/monodev/internal/planner"
 	"github.com/danieljhkim/monodev/internal/state"
@@ -18,6 +26,279 @@ import (
 // 6. Persist workspace state
 // 7. Return result
 func (e *Engine) Apply(ctx context.Context, req *ApplyRequest) (*ApplyResult, error) {
+	req = withDefaultMode(e, req)
+	ac, err := e.prepareApply(req)
+	if err != nil {
+		return nil, err
+	}
+	defer ac.cleanup()
+
+	plan, resumed, err := e.planApply(ac, req)
+	if err != nil {
+		return nil, err
+	}
+	// Only a previewed plan needs a fingerprint to confirm later; hashing
+	// every source on each apply would be wasted work
+	if req.DryRun {
+		plan.Fingerprint = plan.ComputeFingerprint(e.treeChecksum)
+	}
+
+	result, err := e.applyPlanned(ctx, req, ac, plan)
+	if result != nil {
+		result.Resumed = resumed
+	}
+	return result, err
+}
+
+// planApply builds the plan for an apply request. For a Resume apply it
+// first adopts the paths an interrupted apply already placed, then drops
+// their operations from the plan, returning the adopted paths.
+func (e *Engine) planApply(ac *applyContext, req *ApplyRequest) (*planner.ApplyPlan, []string, error) {
+	if !req.Resume {
+		plan, err := ac.buildPlan(e, req.Mode, req.Force)
+		return plan, nil, err
+	}
+	if req.TargetDir != "" {
+		return nil, nil, fmt.Errorf("%w: resume is not supported with a target directory", ErrValidation)
+	}
+
+	resumed, err := e.adoptPlaced(ac, req.Mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, err := ac.buildPlan(e, req.Mode, req.Force)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done := make(map[string]bool, len(resumed))
+	for _, relPath := range resumed {
+		done[relPath] = true
+		plan.AddNote(fmt.Sprintf("resumed: %s already in place", relPath))
+	}
+	remaining := plan.Operations[:0]
+	for _, op := range plan.Operations {
+		if !done[op.RelPath] {
+			remaining = append(remaining, op)
+		}
+	}
+	plan.Operations = remaining
+	return plan, resumed, nil
+}
+
+// adoptPlaced records ownership of the paths an apply that was interrupted
+// before saving state had already placed. It returns those paths, sorted.
+//
+// The interrupted apply ran the plan's operations in order, so only the
+// leading run of unowned paths found on disk exactly as planned can be its
+// work. A matching file after the first path not in place was already there
+// and is left to conflict detection, as are paths some store already owns.
+func (e *Engine) adoptPlaced(ac *applyContext, mode string) ([]string, error) {
+	// Plan against empty ownership, forcing past existing files, to learn
+	// what the store installs at each path
+	fresh := state.NewWorkspaceState(ac.repoFingerprint, ac.workspacePath, mode)
+	plan, err := ac.buildPlanFor(e, fresh, mode, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var placed []string
+	for _, op := range plan.Operations {
+		if op.Type == planner.OpRemove || op.Type == planner.OpBackup {
+			continue
+		}
+		if _, owned := ac.workspaceState.Owner(op.RelPath); owned {
+			continue
+		}
+		if !e.isPlaced(op) {
+			break
+		}
+		ac.workspaceState.Paths[op.RelPath] = e.appliedOwnership(op, ac.storeScope, mode)
+		placed = append(placed, op.RelPath)
+	}
+	sort.Strings(placed)
+	return placed, nil
+}
+
+// isPlaced reports whether op's destination already holds what op would
+// install: a link to its source, a copy of its content, or a directory.
+func (e *Engine) isPlaced(op planner.Operation) bool {
+	info, err := e.fs.Lstat(op.DestPath)
+	if err != nil {
+		return false
+	}
+	switch op.Type {
+	case planner.OpCreateSymlink:
+		target, err := e.fs.Readlink(op.DestPath)
+		return err == nil && filepath.Clean(target) == filepath.Clean(op.SourcePath)
+	case planner.OpMkdir:
+		return info.IsDir()
+	case planner.OpCopy:
+		sourceInfo, err := e.fs.Lstat(op.SourcePath)
+		if err != nil || info.Mode()&os.ModeSymlink != 0 || info.IsDir() != sourceInfo.IsDir() {
+			return false
+		}
+		return e.sameContent(op.SourcePath, op.DestPath)
+	default:
+		return false
+	}
+}
+
+// ApplyConfirmed rebuilds the plan for a previously shown apply and executes
+// it only if its fingerprint still matches the one the user confirmed, so
+// changes to the workspace or store between review and apply are not
+// applied unseen.
+func (e *Engine) ApplyConfirmed(ctx context.Context, req *ApplyConfirmedRequest) (*ApplyResult, error) {
+	if req.Fingerprint == "" {
+		return nil, fmt.Errorf("%w: a plan fingerprint is required", ErrValidation)
+	}
+	if req.DryRun {
+		return nil, fmt.Errorf("%w: a confirmed apply cannot be a dry run", ErrValidation)
+	}
+	req = withDefaultMode(e, req)
+
+	ac, err := e.prepareApply(&req.ApplyRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer ac.cleanup()
+
+	plan, resumed, err := e.planApply(ac, &req.ApplyRequest)
+	if err != nil {
+		return nil, err
+	}
+	plan.Fingerprint = plan.ComputeFingerprint(e.treeChecksum)
+
+	if plan.Fingerprint != req.Fingerprint {
+		return ac.result(plan, []planner.Operation{}), fmt.Errorf("%w: confirmed %s, now %s; review the plan again",
+			ErrPlanChanged, shortFingerprint(req.Fingerprint), shortFingerprint(plan.Fingerprint))
+	}
+
+	result, err := e.applyPlanned(ctx, &req.ApplyRequest, ac, plan)
+	if result != nil {
+		result.Resumed = resumed
+	}
+	return result, err
+}
+
+// shortFingerprint abbreviates a plan fingerprint for messages.
+func shortFingerprint(fingerprint string) string {
+	if len(fingerprint) > checksumDisplayLen {
+		return fingerprint[:checksumDisplayLen]
+	}
+	return fingerprint
+}
+
+// applyPlanned checks a built plan against the request's policies and, unless
+// it is a dry run, executes it.
+func (e *Engine) applyPlanned(ctx context.Context, req *ApplyRequest, ac *applyContext, plan *planner.ApplyPlan) (*ApplyResult, error) {
+	var locked []LockedStore
+	if req.Locked || req.WriteLock {
+		scope := ac.storeScope
+		if scope == "" {
+			scope = e.scopeOf(ac.localRepo)
+		}
+		var err error
+		locked, err = e.lockStores(ac.applyRepo, []string{ac.storeToApply}, map[string]string{ac.storeToApply: scope})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.Locked {
+		if err := e.verifyLock(ac.applyRoot, locked); err != nil {
+			return ac.result(plan, []planner.Operation{}), err
+		}
+	}
+
+	// Nested workspaces managing the same paths would undo each other's work.
+	// Files applied to a TargetDir live outside the workspace and can't clash.
+	if req.TargetDir == "" {
+		overlaps, err := e.workspaceOverlaps(ctx, ac, plan)
+		if err != nil {
+			return nil, err
+		}
+		if len(overlaps) > 0 && req.RefuseOverlap {
+			return ac.result(plan, []planner.Operation{}), fmt.Errorf("%w: %d paths overlap another workspace: %s",
+				ErrConflict, len(overlaps), strings.Join(overlaps, "; "))
+		}
+		for _, overlap := range overlaps {
+			plan.AddWarning(overlap)
+		}
+	}
+
+	if req.WarningsAsErrors && len(plan.Warnings) > 0 {
+		return ac.result(plan, []planner.Operation{}), fmt.Errorf("%w: %d plan warnings: %s",
+			ErrValidation, len(plan.Warnings), strings.Join(plan.Warnings, "; "))
+	}
+
+	if plan.HasConflicts() && !req.Force {
+		// Precompute the choices a caller can pass to ApplyWithResolutions
+		resolutions, err := e.conflictResolutions(ac, req.Mode, plan)
+		if err != nil {
+			return nil, err
+		}
+		result := ac.result(plan, []planner.Operation{})
+		result.Resolutions = resolutions
+		return result, fmt.Errorf("%w: %d conflicts detected", ErrConflict, len(plan.Conflicts))
+	}
+
+	if req.DryRun {
+		if req.EmitScript != nil {
+			if err := plan.WriteScript(req.EmitScript); err != nil {
+				return nil, err
+			}
+		}
+		return ac.result(plan, []planner.Operation{}), nil
+	}
+
+	result, err := e.executeApplyPlan(ctx, req, ac, plan)
+	if err == nil && req.WriteLock {
+		if err := e.upsertLock(ac.applyRoot, locked); err != nil {
+			return result, err
+		}
+	}
+	return result, err
+}
+
+// applyContext holds the workspace and store resolution shared by the
+// planning and execution phases of an apply.
+type applyContext struct {
+	root            string
+	repoFingerprint string
+	workspacePath   string
+	workspaceID     string
+	workspaceState  *state.WorkspaceState
+	planState       *state.WorkspaceState
+	storeToApply    string
+	storeScope      string
+	applyRoot       string
+	applyRepo       stores.StoreRepo
+
+	// localRepo is the store's own repo, which records usage even when
+	// applyRepo reads a pinned copy from pinnedDir
+	localRepo stores.StoreRepo
+	pinnedDir string
+
+	// onlyNew plans only paths the workspace does not already manage
+	onlyNew bool
+
+	// destPrefix places every planned path under this relative directory
+	destPrefix string
+
+	// vars are the variables tracked paths' When conditions are evaluated against
+	vars map[string]string
+}
+
+// cleanup removes the temporary materialization of a pinned store.
+func (ac *applyContext) cleanup() {
+	if ac.pinnedDir != "" {
+		_ = os.RemoveAll(ac.pinnedDir)
+	}
+}
+
+// prepareApply discovers the workspace, loads its state, and resolves the
+// store and destination root for an apply request.
+func (e *Engine) prepareApply(req *ApplyRequest) (*applyContext, error) {
 	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover workspace: %w", err)
@@ -28,124 +309,368 @@ func (e *Engine) Apply(ctx context.Context, req *ApplyRequest) (*ApplyResult, er
 		return nil, fmt.Errorf("failed to load or create workspace state: %w", err)
 	}
 
-	var storeToApply string
+	var storeToApply, storeScope string
 	if req.StoreID != "" {
-		storeToApply = req.StoreID
+		// Accept scope-qualified IDs such as "global/foo"
+		storeToApply, storeScope, err = splitStoreID(req.StoreID, "")
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		if workspaceState.ActiveStore == "" {
 			return nil, ErrNoActiveStore
 		}
 		storeToApply = workspaceState.ActiveStore
 	}
-	orderedStores := []string{storeToApply}
 
-	// If workspace state exists, verify mode matches
-	if workspaceState.Applied && workspaceState.Mode != req.Mode {
-		// TODO: add force option - too overcomplicated for now
-		return nil, fmt.Errorf("%w: existing mode is %s, requested mode is %s", ErrValidation, workspaceState.Mode, req.Mode)
+	// When applying into a separate target directory, plan against an empty
+	// ownership record so conflicts reflect the target, not the workspace.
+	applyRoot := filepath.Join(root, workspacePath)
+	planState := workspaceState
+	if req.TargetDir != "" {
+		applyRoot = req.TargetDir
+		if !filepath.IsAbs(applyRoot) {
+			applyRoot = filepath.Join(req.CWD, applyRoot)
+		}
+		applyRoot = filepath.Clean(applyRoot)
+		planState = state.NewWorkspaceState(repoFingerprint, workspacePath, req.Mode)
 	}
 
+	// Mode compatibility is checked per path by the planner against each
+	// PathOwnership.Type, so a workspace may mix symlink and copy paths.
+
 	// Resolve the store repo.
 	// When StoreID is explicitly provided, search by store ID (no checkout required).
 	// Otherwise fall back to the workspace's active store.
 	var applyRepo stores.StoreRepo
 	if req.StoreID != "" {
-		var resolvedScope string
-		applyRepo, resolvedScope, err = e.resolveStoreRepo(storeToApply, "")
+		applyRepo, storeScope, err = e.resolveStoreRepo(storeToApply, storeScope)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve store: %w", err)
 		}
-		workspaceState.ActiveStoreScope = resolvedScope
 	} else {
 		applyRepo, err = e.activeStoreRepo(workspaceState)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve store repo: %w", err)
 		}
+		storeScope = workspaceState.ActiveStoreScope
+	}
+
+	ac := &applyContext{
+		root:            root,
+		repoFingerprint: repoFingerprint,
+		workspacePath:   workspacePath,
+		workspaceID:     workspaceID,
+		workspaceState:  workspaceState,
+		planState:       planState,
+		storeToApply:    storeToApply,
+		storeScope:      storeScope,
+		applyRoot:       applyRoot,
+		applyRepo:       applyRepo,
+		localRepo:       applyRepo,
+		onlyNew:         req.OnlyNew,
+		destPrefix:      req.DestPrefix,
+		vars:            req.Vars,
+	}
+
+	// Source a pinned store from the sync repository instead of its overlay
+	if err := e.pinStore(ac, req); err != nil {
+		return nil, err
 	}
 
-	plan, err := planner.BuildApplyPlan(
-		workspaceState,
-		orderedStores,
-		req.Mode,
-		root,
-		applyRepo,
+	return ac, nil
+}
+
+// buildPlan plans applying the resolved store under the apply root.
+func (ac *applyContext) buildPlan(e *Engine, mode string, force bool) (*planner.ApplyPlan, error) {
+	return ac.buildPlanFor(e, ac.planState, mode, force)
+}
+
+// buildPlanFor plans applying the resolved store against planState.
+func (ac *applyContext) buildPlanFor(e *Engine, planState *state.WorkspaceState, mode string, force bool) (*planner.ApplyPlan, error) {
+	plan, err := planner.BuildApplyPlanWith(
+		planState,
+		[]string{ac.storeToApply},
+		mode,
+		ac.applyRoot,
+		ac.applyRepo,
 		e.fs,
-		req.Force,
+		planner.PlanOptions{DestPrefix: ac.destPrefix, Vars: ac.vars, Force: force, OnlyNew: ac.onlyNew, Hasher: e.hasher},
 	)
 	if err != nil {
+		if errors.Is(err, planner.ErrProtectedPath) || errors.Is(err, planner.ErrMissingRequiredFile) ||
+			errors.Is(err, planner.ErrInvalidDestPrefix) || errors.Is(err, planner.ErrInvalidDestTemplate) ||
+			errors.Is(err, planner.ErrInvalidCondition) {
+			return nil, fmt.Errorf("%w: %w", ErrValidation, err)
+		}
 		return nil, fmt.Errorf("failed to build apply plan: %w", err)
 	}
+	return plan, nil
+}
 
-	if plan.HasConflicts() && !req.Force {
-		return &ApplyResult{
-			Plan:            plan,
-			Applied:         []planner.Operation{},
-			WorkspaceID:     workspaceID,
-			RepoFingerprint: repoFingerprint,
-			WorkspacePath:   workspacePath,
-		}, fmt.Errorf("%w: %d conflicts detected", ErrConflict, len(plan.Conflicts))
+// result builds an ApplyResult for the given plan and executed operations.
+func (ac *applyContext) result(plan *planner.ApplyPlan, applied []planner.Operation) *ApplyResult {
+	return &ApplyResult{
+		Plan:            plan,
+		Applied:         applied,
+		WorkspaceID:     ac.workspaceID,
+		RepoFingerprint: ac.repoFingerprint,
+		WorkspacePath:   ac.workspacePath,
 	}
+}
 
-	if req.DryRun {
-		return &ApplyResult{
-			Plan:            plan,
-			Applied:         []planner.Operation{},
-			WorkspaceID:     workspaceID,
-			RepoFingerprint: repoFingerprint,
-			WorkspacePath:   workspacePath,
-		}, nil
+// executeApplyPlan runs the plan's operations and records ownership of the
+// placed paths in workspace state.
+func (e *Engine) executeApplyPlan(ctx context.Context, req *ApplyRequest, ac *applyContext, plan *planner.ApplyPlan) (*ApplyResult, error) {
+	workspaceState := ac.workspaceState
+
+	// Paths inside a compacted directory are recorded individually again
+	if req.TargetDir == "" {
+		if err := e.expandCompactedForPlan(workspaceState, plan.Operations); err != nil {
+			return nil, err
+		}
 	}
 
-	// Apply overlays
+	// Apply overlays, stopping between operations if the context is cancelled
 	appliedOps := []planner.Operation{}
-	for _, op := range plan.Operations {
-		if err := e.executeOperation(op); err != nil {
-			return nil, fmt.Errorf("failed to execute operation: %w", err)
+	executor := e.newOpExecutor(ac.applyRepo)
+	if err := executor.checkPlan(plan.Operations); err != nil {
+		return nil, err
+	}
+	if req.TargetDir == "" {
+		if err := e.checkAppliedState(ac.workspaceID, workspaceState); err != nil {
+			return nil, err
 		}
-		appliedOps = append(appliedOps, op)
+	}
 
-		// Update workspace state for non-remove operations
-		if op.Type != planner.OpRemove {
-			ownership := state.PathOwnership{
-				Store:     op.Store,
-				Type:      req.Mode,
-				Timestamp: e.clock.Now(),
-			}
+	// A staged install builds everything first, so a failure here leaves
+	// the workspace untouched
+	var staged *stagedInstall
+	if req.StagedInstall {
+		var err error
+		staged, err = e.stageOperations(ctx, ac.applyRoot, plan.Operations, executor)
+		if err != nil {
+			return nil, err
+		}
+		defer staged.cleanup()
+	}
 
-			// Compute checksum for copy mode (files only, not directories)
-			if req.Mode == "copy" {
-				info, err := e.fs.Lstat(op.DestPath)
-				if err == nil && !info.IsDir() {
-					checksum, err := e.hasher.HashFile(op.DestPath)
-					if err == nil {
-						ownership.Checksum = checksum
-					}
-				}
+	var cancelErr error
+	for i, op := range plan.Operations {
+		if err := ctx.Err(); err != nil {
+			cancelErr = err
+			break
+		}
+		if staged != nil {
+			if err := staged.install(i, op); err != nil {
+				return nil, fmt.Errorf("failed to install staged operation: %w", err)
 			}
+		} else if err := executor.execute(op); err != nil {
+			return nil, err
+		}
+		appliedOps = append(appliedOps, op)
+
+		// Files placed outside the workspace are not owned by it,
+		// and backups of replaced paths are left unmanaged
+		if req.TargetDir != "" || op.Type == planner.OpBackup {
+			continue
+		}
 
-			workspaceState.Paths[op.RelPath] = ownership
+		// Update workspace state for non-remove operations
+		if op.Type != planner.OpRemove {
+			workspaceState.Paths[op.RelPath] = e.appliedOwnership(op, ac.storeScope, req.Mode)
 		} else {
 			delete(workspaceState.Paths, op.RelPath)
 		}
 	}
 
+	if cancelErr != nil {
+		// Record ownership of paths placed before cancellation so they stay managed
+		if req.TargetDir == "" {
+			if err := e.saveAppliedState(ac.workspaceID, workspaceState, appliedOps, resumeHint); err != nil {
+				return nil, err
+			}
+		}
+		return ac.result(plan, appliedOps), cancelErr
+	}
+
+	if req.TargetDir != "" {
+		return ac.result(plan, appliedOps), nil
+	}
+
 	// Update workspace state metadata (only active store, preserve stack)
 	workspaceState.Applied = true
 	workspaceState.Mode = req.Mode
 	// Note: Stack is NOT modified here - apply is for single stores only
-	workspaceState.ActiveStore = storeToApply
-	workspaceState.AddAppliedStore(storeToApply, req.Mode)
+	workspaceState.ActiveStore = ac.storeToApply
+	workspaceState.ActiveStoreScope = ac.storeScope
+	workspaceState.AddAppliedStore(ac.storeToApply, req.Mode)
 
 	// Step 8: Persist workspace state atomically
+	if err := e.saveAppliedState(ac.workspaceID, workspaceState, appliedOps, resumeHint); err != nil {
+		return nil, err
+	}
+
+	// Record recency; the overlay is already applied, so this is best-effort
+	_ = ac.localRepo.MarkUsed(ac.storeToApply, e.clock.Now())
+
+	return ac.result(plan, appliedOps), nil
+}
+
+// appliedOwnership returns the ownership recorded for the path placed by op,
+// a store's install operation applied in mode.
+func (e *Engine) appliedOwnership(op planner.Operation, scope, mode string) state.PathOwnership {
+	ownership := state.PathOwnership{
+		Store:          op.Store,
+		StoreScope:     scope,
+		Type:           mode,
+		Timestamp:      e.clock.Now(),
+		SourceChecksum: e.sourceChecksum(op.SourcePath),
+		AppliedVersion: e.version,
+	}
+	if op.Type == planner.OpMkdir {
+		// A created directory is real whatever the apply mode
+		ownership.Type = "copy"
+	}
+
+	// Compute checksum for copy mode (files only, not directories)
+	if mode == "copy" {
+		info, err := e.fs.Lstat(op.DestPath)
+		if err == nil && !info.IsDir() {
+			checksum, err := e.hasher.HashFile(op.DestPath)
+			if err == nil {
+				ownership.Checksum = checksum
+			}
+		}
+	}
+	return ownership
+}
+
+// Hints for recovering paths placed by an apply whose state save conflicted.
+const (
+	resumeHint     = "run 'monodev apply --resume' to adopt them"
+	stackForceHint = "re-run with --force to adopt them"
+)
+
+// maxReportedPaths caps how many placed paths a save conflict lists.
+const maxReportedPaths = 10
+
+// checkAppliedState fails before any overlay is placed if the workspace
+// state changed since it was loaded, so a conflicting apply leaves the
+// workspace untouched.
+func (e *Engine) checkAppliedState(workspaceID string, workspaceState *state.WorkspaceState) error {
+	if err := e.stateStore.CheckWorkspace(workspaceID, workspaceState); err != nil {
+		if errors.Is(err, state.ErrStateConflict) {
+			return fmt.Errorf("workspace state changed since it was loaded, re-run to retry: %w", err)
+		}
+		return fmt.Errorf("failed to check workspace state: %w", err)
+	}
+	return nil
+}
+
+// saveAppliedState saves workspace state after the applied operations
+// placed overlays. A concurrent change to the state file is not
+// overwritten; the error names the placed paths, which are now unmanaged,
+// and hint says how to adopt them.
+func (e *Engine) saveAppliedState(workspaceID string, workspaceState *state.WorkspaceState, applied []planner.Operation, hint string) error {
 	if err := e.stateStore.SaveWorkspace(workspaceID, workspaceState); err != nil {
-		return nil, fmt.Errorf("failed to save workspace state: %w", err)
+		if errors.Is(err, state.ErrStateConflict) {
+			placed := placedPaths(applied)
+			if len(placed) == 0 {
+				return fmt.Errorf("failed to save workspace state, re-run to retry: %w", err)
+			}
+			return fmt.Errorf("failed to save workspace state after placing %d paths (%s); %s: %w",
+				len(placed), summarizePaths(placed), hint, err)
+		}
+		return fmt.Errorf("failed to save workspace state: %w", err)
 	}
+	return nil
+}
 
-	return &ApplyResult{
-		Plan:            plan,
-		Applied:         appliedOps,
-		WorkspaceID:     workspaceID,
-		RepoFingerprint: repoFingerprint,
-		WorkspacePath:   workspacePath,
-	}, nil
+// placedPaths returns the workspace paths the applied operations created
+// or replaced.
+func placedPaths(applied []planner.Operation) []string {
+	var paths []string
+	for _, op := range applied {
+		if op.Type == planner.OpRemove || op.Type == planner.OpBackup {
+			continue
+		}
+		paths = append(paths, op.RelPath)
+	}
+	return paths
+}
+
+// summarizePaths joins paths for an error message, listing at most
+// maxReportedPaths of them.
+func summarizePaths(paths []string) string {
+	if len(paths) <= maxReportedPaths {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(paths[:maxReportedPaths], ", "), len(paths)-maxReportedPaths)
+}
+
+// sourceChecksum hashes an applied store overlay file so later diffs can tell
+// whether the store changed since apply. Directories and unreadable paths
+// yield an empty checksum.
+func (e *Engine) sourceChecksum(sourcePath string) string {
+	info, err := e.fs.Lstat(sourcePath)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+	checksum, err := e.hasher.HashFile(sourcePath)
+	if err != nil {
+		return ""
+	}
+	return checksum
+}
+
+// treeChecksum is sourceChecksum extended to directories: a directory's
+// checksum covers the relative path, kind and content of every entry under
+// it, so edits inside a tracked directory change a plan's fingerprint.
+func (e *Engine) treeChecksum(sourcePath string) string {
+	info, err := e.fs.Lstat(sourcePath)
+	if err != nil {
+		return ""
+	}
+	if !info.IsDir() {
+		return e.sourceChecksum(sourcePath)
+	}
+
+	h := sha256.New()
+	field := func(s string) {
+		fmt.Fprintf(h, "%d:%s;", len(s), s)
+	}
+	err = e.fs.WalkDir(sourcePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(sourcePath, path)
+		if err != nil {
+			return err
+		}
+		field(rel)
+		switch {
+		case d.IsDir():
+			field("dir")
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := e.fs.Readlink(path)
+			if err != nil {
+				return err
+			}
+			field("symlink")
+			field(target)
+		default:
+			checksum, err := e.hasher.HashFile(path)
+			if err != nil {
+				return err
+			}
+			field("file")
+			field(checksum)
+		}
+		return nil
+	})
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
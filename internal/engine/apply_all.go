@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// ApplyAll applies the stack stores followed by the active store as a single
+// plan, so the active store takes precedence wherever both track a path.
+// Conflicts for every store are checked before anything is changed, and
+// ownership of all placed paths is recorded in one state save. The result's
+// Plan.Stores lists the stores in precedence order.
+func (e *Engine) ApplyAll(ctx context.Context, req *ApplyAllRequest) (*StackApplyResult, error) {
+	req = withDefaultMode(e, req)
+	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover workspace: %w", err)
+	}
+	workspaceState, workspaceID, err := e.LoadOrCreateWorkspaceState(root, repoFingerprint, workspacePath, req.Mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load or create workspace state: %w", err)
+	}
+
+	orderedStores, repo, scopes, err := e.applyAllStores(workspaceState)
+	if err != nil {
+		return nil, err
+	}
+	if len(orderedStores) == 0 {
+		return nil, fmt.Errorf("%w: no active store and the stack is empty", ErrValidation)
+	}
+
+	var locked []LockedStore
+	lockDir := filepath.Join(root, workspacePath)
+	if req.Locked || req.WriteLock {
+		locked, err = e.lockStores(repo, orderedStores, scopes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.Locked {
+		if err := e.verifyLock(lockDir, locked); err != nil {
+			return nil, err
+		}
+	}
+
+	plan, err := planner.BuildApplyPlanWith(
+		workspaceState,
+		orderedStores,
+		req.Mode,
+		filepath.Join(root, workspaceState.WorkspacePath),
+		repo,
+		e.fs,
+		planner.PlanOptions{Vars: req.Vars, Force: req.Force, Hasher: e.hasher},
+	)
+	if err != nil {
+		if errors.Is(err, planner.ErrProtectedPath) || errors.Is(err, planner.ErrMissingRequiredFile) ||
+			errors.Is(err, planner.ErrInvalidCondition) {
+			return nil, fmt.Errorf("%w: %w", ErrValidation, err)
+		}
+		return nil, fmt.Errorf("failed to build apply plan: %w", err)
+	}
+
+	result := &StackApplyResult{
+		Plan:            plan,
+		Applied:         []planner.Operation{},
+		WorkspaceID:     workspaceID,
+		RepoFingerprint: repoFingerprint,
+		WorkspacePath:   workspacePath,
+	}
+
+	if plan.HasConflicts() && !req.Force {
+		return result, fmt.Errorf("%w: %d conflicts detected", ErrConflict, len(plan.Conflicts))
+	}
+	if req.DryRun {
+		return result, nil
+	}
+
+	if err := e.checkAppliedState(workspaceID, workspaceState); err != nil {
+		return nil, err
+	}
+	appliedOps, cancelErr := e.executeStorePlan(ctx, plan, workspaceState, req.Mode, repo, scopes)
+	if appliedOps == nil {
+		return nil, cancelErr
+	}
+	result.Applied = appliedOps
+
+	if cancelErr == nil {
+		workspaceState.Applied = true
+		workspaceState.Mode = req.Mode
+		workspaceState.RefreshAppliedStores()
+	}
+
+	// Save even when cancelled so paths placed so far stay managed
+	if err := e.saveAppliedState(workspaceID, workspaceState, appliedOps, stackForceHint); err != nil {
+		return nil, err
+	}
+	if cancelErr != nil {
+		return result, cancelErr
+	}
+	if req.WriteLock {
+		if err := e.writeLock(lockDir, locked); err != nil {
+			return result, err
+		}
+	}
+
+	// Record recency; the overlays are already applied, so this is best-effort
+	if workspaceState.ActiveStore != "" {
+		if activeRepo, err := e.activeStoreRepo(workspaceState); err == nil {
+			_ = activeRepo.MarkUsed(workspaceState.ActiveStore, e.clock.Now())
+		}
+	}
+
+	return result, nil
+}
+
+// applyAllStores returns the stores that applying everything covers, in
+// precedence order (stack stores, then the active store last so it wins),
+// a repo serving each store from its resolved scope, and those scopes.
+func (e *Engine) applyAllStores(ws *state.WorkspaceState) ([]string, stores.StoreRepo, map[string]string, error) {
+	orderedStores := slices.DeleteFunc(slices.Clone(ws.Stack), func(s string) bool {
+		return s == ws.ActiveStore
+	})
+	if ws.ActiveStore != "" {
+		orderedStores = append(orderedStores, ws.ActiveStore)
+	}
+
+	storeMapping, scopes, err := e.storeRepoMapping(orderedStores)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// The active store resolves through its recorded scope, as in Apply
+	if ws.ActiveStore != "" {
+		activeRepo, err := e.activeStoreRepo(ws)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to resolve store repo: %w", err)
+		}
+		storeMapping[ws.ActiveStore] = activeRepo
+		if ws.ActiveStoreScope != "" {
+			scopes[ws.ActiveStore] = ws.ActiveStoreScope
+		}
+	}
+	return orderedStores, stores.NewMultiStoreRepo(storeMapping, e.storeRepo), scopes, nil
+}
@@ -3,8 +3,17 @@ package engine
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/danieljhkim/monodev/internal/config"
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/state"
 	"github.com/danieljhkim/monodev/internal/stores"
 )
 
@@ -73,3 +82,686 @@ func TestApply_WithoutStoreIDStillRequiresCheckout(t *testing.T) {
 		t.Errorf("expected ErrNoActiveStore without StoreID, got: %v", err)
 	}
 }
+
+// TestApply_TargetDirAppliesOutsideCWD verifies that TargetDir places files in
+// the target directory, leaves CWD untouched, and does not record ownership.
+func TestApply_TargetDirAppliesOutsideCWD(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	targetDir := filepath.Join(tmpDir, "scratch")
+	storesDir := filepath.Join(tmpDir, "stores")
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := fsops.NewRealFS()
+	storeRepo := stores.NewFileStoreRepo(fs, storesDir)
+	if err := storeRepo.Create("my-store", stores.NewStoreMeta("my-store", stores.ScopeGlobal, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "config/app.yaml", Kind: "file"}}
+	if err := storeRepo.SaveTrack("my-store", track); err != nil {
+		t.Fatal(err)
+	}
+	overlayFile := filepath.Join(storeRepo.OverlayRoot("my-store"), "config", "app.yaml")
+	if err := os.MkdirAll(filepath.Dir(overlayFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overlayFile, []byte("key: value\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gitRepo := &trackGitRepo{root: repoDir, fingerprint: "fp1", workspacePath: "."}
+	stateStore := newMockStateStore()
+	eng := New(gitRepo, storeRepo, stateStore, fs, &mockHasher{}, &mockClock{}, config.Paths{})
+
+	result, err := eng.Apply(context.Background(), &ApplyRequest{
+		CWD:       repoDir,
+		StoreID:   "my-store",
+		Mode:      "copy",
+		TargetDir: targetDir,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.WorkspaceID != state.ComputeWorkspaceID("fp1", ".") {
+		t.Errorf("WorkspaceID = %q, want ID derived from CWD", result.WorkspaceID)
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "config", "app.yaml")); err != nil {
+		t.Errorf("expected file in target directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, "config", "app.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected no file in CWD, got err=%v", err)
+	}
+	if _, ok := stateStore.workspaces[result.WorkspaceID]; ok {
+		t.Error("expected workspace state not to be saved when applying into TargetDir")
+	}
+}
+
+// cancelAfterCopyFS cancels a context once the first Copy completes.
+type cancelAfterCopyFS struct {
+	fsops.FS
+	cancel context.CancelFunc
+}
+
+func (f *cancelAfterCopyFS) Copy(src, dst string) error {
+	err := f.FS.Copy(src, dst)
+	f.cancel()
+	return err
+}
+
+// TestApply_CancelledMidwayRecordsPartialState verifies that cancelling the
+// context stops apply between operations and keeps ownership of placed paths.
+func TestApply_CancelledMidwayRecordsPartialState(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{"a.txt": "a\n", "b.txt": "b\n"},
+		nil,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eng.fs = &cancelAfterCopyFS{FS: eng.fs, cancel: cancel}
+
+	result, err := eng.Apply(ctx, &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if result == nil || len(result.Applied) != 1 {
+		t.Fatalf("expected 1 applied operation, got %+v", result)
+	}
+
+	ws, err := eng.stateStore.LoadWorkspace(result.WorkspaceID)
+	if err != nil {
+		t.Fatalf("expected workspace state to be saved: %v", err)
+	}
+	if len(ws.Paths) != 1 {
+		t.Errorf("expected 1 owned path, got %d: %v", len(ws.Paths), ws.Paths)
+	}
+}
+
+// TestApply_RejectsProtectedDestination verifies that a tracked path inside
+// .monodev is rejected with a validation error even when forced.
+func TestApply_RejectsProtectedDestination(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{".monodev/remote.json": "{}\n", "Makefile": "all:\n"},
+		nil,
+	)
+
+	_, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", Force: true})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, "Makefile")); !os.IsNotExist(err) {
+		t.Error("no paths should be applied when the plan is rejected")
+	}
+}
+
+func TestApply_FSMetricsCountCopies(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "abc", "b.txt": "de"}, nil)
+	metrics := eng.EnableFSMetrics()
+	if eng.EnableFSMetrics() != metrics {
+		t.Fatal("EnableFSMetrics should return the existing wrapper")
+	}
+
+	if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	got := metrics.Snapshot()
+	if got.Calls["Copy"] != 2 {
+		t.Errorf("Calls[Copy] = %d, want 2", got.Calls["Copy"])
+	}
+	if got.BytesCopied != 5 {
+		t.Errorf("BytesCopied = %d, want 5", got.BytesCopied)
+	}
+}
+
+func TestApply_MaxWalkDepthBoundsDirectoryCopies(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"cfg/a/b/c.txt": "c\n"}, nil)
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "cfg", Kind: "dir"}}
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+	// The limit reaches the copy through the metrics wrapper too
+	eng.EnableFSMetrics()
+	eng.SetMaxWalkDepth(1)
+
+	_, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"})
+	if !errors.Is(err, fsops.ErrMaxDepth) {
+		t.Fatalf("expected ErrMaxDepth, got %v", err)
+	}
+}
+
+func TestApply_EmptyTrackedDirectoryCopyMode(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, nil, nil)
+	storeRepo := eng.storeRepo
+	if err := os.MkdirAll(filepath.Join(storeRepo.OverlayRoot("s1"), "cache"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "cache", Kind: "dir"}}
+	if err := storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(result.Applied) != 1 || result.Applied[0].Type != planner.OpMkdir {
+		t.Fatalf("expected a single mkdir operation, got %+v", result.Applied)
+	}
+
+	info, err := os.Stat(filepath.Join(repoDir, "cache"))
+	if err != nil || !info.IsDir() {
+		t.Fatalf("expected cache directory in workspace, got %v, %v", info, err)
+	}
+
+	ws, err := eng.stateStore.LoadWorkspace(state.ComputeWorkspaceID("fp1", "."))
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	ownership, ok := ws.Paths["cache"]
+	if !ok {
+		t.Fatal("expected ownership of cache to be recorded")
+	}
+	if ownership.Store != "s1" || ownership.Type != "copy" {
+		t.Errorf("ownership = %+v, want store s1 with type copy", ownership)
+	}
+}
+
+func TestApply_WarningsAsErrors(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n"}, nil)
+	track, err := eng.storeRepo.LoadTrack("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	track.Tracked = append(track.Tracked, stores.TrackedPath{Path: "required.txt", Kind: "file"})
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", WarningsAsErrors: true}
+	result, err := eng.Apply(context.Background(), req)
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if result == nil || len(result.Plan.Warnings) != 1 || len(result.Applied) != 0 {
+		t.Fatalf("expected the warning and no applied operations, got %+v", result)
+	}
+	if _, err := os.Lstat(filepath.Join(repoDir, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("a.txt should not be applied when warnings abort, got %v", err)
+	}
+
+	// Warnings stay non-fatal by default
+	req.WarningsAsErrors = false
+	if _, err := eng.Apply(context.Background(), req); err != nil {
+		t.Fatalf("Apply without WarningsAsErrors failed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(repoDir, "a.txt")); err != nil {
+		t.Errorf("expected a.txt to be applied: %v", err)
+	}
+}
+
+func TestApply_NormalizesLineEndingsInCopyMode(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"run.sh": "echo a\r\necho b\r\n", "raw.txt": "x\r\n"}, nil)
+	track, err := eng.storeRepo.LoadTrack("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	track.Normalize = []stores.NormalizeRule{{Pattern: "*.sh", EOL: stores.EOLLF}}
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoDir, "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "echo a\necho b\n" {
+		t.Errorf("run.sh = %q, want LF line endings", data)
+	}
+	data, err = os.ReadFile(filepath.Join(repoDir, "raw.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "x\r\n" {
+		t.Errorf("raw.txt = %q, want content unchanged", data)
+	}
+}
+
+func TestApply_PreserveMtimeInCopyMode(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"kept.txt": "k\n", "fresh.txt": "f\n"}, nil)
+	track, err := eng.storeRepo.LoadTrack("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range track.Tracked {
+		track.Tracked[i].PreserveMtime = track.Tracked[i].Path == "kept.txt"
+	}
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, name := range []string{"kept.txt", "fresh.txt"} {
+		if err := os.Chtimes(filepath.Join(eng.storeRepo.OverlayRoot("s1"), name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before := time.Now().Add(-time.Second)
+	if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(repoDir, "kept.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(old) {
+		t.Errorf("kept.txt mtime = %v, want source mtime %v", info.ModTime(), old)
+	}
+	info, err = os.Stat(filepath.Join(repoDir, "fresh.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ModTime().Before(before) {
+		t.Errorf("fresh.txt mtime = %v, want current time", info.ModTime())
+	}
+}
+
+func TestApply_StagedInstallReplacesManagedFiles(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "v1\n", "dir/b.txt": "b\n"}, nil)
+	req := &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", StagedInstall: true}
+	if _, err := eng.Apply(context.Background(), req); err != nil {
+		t.Fatalf("first Apply failed: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(eng.storeRepo.OverlayRoot("s1"), "a.txt"), []byte("v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	req.Force = true
+	if _, err := eng.Apply(context.Background(), req); err != nil {
+		t.Fatalf("second Apply failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoDir, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "v2\n" {
+		t.Errorf("a.txt = %q, want updated content", data)
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, "dir", "b.txt")); err != nil {
+		t.Errorf("expected dir/b.txt to be installed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, stagingDirName)); !os.IsNotExist(err) {
+		t.Errorf("expected staging directory to be removed, got %v", err)
+	}
+}
+
+func TestApply_DryRunEmitsScript(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n"}, nil)
+
+	var script strings.Builder
+	_, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", DryRun: true, EmitScript: &script})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	source := filepath.Join(eng.storeRepo.OverlayRoot("s1"), "a.txt")
+	want := fmt.Sprintf("cp -R '%s' '%s'", source, filepath.Join(repoDir, "a.txt"))
+	if !strings.Contains(script.String(), want) {
+		t.Errorf("script missing %q:\n%s", want, script.String())
+	}
+	if _, err := os.Lstat(filepath.Join(repoDir, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("dry run should not create a.txt, got %v", err)
+	}
+}
+
+// racingStateStore changes the stored state behind the engine's back just
+// before the first save, as a concurrent process would.
+type racingStateStore struct {
+	*state.FileStateStore
+	raced bool
+}
+
+func (s *racingStateStore) SaveWorkspace(id string, ws *state.WorkspaceState) error {
+	if !s.raced {
+		s.raced = true
+		if external, err := s.LoadWorkspace(id); err == nil {
+			external.Stack = append(external.Stack, "external")
+			if err := s.FileStateStore.SaveWorkspace(id, external); err != nil {
+				return err
+			}
+		}
+	}
+	return s.FileStateStore.SaveWorkspace(id, ws)
+}
+
+func TestApply_ConcurrentStateChangeConflicts(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n"}, nil)
+	fs := fsops.NewRealFS()
+	fileStore := state.NewFileStateStore(fs, t.TempDir())
+	workspaceID := state.ComputeWorkspaceID("fp1", ".")
+	if err := fileStore.SaveWorkspace(workspaceID, state.NewWorkspaceState("fp1", ".", "copy")); err != nil {
+		t.Fatal(err)
+	}
+	eng.stateStore = &racingStateStore{FileStateStore: fileStore}
+
+	_, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"})
+	if !errors.Is(err, state.ErrStateConflict) {
+		t.Fatalf("expected ErrStateConflict, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "a.txt") || !strings.Contains(err.Error(), "apply --resume") {
+		t.Errorf("error should name the placed path and suggest --resume, got %v", err)
+	}
+
+	ws, err := fileStore.LoadWorkspace(workspaceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws.Stack) != 1 || ws.Stack[0] != "external" {
+		t.Errorf("external change was overwritten: Stack = %v", ws.Stack)
+	}
+
+	// Retrying applies on top of the external change
+	if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", Force: true}); err != nil {
+		t.Fatalf("retried Apply failed: %v", err)
+	}
+	ws, err = fileStore.LoadWorkspace(workspaceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ws.Paths["a.txt"]; !ok || len(ws.Stack) != 1 {
+		t.Errorf("retry should record a.txt and keep the stack, got %+v", ws)
+	}
+}
+
+// racingCheckStateStore changes the stored state just before the engine
+// checks it, after the apply was planned.
+type racingCheckStateStore struct {
+	*state.FileStateStore
+}
+
+func (s *racingCheckStateStore) CheckWorkspace(id string, ws *state.WorkspaceState) error {
+	external, err := s.LoadWorkspace(id)
+	if err != nil {
+		return err
+	}
+	external.Stack = append(external.Stack, "external")
+	if err := s.SaveWorkspace(id, external); err != nil {
+		return err
+	}
+	return s.FileStateStore.CheckWorkspace(id, ws)
+}
+
+func TestApply_ConcurrentStateChangeDetectedBeforePlacing(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n"}, nil)
+	fileStore := state.NewFileStateStore(fsops.NewRealFS(), t.TempDir())
+	workspaceID := state.ComputeWorkspaceID("fp1", ".")
+	if err := fileStore.SaveWorkspace(workspaceID, state.NewWorkspaceState("fp1", ".", "copy")); err != nil {
+		t.Fatal(err)
+	}
+	eng.stateStore = &racingCheckStateStore{FileStateStore: fileStore}
+
+	_, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"})
+	if !errors.Is(err, state.ErrStateConflict) {
+		t.Fatalf("expected ErrStateConflict, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(repoDir, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("a conflicting apply should place nothing, got %v", err)
+	}
+}
+
+func TestApplyConfirmed_ExecutesMatchingPlan(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n"}, nil)
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if preview.Plan.Fingerprint == "" {
+		t.Fatal("expected the dry-run plan to carry a fingerprint")
+	}
+
+	result, err := eng.ApplyConfirmed(context.Background(), &ApplyConfirmedRequest{
+		ApplyRequest: ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"},
+		Fingerprint:  preview.Plan.Fingerprint,
+	})
+	if err != nil {
+		t.Fatalf("ApplyConfirmed failed: %v", err)
+	}
+	if len(result.Applied) != 1 {
+		t.Errorf("expected 1 applied operation, got %d", len(result.Applied))
+	}
+	if _, err := os.Lstat(filepath.Join(repoDir, "a.txt")); err != nil {
+		t.Errorf("expected a.txt to be applied: %v", err)
+	}
+}
+
+func TestApplyConfirmed_RejectsChangedInputs(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, eng *Engine, repoDir string)
+	}{
+		{
+			name: "overlay content edited",
+			change: func(t *testing.T, eng *Engine, repoDir string) {
+				path := filepath.Join(eng.storeRepo.OverlayRoot("s1"), "a.txt")
+				if err := os.WriteFile(path, []byte("edited\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "destination created",
+			change: func(t *testing.T, eng *Engine, repoDir string) {
+				if err := os.WriteFile(filepath.Join(repoDir, "a.txt"), []byte("local\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "path tracked",
+			change: func(t *testing.T, eng *Engine, repoDir string) {
+				track, err := eng.storeRepo.LoadTrack("s1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				track.Tracked = append(track.Tracked, stores.TrackedPath{Path: "b.txt", Kind: "file"})
+				if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(eng.storeRepo.OverlayRoot("s1"), "b.txt"), []byte("b\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n"}, nil)
+			if err := os.MkdirAll(repoDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			req := ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", Force: true}
+			dryRun := req
+			dryRun.DryRun = true
+			preview, err := eng.Apply(context.Background(), &dryRun)
+			if err != nil {
+				t.Fatalf("dry run failed: %v", err)
+			}
+
+			tt.change(t, eng, repoDir)
+
+			result, err := eng.ApplyConfirmed(context.Background(), &ApplyConfirmedRequest{
+				ApplyRequest: req,
+				Fingerprint:  preview.Plan.Fingerprint,
+			})
+			if !errors.Is(err, ErrPlanChanged) {
+				t.Fatalf("expected ErrPlanChanged, got %v", err)
+			}
+			if result == nil || len(result.Applied) != 0 {
+				t.Errorf("expected the rebuilt plan and nothing applied, got %+v", result)
+			}
+			ws, err := eng.stateStore.LoadWorkspace(state.ComputeWorkspaceID("fp1", "."))
+			if err == nil && len(ws.Paths) != 0 {
+				t.Errorf("state should be untouched, got %v", ws.Paths)
+			}
+		})
+	}
+}
+
+func TestApplyConfirmed_RejectsEditInsideTrackedDirectory(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"conf/app.yaml": "a: 1\n"}, nil)
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "conf", Kind: "dir"}}
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	req := ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}
+	dryRun := req
+	dryRun.DryRun = true
+	preview, err := eng.Apply(context.Background(), &dryRun)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+
+	path := filepath.Join(eng.storeRepo.OverlayRoot("s1"), "conf", "app.yaml")
+	if err := os.WriteFile(path, []byte("a: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = eng.ApplyConfirmed(context.Background(), &ApplyConfirmedRequest{
+		ApplyRequest: req,
+		Fingerprint:  preview.Plan.Fingerprint,
+	})
+	if !errors.Is(err, ErrPlanChanged) {
+		t.Fatalf("expected ErrPlanChanged, got %v", err)
+	}
+}
+
+func TestApply_FingerprintsOnlyDryRuns(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n"}, nil)
+
+	result, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if result.Plan.Fingerprint != "" {
+		t.Errorf("Fingerprint = %q, want none outside a dry run", result.Plan.Fingerprint)
+	}
+}
+
+func TestApply_StampsAppliedVersion(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n"}, nil)
+	eng.SetVersion("1.4.2")
+
+	if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	ws, err := eng.stateStore.LoadWorkspace(state.ComputeWorkspaceID("fp1", "."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ws.Paths["a.txt"].AppliedVersion; got != "1.4.2" {
+		t.Errorf("AppliedVersion = %q, want 1.4.2", got)
+	}
+}
+
+func TestApply_EnvModeDefaultsOmittedMode(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "store"}, nil)
+	eng.SetSettings(&config.Settings{Mode: "symlink", Scope: stores.ScopeComponent})
+
+	// An omitted mode takes the override
+	if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1"}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	ws, err := eng.stateStore.LoadWorkspace(state.ComputeWorkspaceID("fp1", "."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Paths["a.txt"].Type != "symlink" {
+		t.Errorf("omitted mode applied as %q, want symlink", ws.Paths["a.txt"].Type)
+	}
+
+	// An explicit mode wins over the override
+	if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", Force: true}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	ws, err = eng.stateStore.LoadWorkspace(state.ComputeWorkspaceID("fp1", "."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Paths["a.txt"].Type != "copy" {
+		t.Errorf("explicit mode applied as %q, want copy", ws.Paths["a.txt"].Type)
+	}
+
+	// The scope override replaces the repo-context default (global here)
+	if scope := eng.defaultScope(); scope != stores.ScopeComponent {
+		t.Errorf("defaultScope = %s, want the component override", scope)
+	}
+}
+
+func TestApply_ExpandsDestFromStoreMeta(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"notes.md": "todo\n"}, nil)
+	meta, err := eng.storeRepo.LoadMeta("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.TaskID = "PROJ-42"
+	if err := eng.storeRepo.SaveMeta("s1", meta); err != nil {
+		t.Fatal(err)
+	}
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "notes.md", Kind: "file", Dest: ".tasks/${taskID}/notes.md"}}
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(repoDir, ".tasks", "PROJ-42", "notes.md")); err != nil {
+		t.Errorf("expected notes.md under the task directory: %v", err)
+	}
+	ws, err := eng.stateStore.LoadWorkspace(state.ComputeWorkspaceID("fp1", "."))
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	if _, ok := ws.Paths[filepath.Join(".tasks", "PROJ-42", "notes.md")]; !ok {
+		t.Errorf("expected ownership of the expanded destination, got %v", ws.Paths)
+	}
+}
+
+func TestApply_RejectsDestWithUnsetMeta(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"notes.md": "todo\n"}, nil)
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "notes.md", Kind: "file", Dest: ".tasks/${taskID}/notes.md"}}
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation for a store without a task ID, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AuditStore checks a store's overlay for symlinks that could lead apply
+// outside the store, such as those planted in a store pulled from an
+// untrusted sync repository. Every symlink with an absolute target and
+// every relative symlink whose target resolves outside the overlay root is
+// reported as a finding. The overlay is not modified.
+func (e *Engine) AuditStore(ctx context.Context, storeID, scope string) (*AuditStoreResult, error) {
+	storeID, scope, err := splitStoreID(storeID, scope)
+	if err != nil {
+		return nil, err
+	}
+	repo, resolvedScope, err := e.resolveStoreRepo(storeID, scope)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := repo.Exists(storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check store: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: store '%s' not found", ErrNotFound, storeID)
+	}
+
+	result := &AuditStoreResult{StoreID: storeID, Scope: resolvedScope, Findings: []AuditFinding{}}
+	overlayRoot := repo.OverlayRoot(storeID)
+	err = e.fs.WalkDir(overlayRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// A store that never had content saved has no overlay yet
+			if path == overlayRoot && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		target, err := e.fs.Readlink(path)
+		if err != nil {
+			return fmt.Errorf("failed to read symlink %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(overlayRoot, path)
+		if err != nil {
+			return err
+		}
+		if filepath.IsAbs(target) {
+			result.Findings = append(result.Findings, AuditFinding{
+				Path:   rel,
+				Target: target,
+				Reason: "symlink has an absolute target",
+			})
+			return nil
+		}
+		resolved, err := filepath.Rel(overlayRoot, filepath.Join(filepath.Dir(path), target))
+		if err != nil {
+			return err
+		}
+		if resolved == ".." || strings.HasPrefix(resolved, ".."+string(filepath.Separator)) {
+			result.Findings = append(result.Findings, AuditFinding{
+				Path:   rel,
+				Target: target,
+				Reason: "symlink target escapes the overlay root",
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to audit store overlay: %w", err)
+	}
+	return result, nil
+}
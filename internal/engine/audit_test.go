@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditStore_FlagsEscapingSymlinks(t *testing.T) {
+	eng, storeRepo, _ := setupTrackPathsEngine(t, nil)
+	overlay := storeRepo.OverlayRoot("s1")
+	if err := os.MkdirAll(filepath.Join(overlay, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(overlay, "config", "app.yaml"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"config/current.yaml": "app.yaml",
+		"config/shared":       "../config",
+		"config/escape":       "../../../etc/passwd",
+		"absolute":            "/etc/hosts",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(overlay, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := eng.AuditStore(context.Background(), "s1", "")
+	if err != nil {
+		t.Fatalf("AuditStore failed: %v", err)
+	}
+
+	flagged := map[string]string{}
+	for _, finding := range result.Findings {
+		flagged[finding.Path] = finding.Target
+	}
+	if len(flagged) != 2 {
+		t.Fatalf("Findings = %+v, want absolute and config/escape", result.Findings)
+	}
+	if flagged["absolute"] != "/etc/hosts" {
+		t.Errorf("absolute symlink not flagged: %+v", result.Findings)
+	}
+	if flagged[filepath.Join("config", "escape")] != "../../../etc/passwd" {
+		t.Errorf("escaping symlink not flagged: %+v", result.Findings)
+	}
+}
+
+func TestAuditStore_CleanOverlay(t *testing.T) {
+	eng, storeRepo, _ := setupTrackPathsEngine(t, nil)
+	overlay := storeRepo.OverlayRoot("s1")
+	if err := os.MkdirAll(overlay, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(overlay, "Makefile"), []byte("all:"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := eng.AuditStore(context.Background(), "s1", "")
+	if err != nil {
+		t.Fatalf("AuditStore failed: %v", err)
+	}
+	if len(result.Findings) != 0 {
+		t.Errorf("Findings = %+v, want none", result.Findings)
+	}
+}
+
+func TestAuditStore_NotFound(t *testing.T) {
+	eng, _, _ := setupTrackPathsEngine(t, nil)
+
+	_, err := eng.AuditStore(context.Background(), "missing", "global")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("AuditStore error = %v, want ErrNotFound", err)
+	}
+}
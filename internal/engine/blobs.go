@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/hash"
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// blobCache returns the local content-addressed cache for large files.
+func (e *Engine) blobCache() *stores.BlobCache {
+	return stores.NewBlobCache(e.fs, filepath.Join(e.configPaths.Root, "blobs"))
+}
+
+// storeLargeFiles replaces regular files under path larger than threshold
+// with pointers, moving their contents into the blob cache.
+func (e *Engine) storeLargeFiles(path string, threshold int64) error {
+	if threshold <= 0 {
+		return nil
+	}
+	cache := e.blobCache()
+	return e.fs.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() <= threshold {
+			return nil
+		}
+		data, err := e.fs.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		pointer, err := cache.Put(data)
+		if err != nil {
+			return err
+		}
+		if err := e.fs.AtomicWrite(p, pointer.Encode(), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write pointer for %s: %w", p, err)
+		}
+		return nil
+	})
+}
+
+// blobResolver swaps pointer files placed by copy operations for the
+// content they address.
+type blobResolver struct {
+	e     *Engine
+	cache *stores.BlobCache
+}
+
+// newBlobResolver creates a resolver reading from the engine's blob cache.
+func (e *Engine) newBlobResolver() *blobResolver {
+	return &blobResolver{e: e, cache: e.blobCache()}
+}
+
+// check rejects symlink operations whose source holds pointers, since a
+// link would expose the pointer rather than the content. Run it before the
+// operation executes.
+func (r *blobResolver) check(op planner.Operation) error {
+	if op.Type != planner.OpCreateSymlink {
+		return nil
+	}
+	return r.walkPointers(op.SourcePath, func(path string, _ *stores.Pointer, _ fs.FileMode) error {
+		return fmt.Errorf("%s is stored as a large-file pointer and can only be applied in copy mode", op.RelPath)
+	})
+}
+
+// checkPlan runs check on every operation and confirms that the blob behind
+// each pointer a copy would place is cached, so a plan that cannot be
+// resolved fails before any operation writes to the workspace.
+func (r *blobResolver) checkPlan(ops []planner.Operation) error {
+	for _, op := range ops {
+		if err := r.check(op); err != nil {
+			return err
+		}
+		if op.Type != planner.OpCopy {
+			continue
+		}
+		err := r.walkPointers(op.SourcePath, func(path string, pointer *stores.Pointer, _ fs.FileMode) error {
+			if err := r.cache.Check(pointer); err != nil {
+				return fmt.Errorf("failed to resolve pointer %s: %w", path, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply resolves the pointers written by a copy operation. Other operations
+// are left alone.
+func (r *blobResolver) apply(op planner.Operation) error {
+	if op.Type != planner.OpCopy {
+		return nil
+	}
+	return r.walkPointers(op.DestPath, func(path string, pointer *stores.Pointer, perm fs.FileMode) error {
+		data, err := r.cache.Get(pointer)
+		if err != nil {
+			return fmt.Errorf("failed to resolve pointer %s: %w", path, err)
+		}
+		if err := r.e.fs.AtomicWrite(path, data, perm); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// pointerHasher hashes the content a pointer file addresses instead of the
+// pointer itself, so store files kept as pointers compare equal to their
+// resolved copies. Pointers whose blob is not cached hash as written.
+type pointerHasher struct {
+	inner hash.Hasher
+	cache *stores.BlobCache
+	fs    fsops.FS
+}
+
+// newPointerHasher wraps inner to resolve pointers through the engine's
+// blob cache.
+func (e *Engine) newPointerHasher(inner hash.Hasher) *pointerHasher {
+	return &pointerHasher{inner: inner, cache: e.blobCache(), fs: e.fs}
+}
+
+// HashFile implements hash.Hasher.
+func (h *pointerHasher) HashFile(path string) (string, error) {
+	info, err := h.fs.Lstat(path)
+	if err == nil && info.Mode().IsRegular() && info.Size() <= stores.MaxPointerSize {
+		if data, err := h.fs.ReadFile(path); err == nil {
+			if pointer, ok := stores.ParsePointer(data); ok && h.cache.Check(pointer) == nil {
+				return h.inner.HashFile(h.cache.Path(pointer))
+			}
+		}
+	}
+	return h.inner.HashFile(path)
+}
+
+// walkPointers calls fn for each pointer file under root.
+func (r *blobResolver) walkPointers(root string, fn func(path string, pointer *stores.Pointer, perm fs.FileMode) error) error {
+	return r.e.fs.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > stores.MaxPointerSize {
+			return nil
+		}
+		data, err := r.e.fs.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		pointer, ok := stores.ParsePointer(data)
+		if !ok {
+			return nil
+		}
+		return fn(path, pointer, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// setupBlobEngine prepares a diff engine with an active store, a blob cache
+// under a temp root and the given large-file threshold.
+func setupBlobEngine(t *testing.T, overlay, workspace map[string]string, threshold int64) (*Engine, string) {
+	t.Helper()
+	eng, repoDir := setupDiffEngine(t, overlay, workspace)
+	eng.configPaths.Root = t.TempDir()
+	track, err := eng.storeRepo.LoadTrack("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	track.LargeFileThreshold = threshold
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.ActiveStore = "s1"
+	if err := eng.stateStore.SaveWorkspace(state.ComputeWorkspaceID("fp1", "."), ws); err != nil {
+		t.Fatal(err)
+	}
+	return eng, repoDir
+}
+
+func TestCommit_StoresLargeFilesAsPointers(t *testing.T) {
+	big := strings.Repeat("z", 100)
+	eng, repoDir := setupBlobEngine(t,
+		map[string]string{"big.bin": "old", "small.txt": "old"},
+		map[string]string{"big.bin": big, "small.txt": "hi"},
+		16,
+	)
+
+	if _, err := eng.Commit(context.Background(), &CommitRequest{CWD: repoDir, All: true}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	overlayRoot := eng.storeRepo.OverlayRoot("s1")
+	data, err := os.ReadFile(filepath.Join(overlayRoot, "big.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pointer, ok := stores.ParsePointer(data)
+	if !ok {
+		t.Fatalf("big.bin in overlay is not a pointer: %q", data)
+	}
+	if pointer.Size != int64(len(big)) {
+		t.Errorf("pointer size = %d, want %d", pointer.Size, len(big))
+	}
+	blob, err := eng.blobCache().Get(pointer)
+	if err != nil {
+		t.Fatalf("blob not cached: %v", err)
+	}
+	if string(blob) != big {
+		t.Errorf("cached blob = %q, want workspace content", blob)
+	}
+
+	data, err = os.ReadFile(filepath.Join(overlayRoot, "small.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hi" {
+		t.Errorf("small.txt in overlay = %q, want it stored directly", data)
+	}
+}
+
+func TestApply_ResolvesPointersInCopyMode(t *testing.T) {
+	big := strings.Repeat("z", 100)
+	eng, repoDir := setupBlobEngine(t, map[string]string{"big.bin": "old"}, map[string]string{"big.bin": big}, 16)
+	if _, err := eng.Commit(context.Background(), &CommitRequest{CWD: repoDir, All: true}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if err := os.Remove(filepath.Join(repoDir, "big.bin")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", Force: true}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoDir, "big.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != big {
+		t.Errorf("big.bin = %q, want resolved content", data)
+	}
+}
+
+func TestApply_MissingBlobFails(t *testing.T) {
+	pointer := &stores.Pointer{
+		Version: stores.PointerVersion,
+		Algo:    stores.PointerAlgoSHA256,
+		Hash:    strings.Repeat("ab", 32),
+		Size:    100,
+	}
+	eng, repoDir := setupBlobEngine(t, map[string]string{"a.txt": "a", "big.bin": string(pointer.Encode())}, nil, 16)
+
+	_, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"})
+	if !errors.Is(err, stores.ErrBlobMissing) {
+		t.Fatalf("Apply error = %v, want ErrBlobMissing", err)
+	}
+	// The missing blob is found before anything is placed
+	for _, name := range []string{"a.txt", "big.bin"} {
+		if _, err := os.Lstat(filepath.Join(repoDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be placed when a blob is missing, got %v", name, err)
+		}
+	}
+
+	_, err = eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "symlink", Force: true})
+	if err == nil || !strings.Contains(err.Error(), "copy mode") {
+		t.Fatalf("symlink Apply error = %v, want pointer rejection", err)
+	}
+}
+
+func TestDiff_ResolvesPointers(t *testing.T) {
+	big := strings.Repeat("z", 100)
+	eng, repoDir := setupBlobEngine(t, map[string]string{"big.bin": "old"}, map[string]string{"big.bin": big}, 16)
+	if _, err := eng.Commit(context.Background(), &CommitRequest{CWD: repoDir, All: true}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0].Status != "unchanged" {
+		t.Fatalf("Files = %+v, want big.bin unchanged", result.Files)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "big.bin"), []byte(strings.Repeat("y", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = eng.Diff(context.Background(), &DiffRequest{CWD: repoDir})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0].Status != "modified" {
+		t.Errorf("Files = %+v, want big.bin modified", result.Files)
+	}
+}
@@ -26,6 +26,11 @@ type CommitRequest struct {
 
 	// DryRun shows what would be committed without actually committing
 	DryRun bool
+
+	// Pin records the checksum of each committed file in the track file, so
+	// apply refuses overlay sources changed outside of commit. Paths pinned
+	// by an earlier commit are re-pinned whenever they are committed.
+	Pin bool
 }
 
 // CommitResult represents the result of a commit operation.
@@ -95,52 +100,65 @@ func (e *Engine) Commit(ctx context.Context, req *CommitRequest) (*CommitResult,
 
 	workspaceRoot := filepath.Join(root, workspacePath)
 
+	// Collect the paths to commit (CWD-relative)
+	var relPaths []string
 	if req.All {
-		// Commit all tracked paths (CWD-relative)
 		for _, trackedPath := range track.Tracked {
-			if err := e.commitFilePath(
-				trackedPath.Path,
-				workspaceRoot,
-				overlayRoot,
-				workspaceState.ActiveStore,
-				workspaceState,
-				result,
-				now,
-				req.DryRun,
-			); err != nil {
-				return nil, err
+			relPaths = append(relPaths, trackedPath.Path)
+		}
+	} else {
+		// Resolve specific paths to workspace-relative first
+		for _, rawPath := range req.Paths {
+			cwdRelPath, err := resolveToWorkspaceRelative(rawPath, req.CWD, root)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve path %q: %w", rawPath, err)
 			}
+			relPaths = append(relPaths, cwdRelPath)
+		}
+	}
+
+	// Check the quota for the whole commit before copying anything, so an
+	// over-quota commit leaves the store untouched
+	if !req.DryRun {
+		growth, err := e.commitGrowth(relPaths, workspaceRoot, overlayRoot)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.checkStoreQuota(repo, workspaceState.ActiveStore, growth); err != nil {
+			return nil, err
 		}
+	}
 
+	for _, relPath := range relPaths {
+		if err := e.commitFilePath(
+			relPath,
+			workspaceRoot,
+			overlayRoot,
+			workspaceState.ActiveStore,
+			workspaceState,
+			result,
+			track.LargeFileThreshold,
+			now,
+			req.DryRun,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.All {
 		// Clean up orphaned files from overlay that are no longer tracked
 		removed, err := e.cleanupOrphanedFiles(overlayRoot, track.Tracked, req.DryRun)
 		if err != nil {
 			return nil, fmt.Errorf("failed to cleanup orphaned files: %w", err)
 		}
 		result.Removed = removed
-	} else {
-		// Commit specific paths — resolve to workspace-relative first
-		for _, rawPath := range req.Paths {
-			cwdRelPath, err := resolveToWorkspaceRelative(rawPath, req.CWD, root)
-			if err != nil {
-				return nil, fmt.Errorf("failed to resolve path %q: %w", rawPath, err)
-			}
-			if err := e.commitFilePath(
-				cwdRelPath,
-				workspaceRoot,
-				overlayRoot,
-				workspaceState.ActiveStore,
-				workspaceState,
-				result,
-				now,
-				req.DryRun,
-			); err != nil {
-				return nil, err
-			}
-		}
 	}
 
 	if !req.DryRun {
+		if err := e.pinCommitted(repo, workspaceState.ActiveStore, track, result.Committed, req.Pin); err != nil {
+			return nil, err
+		}
+
 		// Update store metadata (UpdatedAt timestamp)
 		if err := e.touchStoreMetaIn(repo, workspaceState.ActiveStore); err != nil {
 			return nil, err
@@ -168,6 +186,7 @@ func (e *Engine) commitFilePath(
 	activeStore string,
 	workspaceState *state.WorkspaceState,
 	result *CommitResult,
+	largeFileThreshold int64,
 	now time.Time,
 	dryRun bool,
 ) error {
@@ -202,6 +221,9 @@ func (e *Engine) commitFilePath(
 	if err := e.fs.Copy(workspaceFilePath, storeFilePath); err != nil {
 		return fmt.Errorf("failed to copy %s to store: %w", cleanRelPath, err)
 	}
+	if err := e.storeLargeFiles(storeFilePath, largeFileThreshold); err != nil {
+		return fmt.Errorf("failed to store large files for %s: %w", cleanRelPath, err)
+	}
 
 	// Compute checksum for files (not directories)
 	checksum := ""
@@ -215,16 +237,96 @@ func (e *Engine) commitFilePath(
 
 	// Record this path as managed in workspace state
 	workspaceState.Paths[cleanRelPath] = state.PathOwnership{
-		Store:     activeStore,
-		Type:      "copy",
-		Timestamp: now,
-		Checksum:  checksum,
+		Store:          activeStore,
+		StoreScope:     workspaceState.ActiveStoreScope,
+		Type:           "copy",
+		Timestamp:      now,
+		Checksum:       checksum,
+		AppliedVersion: e.version,
 	}
 
 	result.Committed = append(result.Committed, cleanRelPath)
 	return nil
 }
 
+// pinCommitted updates the pinned checksums of the committed file paths in
+// the store's track file: every committed path when pin is set, otherwise
+// only those already pinned. Checksums are taken from the store copies, which
+// are what apply verifies.
+func (e *Engine) pinCommitted(repo stores.StoreRepo, storeID string, track *stores.TrackFile, committed []string, pin bool) error {
+	committedSet := make(map[string]bool, len(committed))
+	for _, relPath := range committed {
+		committedSet[relPath] = true
+	}
+
+	overlayRoot := repo.OverlayRoot(storeID)
+	changed := false
+	for i := range track.Tracked {
+		trackedPath := &track.Tracked[i]
+		cleanPath := filepath.Clean(trackedPath.Path)
+		if trackedPath.Kind == "dir" || !committedSet[cleanPath] {
+			continue
+		}
+		if !pin && trackedPath.Checksum == "" {
+			continue
+		}
+		info, err := e.fs.Lstat(filepath.Join(overlayRoot, cleanPath))
+		if err != nil {
+			return fmt.Errorf("failed to stat %s in store: %w", cleanPath, err)
+		}
+		if info.IsDir() {
+			continue
+		}
+		checksum, err := e.hasher.HashFile(filepath.Join(overlayRoot, cleanPath))
+		if err != nil {
+			return fmt.Errorf("failed to hash %s in store: %w", cleanPath, err)
+		}
+		if checksum != trackedPath.Checksum {
+			trackedPath.Checksum = checksum
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	if err := repo.SaveTrack(storeID, track); err != nil {
+		return fmt.Errorf("failed to save track file: %w", err)
+	}
+	return nil
+}
+
+// commitGrowth returns how many bytes committing relPaths would add to the
+// store overlay: the workspace copies' size minus the store copies they
+// replace. Paths missing from the workspace add nothing.
+func (e *Engine) commitGrowth(relPaths []string, workspaceRoot, overlayRoot string) (int64, error) {
+	var growth int64
+	for _, relPath := range relPaths {
+		if err := e.fs.ValidateRelPath(relPath); err != nil {
+			return 0, fmt.Errorf("invalid path %q: %w", relPath, err)
+		}
+		cleanRelPath := filepath.Clean(relPath)
+		workspaceFilePath := filepath.Join(workspaceRoot, cleanRelPath)
+		exists, err := e.fs.Exists(workspaceFilePath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check if path exists: %w", err)
+		}
+		if !exists {
+			continue
+		}
+		added, err := e.pathSize(workspaceFilePath)
+		if err != nil {
+			return 0, err
+		}
+		replaced, err := e.pathSize(filepath.Join(overlayRoot, cleanRelPath))
+		if err != nil {
+			return 0, err
+		}
+		growth += added - replaced
+	}
+	return growth, nil
+}
+
 // cleanupOrphanedFiles removes files from the overlay directory that are no longer tracked.
 // It walks the overlay directory and removes any paths that are not in the tracked list.
 // Returns the list of removed paths (relative to overlay root).
@@ -2,10 +2,15 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/danieljhkim/monodev/internal/config"
+	"github.com/danieljhkim/monodev/internal/hash"
 	"github.com/danieljhkim/monodev/internal/state"
 	"github.com/danieljhkim/monodev/internal/stores"
 )
@@ -49,8 +54,12 @@ func (m *copyCapturingFS) Copy(src, dst string) error {
 	m.copyCalls = append(m.copyCalls, copyCall{src: src, dst: dst})
 	return nil
 }
-func (m *copyCapturingFS) ValidateRelPath(relPath string) error { return nil }
-func (m *copyCapturingFS) ValidateIdentifier(id string) error   { return nil }
+func (m *copyCapturingFS) Rename(oldpath, newpath string) error         { return nil }
+func (m *copyCapturingFS) Chtimes(string, time.Time, time.Time) error   { return nil }
+func (m *copyCapturingFS) ValidateRelPath(relPath string) error         { return nil }
+func (m *copyCapturingFS) ValidateIdentifier(id string) error           { return nil }
+func (m *copyCapturingFS) ReadDir(path string) ([]os.DirEntry, error)   { return nil, nil }
+func (m *copyCapturingFS) WalkDir(root string, fn fs.WalkDirFunc) error { return nil }
 
 func newCommitEngine(gitRepo *trackGitRepo, storeRepo *trackStoreRepo, stateStore *mockStateStore, fs *copyCapturingFS) *Engine {
 	return New(
@@ -167,3 +176,102 @@ func TestCommit_RepoRootWorkspaceUnchanged(t *testing.T) {
 		t.Errorf("Copy called with src=%q, want %q", srcCalled, wantSrc)
 	}
 }
+
+// TestCommit_QuotaCheckedBeforeCopying verifies that an over-quota commit
+// fails without copying any of its paths into the store.
+func TestCommit_QuotaCheckedBeforeCopying(t *testing.T) {
+	eng, storeRepo, repoDir := setupTrackPathsEngine(t, map[string]string{
+		"a.txt": "aaaa",
+		"b.txt": "bbbb",
+	})
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "a.txt", Kind: "file"},
+		{Path: "b.txt", Kind: "file"},
+	}
+	if err := storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+	// Each file fits on its own, but not both together
+	eng.SetMaxStoreBytes(stores.ScopeGlobal, 6)
+
+	_, err := eng.Commit(context.Background(), &CommitRequest{CWD: repoDir, All: true})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(storeRepo.OverlayRoot("s1"), name)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be copied by an over-quota commit, stat err = %v", name, err)
+		}
+	}
+
+	eng.SetMaxStoreBytes(stores.ScopeGlobal, 8)
+	result, err := eng.Commit(context.Background(), &CommitRequest{CWD: repoDir, All: true})
+	if err != nil {
+		t.Fatalf("Commit within quota failed: %v", err)
+	}
+	if len(result.Committed) != 2 {
+		t.Errorf("Committed = %v, want both paths", result.Committed)
+	}
+}
+
+// TestCommit_PinRecordsChecksums verifies that a pinned commit records the
+// store copies' checksums and that later commits keep them current.
+func TestCommit_PinRecordsChecksums(t *testing.T) {
+	eng, storeRepo, repoDir := setupTrackPathsEngine(t, map[string]string{
+		"a.txt": "one",
+		"b.txt": "two",
+	})
+	eng.hasher = hash.NewSHA256Hasher()
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "a.txt", Kind: "file"},
+		{Path: "b.txt", Kind: "file"},
+	}
+	if err := storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	checksums := func() map[string]string {
+		t.Helper()
+		track, err := storeRepo.LoadTrack("s1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]string)
+		for _, tp := range track.Tracked {
+			got[tp.Path] = tp.Checksum
+		}
+		return got
+	}
+	storeHash := func(name string) string {
+		t.Helper()
+		sum, err := eng.hasher.HashFile(filepath.Join(storeRepo.OverlayRoot("s1"), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sum
+	}
+
+	if _, err := eng.Commit(context.Background(), &CommitRequest{CWD: repoDir, Paths: []string{"a.txt"}, Pin: true}); err != nil {
+		t.Fatalf("pinned Commit failed: %v", err)
+	}
+	got := checksums()
+	if got["a.txt"] != storeHash("a.txt") || got["b.txt"] != "" {
+		t.Fatalf("checksums after pinning a.txt = %v", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "a.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eng.Commit(context.Background(), &CommitRequest{CWD: repoDir, All: true}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	got = checksums()
+	if got["a.txt"] != storeHash("a.txt") {
+		t.Errorf("a.txt checksum = %q, want it re-pinned to the new store copy", got["a.txt"])
+	}
+	if got["b.txt"] != "" {
+		t.Errorf("b.txt was pinned without Pin set: %q", got["b.txt"])
+	}
+}
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/state"
+)
+
+// CompactWorkspace collapses fully covered directories in the current
+// workspace's path ownership into single entries to shrink its state file.
+// Only directories whose on-disk contents are exactly the managed paths are
+// collapsed. The next apply of a store records the paths it touches
+// individually again.
+func (e *Engine) CompactWorkspace(ctx context.Context, cwd string) (*CompactWorkspaceResult, error) {
+	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(cwd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover workspace: %w", err)
+	}
+	workspaceState, workspaceID, err := e.LoadOrCreateWorkspaceState(root, repoFingerprint, workspacePath, "copy")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load or create workspace state: %w", err)
+	}
+
+	workspaceRoot := filepath.Join(root, workspacePath)
+	removed, err := workspaceState.Compact(e.storeDirEntries, func(dir string) ([]string, bool) {
+		return e.workspaceDirEntries(workspaceRoot, dir)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compact workspace state: %w", err)
+	}
+	if removed != 0 {
+		if err := e.stateStore.SaveWorkspace(workspaceID, workspaceState); err != nil {
+			return nil, fmt.Errorf("failed to save workspace state: %w", err)
+		}
+	}
+
+	return &CompactWorkspaceResult{
+		WorkspaceID: workspaceID,
+		Removed:     removed,
+		Remaining:   len(workspaceState.Paths),
+	}, nil
+}
+
+// workspaceDirEntries lists the entries on disk directly under dir in the
+// workspace at workspaceRoot.
+func (e *Engine) workspaceDirEntries(workspaceRoot, dir string) ([]string, bool) {
+	entries, err := e.fs.ReadDir(filepath.Join(workspaceRoot, dir))
+	if err != nil {
+		return nil, false
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, true
+}
+
+// expandCompactedForPlan expands the compacted directory entries covering
+// every planned path, so a failure to expand one is reported before any
+// operation runs and each path can then be recorded individually.
+func (e *Engine) expandCompactedForPlan(workspaceState *state.WorkspaceState, ops []planner.Operation) error {
+	for _, op := range ops {
+		if err := workspaceState.ExpandCompacted(op.RelPath, e.storeDirEntries); err != nil {
+			return fmt.Errorf("failed to expand compacted ownership: %w", err)
+		}
+	}
+	return nil
+}
+
+// storeDirEntries lists the entries a store's overlay holds directly under
+// dir. It implements state.DirEntries.
+func (e *Engine) storeDirEntries(storeID, dir string) ([]string, bool) {
+	repo, _, err := e.resolveStoreRepo(storeID, "")
+	if err != nil {
+		return nil, false
+	}
+	entries, err := os.ReadDir(filepath.Join(repo.OverlayRoot(storeID), dir))
+	if err != nil {
+		return nil, false
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, true
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+func TestCompactWorkspace_ReapplyExpands(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"conf/a": "a", "conf/b": "b"}, nil)
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "conf", Kind: "dir", LinkContents: true}}
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	apply := func() {
+		t.Helper()
+		if _, err := eng.Apply(ctx, &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+	loadState := func() *state.WorkspaceState {
+		t.Helper()
+		ws, err := eng.stateStore.LoadWorkspace(state.ComputeWorkspaceID("fp1", "."))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ws
+	}
+
+	apply()
+
+	result, err := eng.CompactWorkspace(ctx, repoDir)
+	if err != nil {
+		t.Fatalf("CompactWorkspace failed: %v", err)
+	}
+	if result.Removed != 1 || result.Remaining != 1 {
+		t.Errorf("result = %+v, want 1 removed and 1 remaining", result)
+	}
+	if ownership := loadState().Paths["conf"]; !ownership.Compacted {
+		t.Fatalf("expected conf to be compacted, got %+v", ownership)
+	}
+
+	// Reapplying finds the contents managed and records them individually
+	apply()
+	ws := loadState()
+	if _, ok := ws.Paths["conf"]; ok {
+		t.Error("compacted entry should be replaced on reapply")
+	}
+	for _, p := range []string{filepath.Join("conf", "a"), filepath.Join("conf", "b")} {
+		if ownership, ok := ws.Paths[p]; !ok || ownership.Store != "s1" {
+			t.Errorf("Paths[%s] = %+v, %v; want owned by s1", p, ownership, ok)
+		}
+	}
+}
+
+func TestUnapply_CompactedDirectoryKeepsUnmanagedFiles(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"conf/a": "a", "conf/b": "b"}, nil)
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "conf", Kind: "dir", LinkContents: true}}
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := eng.Apply(ctx, &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if _, err := eng.CompactWorkspace(ctx, repoDir); err != nil {
+		t.Fatalf("CompactWorkspace failed: %v", err)
+	}
+
+	// A file the user adds after compaction is not managed
+	userFile := filepath.Join(repoDir, "conf", "notes.txt")
+	if err := os.WriteFile(userFile, []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := eng.Unapply(ctx, &UnapplyRequest{CWD: repoDir})
+	if err != nil {
+		t.Fatalf("Unapply failed: %v", err)
+	}
+	slices.Sort(result.Removed)
+	if want := []string{filepath.Join("conf", "a"), filepath.Join("conf", "b")}; !slices.Equal(result.Removed, want) {
+		t.Errorf("Removed = %v, want %v", result.Removed, want)
+	}
+	if _, err := os.Stat(userFile); err != nil {
+		t.Errorf("unmanaged file should survive unapply: %v", err)
+	}
+	for _, name := range []string{"a", "b"} {
+		if _, err := os.Stat(filepath.Join(repoDir, "conf", name)); !os.IsNotExist(err) {
+			t.Errorf("conf/%s should be removed, got %v", name, err)
+		}
+	}
+}
+
+func TestCompactedDirectory_ReadersSeeContents(t *testing.T) {
+	setup := func(t *testing.T, mode string) (*Engine, string) {
+		t.Helper()
+		eng, repoDir := setupDiffEngine(t, map[string]string{"conf/a": "a", "conf/b": "b"}, nil)
+		track := stores.NewTrackFile()
+		track.Tracked = []stores.TrackedPath{{Path: "conf", Kind: "dir", LinkContents: true}}
+		if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		if _, err := eng.Apply(ctx, &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: mode}); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+		if _, err := eng.CompactWorkspace(ctx, repoDir); err != nil {
+			t.Fatalf("CompactWorkspace failed: %v", err)
+		}
+		return eng, repoDir
+	}
+
+	t.Run("diff keeps the applied checksum", func(t *testing.T) {
+		eng, repoDir := setup(t, "copy")
+		if err := os.WriteFile(filepath.Join(eng.storeRepo.OverlayRoot("s1"), "conf", "a"), []byte("edited"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1"})
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+		var found bool
+		for _, info := range result.Files {
+			if info.Path == filepath.Join("conf", "a") {
+				found = true
+				if !info.StoreChangedSinceApply {
+					t.Errorf("conf/a = %+v, want the store change since apply detected", info)
+				}
+			}
+		}
+		if !found {
+			t.Fatalf("conf/a missing from diff: %+v", result.Files)
+		}
+	})
+
+	t.Run("symlink reapply leaves compacted links alone", func(t *testing.T) {
+		eng, repoDir := setup(t, "symlink")
+		result, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "symlink"})
+		if err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+		if len(result.Applied) != 0 {
+			t.Errorf("Applied = %+v, want no churn for unchanged links", result.Applied)
+		}
+	})
+}
@@ -20,6 +20,15 @@ import (
 // 6. Delete store
 // 7. Return result
 func (e *Engine) DeleteStore(ctx context.Context, req *DeleteStoreRequest) (*DeleteStoreResult, error) {
+	// Accept scope-qualified IDs such as "global/foo"
+	storeID, scope, err := splitStoreID(req.StoreID, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	normalized := *req
+	normalized.StoreID, normalized.Scope = storeID, scope
+	req = &normalized
+
 	// Step 1: Resolve store scope
 	repo, _, err := e.resolveStoreRepo(req.StoreID, req.Scope)
 	if err != nil {
@@ -3,6 +3,7 @@ package engine
 import (
 	"context"
 	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
@@ -42,10 +43,15 @@ func (m *mockStoreRepo) List() ([]string, error)                            { re
 func (m *mockStoreRepo) Create(id string, meta *stores.StoreMeta) error     { return nil }
 func (m *mockStoreRepo) LoadMeta(id string) (*stores.StoreMeta, error)      { return nil, nil }
 func (m *mockStoreRepo) SaveMeta(id string, meta *stores.StoreMeta) error   { return nil }
+func (m *mockStoreRepo) MarkUsed(id string, at time.Time) error             { return nil }
 func (m *mockStoreRepo) LoadTrack(id string) (*stores.TrackFile, error)     { return nil, nil }
 func (m *mockStoreRepo) SaveTrack(id string, track *stores.TrackFile) error { return nil }
 func (m *mockStoreRepo) OverlayRoot(id string) string                       { return "" }
 
+func (m *mockStoreRepo) LoadMetas(ids []string) (map[string]*stores.StoreMeta, error) {
+	return stores.LoadMetasSequential(m, ids)
+}
+
 type mockStateStore struct {
 	workspaces map[string]*state.WorkspaceState
 	loadError  error
@@ -77,11 +83,20 @@ func (m *mockStateStore) SaveWorkspace(id string, ws *state.WorkspaceState) erro
 	return nil
 }
 
+func (m *mockStateStore) CheckWorkspace(id string, ws *state.WorkspaceState) error {
+	return nil
+}
+
 func (m *mockStateStore) DeleteWorkspace(id string) error {
 	delete(m.workspaces, id)
 	return nil
 }
 
+func (m *mockStateStore) LoadWorkspaceIfChanged(id string, since time.Time) (*state.WorkspaceState, bool, error) {
+	ws, err := m.LoadWorkspace(id)
+	return ws, err == nil, err
+}
+
 type mockFS struct{}
 
 func (m *mockFS) ReadFile(path string) ([]byte, error)                         { return nil, nil }
@@ -94,8 +109,12 @@ func (m *mockFS) Symlink(oldname, newname string) error                        {
 func (m *mockFS) Readlink(name string) (string, error)                         { return "", nil }
 func (m *mockFS) Lstat(name string) (os.FileInfo, error)                       { return nil, nil }
 func (m *mockFS) Copy(src, dst string) error                                   { return nil }
+func (m *mockFS) Rename(oldpath, newpath string) error                         { return nil }
+func (m *mockFS) Chtimes(path string, atime, mtime time.Time) error            { return nil }
 func (m *mockFS) ValidateRelPath(relPath string) error                         { return nil }
 func (m *mockFS) ValidateIdentifier(id string) error                           { return nil }
+func (m *mockFS) ReadDir(path string) ([]os.DirEntry, error)                   { return nil, nil }
+func (m *mockFS) WalkDir(root string, fn fs.WalkDirFunc) error                 { return nil }
 
 type mockGitRepo struct{}
 
@@ -10,6 +10,9 @@ import (
 	"strings"
 	"unicode/utf8"
 
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/hash"
+	"github.com/danieljhkim/monodev/internal/state"
 	"github.com/danieljhkim/monodev/internal/stores"
 )
 
@@ -27,8 +30,11 @@ func (e *Engine) Diff(ctx context.Context, req *DiffRequest) (*DiffResult, error
 		return nil, err
 	}
 
-	// Determine which store to diff against
-	storeID := req.StoreID
+	// Determine which store to diff against, accepting scope-qualified IDs
+	storeID, storeScope, err := splitStoreID(req.StoreID, "")
+	if err != nil {
+		return nil, err
+	}
 	if storeID == "" {
 		storeID = workspaceState.ActiveStore
 		if storeID == "" {
@@ -39,15 +45,25 @@ func (e *Engine) Diff(ctx context.Context, req *DiffRequest) (*DiffResult, error
 	// Resolve store repo (use active store scope or search both)
 	var repo stores.StoreRepo
 	if storeID == workspaceState.ActiveStore && workspaceState.ActiveStoreScope != "" {
-		repo, err = e.storeRepoForScope(workspaceState.ActiveStoreScope)
+		repo, err = e.activeStoreRepo(workspaceState)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		repo, _, err = e.resolveStoreRepo(storeID, "")
+		repo, _, err = e.resolveStoreRepo(storeID, storeScope)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Compare against the store as committed at a ref instead
+	if req.StoreRef != "" {
+		refRepo, storesDir, err := e.checkoutStoreAt(root, storeID, req.StoreRef)
 		if err != nil {
 			return nil, err
 		}
+		defer func() { _ = os.RemoveAll(storesDir) }()
+		repo = refRepo
 	}
 
 	// Load tracked paths from store
@@ -66,22 +82,72 @@ func (e *Engine) Diff(ctx context.Context, req *DiffRequest) (*DiffResult, error
 	// Get overlay root path
 	overlayRoot := repo.OverlayRoot(storeID)
 
+	// Reuse hashes of unchanged files across runs when the cache is enabled
+	var hasher hash.Hasher = e.hasher
+	var cache *hash.CachingHasher
+	if e.hashCache && req.StoreRef == "" {
+		storeDir := filepath.Dir(overlayRoot)
+		cache = hash.NewCachingHasher(e.hasher, e.fs, storeDir, filepath.Join(storeDir, hash.CacheFileName))
+		hasher = cache
+	}
+	// Store files kept as large-file pointers compare by the content they address
+	hasher = e.newPointerHasher(hasher)
+
 	// Compare each tracked path
 	files := make([]DiffFileInfo, 0, len(trackFile.Tracked))
+	var summary DiffSummary
+	collect := func(infos ...DiffFileInfo) {
+		for _, info := range infos {
+			e.ignoreEOLOnly(trackFile, &info, filepath.Join(root, info.Path), filepath.Join(overlayRoot, info.Path))
+			ownership, _ := workspaceState.Owner(info.Path)
+			e.compareApplied(hasher, &info, ownership, storeID,
+				filepath.Join(root, info.Path), filepath.Join(overlayRoot, info.Path), req.SinceApply)
+			summary.add(info.Status)
+			if info.StoreChangedSinceApply {
+				summary.StoreChanged++
+			}
+			if req.ChangedOnly && info.Status == "unchanged" {
+				continue
+			}
+			files = append(files, info)
+		}
+	}
 	for _, tracked := range trackFile.Tracked {
+		if err := ctx.Err(); err != nil {
+			return &DiffResult{
+				WorkspaceID: workspaceID,
+				StoreID:     storeID,
+				Files:       files,
+				Summary:     summary,
+			}, err
+		}
+		if tracked.ExcludeFromDiff && !req.IncludeExcluded {
+			continue
+		}
+
 		workspacePath := filepath.Join(root, tracked.Path)
 		storePath := filepath.Join(overlayRoot, tracked.Path)
 
 		if tracked.Kind == "dir" {
 			// For directories, walk and compare all files within
-			dirFiles, err := e.compareDirPath(root, overlayRoot, workspacePath, storePath, tracked.Path, req.ShowContent)
+			dirFiles, err := e.compareDirPath(hasher, root, overlayRoot, workspacePath, storePath, tracked.Path, req.ShowContent)
 			if err != nil {
 				return nil, fmt.Errorf("failed to compare directory %s: %w", tracked.Path, err)
 			}
-			files = append(files, dirFiles...)
+			collect(dirFiles...)
 		} else {
-			fileInfo := e.comparePath(workspacePath, storePath, tracked.Path, tracked.Kind, req.ShowContent)
-			files = append(files, fileInfo)
+			fileInfo := e.comparePath(hasher, workspacePath, storePath, tracked.Path, tracked.Kind, req.ShowContent)
+			collect(fileInfo)
+		}
+	}
+
+	if req.DetectRenames {
+		files = detectRenames(files, &summary)
+	}
+
+	if cache != nil {
+		if err := cache.Save(); err != nil {
+			return nil, fmt.Errorf("failed to save hash cache: %w", err)
 		}
 	}
 
@@ -89,11 +155,94 @@ func (e *Engine) Diff(ctx context.Context, req *DiffRequest) (*DiffResult, error
 		WorkspaceID: workspaceID,
 		StoreID:     storeID,
 		Files:       files,
+		Summary:     summary,
 	}, nil
 }
 
+// HasChanges reports whether any compared file differs from the store.
+// Paths excluded from the diff are never compared, so they do not count.
+func (r *DiffResult) HasChanges() bool {
+	for _, file := range r.Files {
+		if file.Status != "unchanged" {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangedPaths returns the sorted paths of files that differ from the store.
+func (r *DiffResult) ChangedPaths() []string {
+	paths := []string{}
+	for _, file := range r.Files {
+		if file.Status != "unchanged" {
+			paths = append(paths, file.Path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// detectRenames pairs each removed file with an added file of identical
+// content, replacing the pair with a single "renamed" entry at the added
+// file's position. Candidates are matched in path order so the pairing is
+// deterministic. The summary is adjusted to count each pair once.
+func detectRenames(files []DiffFileInfo, summary *DiffSummary) []DiffFileInfo {
+	// Index added files by content hash, in path order
+	added := make(map[string][]int)
+	var removed []int
+	for i, file := range files {
+		switch {
+		case file.IsDir:
+		case file.Status == "added" && file.WorkspaceHash != "":
+			added[file.WorkspaceHash] = append(added[file.WorkspaceHash], i)
+		case file.Status == "removed" && file.StoreHash != "":
+			removed = append(removed, i)
+		}
+	}
+	if len(added) == 0 || len(removed) == 0 {
+		return files
+	}
+	for _, candidates := range added {
+		sort.Slice(candidates, func(a, b int) bool {
+			return files[candidates[a]].Path < files[candidates[b]].Path
+		})
+	}
+	sort.Slice(removed, func(a, b int) bool {
+		return files[removed[a]].Path < files[removed[b]].Path
+	})
+
+	dropped := make(map[int]bool)
+	for _, i := range removed {
+		candidates := added[files[i].StoreHash]
+		if len(candidates) == 0 {
+			continue
+		}
+		j := candidates[0]
+		added[files[i].StoreHash] = candidates[1:]
+
+		files[j].Status = "renamed"
+		files[j].OldPath = files[i].Path
+		files[j].StoreHash = files[i].StoreHash
+		files[j].UnifiedDiff, files[j].Additions, files[j].Deletions = "", 0, 0
+		dropped[i] = true
+
+		summary.Added--
+		summary.Removed--
+		summary.Renamed++
+		summary.Total--
+	}
+
+	result := files[:0]
+	for i, file := range files {
+		if !dropped[i] {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
 // compareDirPath walks a directory and compares all files within it.
-func (e *Engine) compareDirPath(workspaceRoot, overlayRoot, workspaceDir, storeDir, trackedPath string, showContent bool) ([]DiffFileInfo, error) {
+func (e *Engine) compareDirPath(hasher hash.Hasher, workspaceRoot, overlayRoot, workspaceDir, storeDir, trackedPath string, showContent bool) ([]DiffFileInfo, error) {
 	// Collect all file paths from both workspace and store
 	fileMap := make(map[string]bool)
 
@@ -127,10 +276,9 @@ func (e *Engine) compareDirPath(workspaceRoot, overlayRoot, workspaceDir, storeD
 		return nil, fmt.Errorf("failed to check store directory existence: %w", err)
 	}
 	if storeExists {
-		err := filepath.Walk(storeDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
+		// Overlay directories may contain symlinked directories, so guard
+		// against loops and runaway nesting while following them.
+		err := fsops.WalkFollow(storeDir, e.maxWalkDepth, func(path string, info os.FileInfo) error {
 			if !info.IsDir() {
 				relPath, err := filepath.Rel(overlayRoot, path)
 				if err != nil {
@@ -157,15 +305,55 @@ func (e *Engine) compareDirPath(workspaceRoot, overlayRoot, workspaceDir, storeD
 		workspacePath := filepath.Join(workspaceRoot, relPath)
 		storePath := filepath.Join(overlayRoot, relPath)
 
-		fileInfo := e.comparePath(workspacePath, storePath, relPath, "file", showContent)
+		fileInfo := e.comparePath(hasher, workspacePath, storePath, relPath, "file", showContent)
 		result = append(result, fileInfo)
 	}
 
 	return result, nil
 }
 
+// compareApplied checks a compared file against the store content recorded
+// when it was applied. It flags files whose store content changed since
+// apply and, when sinceApply is set, re-derives the status by comparing the
+// workspace with the applied content. Historical content is not retained,
+// so no unified diff is available for the since-apply comparison.
+func (e *Engine) compareApplied(hasher hash.Hasher, info *DiffFileInfo, ownership state.PathOwnership, storeID, workspacePath, storePath string, sinceApply bool) {
+	if info.IsDir || ownership.Store != storeID || ownership.SourceChecksum == "" {
+		return
+	}
+	info.AppliedHash = ownership.SourceChecksum
+
+	// comparePath leaves StoreHash empty when the store file is missing
+	// or the workspace file could not be hashed
+	if info.StoreHash == "" {
+		if storeHash, err := hasher.HashFile(storePath); err == nil {
+			info.StoreHash = storeHash
+		}
+	}
+	info.StoreChangedSinceApply = info.StoreHash != info.AppliedHash
+
+	if !sinceApply || !info.StoreChangedSinceApply {
+		return
+	}
+
+	info.UnifiedDiff, info.Additions, info.Deletions = "", 0, 0
+	if info.WorkspaceHash == "" {
+		workspaceHash, err := hasher.HashFile(workspacePath)
+		if err != nil {
+			info.Status = "removed"
+			return
+		}
+		info.WorkspaceHash = workspaceHash
+	}
+	if info.WorkspaceHash == info.AppliedHash {
+		info.Status = "unchanged"
+	} else {
+		info.Status = "modified"
+	}
+}
+
 // comparePath compares a single path between workspace and store overlay.
-func (e *Engine) comparePath(workspacePath, storePath, relPath, kind string, showContent bool) DiffFileInfo {
+func (e *Engine) comparePath(hasher hash.Hasher, workspacePath, storePath, relPath, kind string, showContent bool) DiffFileInfo {
 	info := DiffFileInfo{
 		Path:  relPath,
 		IsDir: kind == "dir",
@@ -192,7 +380,7 @@ func (e *Engine) comparePath(workspacePath, storePath, relPath, kind string, sho
 	if !storeExists && workspaceExists {
 		info.Status = "added"
 		if !info.IsDir {
-			hash, err := e.hasher.HashFile(workspacePath)
+			hash, err := hasher.HashFile(workspacePath)
 			if err == nil {
 				info.WorkspaceHash = hash
 			}
@@ -208,7 +396,7 @@ func (e *Engine) comparePath(workspacePath, storePath, relPath, kind string, sho
 	if storeExists && !workspaceExists {
 		info.Status = "removed"
 		if !info.IsDir {
-			hash, err := e.hasher.HashFile(storePath)
+			hash, err := hasher.HashFile(storePath)
 			if err == nil {
 				info.StoreHash = hash
 			}
@@ -229,14 +417,14 @@ func (e *Engine) comparePath(workspacePath, storePath, relPath, kind string, sho
 	}
 
 	// Hash both files
-	workspaceHash, err := e.hasher.HashFile(workspacePath)
+	workspaceHash, err := hasher.HashFile(workspacePath)
 	if err != nil {
 		info.Status = "modified"
 		return info
 	}
 	info.WorkspaceHash = workspaceHash
 
-	storeHash, err := e.hasher.HashFile(storePath)
+	storeHash, err := hasher.HashFile(storePath)
 	if err != nil {
 		info.Status = "modified"
 		return info
@@ -1,13 +1,19 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/danieljhkim/monodev/internal/config"
 	"github.com/danieljhkim/monodev/internal/fsops"
 	"github.com/danieljhkim/monodev/internal/hash"
+	"github.com/danieljhkim/monodev/internal/stores"
 )
 
 func TestGenerateUnifiedDiff_ModifiedFile(t *testing.T) {
@@ -85,7 +91,7 @@ func TestComparePath_ShowContentPopulatesUnifiedDiff(t *testing.T) {
 		hasher: hash.NewSHA256Hasher(),
 	}
 
-	info := eng.comparePath(workspacePath, storePath, "example.txt", "file", true)
+	info := eng.comparePath(eng.hasher, workspacePath, storePath, "example.txt", "file", true)
 
 	if info.Status != "modified" {
 		t.Fatalf("status = %q, want modified", info.Status)
@@ -100,3 +106,435 @@ func TestComparePath_ShowContentPopulatesUnifiedDiff(t *testing.T) {
 		t.Fatalf("unexpected UnifiedDiff:\n%s", info.UnifiedDiff)
 	}
 }
+
+// setupDiffEngine creates an engine backed by a real store with the given
+// overlay and workspace files, tracked individually, under a temp directory.
+// The other engine fixtures build on it.
+func setupDiffEngine(t *testing.T, overlay, workspace map[string]string) (*Engine, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	fs := fsops.NewRealFS()
+	storeRepo := stores.NewFileStoreRepo(fs, filepath.Join(tmpDir, "stores"))
+	if err := storeRepo.Create("s1", stores.NewStoreMeta("s1", stores.ScopeGlobal, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFiles(t, storeRepo.OverlayRoot("s1"), overlay)
+	writeTestFiles(t, repoDir, workspace)
+	seen := make(map[string]bool)
+	track := stores.NewTrackFile()
+	for _, files := range []map[string]string{overlay, workspace} {
+		for rel := range files {
+			if !seen[rel] {
+				seen[rel] = true
+				track.Tracked = append(track.Tracked, stores.TrackedPath{Path: rel, Kind: "file"})
+			}
+		}
+	}
+	if err := storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	gitRepo := &trackGitRepo{root: repoDir, fingerprint: "fp1", workspacePath: "."}
+	eng := New(gitRepo, storeRepo, newMockStateStore(), fs, hash.NewSHA256Hasher(), &mockClock{}, config.Paths{})
+	return eng, repoDir
+}
+
+// writeTestFiles writes files, keyed by their path relative to root.
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiff_ChangedOnlyOmitsUnchanged(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{"same.txt": "same\n", "mod.txt": "old\n", "gone.txt": "bye\n"},
+		map[string]string{"same.txt": "same\n", "mod.txt": "new\n", "new.txt": "hi\n"},
+	)
+
+	result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1", ChangedOnly: true})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	if len(result.Files) != 3 {
+		t.Fatalf("expected 3 changed files, got %d: %+v", len(result.Files), result.Files)
+	}
+	for _, f := range result.Files {
+		if f.Status == "unchanged" {
+			t.Errorf("unexpected unchanged entry %q with ChangedOnly", f.Path)
+		}
+	}
+
+	want := DiffSummary{Added: 1, Removed: 1, Modified: 1, Unchanged: 1, Total: 4}
+	if result.Summary != want {
+		t.Errorf("Summary = %+v, want %+v", result.Summary, want)
+	}
+}
+
+func TestDiff_DefaultIncludesUnchanged(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{"same.txt": "same\n", "mod.txt": "old\n"},
+		map[string]string{"same.txt": "same\n", "mod.txt": "new\n"},
+	)
+
+	result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1"})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	if len(result.Files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(result.Files))
+	}
+	if result.Summary.Unchanged != 1 || result.Summary.Total != 2 {
+		t.Errorf("Summary = %+v, want 1 unchanged of 2", result.Summary)
+	}
+}
+
+func TestDiff_CancelledContext(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{"a.txt": "a\n"},
+		map[string]string{"a.txt": "a\n"},
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := eng.Diff(ctx, &DiffRequest{CWD: repoDir, StoreID: "s1"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if result == nil || len(result.Files) != 0 {
+		t.Errorf("expected empty partial result, got %+v", result)
+	}
+}
+
+func TestDiff_ExcludeFromDiff(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{"app.txt": "old\n", "lock.json": "v1\n"},
+		map[string]string{"app.txt": "new\n", "lock.json": "v2\n"},
+	)
+	repo := eng.storeRepo
+	track, err := repo.LoadTrack("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range track.Tracked {
+		if track.Tracked[i].Path == "lock.json" {
+			track.Tracked[i].ExcludeFromDiff = true
+		}
+	}
+	if err := repo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1"})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0].Path != "app.txt" {
+		t.Fatalf("expected only app.txt by default, got %+v", result.Files)
+	}
+
+	result, err = eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1", IncludeExcluded: true})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("expected 2 files with IncludeExcluded, got %+v", result.Files)
+	}
+}
+
+func TestDiff_OverlaySymlinkLoop(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"cfg/a.txt": "a\n"}, nil)
+	repo := eng.storeRepo
+	cfgDir := filepath.Join(repo.OverlayRoot("s1"), "cfg")
+	if err := os.Symlink(cfgDir, filepath.Join(cfgDir, "loop")); err != nil {
+		t.Fatal(err)
+	}
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "cfg", Kind: "dir"}}
+	if err := repo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1"})
+	if !errors.Is(err, fsops.ErrSymlinkLoop) {
+		t.Fatalf("expected ErrSymlinkLoop, got %v", err)
+	}
+}
+
+// countingHasher counts HashFile calls on the wrapped hasher.
+type countingHasher struct {
+	hash.Hasher
+	calls int
+}
+
+func (h *countingHasher) HashFile(path string) (string, error) {
+	h.calls++
+	return h.Hasher.HashFile(path)
+}
+
+func TestDiff_HashCacheReusesHashes(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{"a.txt": "same\n", "b.txt": "old\n"},
+		map[string]string{"a.txt": "same\n", "b.txt": "old\n"},
+	)
+	counter := &countingHasher{Hasher: eng.hasher}
+	eng.hasher = counter
+	WithHashCache(true)(eng)
+	req := &DiffRequest{CWD: repoDir, StoreID: "s1"}
+
+	if _, err := eng.Diff(context.Background(), req); err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if counter.calls != 4 {
+		t.Fatalf("first Diff hashed %d files, want 4", counter.calls)
+	}
+	cacheFile := filepath.Join(filepath.Dir(eng.storeRepo.OverlayRoot("s1")), hash.CacheFileName)
+	if _, err := os.Stat(cacheFile); err != nil {
+		t.Fatalf("expected hash cache file: %v", err)
+	}
+
+	// Only store files are cached; the workspace copies are hashed every time
+	counter.calls = 0
+	if _, err := eng.Diff(context.Background(), req); err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if counter.calls != 2 {
+		t.Errorf("second Diff hashed %d files, want 2", counter.calls)
+	}
+
+	// A modified store file (different size) is re-hashed
+	if err := os.WriteFile(filepath.Join(eng.storeRepo.OverlayRoot("s1"), "b.txt"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	counter.calls = 0
+	result, err := eng.Diff(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if counter.calls != 3 {
+		t.Errorf("Diff after modification hashed %d files, want 3", counter.calls)
+	}
+	if result.Summary.Modified != 1 {
+		t.Errorf("Summary = %+v, want 1 modified", result.Summary)
+	}
+}
+
+func TestDiff_StoreChangedSinceApply(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{"drift.txt": "v1\n", "clean.txt": "c1\n", "same.txt": "same\n"},
+		nil,
+	)
+	ctx := context.Background()
+
+	if _, err := eng.Apply(ctx, &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// The store moves on after apply, and the workspace copy of drift.txt is edited too
+	overlayRoot := eng.storeRepo.OverlayRoot("s1")
+	for rel, content := range map[string]string{"drift.txt": "v2\n", "clean.txt": "c2\n"} {
+		if err := os.WriteFile(filepath.Join(overlayRoot, rel), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "drift.txt"), []byte("local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := func(result *DiffResult) map[string]DiffFileInfo {
+		byPath := make(map[string]DiffFileInfo)
+		for _, f := range result.Files {
+			byPath[f.Path] = f
+		}
+		return byPath
+	}
+
+	result, err := eng.Diff(ctx, &DiffRequest{CWD: repoDir, StoreID: "s1"})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	files := statuses(result)
+	for path, want := range map[string]bool{"drift.txt": true, "clean.txt": true, "same.txt": false} {
+		if got := files[path].StoreChangedSinceApply; got != want {
+			t.Errorf("%s StoreChangedSinceApply = %v, want %v", path, got, want)
+		}
+	}
+	// Against the current store, both changed files show as modified
+	if files["clean.txt"].Status != "modified" || files["drift.txt"].Status != "modified" {
+		t.Errorf("unexpected statuses against current store: %+v", files)
+	}
+	if result.Summary.StoreChanged != 2 {
+		t.Errorf("Summary.StoreChanged = %d, want 2", result.Summary.StoreChanged)
+	}
+
+	// Since apply, only the workspace edit counts as a change
+	result, err = eng.Diff(ctx, &DiffRequest{CWD: repoDir, StoreID: "s1", SinceApply: true})
+	if err != nil {
+		t.Fatalf("Diff SinceApply failed: %v", err)
+	}
+	files = statuses(result)
+	want := map[string]string{"drift.txt": "modified", "clean.txt": "unchanged", "same.txt": "unchanged"}
+	for path, status := range want {
+		if files[path].Status != status {
+			t.Errorf("%s Status = %q since apply, want %q", path, files[path].Status, status)
+		}
+	}
+	if files["drift.txt"].UnifiedDiff != "" {
+		t.Error("since-apply comparison should not include a diff against current store content")
+	}
+}
+
+func TestDiff_IgnoresLineEndingOnlyChanges(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{"run.sh": "echo a\r\n", "raw.txt": "x\r\n"},
+		map[string]string{"run.sh": "echo a\n", "raw.txt": "x\n"},
+	)
+	track, err := eng.storeRepo.LoadTrack("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	track.Normalize = []stores.NormalizeRule{{Pattern: "*.sh", EOL: stores.EOLLF}}
+	if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1", ShowContent: true})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	statuses := make(map[string]string)
+	for _, f := range result.Files {
+		statuses[f.Path] = f.Status
+	}
+	if statuses["run.sh"] != "unchanged" {
+		t.Errorf("run.sh status = %q, want unchanged", statuses["run.sh"])
+	}
+	if statuses["raw.txt"] != "modified" {
+		t.Errorf("raw.txt status = %q, want modified without a normalize rule", statuses["raw.txt"])
+	}
+}
+
+func TestDiffResult_HasChanges(t *testing.T) {
+	t.Run("all unchanged", func(t *testing.T) {
+		eng, repoDir := setupDiffEngine(t,
+			map[string]string{"a.txt": "a\n", "b.txt": "b\n"},
+			map[string]string{"a.txt": "a\n", "b.txt": "b\n"},
+		)
+		result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1"})
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+		if result.HasChanges() {
+			t.Errorf("HasChanges() = true for identical files: %+v", result.Files)
+		}
+		if got := result.ChangedPaths(); len(got) != 0 {
+			t.Errorf("ChangedPaths() = %v, want none", got)
+		}
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		eng, repoDir := setupDiffEngine(t,
+			map[string]string{"same.txt": "same\n", "mod.txt": "old\n", "gone.txt": "bye\n", "lock.json": "v1\n"},
+			map[string]string{"same.txt": "same\n", "mod.txt": "new\n", "lock.json": "v2\n"},
+		)
+		track, err := eng.storeRepo.LoadTrack("s1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range track.Tracked {
+			if track.Tracked[i].Path == "lock.json" {
+				track.Tracked[i].ExcludeFromDiff = true
+			}
+		}
+		if err := eng.storeRepo.SaveTrack("s1", track); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1"})
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+		if !result.HasChanges() {
+			t.Error("HasChanges() = false, want true")
+		}
+		want := []string{"gone.txt", "mod.txt"}
+		if got := result.ChangedPaths(); !slices.Equal(got, want) {
+			t.Errorf("ChangedPaths() = %v, want %v (excluded lock.json omitted)", got, want)
+		}
+	})
+}
+
+func TestDiff_DetectRenames(t *testing.T) {
+	// The workspace still has the file at its old path; the store moved it
+	newEngine := func(t *testing.T) (*Engine, string) {
+		return setupDiffEngine(t,
+			map[string]string{"lib/util.go": "package lib\n", "gone.txt": "bye\n"},
+			map[string]string{"util.go": "package lib\n", "new.txt": "hi\n"},
+		)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		eng, repoDir := newEngine(t)
+		result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1"})
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+		statuses := make(map[string]string)
+		for _, f := range result.Files {
+			statuses[f.Path] = f.Status
+		}
+		if statuses["lib/util.go"] != "removed" || statuses["util.go"] != "added" {
+			t.Errorf("expected separate removed/added entries, got %v", statuses)
+		}
+		if result.Summary.Renamed != 0 {
+			t.Errorf("Summary.Renamed = %d, want 0", result.Summary.Renamed)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		eng, repoDir := newEngine(t)
+		result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1", DetectRenames: true})
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+
+		var renamed []DiffFileInfo
+		statuses := make(map[string]string)
+		for _, f := range result.Files {
+			statuses[f.Path] = f.Status
+			if f.Status == "renamed" {
+				renamed = append(renamed, f)
+			}
+		}
+		if len(renamed) != 1 {
+			t.Fatalf("expected one renamed entry, got %+v", result.Files)
+		}
+		if renamed[0].OldPath != "lib/util.go" || renamed[0].Path != "util.go" {
+			t.Errorf("renamed %q -> %q, want lib/util.go -> util.go", renamed[0].OldPath, renamed[0].Path)
+		}
+		if _, ok := statuses["lib/util.go"]; ok {
+			t.Error("old path should not be reported separately")
+		}
+		// Files with different content are not paired
+		if statuses["gone.txt"] != "removed" || statuses["new.txt"] != "added" {
+			t.Errorf("unmatched files should stay removed/added, got %v", statuses)
+		}
+
+		want := DiffSummary{Added: 1, Removed: 1, Renamed: 1, Total: 3}
+		if result.Summary != want {
+			t.Errorf("Summary = %+v, want %+v", result.Summary, want)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// EffectiveTrackFile returns a store's track file with repo-level defaults
+// merged in: the repo's ignore patterns follow the store's own, and tracked
+// paths without a role get the repo's default role. This is the tracked set
+// monodev actually works with. The stored track file is not modified.
+func (e *Engine) EffectiveTrackFile(storeID, scope string) (*stores.TrackFile, error) {
+	storeID, scope, err := splitStoreID(storeID, scope)
+	if err != nil {
+		return nil, err
+	}
+	repo, _, err := e.resolveStoreRepo(storeID, scope)
+	if err != nil {
+		return nil, err
+	}
+	track, err := repo.LoadTrack(storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load track file: %w", err)
+	}
+	return e.effectiveTrack(track), nil
+}
+
+// effectiveTrack returns a copy of track with repo defaults applied.
+func (e *Engine) effectiveTrack(track *stores.TrackFile) *stores.TrackFile {
+	merged := *track
+	merged.Tracked = slices.Clone(track.Tracked)
+	merged.Ignore = slices.Clone(track.Ignore)
+	if e.repoSettings == nil {
+		return &merged
+	}
+
+	for _, pattern := range e.repoSettings.Ignore {
+		if !slices.Contains(merged.Ignore, pattern) {
+			merged.Ignore = append(merged.Ignore, pattern)
+		}
+	}
+	if e.repoSettings.DefaultRole != "" {
+		for i := range merged.Tracked {
+			if merged.Tracked[i].Role == "" {
+				merged.Tracked[i].Role = e.repoSettings.DefaultRole
+			}
+		}
+	}
+	return &merged
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/config"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+func TestEffectiveTrackFile_MergesRepoDefaults(t *testing.T) {
+	eng, storeRepo, repoDir := setupTrackPathsEngine(t, map[string]string{
+		"src/a.go":      "a",
+		"src/gen/b.go":  "b",
+		"src/debug.log": "log",
+	})
+	eng.SetRepoSettings(&config.RepoSettings{Ignore: []string{"gen/", "*.log"}, DefaultRole: stores.RoleConfig})
+
+	track := stores.NewTrackFile()
+	track.Ignore = []string{"*.log"}
+	track.Tracked = []stores.TrackedPath{{Path: "README.md", Kind: "file", Role: stores.RoleDocs}}
+	if err := storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	// Directory expansion honors the repo-level ignore
+	result, err := eng.TrackPaths(context.Background(), &TrackPathsRequest{CWD: repoDir, Dir: "src", Recursive: true})
+	if err != nil {
+		t.Fatalf("TrackPaths failed: %v", err)
+	}
+	if strings.Join(result.AddedPaths, ",") != "src/a.go" {
+		t.Errorf("AddedPaths = %v, want [src/a.go]", result.AddedPaths)
+	}
+
+	effective, err := eng.EffectiveTrackFile("s1", "")
+	if err != nil {
+		t.Fatalf("EffectiveTrackFile failed: %v", err)
+	}
+	if strings.Join(effective.Ignore, ",") != "*.log,gen/" {
+		t.Errorf("Ignore = %v, want [*.log gen/]", effective.Ignore)
+	}
+	roles := map[string]string{}
+	for _, tp := range effective.Tracked {
+		roles[tp.Path] = tp.Role
+	}
+	if roles["README.md"] != stores.RoleDocs || roles["src/a.go"] != stores.RoleConfig {
+		t.Errorf("roles = %v, want README.md docs and src/a.go config", roles)
+	}
+
+	// The stored track file keeps only its own settings
+	stored, err := storeRepo.LoadTrack("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(stored.Ignore, ",") != "*.log" {
+		t.Errorf("stored Ignore = %v, want [*.log]", stored.Ignore)
+	}
+	for _, tp := range stored.Tracked {
+		if tp.Path == "src/a.go" && tp.Role != "" {
+			t.Errorf("stored role for src/a.go = %q, want none", tp.Role)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/danieljhkim/monodev/internal/gitx"
 	"github.com/danieljhkim/monodev/internal/hash"
 	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/remote"
 	"github.com/danieljhkim/monodev/internal/state"
 	"github.com/danieljhkim/monodev/internal/stores"
 )
@@ -43,9 +44,50 @@ type Engine struct {
 	globalStateStore    state.StateStore
 	componentStateStore state.StateStore
 	scopedPaths         *config.ScopedPaths
+
+	// maxWalkDepth bounds overlay directory traversal (0 = fsops.DefaultMaxDepth)
+	maxWalkDepth int
+
+	// hashCache enables the per-store hash cache used by Diff
+	hashCache bool
+
+	// gitPersistence reads pinned stores from the sync repository
+	gitPersistence remote.GitPersistence
+
+	// repoSettings supplies repo-level defaults merged into track files
+	repoSettings *config.RepoSettings
+
+	// version is the running monodev version, recorded on path ownership
+	version string
+
+	// settings forces the mode and scope of requests that leave them unset
+	settings *config.Settings
+
+	// maxStoreBytes is the overlay size limit per scope (absent = unlimited)
+	maxStoreBytes map[string]int64
 }
 
-// New creates a new Engine with the given dependencies.
+// Option configures an Engine at construction.
+type Option func(*Engine)
+
+// WithHashCache enables the per-store hash cache. When enabled, Diff records
+// file hashes in the store directory (see hash.CacheFileName) and skips
+// rehashing files whose size and modification time are unchanged.
+func WithHashCache(enabled bool) Option {
+	return func(e *Engine) {
+		e.hashCache = enabled
+	}
+}
+
+// auditable is implemented by state stores that can record mutations to an
+// audit sink.
+type auditable interface {
+	SetAuditSink(sink state.AuditSink)
+}
+
+// New creates a new Engine with the given dependencies. When paths has a
+// root and stateStore supports auditing, state mutations are recorded in
+// the audit log under that root.
 func New(
 	gitRepo gitx.GitRepo,
 	storeRepo stores.StoreRepo,
@@ -54,8 +96,12 @@ func New(
 	hasher hash.Hasher,
 	clk clock.Clock,
 	paths config.Paths,
+	opts ...Option,
 ) *Engine {
-	return &Engine{
+	if audited, ok := stateStore.(auditable); ok && paths.Root != "" {
+		audited.SetAuditSink(state.NewFileAuditSink(filepath.Join(paths.Root, state.AuditLogFileName)))
+	}
+	e := &Engine{
 		gitRepo:          gitRepo,
 		storeRepo:        storeRepo,
 		stateStore:       stateStore,
@@ -66,6 +112,10 @@ func New(
 		globalStoreRepo:  storeRepo,
 		globalStateStore: stateStore,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 // NewScoped creates a new Engine with dual-scope StoreRepo instances.
@@ -76,9 +126,11 @@ func NewScoped(
 	fs fsops.FS,
 	hasher hash.Hasher,
 	clk clock.Clock,
+	opts ...Option,
 ) *Engine {
 	globalStoreRepo := stores.NewFileStoreRepo(fs, scopedPaths.Global.Stores)
-	globalStateStore := state.NewFileStateStore(fs, scopedPaths.Global.Workspaces)
+	globalStateStore := state.NewFileStateStore(fs, scopedPaths.Global.Workspaces, state.WithClock(clk))
+	globalStateStore.SetAuditSink(state.NewFileAuditSink(filepath.Join(scopedPaths.Global.Root, state.AuditLogFileName)))
 
 	e := &Engine{
 		gitRepo:          gitRepo,
@@ -96,14 +148,110 @@ func NewScoped(
 
 	if scopedPaths.Component != nil {
 		componentStoreRepo := stores.NewFileStoreRepo(fs, scopedPaths.Component.Stores)
-		componentStateStore := state.NewFileStateStore(fs, scopedPaths.Component.Workspaces)
+		componentStateStore := state.NewFileStateStore(fs, scopedPaths.Component.Workspaces, state.WithClock(clk))
+		componentStateStore.SetAuditSink(state.NewFileAuditSink(filepath.Join(scopedPaths.Component.Root, state.AuditLogFileName)))
 		e.componentStoreRepo = componentStoreRepo
 		e.componentStateStore = componentStateStore
 	}
 
+	for _, opt := range opts {
+		opt(e)
+	}
 	return e
 }
 
+// SetMaxWalkDepth sets the maximum nesting depth followed when walking
+// overlay directories, in diff as well as in apply's directory copies.
+// Zero or less restores fsops.DefaultMaxDepth.
+func (e *Engine) SetMaxWalkDepth(depth int) {
+	e.maxWalkDepth = depth
+	if limiter, ok := e.fs.(fsops.DepthLimiter); ok {
+		limiter.SetMaxDepth(depth)
+	}
+}
+
+// SetGitPersistence sets the sync repository access used to apply stores
+// pinned to a ref (see ApplyRequest.StorePins).
+func (e *Engine) SetGitPersistence(git remote.GitPersistence) {
+	e.gitPersistence = git
+}
+
+// SetRepoSettings sets the repo settings whose defaults (ignore patterns,
+// default role) are merged into each store's track file. See
+// EffectiveTrackFile.
+func (e *Engine) SetRepoSettings(settings *config.RepoSettings) {
+	e.repoSettings = settings
+}
+
+// SetVersion sets the monodev version recorded as AppliedVersion on each
+// path the engine places.
+func (e *Engine) SetVersion(version string) {
+	e.version = version
+}
+
+// SetSettings sets the environment overrides (see config.EffectiveSettings)
+// used for requests that leave mode or scope unset.
+func (e *Engine) SetSettings(settings *config.Settings) {
+	e.settings = settings
+}
+
+// SetMaxStoreBytes sets the overlay size limit for stores in scope. Zero or
+// less removes the limit. See StoreSize.
+func (e *Engine) SetMaxStoreBytes(scope string, limit int64) {
+	if limit <= 0 {
+		delete(e.maxStoreBytes, scope)
+		return
+	}
+	if e.maxStoreBytes == nil {
+		e.maxStoreBytes = make(map[string]int64)
+	}
+	e.maxStoreBytes[scope] = limit
+}
+
+// defaultMode returns the apply mode for requests that leave it unset:
+// the MONODEV_MODE override if set, otherwise copy.
+func (e *Engine) defaultMode() string {
+	if e.settings != nil && e.settings.Mode != "" {
+		return e.settings.Mode
+	}
+	return "copy"
+}
+
+// modeRequest is a request type that selects an apply mode.
+type modeRequest[R any] interface {
+	*R
+	mode() *string
+}
+
+func (r *ApplyRequest) mode() *string      { return &r.Mode }
+func (r *ApplyAllRequest) mode() *string   { return &r.Mode }
+func (r *StackApplyRequest) mode() *string { return &r.Mode }
+
+// withDefaultMode returns req if it selects a mode, and otherwise a copy of
+// it selecting the engine's default mode, leaving the caller's request as is.
+func withDefaultMode[R any, P modeRequest[R]](e *Engine, req P) P {
+	if *req.mode() != "" {
+		return req
+	}
+	withMode := P(new(R))
+	*withMode = *req
+	*withMode.mode() = e.defaultMode()
+	return withMode
+}
+
+// EnableFSMetrics wraps the engine's filesystem in an fsops.MetricsFS and
+// returns it, so callers can read a Snapshot after running operations.
+// Calling it again returns the existing wrapper. Store repositories keep
+// using the filesystem they were created with.
+func (e *Engine) EnableFSMetrics() *fsops.MetricsFS {
+	if metrics, ok := e.fs.(*fsops.MetricsFS); ok {
+		return metrics
+	}
+	metrics := fsops.NewMetricsFS(e.fs)
+	e.fs = metrics
+	return metrics
+}
+
 // storeRepoForScope returns the StoreRepo for the given scope.
 func (e *Engine) storeRepoForScope(scope string) (stores.StoreRepo, error) {
 	switch scope {
@@ -125,10 +273,16 @@ func (e *Engine) storeRepoForScope(scope string) (stores.StoreRepo, error) {
 // findStore searches both scopes for a store with the given ID.
 // Returns the locations where the store was found.
 func (e *Engine) findStore(storeID string) ([]stores.StoreLocation, error) {
+	// A scope-qualified ID only looks in its own scope
+	storeID, scope, err := splitStoreID(storeID, "")
+	if err != nil {
+		return nil, err
+	}
+
 	var locations []stores.StoreLocation
 
 	// Check global scope
-	if e.globalStoreRepo != nil {
+	if e.globalStoreRepo != nil && scope != stores.ScopeComponent {
 		exists, err := e.globalStoreRepo.Exists(storeID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check global store: %w", err)
@@ -142,7 +296,7 @@ func (e *Engine) findStore(storeID string) ([]stores.StoreLocation, error) {
 	}
 
 	// Check component scope
-	if e.componentStoreRepo != nil {
+	if e.componentStoreRepo != nil && scope != stores.ScopeGlobal {
 		exists, err := e.componentStoreRepo.Exists(storeID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check component store: %w", err)
@@ -158,8 +312,12 @@ func (e *Engine) findStore(storeID string) ([]stores.StoreLocation, error) {
 	return locations, nil
 }
 
-// defaultScope returns "component" if repo context exists, else "global".
+// defaultScope returns the MONODEV_SCOPE override if set, otherwise
+// "component" if repo context exists, else "global".
 func (e *Engine) defaultScope() string {
+	if e.settings != nil && e.settings.Scope != "" {
+		return e.settings.Scope
+	}
 	if e.componentStoreRepo != nil {
 		return stores.ScopeComponent
 	}
@@ -167,7 +325,8 @@ func (e *Engine) defaultScope() string {
 }
 
 // activeStoreRepo resolves the StoreRepo for the workspace's active store.
-// It uses ActiveStoreScope if set, otherwise searches both scopes.
+// It uses ActiveStoreScope if set, otherwise searches both scopes. A recorded
+// scope with no configured repo returns ErrScopeUnavailable.
 func (e *Engine) activeStoreRepo(ws *state.WorkspaceState) (stores.StoreRepo, error) {
 	if ws.ActiveStore == "" {
 		return nil, ErrNoActiveStore
@@ -175,6 +334,9 @@ func (e *Engine) activeStoreRepo(ws *state.WorkspaceState) (stores.StoreRepo, er
 
 	// If scope is explicitly set, use it
 	if ws.ActiveStoreScope != "" {
+		if ws.ActiveStoreScope == stores.ScopeComponent && e.componentStoreRepo == nil {
+			return nil, fmt.Errorf("%w: active store %q is component-scoped but no component repo is configured here; cd into the repository that owns it", ErrScopeUnavailable, ws.ActiveStore)
+		}
 		return e.storeRepoForScope(ws.ActiveStoreScope)
 	}
 
@@ -215,6 +377,11 @@ func (e *Engine) touchStoreMetaIn(repo stores.StoreRepo, storeID string) error {
 // If scope is provided, uses that scope directly. Otherwise searches both scopes.
 // If found in exactly one scope, uses that. If found in both, returns error.
 func (e *Engine) resolveStoreRepo(storeID, scope string) (stores.StoreRepo, string, error) {
+	storeID, scope, err := splitStoreID(storeID, scope)
+	if err != nil {
+		return nil, "", err
+	}
+
 	if scope != "" {
 		repo, err := e.storeRepoForScope(scope)
 		if err != nil {
@@ -234,10 +401,27 @@ func (e *Engine) resolveStoreRepo(storeID, scope string) (stores.StoreRepo, stri
 	case 1:
 		return locations[0].Repo, locations[0].Scope, nil
 	default:
-		return nil, "", fmt.Errorf("store '%s' exists in both global and component scopes; specify --scope to disambiguate", storeID)
+		return nil, "", fmt.Errorf("store '%s' exists in both global and component scopes; specify --scope or use global/%s or component/%s to disambiguate", storeID, storeID, storeID)
 	}
 }
 
+// splitStoreID resolves a possibly scope-qualified store ID (see
+// stores.ParseQualifiedID) to its bare ID and scope. An explicit scope must
+// agree with the qualifier; unqualified IDs keep the given scope.
+func splitStoreID(storeID, scope string) (string, string, error) {
+	qualified, bare, err := stores.ParseQualifiedID(storeID)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	if qualified == "" {
+		return storeID, scope, nil
+	}
+	if scope != "" && scope != qualified {
+		return "", "", fmt.Errorf("%w: store '%s' is qualified with scope %s but scope %s was requested", ErrValidation, storeID, qualified, scope)
+	}
+	return bare, qualified, nil
+}
+
 // workspacesDirs returns workspace directory paths for scanning (both scopes).
 func (e *Engine) workspacesDirs() []string {
 	dirs := []string{e.configPaths.Workspaces}
@@ -256,6 +440,10 @@ func (e *Engine) executeOperation(op planner.Operation) error {
 		return e.executeCreateSymlink(op)
 	case planner.OpCopy:
 		return e.executeCopy(op)
+	case planner.OpBackup:
+		return e.executeBackup(op)
+	case planner.OpMkdir:
+		return e.executeMkdir(op)
 	default:
 		return fmt.Errorf("unknown operation type: %s", op.Type)
 	}
@@ -300,6 +488,15 @@ func (e *Engine) executeCopy(op planner.Operation) error {
 	return nil
 }
 
+// executeMkdir creates an empty directory.
+func (e *Engine) executeMkdir(op planner.Operation) error {
+	if err := e.fs.MkdirAll(op.DestPath, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return nil
+}
+
 // discoverWorkspace returns repo root, fingerprint, and workspace path
 func (e *Engine) DiscoverWorkspace(cwd string) (root, fingerprint, workspacePath string, err error) {
 	root, err = e.gitRepo.Discover(cwd)
@@ -335,3 +532,15 @@ func (e *Engine) LoadOrCreateWorkspaceState(root, repoFingerprint, workspacePath
 	workspaceState.AbsolutePath = filepath.Join(root, workspacePath)
 	return workspaceState, workspaceID, nil
 }
+
+// executeBackup moves an existing path aside so it can be replaced.
+func (e *Engine) executeBackup(op planner.Operation) error {
+	if err := e.fs.Copy(op.SourcePath, op.DestPath); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", op.RelPath, err)
+	}
+	if err := e.fs.RemoveAll(op.SourcePath); err != nil {
+		return fmt.Errorf("failed to remove backed up path: %w", err)
+	}
+
+	return nil
+}
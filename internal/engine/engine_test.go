@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/config"
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/hash"
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+func TestNew_AuditsStateUnderRoot(t *testing.T) {
+	root := t.TempDir()
+	fs := fsops.NewRealFS()
+	paths := config.Paths{Root: root, Stores: filepath.Join(root, "stores"), Workspaces: filepath.Join(root, "workspaces")}
+	stateStore := state.NewFileStateStore(fs, paths.Workspaces)
+
+	New(&trackGitRepo{}, stores.NewFileStoreRepo(fs, paths.Stores), stateStore, fs, hash.NewSHA256Hasher(), &mockClock{}, paths)
+
+	if err := stateStore.SaveWorkspace("ws1", state.NewWorkspaceState("fp1", ".", "copy")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, state.AuditLogFileName)); err != nil {
+		t.Errorf("expected the save to be audited: %v", err)
+	}
+}
+
+func TestWithDefaultMode(t *testing.T) {
+	eng := New(&trackGitRepo{}, nil, newMockStateStore(), fsops.NewRealFS(), hash.NewSHA256Hasher(), &mockClock{}, config.Paths{})
+	eng.SetSettings(&config.Settings{Mode: "symlink"})
+
+	req := &ApplyConfirmedRequest{ApplyRequest: ApplyRequest{StoreID: "s1"}, Fingerprint: "abc"}
+	got := withDefaultMode(eng, req)
+	if got.Mode != "symlink" || got.StoreID != "s1" || got.Fingerprint != "abc" {
+		t.Errorf("withDefaultMode = %+v, want the request with mode symlink", got)
+	}
+	if req.Mode != "" {
+		t.Errorf("caller's request was modified: Mode = %q", req.Mode)
+	}
+
+	explicit := &StackApplyRequest{Mode: "copy"}
+	if withDefaultMode(eng, explicit) != explicit {
+		t.Error("a request selecting a mode should be returned as is")
+	}
+}
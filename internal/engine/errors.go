@@ -23,4 +23,20 @@ var (
 
 	// ErrNoActiveStore indicates no active store is set.
 	ErrNoActiveStore = errors.New("no active store set")
+
+	// ErrScopeUnavailable indicates the active store's recorded scope has no
+	// configured store repo, e.g. a component store used outside its repo.
+	ErrScopeUnavailable = errors.New("store scope unavailable")
+
+	// ErrPlanChanged indicates the rebuilt plan no longer matches the one
+	// the user confirmed.
+	ErrPlanChanged = errors.New("plan changed since it was confirmed")
+
+	// ErrQuotaExceeded indicates a change would grow a store's overlay past
+	// its scope's configured size limit.
+	ErrQuotaExceeded = errors.New("store size quota exceeded")
+
+	// ErrLockMismatch indicates a store about to be applied differs from
+	// the version pinned in the workspace's lockfile.
+	ErrLockMismatch = errors.New("store does not match lockfile")
 )
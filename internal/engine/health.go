@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// ListStoresWith returns all available stores in req.SortBy order, with a
+// health summary attached to each when req.IncludeHealth is set.
+func (e *Engine) ListStoresWith(ctx context.Context, req *ListStoresRequest) ([]stores.ScopedStore, error) {
+	storeList, err := e.ListStoresSorted(ctx, req.SortBy)
+	if err != nil {
+		return nil, err
+	}
+	if !req.IncludeHealth {
+		return storeList, nil
+	}
+
+	for i := range storeList {
+		health, err := e.storeHealth(ctx, storeList[i].ID, storeList[i].Scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check store '%s' (%s): %w", storeList[i].ID, storeList[i].Scope, err)
+		}
+		storeList[i].Health = health
+	}
+	return storeList, nil
+}
+
+// storeHealth audits a store's overlay, compares it with the track file and
+// looks for workspaces that reference the store.
+func (e *Engine) storeHealth(ctx context.Context, storeID, scope string) (*stores.StoreHealth, error) {
+	audit, err := e.AuditStore(ctx, storeID, scope)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := e.findWorkspacesUsingStore(storeID)
+	if err != nil {
+		return nil, err
+	}
+	mismatch, err := e.trackMismatch(storeID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stores.StoreHealth{
+		AuditFindings: len(audit.Findings),
+		Orphaned:      len(usages) == 0,
+		TrackMismatch: mismatch,
+	}, nil
+}
+
+// trackMismatch reports whether the store's overlay holds paths outside its
+// tracked paths, or is missing one of them.
+func (e *Engine) trackMismatch(storeID, scope string) (bool, error) {
+	repo, err := e.storeRepoForScope(scope)
+	if err != nil {
+		return false, err
+	}
+	track, err := repo.LoadTrack(storeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load track file: %w", err)
+	}
+
+	overlayRoot := repo.OverlayRoot(storeID)
+	untracked, err := e.cleanupOrphanedFiles(overlayRoot, track.Tracked, true)
+	if err != nil {
+		return false, err
+	}
+	if len(untracked) > 0 {
+		return true, nil
+	}
+
+	for _, tp := range track.Tracked {
+		exists, err := e.fs.Exists(filepath.Join(overlayRoot, tp.Path))
+		if err != nil {
+			return false, fmt.Errorf("failed to check overlay path %s: %w", tp.Path, err)
+		}
+		if !exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
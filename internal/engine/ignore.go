@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danieljhkim/monodev/internal/fsops"
+)
+
+// ignoreFileName is the name of the per-workspace ignore file.
+const ignoreFileName = ".monodevignore"
+
+// ignoreMatcher matches workspace-relative paths against gitignore-style patterns.
+// Supported syntax: blank lines and "#" comments are skipped, a trailing "/"
+// restricts a pattern to directories, and patterns containing "/" are matched
+// against the full relative path while others match the base name.
+type ignoreMatcher struct {
+	patterns []string
+}
+
+// loadIgnoreMatcher builds a matcher from the given patterns plus any patterns
+// in the .monodevignore file in dir. A missing ignore file is not an error.
+func loadIgnoreMatcher(fs fsops.FS, dir string, patterns []string) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{}
+	for _, p := range patterns {
+		m.add(p)
+	}
+
+	data, err := fs.ReadFile(filepath.Join(dir, ignoreFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		m.add(line)
+	}
+	return m, nil
+}
+
+// add appends a pattern, skipping blanks and comments.
+func (m *ignoreMatcher) add(pattern string) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return
+	}
+	m.patterns = append(m.patterns, pattern)
+}
+
+// Match reports whether relPath (slash- or OS-separated) is ignored.
+func (m *ignoreMatcher) Match(relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(relPath)
+	base := relPath[strings.LastIndex(relPath, "/")+1:]
+
+	for _, p := range m.patterns {
+		dirOnly := strings.HasSuffix(p, "/")
+		p = strings.TrimSuffix(p, "/")
+		if dirOnly && !isDir {
+			continue
+		}
+
+		target := base
+		if strings.Contains(p, "/") {
+			target = relPath
+			p = strings.TrimPrefix(p, "/")
+		}
+		if ok, _ := filepath.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// LockFileName is the name of the lockfile Apply writes into the workspace.
+const LockFileName = "monodev.lock"
+
+// lockSchemaVersion is the current version of the StoreLock format.
+const lockSchemaVersion = 1
+
+// StoreLock pins the content of the stores applied to a workspace, so a
+// teammate can reproduce the same overlay with a locked apply.
+type StoreLock struct {
+	// SchemaVersion is the version of the lockfile format
+	SchemaVersion int `json:"schemaVersion"`
+
+	// Stores lists the applied stores in apply order
+	Stores []LockedStore `json:"stores"`
+}
+
+// LockedStore pins one store's overlay content.
+type LockedStore struct {
+	ID    string `json:"id"`
+	Scope string `json:"scope"`
+
+	// Hash digests every overlay path and checksum; empty for an empty overlay
+	Hash string `json:"hash"`
+}
+
+// lockStores computes the lock entries for storeIDs, read through repo.
+func (e *Engine) lockStores(repo stores.StoreRepo, storeIDs []string, scopes map[string]string) ([]LockedStore, error) {
+	locked := make([]LockedStore, 0, len(storeIDs))
+	for _, storeID := range storeIDs {
+		digest, err := e.overlayDigest(repo.OverlayRoot(storeID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash store '%s': %w", storeID, err)
+		}
+		locked = append(locked, LockedStore{ID: storeID, Scope: scopes[storeID], Hash: digest})
+	}
+	return locked, nil
+}
+
+// writeLock writes the lockfile for locked into dir, replacing any existing
+// entries.
+func (e *Engine) writeLock(dir string, locked []LockedStore) error {
+	data, err := json.MarshalIndent(StoreLock{SchemaVersion: lockSchemaVersion, Stores: locked}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %w", err)
+	}
+	if err := e.fs.AtomicWrite(filepath.Join(dir, LockFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+// upsertLock merges locked into the lockfile in dir, replacing the entries
+// of stores already pinned and appending the rest, so locking one store
+// keeps the pins of every other store.
+func (e *Engine) upsertLock(dir string, locked []LockedStore) error {
+	lock, err := e.readLock(dir)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		lock = &StoreLock{}
+	}
+
+	merged := lock.Stores
+	for _, s := range locked {
+		i := slices.IndexFunc(merged, func(pinned LockedStore) bool { return pinned.ID == s.ID })
+		if i >= 0 {
+			merged[i] = s
+		} else {
+			merged = append(merged, s)
+		}
+	}
+	return e.writeLock(dir, merged)
+}
+
+// readLock reads the lockfile in dir, returning ErrNotFound if there is none.
+func (e *Engine) readLock(dir string) (*StoreLock, error) {
+	path := filepath.Join(dir, LockFileName)
+	data, err := e.fs.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: no %s in %s", ErrNotFound, LockFileName, dir)
+		}
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	var lock StoreLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("%w: invalid lockfile %s: %v", ErrValidation, path, err)
+	}
+	if lock.SchemaVersion > lockSchemaVersion {
+		return nil, fmt.Errorf("%w: unsupported lockfile schema version %d", ErrValidation, lock.SchemaVersion)
+	}
+	return &lock, nil
+}
+
+// verifyLock checks every entry of current against the lockfile in dir,
+// returning ErrLockMismatch for a store missing from the lock or whose
+// scope or content differs.
+func (e *Engine) verifyLock(dir string, current []LockedStore) error {
+	lock, err := e.readLock(dir)
+	if err != nil {
+		return err
+	}
+
+	pinned := make(map[string]LockedStore, len(lock.Stores))
+	for _, s := range lock.Stores {
+		pinned[s.ID] = s
+	}
+	for _, s := range current {
+		want, ok := pinned[s.ID]
+		switch {
+		case !ok:
+			return fmt.Errorf("%w: store '%s' is not in %s", ErrLockMismatch, s.ID, LockFileName)
+		case want.Scope != s.Scope:
+			return fmt.Errorf("%w: store '%s' is locked in scope %s but resolves to %s", ErrLockMismatch, s.ID, want.Scope, s.Scope)
+		case want.Hash != s.Hash:
+			return fmt.Errorf("%w: store '%s' content differs from %s", ErrLockMismatch, s.ID, LockFileName)
+		}
+	}
+	return nil
+}
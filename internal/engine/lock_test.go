@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+func TestApply_LockedDetectsStoreChange(t *testing.T) {
+	eng, repoDir := setupStackAddEngine(t, []string{"base"}, nil)
+	ctx := context.Background()
+
+	if _, err := eng.Apply(ctx, &ApplyRequest{
+		CWD:       repoDir,
+		StoreID:   "base",
+		Mode:      "copy",
+		WriteLock: true,
+	}); err != nil {
+		t.Fatalf("Apply with WriteLock failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, LockFileName)); err != nil {
+		t.Fatalf("expected %s to be written: %v", LockFileName, err)
+	}
+
+	// An unchanged store passes verification
+	if _, err := eng.Apply(ctx, &ApplyRequest{
+		CWD:     repoDir,
+		StoreID: "base",
+		Mode:    "copy",
+		Locked:  true,
+	}); err != nil {
+		t.Fatalf("Apply with Locked failed on an unchanged store: %v", err)
+	}
+
+	storeRepo := stores.NewFileStoreRepo(fsops.NewRealFS(), filepath.Join(filepath.Dir(repoDir), "stores"))
+	overlayFile := filepath.Join(storeRepo.OverlayRoot("base"), "shared.txt")
+	if err := os.WriteFile(overlayFile, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := eng.Apply(ctx, &ApplyRequest{
+		CWD:     repoDir,
+		StoreID: "base",
+		Mode:    "copy",
+		Force:   true,
+		Locked:  true,
+	})
+	if !errors.Is(err, ErrLockMismatch) {
+		t.Fatalf("expected ErrLockMismatch, got %v", err)
+	}
+
+	// Nothing was applied from the changed store
+	data, err := os.ReadFile(filepath.Join(repoDir, "shared.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "base" {
+		t.Errorf("shared.txt = %q, want the locked content %q", data, "base")
+	}
+}
+
+func TestApply_LockedWithoutLockfile(t *testing.T) {
+	eng, repoDir := setupStackAddEngine(t, []string{"base"}, nil)
+
+	_, err := eng.Apply(context.Background(), &ApplyRequest{
+		CWD:     repoDir,
+		StoreID: "base",
+		Mode:    "copy",
+		Locked:  true,
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestApply_WriteLockKeepsOtherStores(t *testing.T) {
+	eng, repoDir := setupStackAddEngine(t, []string{"base", "team", "extra"}, []string{"base", "team"})
+	ctx := context.Background()
+
+	if _, err := eng.ApplyAll(ctx, &ApplyAllRequest{CWD: repoDir, Mode: "copy", WriteLock: true}); err != nil {
+		t.Fatalf("ApplyAll with WriteLock failed: %v", err)
+	}
+	if _, err := eng.Apply(ctx, &ApplyRequest{
+		CWD:       repoDir,
+		StoreID:   "extra",
+		Mode:      "copy",
+		Force:     true,
+		WriteLock: true,
+	}); err != nil {
+		t.Fatalf("Apply with WriteLock failed: %v", err)
+	}
+
+	lock, err := eng.readLock(repoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, s := range lock.Stores {
+		ids = append(ids, s.ID)
+	}
+	if want := []string{"base", "team", "extra"}; !slices.Equal(ids, want) {
+		t.Fatalf("locked stores = %v, want %v", ids, want)
+	}
+
+	// The stack plus the newly active store verifies against the merged lock
+	if _, err := eng.ApplyAll(ctx, &ApplyAllRequest{CWD: repoDir, Mode: "copy", Force: true, Locked: true}); err != nil {
+		t.Fatalf("locked ApplyAll failed: %v", err)
+	}
+}
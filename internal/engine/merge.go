@@ -0,0 +1,306 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// FindDuplicateStores groups stores, across both scopes, whose overlays have
+// identical content. Each group lists scope-qualified store IDs (such as
+// "global/foo") in sorted order, and groups are sorted by their first ID.
+// Stores with an empty or missing overlay are never reported. Every group,
+// including one store ID found in both scopes, can be passed to MergeStores.
+func (e *Engine) FindDuplicateStores(ctx context.Context) ([][]string, error) {
+	storeList, err := e.ListStores(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byDigest := make(map[string][]string)
+	for _, s := range storeList {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		repo, err := e.storeRepoForScope(s.Scope)
+		if err != nil {
+			return nil, err
+		}
+		digest, err := e.overlayDigest(repo.OverlayRoot(s.ID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash store '%s' (%s): %w", s.ID, s.Scope, err)
+		}
+		if digest == "" {
+			continue
+		}
+		byDigest[digest] = append(byDigest[digest], s.Scope+"/"+s.ID)
+	}
+
+	groups := [][]string{}
+	for _, ids := range byDigest {
+		if len(ids) < 2 {
+			continue
+		}
+		sort.Strings(ids)
+		groups = append(groups, ids)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups, nil
+}
+
+// overlayDigest returns a hash of every file path and checksum in an
+// overlay, or "" if the overlay is missing or holds no files.
+func (e *Engine) overlayDigest(overlayRoot string) (string, error) {
+	exists, err := e.fs.Exists(overlayRoot)
+	if err != nil || !exists {
+		return "", err
+	}
+	checksums, err := e.treeChecksums(overlayRoot)
+	if err != nil {
+		return "", err
+	}
+	if len(checksums) == 0 {
+		return "", nil
+	}
+
+	paths := make([]string, 0, len(checksums))
+	for rel := range checksums {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	h := sha256.New()
+	for _, rel := range paths {
+		fmt.Fprintf(h, "%s\x00%s\n", rel, checksums[rel])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// MergeStores folds mergeID into keepID: every workspace that references
+// mergeID in its stack, active store, applied stores or path ownership is
+// rewritten to reference keepID, and mergeID is deleted. Both IDs may be
+// scope-qualified, so a store can be merged into one of the same ID in the
+// other scope; only references that resolve to mergeID's scope are
+// rewritten. The overlays must have identical content, and mergeID must not
+// be applied in symlink mode anywhere, since those links point into the
+// overlay being deleted.
+func (e *Engine) MergeStores(ctx context.Context, keepID, mergeID string) (*MergeStoresResult, error) {
+	keepID, keepScope, err := e.resolveExistingStore(keepID)
+	if err != nil {
+		return nil, err
+	}
+	mergeID, mergeScope, err := e.resolveExistingStore(mergeID)
+	if err != nil {
+		return nil, err
+	}
+	if keepID == mergeID && keepScope == mergeScope {
+		return nil, fmt.Errorf("%w: cannot merge store '%s' with itself", ErrValidation, keepID)
+	}
+
+	keepRepo, err := e.storeRepoForScope(keepScope)
+	if err != nil {
+		return nil, err
+	}
+	mergeRepo, err := e.storeRepoForScope(mergeScope)
+	if err != nil {
+		return nil, err
+	}
+	same, err := e.sameTree(keepRepo.OverlayRoot(keepID), mergeRepo.OverlayRoot(mergeID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare store overlays: %w", err)
+	}
+	if !same {
+		return nil, fmt.Errorf("%w: stores '%s' and '%s' have different overlays", ErrConflict, keepID, mergeID)
+	}
+
+	rewrite, err := e.newStoreRewrite(mergeID, mergeScope, keepID, keepScope)
+	if err != nil {
+		return nil, err
+	}
+
+	// Usages are found by bare ID, so some may only reference the other scope
+	usages, err := e.findWorkspacesUsingStore(mergeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find workspaces using store: %w", err)
+	}
+	workspaces := make(map[string]*state.WorkspaceState, len(usages))
+	for _, usage := range usages {
+		ws, err := e.stateStore.LoadWorkspace(usage.WorkspaceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load workspace %s: %w", usage.WorkspaceID, err)
+		}
+		for path, ownership := range ws.Paths {
+			if rewrite.matchesScoped(ownership.Store, ownership.StoreScope) && ownership.Type == "symlink" {
+				return nil, fmt.Errorf("%w: store '%s' is linked at %s in workspace %s; unapply it first",
+					ErrConflict, mergeID, path, usage.WorkspaceID)
+			}
+		}
+		workspaces[usage.WorkspaceID] = ws
+	}
+
+	result := &MergeStoresResult{KeepID: keepID, MergedID: mergeID, Workspaces: []string{}}
+	for _, usage := range usages {
+		ws := workspaces[usage.WorkspaceID]
+		if !rewrite.apply(ws) {
+			continue
+		}
+		if err := e.stateStore.SaveWorkspace(usage.WorkspaceID, ws); err != nil {
+			return nil, fmt.Errorf("failed to save workspace %s: %w", usage.WorkspaceID, err)
+		}
+		result.Workspaces = append(result.Workspaces, usage.WorkspaceID)
+	}
+	sort.Strings(result.Workspaces)
+
+	if err := mergeRepo.Delete(mergeID); err != nil {
+		return nil, fmt.Errorf("failed to delete store: %w", err)
+	}
+	return result, nil
+}
+
+// resolveExistingStore resolves a possibly scope-qualified store ID to its
+// bare ID and scope, failing if the store does not exist there.
+func (e *Engine) resolveExistingStore(storeID string) (string, string, error) {
+	bare, scope, err := splitStoreID(storeID, "")
+	if err != nil {
+		return "", "", err
+	}
+	repo, scope, err := e.resolveStoreRepo(bare, scope)
+	if err != nil {
+		return "", "", err
+	}
+	exists, err := repo.Exists(bare)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check store: %w", err)
+	}
+	if !exists {
+		return "", "", fmt.Errorf("%w: store '%s' not found", ErrNotFound, bare)
+	}
+	return bare, scope, nil
+}
+
+// storeRewrite replaces workspace references to one store, identified by
+// ID and scope, with references to another.
+type storeRewrite struct {
+	fromID, fromScope string
+	toID, toScope     string
+
+	// bareScope is the scope an unqualified reference to fromID resolves to
+	bareScope string
+
+	// toRef is how stack entries reference the kept store once fromID is gone
+	toRef string
+}
+
+// newStoreRewrite prepares rewriting references to fromID in fromScope into
+// references to toID in toScope, resolving unqualified IDs the way stack
+// apply does: component scope first.
+func (e *Engine) newStoreRewrite(fromID, fromScope, toID, toScope string) (*storeRewrite, error) {
+	fromLocations, err := e.findStore(fromID)
+	if err != nil {
+		return nil, err
+	}
+	bareScope := ""
+	for _, loc := range fromLocations {
+		if bareScope == "" || loc.Scope == stores.ScopeComponent {
+			bareScope = loc.Scope
+		}
+	}
+
+	// A bare toID would resolve to a component store of that ID, unless it is
+	// the one being merged away
+	toLocations, err := e.findStore(toID)
+	if err != nil {
+		return nil, err
+	}
+	toRef := toID
+	for _, loc := range toLocations {
+		shadowed := loc.Scope == stores.ScopeComponent && toScope != stores.ScopeComponent
+		if shadowed && !(toID == fromID && fromScope == stores.ScopeComponent) {
+			toRef = toScope + "/" + toID
+		}
+	}
+
+	return &storeRewrite{
+		fromID:    fromID,
+		fromScope: fromScope,
+		toID:      toID,
+		toScope:   toScope,
+		bareScope: bareScope,
+		toRef:     toRef,
+	}, nil
+}
+
+// matchesScoped reports whether a reference to id recorded with scope
+// refers to the merged store. An empty scope is resolved like a bare ID.
+func (r *storeRewrite) matchesScoped(id, scope string) bool {
+	if id != r.fromID {
+		return false
+	}
+	if scope == "" {
+		scope = r.bareScope
+	}
+	return scope == r.fromScope
+}
+
+// matchesRef reports whether a possibly scope-qualified stack entry refers
+// to the merged store.
+func (r *storeRewrite) matchesRef(ref string) bool {
+	scope, bare, err := stores.ParseQualifiedID(ref)
+	if err != nil {
+		return false
+	}
+	return r.matchesScoped(bare, scope)
+}
+
+// apply replaces every reference to the merged store in ws with the kept
+// store, dropping stack and applied-store entries that would become
+// duplicates. It reports whether ws changed.
+func (r *storeRewrite) apply(ws *state.WorkspaceState) bool {
+	changed := false
+	if r.matchesScoped(ws.ActiveStore, ws.ActiveStoreScope) {
+		ws.ActiveStore = r.toID
+		ws.ActiveStoreScope = r.toScope
+		changed = true
+	}
+
+	stack := make([]string, 0, len(ws.Stack))
+	for _, ref := range ws.Stack {
+		if r.matchesRef(ref) {
+			ref = r.toRef
+			changed = true
+		}
+		if !slices.Contains(stack, ref) {
+			stack = append(stack, ref)
+		}
+	}
+	ws.Stack = stack
+
+	// Applied stores are recorded by bare ID only
+	if r.fromID != r.toID {
+		for _, applied := range ws.AppliedStores {
+			if r.matchesScoped(applied.Store, "") {
+				ws.RemoveAppliedStore(r.fromID)
+				if !slices.ContainsFunc(ws.AppliedStores, func(a state.AppliedStore) bool { return a.Store == r.toID }) {
+					ws.AddAppliedStore(r.toID, applied.Type)
+				}
+				changed = true
+				break
+			}
+		}
+	}
+
+	for path, ownership := range ws.Paths {
+		if r.matchesScoped(ownership.Store, ownership.StoreScope) {
+			ownership.Store = r.toID
+			ownership.StoreScope = r.toScope
+			ws.Paths[path] = ownership
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/danieljhkim/monodev/internal/config"
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/hash"
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// setupMergeEngine creates global stores whose overlays hold a Makefile with
+// the given content, and a workspace ws1 that uses store "b".
+func setupMergeEngine(t *testing.T, contents map[string]string) (*Engine, stores.StoreRepo, *mockStateStore) {
+	t.Helper()
+	eng, _ := setupDiffEngine(t, nil, nil)
+	for id, content := range contents {
+		addTrackedStore(t, eng, id, map[string]string{"Makefile": content})
+	}
+
+	eng.configPaths.Workspaces = filepath.Join(t.TempDir(), "workspaces")
+	writeTestFiles(t, eng.configPaths.Workspaces, map[string]string{"ws1.json": "{}"})
+	stateStore := eng.stateStore.(*mockStateStore)
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.Stack = []string{"a", "b"}
+	ws.ActiveStore = "b"
+	ws.ActiveStoreScope = stores.ScopeGlobal
+	ws.AddAppliedStore("b", "copy")
+	ws.Paths["Makefile"] = state.PathOwnership{Store: "b", StoreScope: stores.ScopeGlobal, Type: "copy"}
+	stateStore.workspaces["ws1"] = ws
+	return eng, eng.storeRepo, stateStore
+}
+
+func TestFindDuplicateStores(t *testing.T) {
+	eng, _, _ := setupMergeEngine(t, map[string]string{"a": "all:", "b": "all:", "c": "test:"})
+
+	groups, err := eng.FindDuplicateStores(context.Background())
+	if err != nil {
+		t.Fatalf("FindDuplicateStores failed: %v", err)
+	}
+	if len(groups) != 1 || !slices.Equal(groups[0], []string{"global/a", "global/b"}) {
+		t.Errorf("groups = %v, want [[global/a global/b]]", groups)
+	}
+}
+
+func TestMergeStores_RewritesWorkspaceReferences(t *testing.T) {
+	eng, storeRepo, stateStore := setupMergeEngine(t, map[string]string{"a": "all:", "b": "all:", "c": "test:"})
+
+	result, err := eng.MergeStores(context.Background(), "a", "global/b")
+	if err != nil {
+		t.Fatalf("MergeStores failed: %v", err)
+	}
+	if !slices.Equal(result.Workspaces, []string{"ws1"}) {
+		t.Errorf("Workspaces = %v, want [ws1]", result.Workspaces)
+	}
+
+	ws := stateStore.workspaces["ws1"]
+	if !slices.Equal(ws.Stack, []string{"a"}) {
+		t.Errorf("Stack = %v, want [a]", ws.Stack)
+	}
+	if ws.ActiveStore != "a" {
+		t.Errorf("ActiveStore = %q, want a", ws.ActiveStore)
+	}
+	if len(ws.AppliedStores) != 1 || ws.AppliedStores[0].Store != "a" {
+		t.Errorf("AppliedStores = %+v, want only a", ws.AppliedStores)
+	}
+	if owner := ws.Paths["Makefile"].Store; owner != "a" {
+		t.Errorf("Makefile owner = %q, want a", owner)
+	}
+	if exists, _ := storeRepo.Exists("b"); exists {
+		t.Error("expected merged store b to be deleted")
+	}
+
+	// Stores with different content are never merged
+	if _, err := eng.MergeStores(context.Background(), "a", "c"); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for different overlays, got %v", err)
+	}
+	if exists, _ := storeRepo.Exists("c"); !exists {
+		t.Error("store c should not be deleted")
+	}
+}
+
+func TestMergeStores_AcrossScopesWithSameID(t *testing.T) {
+	tmpDir := t.TempDir()
+	fs := fsops.NewRealFS()
+	repos := map[string]stores.StoreRepo{}
+	for _, scope := range []string{stores.ScopeGlobal, stores.ScopeComponent} {
+		repo := stores.NewFileStoreRepo(fs, filepath.Join(tmpDir, scope))
+		if err := repo.Create("foo", stores.NewStoreMeta("foo", scope, time.Now())); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(repo.OverlayRoot("foo"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo.OverlayRoot("foo"), "Makefile"), []byte("all:"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		repos[scope] = repo
+	}
+
+	workspacesDir := filepath.Join(tmpDir, "workspaces")
+	if err := os.MkdirAll(workspacesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspacesDir, "ws1.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stateStore := newMockStateStore()
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.Stack = []string{"global/foo"}
+	ws.ActiveStore = "foo"
+	ws.ActiveStoreScope = stores.ScopeGlobal
+	ws.Paths["global.txt"] = state.PathOwnership{Store: "foo", StoreScope: stores.ScopeGlobal, Type: "copy"}
+	ws.Paths["component.txt"] = state.PathOwnership{Store: "foo", StoreScope: stores.ScopeComponent, Type: "copy"}
+	ws.Paths["legacy.txt"] = state.PathOwnership{Store: "foo", Type: "copy"}
+	stateStore.workspaces["ws1"] = ws
+
+	eng := New(&trackGitRepo{}, repos[stores.ScopeGlobal], stateStore, fs, hash.NewSHA256Hasher(), &mockClock{}, config.Paths{Workspaces: workspacesDir})
+	eng.globalStoreRepo = repos[stores.ScopeGlobal]
+	eng.componentStoreRepo = repos[stores.ScopeComponent]
+
+	groups, err := eng.FindDuplicateStores(context.Background())
+	if err != nil {
+		t.Fatalf("FindDuplicateStores failed: %v", err)
+	}
+	if len(groups) != 1 || !slices.Equal(groups[0], []string{"component/foo", "global/foo"}) {
+		t.Fatalf("groups = %v, want [[component/foo global/foo]]", groups)
+	}
+
+	if _, err := eng.MergeStores(context.Background(), "component/foo", "global/foo"); err != nil {
+		t.Fatalf("MergeStores failed: %v", err)
+	}
+
+	got := stateStore.workspaces["ws1"]
+	if !slices.Equal(got.Stack, []string{"foo"}) {
+		t.Errorf("Stack = %v, want [foo]", got.Stack)
+	}
+	if got.ActiveStore != "foo" || got.ActiveStoreScope != stores.ScopeComponent {
+		t.Errorf("active store = %s (%s), want foo (component)", got.ActiveStore, got.ActiveStoreScope)
+	}
+	for path, want := range map[string]string{
+		"global.txt":    stores.ScopeComponent,
+		"component.txt": stores.ScopeComponent,
+		"legacy.txt":    "", // a bare reference already resolved to component scope
+	} {
+		if scope := got.Paths[path].StoreScope; scope != want {
+			t.Errorf("%s scope = %q, want %q", path, scope, want)
+		}
+	}
+	if exists, _ := repos[stores.ScopeGlobal].Exists("foo"); exists {
+		t.Error("expected global/foo to be deleted")
+	}
+	if exists, _ := repos[stores.ScopeComponent].Exists("foo"); !exists {
+		t.Error("component/foo should be kept")
+	}
+
+	// A store cannot be merged with itself
+	if _, err := eng.MergeStores(context.Background(), "component/foo", "component/foo"); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected ErrValidation, got %v", err)
+	}
+}
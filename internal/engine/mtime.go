@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// mtimeStamper sets the modification times of files placed by copy
+// operations: the overlay source's time for tracked paths with PreserveMtime,
+// otherwise the current time. Track files are loaded once per store.
+type mtimeStamper struct {
+	e      *Engine
+	repo   stores.StoreRepo
+	tracks map[string]*stores.TrackFile
+}
+
+// newMtimeStamper creates a stamper that reads PreserveMtime flags from repo.
+func (e *Engine) newMtimeStamper(repo stores.StoreRepo) *mtimeStamper {
+	return &mtimeStamper{e: e, repo: repo, tracks: make(map[string]*stores.TrackFile)}
+}
+
+// preserve reports whether the tracked path covering the operation's source
+// asks for the source mtime to be kept.
+func (m *mtimeStamper) preserve(op planner.Operation) bool {
+	track, ok := m.tracks[op.Store]
+	if !ok {
+		track, _ = m.repo.LoadTrack(op.Store)
+		m.tracks[op.Store] = track
+	}
+	if track == nil {
+		return false
+	}
+	storeRel, err := filepath.Rel(m.repo.OverlayRoot(op.Store), op.SourcePath)
+	if err != nil {
+		return false
+	}
+	for _, tp := range track.Tracked {
+		path := filepath.Clean(tp.Path)
+		if storeRel == path || strings.HasPrefix(storeRel, path+string(filepath.Separator)) {
+			return tp.PreserveMtime
+		}
+	}
+	return false
+}
+
+// apply stamps the regular files written by a copy operation. Run it after
+// anything else that rewrites them. Other operations are left alone.
+func (m *mtimeStamper) apply(op planner.Operation) error {
+	if op.Type != planner.OpCopy {
+		return nil
+	}
+	preserve := m.preserve(op)
+	now := m.e.clock.Now()
+
+	return m.e.fs.WalkDir(op.DestPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		mtime := now
+		if preserve {
+			rel, err := filepath.Rel(op.DestPath, path)
+			if err != nil {
+				return err
+			}
+			info, err := m.e.fs.Lstat(filepath.Join(op.SourcePath, rel))
+			if err != nil {
+				return fmt.Errorf("failed to stat source of %s: %w", path, err)
+			}
+			mtime = info.ModTime()
+		}
+		if err := m.e.fs.Chtimes(path, mtime, mtime); err != nil {
+			return fmt.Errorf("failed to set times of %s: %w", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// eolNormalizer rewrites the line endings of files placed by copy operations
+// according to each store's Normalize rules. Track files are loaded once per
+// store.
+type eolNormalizer struct {
+	e      *Engine
+	repo   stores.StoreRepo
+	tracks map[string]*stores.TrackFile
+}
+
+// newEOLNormalizer creates a normalizer that reads rules from repo.
+func (e *Engine) newEOLNormalizer(repo stores.StoreRepo) *eolNormalizer {
+	return &eolNormalizer{e: e, repo: repo, tracks: make(map[string]*stores.TrackFile)}
+}
+
+// track returns the store's track file, or nil if it cannot be loaded.
+func (n *eolNormalizer) track(storeID string) *stores.TrackFile {
+	track, ok := n.tracks[storeID]
+	if !ok {
+		track, _ = n.repo.LoadTrack(storeID)
+		n.tracks[storeID] = track
+	}
+	return track
+}
+
+// apply normalizes the text files written by a copy operation. Symlinks and
+// other operations are left alone.
+func (n *eolNormalizer) apply(op planner.Operation) error {
+	if op.Type != planner.OpCopy {
+		return nil
+	}
+	track := n.track(op.Store)
+	if track == nil || len(track.Normalize) == 0 {
+		return nil
+	}
+	overlayRoot := n.repo.OverlayRoot(op.Store)
+
+	return n.e.fs.WalkDir(op.DestPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		// Rules match store-relative paths, so map the copy back to its source
+		rel, err := filepath.Rel(op.DestPath, path)
+		if err != nil {
+			return err
+		}
+		storeRel, err := filepath.Rel(overlayRoot, filepath.Join(op.SourcePath, rel))
+		if err != nil {
+			return err
+		}
+		eol := track.EOLFor(storeRel)
+		if eol == "" {
+			return nil
+		}
+
+		data, err := n.e.fs.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		normalized := stores.NormalizeEOL(data, eol)
+		if bytes.Equal(normalized, data) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := n.e.fs.AtomicWrite(path, normalized, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to normalize line endings of %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// ignoreEOLOnly marks a modified file unchanged when the workspace and store
+// contents differ only in line endings and a Normalize rule covers the path.
+func (e *Engine) ignoreEOLOnly(track *stores.TrackFile, info *DiffFileInfo, workspacePath, storePath string) {
+	if info.Status != "modified" || info.IsDir || track.EOLFor(info.Path) == "" {
+		return
+	}
+	workspaceData, err := e.fs.ReadFile(workspacePath)
+	if err != nil {
+		return
+	}
+	storeData, err := e.fs.ReadFile(storePath)
+	if err != nil {
+		return
+	}
+	if isBinary(workspaceData) || isBinary(storeData) {
+		return
+	}
+	if bytes.Equal(stores.NormalizeEOL(workspaceData, stores.EOLLF), stores.NormalizeEOL(storeData, stores.EOLLF)) {
+		info.Status = "unchanged"
+		info.UnifiedDiff, info.Additions, info.Deletions = "", 0, 0
+	}
+}
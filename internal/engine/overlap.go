@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+)
+
+// workspaceOverlaps reports planned paths that another workspace of the same
+// repo already manages. Only workspaces nested in, or containing, the one
+// being applied can overlap. Paths are compared repo-relative, and a managed
+// directory overlaps everything beneath it.
+func (e *Engine) workspaceOverlaps(ctx context.Context, ac *applyContext, plan *planner.ApplyPlan) ([]string, error) {
+	if len(plan.Operations) == 0 {
+		return nil, nil
+	}
+	list, err := e.ListWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var overlaps []string
+	for _, info := range list.Workspaces {
+		if info.WorkspaceID == ac.workspaceID || info.Repo != ac.repoFingerprint || info.AppliedPathCount == 0 {
+			continue
+		}
+		if !pathsNested(info.WorkspacePath, ac.workspacePath) {
+			continue
+		}
+		other, err := e.stateStore.LoadWorkspace(info.WorkspaceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load workspace %s: %w", info.WorkspaceID, err)
+		}
+		for _, op := range plan.Operations {
+			planned := repoRelPath(ac.workspacePath, op.RelPath)
+			for managed := range other.Paths {
+				if pathsNested(planned, repoRelPath(other.WorkspacePath, managed)) {
+					overlaps = append(overlaps, fmt.Sprintf("path %s overlaps %s managed by workspace %s",
+						op.RelPath, managed, other.WorkspacePath))
+					break
+				}
+			}
+		}
+	}
+	return overlaps, nil
+}
+
+// repoRelPath joins a workspace path and a workspace-relative path into a
+// repo-relative slash path.
+func repoRelPath(workspacePath, relPath string) string {
+	return path.Join(filepath.ToSlash(workspacePath), filepath.ToSlash(relPath))
+}
+
+// pathsNested reports whether the repo-relative paths a and b are equal or
+// one contains the other. The repo root "." contains every path.
+func pathsNested(a, b string) bool {
+	a, b = path.Clean(filepath.ToSlash(a)), path.Clean(filepath.ToSlash(b))
+	if a == "." || b == "." || a == b {
+		return true
+	}
+	return strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
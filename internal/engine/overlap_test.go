@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieljhkim/monodev/internal/config"
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/gitx"
+	"github.com/danieljhkim/monodev/internal/hash"
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+func TestApply_ReportsOverlapWithEnclosingWorkspace(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	subDir := filepath.Join(repoDir, "sub")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := fsops.NewRealFS()
+	storeRepo := stores.NewFileStoreRepo(fs, filepath.Join(tmpDir, "stores"))
+	if err := storeRepo.Create("s1", stores.NewStoreMeta("s1", stores.ScopeGlobal, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "config.json", Kind: "file"}, {Path: "own.txt", Kind: "file"}}
+	if err := storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"config.json", "own.txt"} {
+		if err := os.WriteFile(filepath.Join(storeRepo.OverlayRoot("s1"), name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The repo-root workspace already manages sub/config.json
+	workspacesDir := filepath.Join(tmpDir, "workspaces")
+	stateStore := state.NewFileStateStore(fs, workspacesDir)
+	parent := state.NewWorkspaceState("fp1", ".", "copy")
+	parent.Paths["sub/config.json"] = state.PathOwnership{Store: "other", Type: "copy"}
+	if err := stateStore.SaveWorkspace(state.ComputeWorkspaceID("fp1", "."), parent); err != nil {
+		t.Fatal(err)
+	}
+
+	eng := New(gitx.NewFakeGitRepo(repoDir, "fp1"), storeRepo, stateStore, fs,
+		hash.NewSHA256Hasher(), &mockClock{}, config.Paths{Workspaces: workspacesDir})
+
+	result, err := eng.Apply(context.Background(), &ApplyRequest{CWD: subDir, StoreID: "s1", Mode: "copy", DryRun: true})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(result.Plan.Warnings) != 1 || !strings.Contains(result.Plan.Warnings[0], "config.json overlaps sub/config.json") {
+		t.Errorf("Warnings = %v, want one overlap on config.json", result.Plan.Warnings)
+	}
+
+	_, err = eng.Apply(context.Background(), &ApplyRequest{CWD: subDir, StoreID: "s1", Mode: "copy", RefuseOverlap: true})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("Apply error = %v, want ErrConflict", err)
+	}
+	if _, err := os.Stat(filepath.Join(subDir, "own.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected no changes after refused apply, got err=%v", err)
+	}
+}
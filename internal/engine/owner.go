@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/state"
+)
+
+// WhoOwns reports which store owns a workspace path according to recorded
+// state, and which store would own it if the stack and the active store were
+// reapplied now. A difference between the two is a pending precedence change.
+func (e *Engine) WhoOwns(ctx context.Context, req *WhoOwnsRequest) (*OwnershipResult, error) {
+	relPath := filepath.Clean(req.RelPath)
+	if err := e.fs.ValidateRelPath(relPath); err != nil {
+		return nil, fmt.Errorf("%w: invalid path %q: %v", ErrValidation, req.RelPath, err)
+	}
+
+	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover workspace: %w", err)
+	}
+	workspaceState, _, err := e.LoadOrCreateWorkspaceState(root, repoFingerprint, workspacePath, "copy")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load or create workspace state: %w", err)
+	}
+
+	result := &OwnershipResult{
+		RelPath:    relPath,
+		Candidates: []string{},
+	}
+	if ownership, ok := workspaceState.Owner(relPath); ok {
+		result.Recorded = &ownership
+	}
+
+	orderedStores, repo, _, err := e.applyAllStores(workspaceState)
+	if err != nil {
+		return nil, err
+	}
+	if len(orderedStores) == 0 {
+		return result, nil
+	}
+
+	// Plan against empty ownership with force, so every store that tracks
+	// the path contributes a create operation regardless of what is on disk
+	plan, err := planner.BuildApplyPlanWith(
+		state.NewWorkspaceState(repoFingerprint, workspacePath, "copy"),
+		orderedStores,
+		"copy",
+		filepath.Join(root, workspacePath),
+		repo,
+		e.fs,
+		planner.PlanOptions{Vars: req.Vars, Force: true, Hasher: e.hasher},
+	)
+	if err != nil {
+		if errors.Is(err, planner.ErrProtectedPath) || errors.Is(err, planner.ErrMissingRequiredFile) ||
+			errors.Is(err, planner.ErrInvalidCondition) {
+			return nil, fmt.Errorf("%w: %w", ErrValidation, err)
+		}
+		return nil, fmt.Errorf("failed to build apply plan: %w", err)
+	}
+
+	for _, op := range plan.Operations {
+		if op.Type == planner.OpRemove || op.Type == planner.OpBackup {
+			continue
+		}
+		if op.RelPath != relPath && !strings.HasPrefix(relPath, op.RelPath+string(filepath.Separator)) {
+			continue
+		}
+		if !slices.Contains(result.Candidates, op.Store) {
+			result.Candidates = append(result.Candidates, op.Store)
+		}
+		// Later operations take precedence
+		result.Winner = op.Store
+	}
+
+	recordedStore := ""
+	if result.Recorded != nil {
+		recordedStore = result.Recorded.Store
+	}
+	result.Pending = result.Winner != recordedStore
+	return result, nil
+}
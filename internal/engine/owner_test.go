@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// addTrackedStore creates a second store tracking the given files.
+func addTrackedStore(t *testing.T, eng *Engine, storeID string, files map[string]string) {
+	t.Helper()
+	if err := eng.storeRepo.Create(storeID, stores.NewStoreMeta(storeID, stores.ScopeGlobal, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, eng.storeRepo.OverlayRoot(storeID), files)
+	track := stores.NewTrackFile()
+	for rel := range files {
+		track.Tracked = append(track.Tracked, stores.TrackedPath{Path: rel, Kind: "file"})
+	}
+	if err := eng.storeRepo.SaveTrack(storeID, track); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWhoOwns_PendingOverride(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"shared.txt": "one\n", "only1.txt": "x\n"}, nil)
+	addTrackedStore(t, eng, "s2", map[string]string{"shared.txt": "two\n"})
+
+	// s1 applied the file; s2 was activated later but not yet applied
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.Stack = []string{"s1"}
+	ws.ActiveStore = "s2"
+	ws.Applied = true
+	ws.Paths["shared.txt"] = state.PathOwnership{Store: "s1", Type: "copy"}
+	ws.Paths["only1.txt"] = state.PathOwnership{Store: "s1", Type: "copy"}
+	if err := eng.stateStore.SaveWorkspace(state.ComputeWorkspaceID("fp1", "."), ws); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := eng.WhoOwns(context.Background(), &WhoOwnsRequest{CWD: repoDir, RelPath: "shared.txt"})
+	if err != nil {
+		t.Fatalf("WhoOwns failed: %v", err)
+	}
+	if result.Recorded == nil || result.Recorded.Store != "s1" {
+		t.Errorf("Recorded = %+v, want s1", result.Recorded)
+	}
+	if !slices.Equal(result.Candidates, []string{"s1", "s2"}) {
+		t.Errorf("Candidates = %v, want [s1 s2]", result.Candidates)
+	}
+	if result.Winner != "s2" || !result.Pending {
+		t.Errorf("Winner = %q, Pending = %v; want s2, true", result.Winner, result.Pending)
+	}
+
+	result, err = eng.WhoOwns(context.Background(), &WhoOwnsRequest{CWD: repoDir, RelPath: "only1.txt"})
+	if err != nil {
+		t.Fatalf("WhoOwns failed: %v", err)
+	}
+	if result.Winner != "s1" || result.Pending {
+		t.Errorf("only1.txt: Winner = %q, Pending = %v; want s1, false", result.Winner, result.Pending)
+	}
+}
+
+func TestWhoOwns_UntrackedPath(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n"}, nil)
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.ActiveStore = "s1"
+	if err := eng.stateStore.SaveWorkspace(state.ComputeWorkspaceID("fp1", "."), ws); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := eng.WhoOwns(context.Background(), &WhoOwnsRequest{CWD: repoDir, RelPath: "other.txt"})
+	if err != nil {
+		t.Fatalf("WhoOwns failed: %v", err)
+	}
+	if result.Recorded != nil || result.Winner != "" || len(result.Candidates) != 0 || result.Pending {
+		t.Errorf("unexpected result for untracked path: %+v", result)
+	}
+}
+
+func TestWhoOwns_EvaluatesConditionsWithVars(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"shared.txt": "one\n"}, nil)
+	addTrackedStore(t, eng, "s2", map[string]string{"shared.txt": "two\n"})
+	track, err := eng.storeRepo.LoadTrack("s2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	track.Tracked[0].When = "DEBUG==1"
+	if err := eng.storeRepo.SaveTrack("s2", track); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.Stack = []string{"s1"}
+	ws.ActiveStore = "s2"
+	if err := eng.stateStore.SaveWorkspace(state.ComputeWorkspaceID("fp1", "."), ws); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		vars map[string]string
+		want string
+	}{
+		{vars: nil, want: "s1"},
+		{vars: map[string]string{"DEBUG": "1"}, want: "s2"},
+	} {
+		result, err := eng.WhoOwns(context.Background(), &WhoOwnsRequest{CWD: repoDir, RelPath: "shared.txt", Vars: tt.vars})
+		if err != nil {
+			t.Fatalf("WhoOwns failed: %v", err)
+		}
+		if result.Winner != tt.want {
+			t.Errorf("vars %v: Winner = %q, want %s", tt.vars, result.Winner, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/danieljhkim/monodev/internal/persist"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// pinStore points the apply context at a temporary copy of the store as it
+// was committed at its pinned ref in the sync repository. It does nothing
+// when the request has no pins.
+func (e *Engine) pinStore(ac *applyContext, req *ApplyRequest) error {
+	if len(req.StorePins) == 0 {
+		return nil
+	}
+
+	for storeID := range req.StorePins {
+		if storeID != ac.storeToApply && storeID != req.StoreID {
+			return fmt.Errorf("%w: store %s is pinned but not being applied", ErrValidation, storeID)
+		}
+	}
+	ref, ok := req.StorePins[ac.storeToApply]
+	if !ok {
+		ref = req.StorePins[req.StoreID]
+	}
+	if ref == "" {
+		return fmt.Errorf("%w: pin for store %s has no ref", ErrValidation, ac.storeToApply)
+	}
+	if req.Mode != "copy" {
+		// A symlink into the temporary copy would dangle after apply
+		return fmt.Errorf("%w: pinned stores can only be applied in copy mode", ErrValidation)
+	}
+	repo, storesDir, err := e.checkoutStoreAt(ac.root, ac.storeToApply, ref)
+	if err != nil {
+		return err
+	}
+	ac.applyRepo = repo
+	ac.pinnedDir = storesDir
+	return nil
+}
+
+// checkoutStoreAt materializes storeID as committed at ref in the sync
+// repository of the repo at root. It returns a repo serving the copy and
+// the temporary directory holding it, which the caller must remove.
+func (e *Engine) checkoutStoreAt(root, storeID, ref string) (stores.StoreRepo, string, error) {
+	if e.gitPersistence == nil {
+		return nil, "", fmt.Errorf("store refs require access to the sync repository")
+	}
+
+	storesDir, err := os.MkdirTemp("", "monodev-pin-*")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create pinned store directory: %w", err)
+	}
+	dest := filepath.Join(storesDir, storeID)
+	if err := e.gitPersistence.CheckoutPathAt(root, ref, persist.PersistedStorePath(storeID), dest); err != nil {
+		_ = os.RemoveAll(storesDir)
+		return nil, "", fmt.Errorf("failed to check out store %s at %s: %w", storeID, ref, err)
+	}
+	return stores.NewFileStoreRepo(e.fs, storesDir), storesDir, nil
+}
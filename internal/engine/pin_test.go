@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danieljhkim/monodev/internal/remote"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// pinnedRefTree returns a fake sync repo tree holding store s1 with a.txt.
+func pinnedRefTree(t *testing.T, content string) map[string]string {
+	t.Helper()
+	meta, err := json.Marshal(stores.NewStoreMeta("s1", stores.ScopeGlobal, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "a.txt", Kind: "file"}}
+	trackData, err := json.Marshal(track)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{
+		"persist/stores/s1/meta.json":     string(meta),
+		"persist/stores/s1/track.json":    string(trackData),
+		"persist/stores/s1/overlay/a.txt": content,
+	}
+}
+
+func TestApply_PinnedStoreUsesRefContent(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "local"}, nil)
+	git := remote.NewFakeGitPersistence()
+	git.RefTrees = map[string]map[string]string{
+		"v1": pinnedRefTree(t, "from v1"),
+		"v2": pinnedRefTree(t, "from v2"),
+	}
+	eng.SetGitPersistence(git)
+
+	_, err := eng.Apply(context.Background(), &ApplyRequest{
+		CWD:       repoDir,
+		StoreID:   "s1",
+		Mode:      "copy",
+		StorePins: map[string]string{"s1": "v1"},
+	})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoDir, "a.txt"))
+	if err != nil {
+		t.Fatalf("expected a.txt to be applied: %v", err)
+	}
+	if string(data) != "from v1" {
+		t.Errorf("a.txt = %q, want content from the pinned ref", data)
+	}
+
+	if len(git.CheckoutPathAtCalls) != 1 {
+		t.Fatalf("expected 1 checkout, got %d", len(git.CheckoutPathAtCalls))
+	}
+	call := git.CheckoutPathAtCalls[0]
+	if call.Ref != "v1" || call.Path != "persist/stores/s1" {
+		t.Errorf("checkout = %+v, want ref v1 of persist/stores/s1", call)
+	}
+	// The temporary materialization is removed after apply
+	if _, err := os.Stat(filepath.Dir(call.Dest)); !os.IsNotExist(err) {
+		t.Errorf("expected pinned store directory to be removed, got %v", err)
+	}
+}
+
+func TestApply_PinValidation(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "local"}, nil)
+	git := remote.NewFakeGitPersistence()
+	git.RefTrees = map[string]map[string]string{"v1": pinnedRefTree(t, "from v1")}
+	eng.SetGitPersistence(git)
+
+	tests := []struct {
+		name string
+		mode string
+		pins map[string]string
+	}{
+		{name: "symlink mode", mode: "symlink", pins: map[string]string{"s1": "v1"}},
+		{name: "store not applied", mode: "copy", pins: map[string]string{"other": "v1"}},
+		{name: "empty ref", mode: "copy", pins: map[string]string{"s1": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := eng.Apply(context.Background(), &ApplyRequest{
+				CWD:       repoDir,
+				StoreID:   "s1",
+				Mode:      tt.mode,
+				StorePins: tt.pins,
+			})
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("expected ErrValidation, got %v", err)
+			}
+		})
+	}
+	if len(git.CheckoutPathAtCalls) != 0 {
+		t.Errorf("invalid pins should not check anything out, got %d calls", len(git.CheckoutPathAtCalls))
+	}
+}
+
+func TestDiff_StoreRefComparesCommittedVersion(t *testing.T) {
+	// The current overlay matches the workspace, the committed version does not
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "current"}, map[string]string{"a.txt": "current"})
+	git := remote.NewFakeGitPersistence()
+	git.RefTrees = map[string]map[string]string{"v1": pinnedRefTree(t, "from v1")}
+	eng.SetGitPersistence(git)
+
+	result, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1"})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if result.Summary.Modified != 0 {
+		t.Fatalf("current overlay: %d modified, want none", result.Summary.Modified)
+	}
+
+	result, err = eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1", StoreRef: "v1"})
+	if err != nil {
+		t.Fatalf("Diff at ref failed: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0].Status != "modified" {
+		t.Fatalf("files at ref = %+v, want a.txt modified", result.Files)
+	}
+
+	if len(git.CheckoutPathAtCalls) != 1 {
+		t.Fatalf("expected 1 checkout, got %d", len(git.CheckoutPathAtCalls))
+	}
+	call := git.CheckoutPathAtCalls[0]
+	if call.Ref != "v1" || call.Path != "persist/stores/s1" {
+		t.Errorf("checkout = %+v, want ref v1 of persist/stores/s1", call)
+	}
+	if _, err := os.Stat(filepath.Dir(call.Dest)); !os.IsNotExist(err) {
+		t.Errorf("expected checked-out store to be removed, got %v", err)
+	}
+
+	if _, err := eng.Diff(context.Background(), &DiffRequest{CWD: repoDir, StoreID: "s1", StoreRef: "missing"}); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
@@ -156,7 +156,7 @@ func (e *Engine) Prune(ctx context.Context, req *PruneRequest) (*PruneResult, er
 
 		// If workspace state exists and this path is in it, clean it up
 		if hasWorkspaceState {
-			if pathInfo, exists := workspaceState.Paths[path]; exists {
+			if pathInfo, exists := workspaceState.Owner(path); exists {
 				// Only remove if it belongs to the active store
 				if pathInfo.Store == workspaceState.ActiveStore {
 					// Remove the overlay from workspace (symlink or copied file)
@@ -164,7 +164,11 @@ func (e *Engine) Prune(ctx context.Context, req *PruneRequest) (*PruneResult, er
 					_ = e.fs.RemoveAll(workspacePath)
 					// Ignore errors - workspace file might already be gone
 
-					// Remove from workspace state
+					// Remove from workspace state, recording the siblings
+					// of a path inside a compacted directory individually
+					if err := workspaceState.ExpandCompacted(path, e.storeDirEntries); err != nil {
+						return nil, err
+					}
 					delete(workspaceState.Paths, path)
 				}
 			}
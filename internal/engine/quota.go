@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// StoreSize returns the total size in bytes of the regular files in a
+// store's overlay. An empty scope searches both scopes.
+func (e *Engine) StoreSize(storeID, scope string) (int64, error) {
+	storeID, scope, err := splitStoreID(storeID, scope)
+	if err != nil {
+		return 0, err
+	}
+	repo, _, err := e.resolveStoreRepo(storeID, scope)
+	if err != nil {
+		return 0, err
+	}
+	return e.pathSize(repo.OverlayRoot(storeID))
+}
+
+// pathSize returns the total size of the regular files at or under path.
+// A missing path has size zero.
+func (e *Engine) pathSize(path string) (int64, error) {
+	var size int64
+	err := e.fs.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to measure %s: %w", path, err)
+	}
+	return size, nil
+}
+
+// scopeOf returns the scope a store repo serves.
+func (e *Engine) scopeOf(repo stores.StoreRepo) string {
+	if e.componentStoreRepo != nil && repo == e.componentStoreRepo {
+		return stores.ScopeComponent
+	}
+	return stores.ScopeGlobal
+}
+
+// checkStoreQuota returns ErrQuotaExceeded if adding growth bytes to the
+// store's overlay in repo would exceed its scope's limit.
+func (e *Engine) checkStoreQuota(repo stores.StoreRepo, storeID string, growth int64) error {
+	scope := e.scopeOf(repo)
+	limit, ok := e.maxStoreBytes[scope]
+	if !ok || growth <= 0 {
+		return nil
+	}
+	size, err := e.pathSize(repo.OverlayRoot(storeID))
+	if err != nil {
+		return err
+	}
+	if size+growth > limit {
+		return fmt.Errorf("%w: store '%s' would grow to %d bytes, over the %s limit of %d bytes",
+			ErrQuotaExceeded, storeID, size+growth, scope, limit)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/state"
+)
+
+// Reconcile adopts files already present in the workspace into a store's
+// ownership without touching the filesystem. Each tracked path whose
+// destination matches the store overlay (same content, or a symlink to the
+// overlay path) is recorded as managed; mismatches are reported as conflicts
+// and left alone.
+func (e *Engine) Reconcile(ctx context.Context, req *ReconcileRequest) (*ReconcileResult, error) {
+	ac, err := e.prepareApply(&ApplyRequest{CWD: req.CWD, StoreID: req.StoreID, Mode: "copy"})
+	if err != nil {
+		return nil, err
+	}
+	defer ac.cleanup()
+
+	// Plan with force so every tracked path yields its create operation,
+	// which carries the resolved source and destination paths
+	plan, err := ac.buildPlan(e, "copy", true)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceState := ac.workspaceState
+	result := &ReconcileResult{
+		WorkspaceID: ac.workspaceID,
+		StoreID:     ac.storeToApply,
+		Adopted:     []string{},
+		Missing:     []string{},
+		Conflicts:   []planner.Conflict{},
+	}
+
+	for _, op := range plan.Operations {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if op.Type != planner.OpCopy && op.Type != planner.OpMkdir {
+			continue
+		}
+
+		if owner, ok := workspaceState.Owner(op.RelPath); ok {
+			if owner.Store != op.Store || (owner.StoreScope != "" && owner.StoreScope != ac.storeScope) {
+				result.Conflicts = append(result.Conflicts, planner.Conflict{
+					Path:     op.RelPath,
+					Reason:   fmt.Sprintf("path is managed by store %s", owner.Store),
+					Existing: fmt.Sprintf("managed by %s", owner.Store),
+					Incoming: fmt.Sprintf("store %s", op.Store),
+				})
+			}
+			continue
+		}
+
+		ownership, conflict, err := e.reconcilePath(op, ac.storeScope)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case conflict != nil:
+			result.Conflicts = append(result.Conflicts, *conflict)
+		case ownership == nil:
+			result.Missing = append(result.Missing, op.RelPath)
+		default:
+			if err := workspaceState.ExpandCompacted(op.RelPath, e.storeDirEntries); err != nil {
+				return nil, fmt.Errorf("failed to expand compacted ownership: %w", err)
+			}
+			workspaceState.Paths[op.RelPath] = *ownership
+			result.Adopted = append(result.Adopted, op.RelPath)
+		}
+	}
+
+	if len(result.Adopted) == 0 {
+		return result, nil
+	}
+
+	workspaceState.Applied = true
+	if workspaceState.ActiveStore == "" {
+		workspaceState.ActiveStore = ac.storeToApply
+		workspaceState.ActiveStoreScope = ac.storeScope
+	}
+	if workspaceState.GetAppliedStore(ac.storeToApply) == nil {
+		workspaceState.AddAppliedStore(ac.storeToApply, workspaceState.Mode)
+	}
+
+	if err := e.stateStore.SaveWorkspace(ac.workspaceID, workspaceState); err != nil {
+		return nil, fmt.Errorf("failed to save workspace state: %w", err)
+	}
+	return result, nil
+}
+
+// reconcilePath compares an existing destination with its overlay source in
+// op.Store, a store of the given scope.
+// It returns the ownership to record when they match, a conflict when they
+// differ, or neither when the destination does not exist.
+func (e *Engine) reconcilePath(op planner.Operation, scope string) (*state.PathOwnership, *planner.Conflict, error) {
+	destInfo, err := e.fs.Lstat(op.DestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to stat %s: %w", op.DestPath, err)
+	}
+
+	mismatch := func(existing string) *planner.Conflict {
+		return &planner.Conflict{
+			Path:     op.RelPath,
+			Reason:   "existing path does not match the store overlay",
+			Existing: existing,
+			Incoming: fmt.Sprintf("store %s", op.Store),
+		}
+	}
+
+	ownership := &state.PathOwnership{
+		Store:          op.Store,
+		StoreScope:     scope,
+		Type:           "copy",
+		Timestamp:      e.clock.Now(),
+		SourceChecksum: e.sourceChecksum(op.SourcePath),
+		AppliedVersion: e.version,
+	}
+
+	// A link to the overlay path is what a symlink apply would have created
+	if destInfo.Mode()&os.ModeSymlink != 0 {
+		target, err := e.fs.Readlink(op.DestPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read symlink %s: %w", op.DestPath, err)
+		}
+		if target != op.SourcePath {
+			return nil, mismatch(fmt.Sprintf("symlink to %s", target)), nil
+		}
+		ownership.Type = "symlink"
+		return ownership, nil, nil
+	}
+
+	sourceInfo, err := e.fs.Lstat(op.SourcePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat %s: %w", op.SourcePath, err)
+	}
+	if sourceInfo.IsDir() != destInfo.IsDir() {
+		existing := "file"
+		if destInfo.IsDir() {
+			existing = "directory"
+		}
+		return nil, mismatch(existing), nil
+	}
+
+	if destInfo.IsDir() {
+		same, err := e.sameTree(op.SourcePath, op.DestPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !same {
+			return nil, mismatch("directory with different contents"), nil
+		}
+		return ownership, nil, nil
+	}
+
+	destChecksum, err := e.hasher.HashFile(op.DestPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to hash %s: %w", op.DestPath, err)
+	}
+	if ownership.SourceChecksum == "" || destChecksum != ownership.SourceChecksum {
+		return nil, mismatch("file with different contents"), nil
+	}
+	ownership.Checksum = destChecksum
+	return ownership, nil, nil
+}
+
+// sameTree reports whether two directories hold the same files with the
+// same contents.
+func (e *Engine) sameTree(sourceDir, destDir string) (bool, error) {
+	sourceFiles, err := e.treeChecksums(sourceDir)
+	if err != nil {
+		return false, err
+	}
+	destFiles, err := e.treeChecksums(destDir)
+	if err != nil {
+		return false, err
+	}
+	if len(sourceFiles) != len(destFiles) {
+		return false, nil
+	}
+	for rel, checksum := range sourceFiles {
+		if destFiles[rel] != checksum {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// treeChecksums hashes every file under dir, keyed by its path relative to dir.
+// Symlinks are keyed by their target instead of a content hash.
+func (e *Engine) treeChecksums(dir string) (map[string]string, error) {
+	checksums := make(map[string]string)
+	err := e.fs.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := e.fs.Readlink(path)
+			if err != nil {
+				return err
+			}
+			checksums[rel] = "symlink:" + target
+			return nil
+		}
+		checksum, err := e.hasher.HashFile(path)
+		if err != nil {
+			return err
+		}
+		checksums[rel] = checksum
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	return checksums, nil
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+func TestReconcile_AdoptsMatchingAndFlagsMismatch(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{"same.txt": "same\n", "mod.txt": "store\n", "absent.txt": "x\n"},
+		map[string]string{"same.txt": "same\n", "mod.txt": "local\n"},
+	)
+
+	result, err := eng.Reconcile(context.Background(), &ReconcileRequest{CWD: repoDir, StoreID: "s1"})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if len(result.Adopted) != 1 || result.Adopted[0] != "same.txt" {
+		t.Errorf("Adopted = %v, want [same.txt]", result.Adopted)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "mod.txt" {
+		t.Errorf("Conflicts = %+v, want one for mod.txt", result.Conflicts)
+	}
+	if len(result.Missing) != 1 || result.Missing[0] != "absent.txt" {
+		t.Errorf("Missing = %v, want [absent.txt]", result.Missing)
+	}
+
+	// No filesystem changes
+	data, err := os.ReadFile(filepath.Join(repoDir, "mod.txt"))
+	if err != nil || string(data) != "local\n" {
+		t.Errorf("mod.txt was modified: %q, %v", data, err)
+	}
+	if _, err := os.Lstat(filepath.Join(repoDir, "absent.txt")); !os.IsNotExist(err) {
+		t.Errorf("absent.txt should not be created, got %v", err)
+	}
+
+	ws, err := eng.stateStore.LoadWorkspace(state.ComputeWorkspaceID("fp1", "."))
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	ownership, ok := ws.Paths["same.txt"]
+	if !ok || ownership.Store != "s1" || ownership.StoreScope != stores.ScopeGlobal || ownership.Type != "copy" || ownership.Checksum == "" {
+		t.Errorf("same.txt ownership = %+v, %v", ownership, ok)
+	}
+	if ws.ActiveStore != "s1" || ws.ActiveStoreScope != stores.ScopeGlobal {
+		t.Errorf("active store = %s (%s), want s1 (global)", ws.ActiveStore, ws.ActiveStoreScope)
+	}
+	if _, ok := ws.Paths["mod.txt"]; ok {
+		t.Error("mismatched mod.txt should not be adopted")
+	}
+	if ws.GetAppliedStore("s1") == nil {
+		t.Error("expected s1 to be recorded as applied")
+	}
+}
+
+func TestReconcile_AdoptsSymlinkToOverlay(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"linked.txt": "x\n"}, nil)
+	source := filepath.Join(eng.storeRepo.OverlayRoot("s1"), "linked.txt")
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(source, filepath.Join(repoDir, "linked.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := eng.Reconcile(context.Background(), &ReconcileRequest{CWD: repoDir, StoreID: "s1"})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if len(result.Adopted) != 1 {
+		t.Fatalf("Adopted = %v, want [linked.txt]", result.Adopted)
+	}
+
+	ws, err := eng.stateStore.LoadWorkspace(state.ComputeWorkspaceID("fp1", "."))
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	if got := ws.Paths["linked.txt"].Type; got != "symlink" {
+		t.Errorf("linked.txt type = %q, want symlink", got)
+	}
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+)
+
+// ResolutionChoice is how a single apply conflict should be resolved.
+type ResolutionChoice string
+
+const (
+	// ResolveForce removes the existing path and applies the overlay over it.
+	ResolveForce ResolutionChoice = "force"
+
+	// ResolveBackup moves the existing path aside (see BackupSuffix) and
+	// applies the overlay in its place.
+	ResolveBackup ResolutionChoice = "backup"
+
+	// ResolveSkip leaves the existing path untouched and does not apply it.
+	ResolveSkip ResolutionChoice = "skip"
+
+	// ResolveAbort cancels the whole apply without making changes.
+	ResolveAbort ResolutionChoice = "abort"
+)
+
+// BackupSuffix is appended to a conflicting path when it is backed up.
+const BackupSuffix = ".monodev-backup"
+
+// ResolutionOption is one choice for resolving a conflict, together with the
+// operations that choosing it would execute.
+type ResolutionOption struct {
+	// Choice identifies the option
+	Choice ResolutionChoice
+
+	// Operations are executed in order if this option is chosen.
+	// Empty for skip and abort.
+	Operations []planner.Operation
+}
+
+// ConflictResolution enumerates the ways a single conflict can be resolved.
+type ConflictResolution struct {
+	// Conflict is the conflict being resolved
+	Conflict planner.Conflict
+
+	// Options lists the available choices in the order force, backup, skip, abort
+	Options []ResolutionOption
+}
+
+// Option returns the option for the given choice, or nil if it is not offered.
+func (r *ConflictResolution) Option(choice ResolutionChoice) *ResolutionOption {
+	for i := range r.Options {
+		if r.Options[i].Choice == choice {
+			return &r.Options[i]
+		}
+	}
+	return nil
+}
+
+// ApplyWithResolutions applies like Apply, resolving each conflict with the
+// choice given for its path in choices. Every conflict must have a choice;
+// choosing ResolveAbort for any of them cancels the apply without changes.
+// req.Force is ignored. Non-conflicting paths are applied as usual, and the
+// resolved plan goes through the same lock, overlap, warning and dry-run
+// handling as Apply.
+func (e *Engine) ApplyWithResolutions(ctx context.Context, req *ApplyRequest, choices map[string]ResolutionChoice) (*ApplyResult, error) {
+	req = withDefaultMode(e, req)
+	unforced := *req
+	unforced.Force = false
+	req = &unforced
+
+	ac, err := e.prepareApply(req)
+	if err != nil {
+		return nil, err
+	}
+	defer ac.cleanup()
+
+	plan, resumed, err := e.planApply(ac, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resolutions, err := e.conflictResolutions(ac, req.Mode, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := planner.NewApplyPlan(plan.Stores)
+	resolved.Operations = append(resolved.Operations, plan.Operations...)
+	resolved.Warnings = plan.Warnings
+	resolved.Notes = plan.Notes
+	for i := range resolutions {
+		res := &resolutions[i]
+		path := res.Conflict.Path
+
+		choice, ok := choices[path]
+		if !ok {
+			return nil, fmt.Errorf("%w: no resolution chosen for conflict at %s", ErrValidation, path)
+		}
+		if choice == ResolveAbort {
+			result := ac.result(plan, []planner.Operation{})
+			result.Resolutions = resolutions
+			return result, fmt.Errorf("%w: apply aborted at %s", ErrConflict, path)
+		}
+		option := res.Option(choice)
+		if option == nil {
+			return nil, fmt.Errorf("%w: unknown resolution %q for %s", ErrValidation, choice, path)
+		}
+		resolved.Operations = append(resolved.Operations, option.Operations...)
+	}
+
+	result, err := e.applyPlanned(ctx, req, ac, resolved)
+	if result != nil {
+		result.Resumed = resumed
+	}
+	return result, err
+}
+
+// conflictResolutions precomputes the resolution options for each conflict
+// in plan. The force operations are taken from a forced plan for the same
+// apply, so they match what Apply with Force would execute for that path.
+func (e *Engine) conflictResolutions(ac *applyContext, mode string, plan *planner.ApplyPlan) ([]ConflictResolution, error) {
+	if !plan.HasConflicts() {
+		return nil, nil
+	}
+
+	forced, err := ac.buildPlan(e, mode, true)
+	if err != nil {
+		return nil, err
+	}
+	forcedOps := make(map[string][]planner.Operation)
+	for _, op := range forced.Operations {
+		forcedOps[op.RelPath] = append(forcedOps[op.RelPath], op)
+	}
+
+	resolutions := make([]ConflictResolution, 0, len(plan.Conflicts))
+	for _, conflict := range plan.Conflicts {
+		forceOps := forcedOps[conflict.Path]
+
+		// Backing up replaces the removal with a move aside
+		var backupOps []planner.Operation
+		for _, op := range forceOps {
+			if op.Type == planner.OpRemove {
+				continue
+			}
+			if len(backupOps) == 0 {
+				backupOps = append(backupOps, planner.Operation{
+					Type:       planner.OpBackup,
+					SourcePath: op.DestPath,
+					DestPath:   op.DestPath + BackupSuffix,
+					RelPath:    conflict.Path,
+					Reason:     "backup: existing path moved aside",
+				})
+			}
+			backupOps = append(backupOps, op)
+		}
+
+		resolutions = append(resolutions, ConflictResolution{
+			Conflict: conflict,
+			Options: []ResolutionOption{
+				{Choice: ResolveForce, Operations: forceOps},
+				{Choice: ResolveBackup, Operations: backupOps},
+				{Choice: ResolveSkip},
+				{Choice: ResolveAbort},
+			},
+		})
+	}
+
+	return resolutions, nil
+}
@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/state"
+)
+
+// setupResolutionEngine creates a store with three files where a.txt conflicts
+// with an unmanaged workspace file, b.txt conflicts with a managed symlink-mode
+// record, and c.txt applies cleanly.
+func setupResolutionEngine(t *testing.T) (*Engine, string) {
+	t.Helper()
+	eng, repoDir := setupDiffEngine(t,
+		map[string]string{"a.txt": "store a\n", "b.txt": "store b\n", "c.txt": "store c\n"},
+		map[string]string{"a.txt": "local a\n", "b.txt": "local b\n"},
+	)
+
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.Paths["b.txt"] = state.PathOwnership{Store: "s1", Type: "symlink"}
+	if err := eng.stateStore.SaveWorkspace(state.ComputeWorkspaceID("fp1", "."), ws); err != nil {
+		t.Fatal(err)
+	}
+	return eng, repoDir
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestApply_ConflictResolutions(t *testing.T) {
+	eng, repoDir := setupResolutionEngine(t)
+
+	result, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if len(result.Resolutions) != 2 {
+		t.Fatalf("expected 2 resolutions, got %d", len(result.Resolutions))
+	}
+
+	byPath := make(map[string]*ConflictResolution)
+	for i := range result.Resolutions {
+		byPath[result.Resolutions[i].Conflict.Path] = &result.Resolutions[i]
+	}
+	if byPath["a.txt"].Conflict.Existing != "unmanaged" {
+		t.Errorf("a.txt Existing = %q, want unmanaged", byPath["a.txt"].Conflict.Existing)
+	}
+	if byPath["b.txt"].Conflict.Existing != "symlink" {
+		t.Errorf("b.txt Existing = %q, want symlink", byPath["b.txt"].Conflict.Existing)
+	}
+
+	for path, res := range byPath {
+		force := res.Option(ResolveForce)
+		if len(force.Operations) != 2 || force.Operations[0].Type != planner.OpRemove || force.Operations[1].Type != planner.OpCopy {
+			t.Errorf("%s force ops = %+v, want remove then copy", path, force.Operations)
+		}
+		backup := res.Option(ResolveBackup)
+		if len(backup.Operations) != 2 || backup.Operations[0].Type != planner.OpBackup {
+			t.Errorf("%s backup ops = %+v, want backup then copy", path, backup.Operations)
+		}
+		if len(res.Option(ResolveSkip).Operations) != 0 || len(res.Option(ResolveAbort).Operations) != 0 {
+			t.Errorf("%s skip/abort should generate no operations", path)
+		}
+	}
+}
+
+func TestApplyWithResolutions_SkipAndForce(t *testing.T) {
+	eng, repoDir := setupResolutionEngine(t)
+
+	result, err := eng.ApplyWithResolutions(context.Background(),
+		&ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"},
+		map[string]ResolutionChoice{"a.txt": ResolveSkip, "b.txt": ResolveForce},
+	)
+	if err != nil {
+		t.Fatalf("ApplyWithResolutions failed: %v", err)
+	}
+
+	if got := readTestFile(t, filepath.Join(repoDir, "a.txt")); got != "local a\n" {
+		t.Errorf("skipped a.txt = %q, want local content", got)
+	}
+	if got := readTestFile(t, filepath.Join(repoDir, "b.txt")); got != "store b\n" {
+		t.Errorf("forced b.txt = %q, want store content", got)
+	}
+	if got := readTestFile(t, filepath.Join(repoDir, "c.txt")); got != "store c\n" {
+		t.Errorf("c.txt = %q, want store content", got)
+	}
+
+	ws, err := eng.stateStore.LoadWorkspace(result.WorkspaceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ws.Paths["a.txt"]; ok {
+		t.Error("skipped a.txt should not be managed")
+	}
+	if ws.Paths["b.txt"].Type != "copy" {
+		t.Errorf("b.txt ownership = %+v, want copy", ws.Paths["b.txt"])
+	}
+}
+
+func TestApplyWithResolutions_Backup(t *testing.T) {
+	eng, repoDir := setupResolutionEngine(t)
+
+	_, err := eng.ApplyWithResolutions(context.Background(),
+		&ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"},
+		map[string]ResolutionChoice{"a.txt": ResolveBackup, "b.txt": ResolveSkip},
+	)
+	if err != nil {
+		t.Fatalf("ApplyWithResolutions failed: %v", err)
+	}
+
+	if got := readTestFile(t, filepath.Join(repoDir, "a.txt")); got != "store a\n" {
+		t.Errorf("a.txt = %q, want store content", got)
+	}
+	if got := readTestFile(t, filepath.Join(repoDir, "a.txt"+BackupSuffix)); got != "local a\n" {
+		t.Errorf("backup = %q, want local content", got)
+	}
+}
+
+func TestApplyWithResolutions_AbortAndMissingChoice(t *testing.T) {
+	eng, repoDir := setupResolutionEngine(t)
+	req := &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}
+
+	_, err := eng.ApplyWithResolutions(context.Background(), req,
+		map[string]ResolutionChoice{"a.txt": ResolveForce, "b.txt": ResolveAbort})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict on abort, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, "c.txt")); !os.IsNotExist(err) {
+		t.Error("abort should not apply any paths")
+	}
+
+	_, err = eng.ApplyWithResolutions(context.Background(), req,
+		map[string]ResolutionChoice{"a.txt": ResolveSkip})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation for missing choice, got %v", err)
+	}
+}
+
+func TestApplyWithResolutions_EnforcesApplyPolicies(t *testing.T) {
+	choices := map[string]ResolutionChoice{"a.txt": ResolveSkip, "b.txt": ResolveForce}
+
+	t.Run("locked without a lockfile", func(t *testing.T) {
+		eng, repoDir := setupResolutionEngine(t)
+		_, err := eng.ApplyWithResolutions(context.Background(),
+			&ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", Locked: true}, choices)
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound for the missing lockfile, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "c.txt")); !os.IsNotExist(err) {
+			t.Errorf("nothing should be applied, stat c.txt err = %v", err)
+		}
+	})
+
+	t.Run("write lock", func(t *testing.T) {
+		eng, repoDir := setupResolutionEngine(t)
+		if _, err := eng.ApplyWithResolutions(context.Background(),
+			&ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", WriteLock: true}, choices); err != nil {
+			t.Fatalf("ApplyWithResolutions failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, LockFileName)); err != nil {
+			t.Errorf("expected %s to be written: %v", LockFileName, err)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		eng, repoDir := setupResolutionEngine(t)
+		result, err := eng.ApplyWithResolutions(context.Background(),
+			&ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy", DryRun: true}, choices)
+		if err != nil {
+			t.Fatalf("ApplyWithResolutions failed: %v", err)
+		}
+		if len(result.Applied) != 0 {
+			t.Errorf("Applied = %+v, want nothing in a dry run", result.Applied)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "c.txt")); !os.IsNotExist(err) {
+			t.Errorf("dry run should not apply c.txt, stat err = %v", err)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/state"
+)
+
+// PreviewScopeSwitch reports which paths would change if the workspace's
+// store were applied from another scope. Both scopes' versions of the store
+// are planned against an empty workspace, and their installs are compared
+// path by path. Nothing is modified.
+func (e *Engine) PreviewScopeSwitch(ctx context.Context, req *PreviewScopeSwitchRequest) (*PreviewScopeSwitchResult, error) {
+	if req.ToScope == "" {
+		return nil, fmt.Errorf("%w: a target scope is required", ErrValidation)
+	}
+
+	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover workspace: %w", err)
+	}
+	workspaceState, _, err := e.LoadOrCreateWorkspaceState(root, repoFingerprint, workspacePath, e.defaultMode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workspace state: %w", err)
+	}
+
+	storeID, fromScope := req.StoreID, req.FromScope
+	if storeID == "" {
+		if workspaceState.ActiveStore == "" {
+			return nil, ErrNoActiveStore
+		}
+		storeID = workspaceState.ActiveStore
+		if fromScope == "" {
+			fromScope = workspaceState.ActiveStoreScope
+		}
+	}
+	storeID, fromScope, err = splitStoreID(storeID, fromScope)
+	if err != nil {
+		return nil, err
+	}
+	if fromScope == "" {
+		return nil, fmt.Errorf("%w: the current scope of store '%s' is unknown; specify it", ErrValidation, storeID)
+	}
+	if fromScope == req.ToScope {
+		return nil, fmt.Errorf("%w: store '%s' is already in scope %s", ErrValidation, storeID, req.ToScope)
+	}
+
+	mode := workspaceState.Mode
+	if mode == "" {
+		mode = e.defaultMode()
+	}
+	applyRoot := filepath.Join(root, workspacePath)
+
+	from, err := e.plannedInstalls(storeID, fromScope, mode, applyRoot, repoFingerprint, workspacePath, req.Vars)
+	if err != nil {
+		return nil, err
+	}
+	to, err := e.plannedInstalls(storeID, req.ToScope, mode, applyRoot, repoFingerprint, workspacePath, req.Vars)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PreviewScopeSwitchResult{
+		StoreID:   storeID,
+		FromScope: fromScope,
+		ToScope:   req.ToScope,
+		Mode:      mode,
+		Changes:   []ScopeSwitchChange{},
+	}
+	planned := maps.Clone(from)
+	maps.Copy(planned, to)
+	for _, relPath := range slices.Sorted(maps.Keys(planned)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fromOp, inFrom := from[relPath]
+		toOp, inTo := to[relPath]
+		change := ScopeSwitchChange{Path: relPath, FromSource: fromOp.SourcePath, ToSource: toOp.SourcePath}
+		switch {
+		case !inFrom:
+			change.Change = ScopeChangeAdded
+		case !inTo:
+			change.Change = ScopeChangeRemoved
+		case mode == "symlink" || fromOp.Type != toOp.Type || !e.sameContent(fromOp.SourcePath, toOp.SourcePath):
+			// A symlink points into the other scope's overlay even when
+			// the content matches
+			change.Change = ScopeChangeRetargeted
+		default:
+			continue
+		}
+		result.Changes = append(result.Changes, change)
+	}
+	return result, nil
+}
+
+// plannedInstalls plans applying storeID from scope into an empty workspace
+// and returns the install operation for each destination path.
+func (e *Engine) plannedInstalls(storeID, scope, mode, applyRoot, repoFingerprint, workspacePath string, vars map[string]string) (map[string]planner.Operation, error) {
+	repo, err := e.storeRepoForScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := repo.Exists(storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check store: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: store '%s' not found in %s scope", ErrNotFound, storeID, scope)
+	}
+
+	// Force keeps files already in the workspace from showing up as
+	// conflicts; the removals it plans for them are ignored
+	plan, err := planner.BuildApplyPlanWith(
+		state.NewWorkspaceState(repoFingerprint, workspacePath, mode),
+		[]string{storeID},
+		mode,
+		applyRoot,
+		repo,
+		e.fs,
+		planner.PlanOptions{Vars: vars, Force: true, Hasher: e.hasher},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan store %s from %s scope: %w", storeID, scope, err)
+	}
+
+	installs := make(map[string]planner.Operation)
+	for _, op := range plan.Operations {
+		if op.Type != planner.OpRemove {
+			installs[op.RelPath] = op
+		}
+	}
+	return installs, nil
+}
+
+// sameContent reports whether two overlay sources hold the same content.
+// Directories match when they contain the same files with the same content.
+func (e *Engine) sameContent(a, b string) bool {
+	aSums, err := e.contentChecksums(a)
+	if err != nil {
+		return false
+	}
+	bSums, err := e.contentChecksums(b)
+	if err != nil {
+		return false
+	}
+	return maps.Equal(aSums, bSums)
+}
+
+// contentChecksums maps each regular file at or under path, relative to
+// path, to its checksum.
+func (e *Engine) contentChecksums(path string) (map[string]string, error) {
+	sums := make(map[string]string)
+	err := e.fs.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		sum, err := e.hasher.HashFile(p)
+		if err != nil {
+			return err
+		}
+		sums[rel] = sum
+		return nil
+	})
+	return sums, err
+}
@@ -3,10 +3,16 @@ package engine
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/danieljhkim/monodev/internal/config"
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/hash"
 	"github.com/danieljhkim/monodev/internal/state"
 	"github.com/danieljhkim/monodev/internal/stores"
 )
@@ -48,10 +54,21 @@ func (m *scopedMockStoreRepo) LoadMeta(id string) (*stores.StoreMeta, error) {
 	}
 	return nil, errors.New("store not found")
 }
+func (m *scopedMockStoreRepo) LoadMetas(ids []string) (map[string]*stores.StoreMeta, error) {
+	return stores.LoadMetasSequential(m, ids)
+}
 func (m *scopedMockStoreRepo) SaveMeta(id string, meta *stores.StoreMeta) error {
 	m.metas[id] = meta
 	return nil
 }
+func (m *scopedMockStoreRepo) MarkUsed(id string, at time.Time) error {
+	meta, ok := m.metas[id]
+	if !ok {
+		return errors.New("store not found")
+	}
+	meta.LastUsedAt = &at
+	return nil
+}
 func (m *scopedMockStoreRepo) LoadTrack(id string) (*stores.TrackFile, error) {
 	if track, ok := m.tracks[id]; ok {
 		return track, nil
@@ -125,6 +142,60 @@ func TestCreateStore_GlobalScope(t *testing.T) {
 	}
 }
 
+func TestCreateStore_RejectDuplicateName(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	globalRepo.storeIDs["existing"] = true
+	globalRepo.metas["existing"] = stores.NewStoreMeta("Shared Tools", stores.ScopeGlobal, time.Now())
+	componentRepo := newScopedMockStoreRepo()
+	eng := newScopedTestEngine(globalRepo, componentRepo)
+
+	err := eng.CreateStore(context.Background(), &CreateStoreRequest{
+		CWD:                 "/repo",
+		StoreID:             "new-store",
+		Name:                "Shared Tools",
+		Scope:               stores.ScopeGlobal,
+		RejectDuplicateName: true,
+	})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if _, ok := globalRepo.created["new-store"]; ok {
+		t.Error("expected store not to be created")
+	}
+
+	// Same name in a different scope is allowed
+	err = eng.CreateStore(context.Background(), &CreateStoreRequest{
+		CWD:                 "/repo",
+		StoreID:             "comp-store",
+		Name:                "Shared Tools",
+		Scope:               stores.ScopeComponent,
+		RejectDuplicateName: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error for other scope: %v", err)
+	}
+}
+
+func TestCreateStore_AllowsDuplicateNameByDefault(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	globalRepo.storeIDs["existing"] = true
+	globalRepo.metas["existing"] = stores.NewStoreMeta("Shared Tools", stores.ScopeGlobal, time.Now())
+	eng := newScopedTestEngine(globalRepo, newScopedMockStoreRepo())
+
+	err := eng.CreateStore(context.Background(), &CreateStoreRequest{
+		CWD:     "/repo",
+		StoreID: "new-store",
+		Name:    "Shared Tools",
+		Scope:   stores.ScopeGlobal,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := globalRepo.created["new-store"]; !ok {
+		t.Error("expected store to be created")
+	}
+}
+
 func TestCreateStore_ComponentScope(t *testing.T) {
 	globalRepo := newScopedMockStoreRepo()
 	componentRepo := newScopedMockStoreRepo()
@@ -215,6 +286,117 @@ func TestListStores_GlobalOnly(t *testing.T) {
 	}
 }
 
+func TestListStoresWith_IncludeHealth(t *testing.T) {
+	tmpDir := t.TempDir()
+	fs := fsops.NewRealFS()
+	storeRepo := stores.NewFileStoreRepo(fs, filepath.Join(tmpDir, "stores"))
+	for _, id := range []string{"used", "unused"} {
+		if err := storeRepo.Create(id, stores.NewStoreMeta(id, stores.ScopeGlobal, time.Now())); err != nil {
+			t.Fatal(err)
+		}
+		track := stores.NewTrackFile()
+		track.Tracked = []stores.TrackedPath{{Path: "Makefile", Kind: "file"}}
+		if err := storeRepo.SaveTrack(id, track); err != nil {
+			t.Fatal(err)
+		}
+		overlay := storeRepo.OverlayRoot(id)
+		if err := os.MkdirAll(overlay, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(overlay, "Makefile"), []byte("all:"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A file left in the overlay without a tracked path
+	if err := os.WriteFile(filepath.Join(storeRepo.OverlayRoot("used"), "stray.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workspacesDir := filepath.Join(tmpDir, "workspaces")
+	if err := os.MkdirAll(workspacesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspacesDir, "ws1.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stateStore := newMockStateStore()
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.Stack = []string{"used"}
+	stateStore.workspaces["ws1"] = ws
+
+	eng := New(&trackGitRepo{}, storeRepo, stateStore, fs, hash.NewSHA256Hasher(), &mockClock{}, config.Paths{Workspaces: workspacesDir})
+
+	result, err := eng.ListStoresWith(context.Background(), &ListStoresRequest{IncludeHealth: true})
+	if err != nil {
+		t.Fatalf("ListStoresWith failed: %v", err)
+	}
+	health := map[string]*stores.StoreHealth{}
+	for _, s := range result {
+		health[s.ID] = s.Health
+	}
+
+	if got := health["used"]; got == nil || !got.TrackMismatch || got.Orphaned || got.AuditFindings != 0 {
+		t.Errorf("used health = %+v, want TrackMismatch only", got)
+	}
+	if got := health["unused"]; got == nil || got.TrackMismatch || !got.Orphaned || got.AuditFindings != 0 {
+		t.Errorf("unused health = %+v, want Orphaned only", got)
+	}
+
+	result, err = eng.ListStoresWith(context.Background(), &ListStoresRequest{})
+	if err != nil {
+		t.Fatalf("ListStoresWith failed: %v", err)
+	}
+	for _, s := range result {
+		if s.Health != nil {
+			t.Errorf("store %s has health without IncludeHealth", s.ID)
+		}
+	}
+}
+
+// stepClock advances by one minute on every call to Now.
+type stepClock struct{ now time.Time }
+
+func (c *stepClock) Now() time.Time {
+	c.now = c.now.Add(time.Minute)
+	return c.now
+}
+
+func TestListStoresSorted_RecentReflectsUseOrder(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	globalRepo := newScopedMockStoreRepo()
+	for _, id := range []string{"a", "b", "c"} {
+		globalRepo.storeIDs[id] = true
+		globalRepo.metas[id] = stores.NewStoreMeta(id, stores.ScopeGlobal, base)
+	}
+	// Never used, but modified after everything else was used
+	globalRepo.metas["c"].UpdatedAt = base.Add(time.Hour)
+
+	eng := newScopedTestEngine(globalRepo, nil)
+	eng.clock = &stepClock{now: base}
+
+	for _, id := range []string{"b", "a"} {
+		if err := eng.UseStore(context.Background(), &UseStoreRequest{CWD: "/repo", StoreID: id}); err != nil {
+			t.Fatalf("UseStore(%s) failed: %v", id, err)
+		}
+	}
+
+	result, err := eng.ListStoresSorted(context.Background(), StoreSortRecent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, s := range result {
+		got = append(got, s.ID)
+	}
+	if want := []string{"c", "a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("recent order = %v, want %v", got, want)
+	}
+
+	if _, err := eng.ListStoresSorted(context.Background(), "bogus"); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected ErrValidation for unknown sort, got %v", err)
+	}
+}
+
 func TestDescribeStore_BothScopes(t *testing.T) {
 	globalRepo := newScopedMockStoreRepo()
 	globalRepo.storeIDs["shared"] = true
@@ -256,6 +438,42 @@ func TestDescribeStore_NotFound(t *testing.T) {
 	}
 }
 
+func TestDescribeStore_RequiredBy(t *testing.T) {
+	now := time.Now()
+	globalRepo := newScopedMockStoreRepo()
+	globalRepo.storeIDs["a"] = true
+	globalRepo.metas["a"] = stores.NewStoreMeta("a", stores.ScopeGlobal, now)
+	globalRepo.storeIDs["c"] = true
+	globalRepo.metas["c"] = stores.NewStoreMeta("c", stores.ScopeGlobal, now)
+
+	componentRepo := newScopedMockStoreRepo()
+	b := stores.NewStoreMeta("b", stores.ScopeComponent, now)
+	b.Requires = []string{"a"}
+	componentRepo.storeIDs["b"] = true
+	componentRepo.metas["b"] = b
+
+	eng := newScopedTestEngine(globalRepo, componentRepo)
+
+	result, err := eng.DescribeStore(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(result))
+	}
+	if got := result[0].RequiredBy; len(got) != 1 || got[0] != "b" {
+		t.Errorf("RequiredBy = %v, want [b]", got)
+	}
+
+	result, err = eng.DescribeStore(context.Background(), "c")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result[0].RequiredBy) != 0 {
+		t.Errorf("RequiredBy = %v, want none", result[0].RequiredBy)
+	}
+}
+
 func TestDeleteStore_Ambiguous(t *testing.T) {
 	globalRepo := newScopedMockStoreRepo()
 	globalRepo.storeIDs["shared"] = true
@@ -369,6 +587,69 @@ func TestFindStore_BothScopes(t *testing.T) {
 	}
 }
 
+func TestResolveStoreRepo_QualifiedID(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	globalRepo.storeIDs["shared"] = true
+	componentRepo := newScopedMockStoreRepo()
+	componentRepo.storeIDs["shared"] = true
+	eng := newScopedTestEngine(globalRepo, componentRepo)
+
+	repo, scope, err := eng.resolveStoreRepo("global/shared", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if scope != stores.ScopeGlobal || repo != globalRepo {
+		t.Errorf("expected global repo, got scope %s", scope)
+	}
+
+	locations, err := eng.findStore("component/shared")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(locations) != 1 || locations[0].Scope != stores.ScopeComponent {
+		t.Errorf("expected only the component location, got %+v", locations)
+	}
+
+	// A bare ID present in both scopes stays ambiguous
+	if _, _, err := eng.resolveStoreRepo("shared", ""); err == nil || !strings.Contains(err.Error(), "both global and component") {
+		t.Errorf("expected ambiguity error for bare ID, got %v", err)
+	}
+
+	if _, _, err := eng.resolveStoreRepo("team/shared", ""); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected ErrValidation for unknown scope prefix, got %v", err)
+	}
+	if _, _, err := eng.resolveStoreRepo("global/shared", stores.ScopeComponent); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected ErrValidation for conflicting scope, got %v", err)
+	}
+}
+
+func TestUseStore_QualifiedID(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	componentRepo := newScopedMockStoreRepo()
+	for _, repo := range []*scopedMockStoreRepo{globalRepo, componentRepo} {
+		repo.storeIDs["shared"] = true
+		repo.metas["shared"] = stores.NewStoreMeta("shared", stores.ScopeGlobal, time.Now())
+	}
+	stateStore := newMockStateStore()
+	eng := newScopedTestEngineWithState(globalRepo, componentRepo, stateStore)
+
+	if err := eng.UseStore(context.Background(), &UseStoreRequest{CWD: "/repo", StoreID: "shared"}); err == nil {
+		t.Fatal("expected ambiguity error for bare ID")
+	}
+
+	if err := eng.UseStore(context.Background(), &UseStoreRequest{CWD: "/repo", StoreID: "component/shared"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stateStore.workspaces) != 1 {
+		t.Fatalf("expected one workspace state, got %d", len(stateStore.workspaces))
+	}
+	for _, ws := range stateStore.workspaces {
+		if ws.ActiveStore != "shared" || ws.ActiveStoreScope != stores.ScopeComponent {
+			t.Errorf("active store = %s (%s), want shared (component)", ws.ActiveStore, ws.ActiveStoreScope)
+		}
+	}
+}
+
 func TestFindStore_OnlyGlobal(t *testing.T) {
 	globalRepo := newScopedMockStoreRepo()
 	globalRepo.storeIDs["global-only"] = true
@@ -546,3 +827,198 @@ func TestCreateStore_SetsActiveStoreScope(t *testing.T) {
 	}
 	t.Error("workspace state with new-store not found")
 }
+
+func TestActiveStoreRepo_ComponentScopeUnavailable(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	eng := newScopedTestEngine(globalRepo, nil)
+
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.ActiveStore = "comp-store"
+	ws.ActiveStoreScope = stores.ScopeComponent
+
+	_, err := eng.activeStoreRepo(ws)
+	if !errors.Is(err, ErrScopeUnavailable) {
+		t.Fatalf("activeStoreRepo error = %v, want ErrScopeUnavailable", err)
+	}
+	if !strings.Contains(err.Error(), "comp-store") || !strings.Contains(err.Error(), "cd into") {
+		t.Errorf("error %q should name the store and suggest cd into the repo", err)
+	}
+
+	// The same state resolves once a component repo is configured
+	eng = newScopedTestEngine(globalRepo, newScopedMockStoreRepo())
+	if _, err := eng.activeStoreRepo(ws); err != nil {
+		t.Errorf("activeStoreRepo with component repo: %v", err)
+	}
+}
+
+func TestStackApply_QualifiedStoresSharingID(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	realFS := fsops.NewRealFS()
+
+	// A store named foo in each scope, both tracking Makefile
+	repos := map[string]stores.StoreRepo{}
+	for _, scope := range []string{stores.ScopeGlobal, stores.ScopeComponent} {
+		repo := stores.NewFileStoreRepo(realFS, filepath.Join(tmpDir, scope))
+		if err := repo.Create("foo", stores.NewStoreMeta("foo", scope, time.Now())); err != nil {
+			t.Fatal(err)
+		}
+		track := stores.NewTrackFile()
+		track.Tracked = []stores.TrackedPath{{Path: "Makefile", Kind: "file"}}
+		if err := repo.SaveTrack("foo", track); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(repo.OverlayRoot("foo"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo.OverlayRoot("foo"), "Makefile"), []byte(scope), 0644); err != nil {
+			t.Fatal(err)
+		}
+		repos[scope] = repo
+	}
+
+	stateStore := newMockStateStore()
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.Stack = []string{"global/foo", "component/foo"}
+	stateStore.workspaces[state.ComputeWorkspaceID("fp1", ".")] = ws
+
+	eng := New(&trackGitRepo{root: repoDir, fingerprint: "fp1", workspacePath: "."},
+		repos[stores.ScopeGlobal], stateStore, realFS, &mockHasher{}, &mockClock{}, config.Paths{})
+	eng.globalStoreRepo = repos[stores.ScopeGlobal]
+	eng.componentStoreRepo = repos[stores.ScopeComponent]
+
+	if _, err := eng.StackApply(context.Background(), &StackApplyRequest{CWD: repoDir, Mode: "copy"}); err != nil {
+		t.Fatalf("StackApply failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoDir, "Makefile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != stores.ScopeComponent {
+		t.Errorf("Makefile = %q, want the component store's copy", data)
+	}
+	ownership := ws.Paths["Makefile"]
+	if ownership.Store != "component/foo" || ownership.StoreScope != stores.ScopeComponent {
+		t.Errorf("ownership = %s (%s), want component/foo (component)", ownership.Store, ownership.StoreScope)
+	}
+}
+
+func TestListStoresGrouped_ByOwner(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	componentRepo := newScopedMockStoreRepo()
+	for _, s := range []struct {
+		repo      *scopedMockStoreRepo
+		id, owner string
+		scope     string
+	}{
+		{globalRepo, "g1", "bob", stores.ScopeGlobal},
+		{globalRepo, "g2", "alice", stores.ScopeGlobal},
+		{componentRepo, "c1", "bob", stores.ScopeComponent},
+		{componentRepo, "c2", "", stores.ScopeComponent},
+	} {
+		meta := stores.NewStoreMeta(s.id, s.scope, time.Now())
+		meta.Owner = s.owner
+		s.repo.storeIDs[s.id] = true
+		s.repo.metas[s.id] = meta
+	}
+	eng := newScopedTestEngine(globalRepo, componentRepo)
+
+	groups, err := eng.ListStoresGrouped(context.Background(), StoreSortDefault, StoreGroupOwner)
+	if err != nil {
+		t.Fatalf("ListStoresGrouped failed: %v", err)
+	}
+
+	// Owners sort alphabetically with unowned stores last
+	if !slices.Equal(groups.Keys, []string{"alice", "bob", ""}) {
+		t.Fatalf("keys = %q, want alice, bob, then unowned", groups.Keys)
+	}
+	want := map[string][]string{"alice": {"g2"}, "bob": {"g1", "c1"}, "": {"c2"}}
+	for key, ids := range want {
+		var got []string
+		for _, s := range groups.Groups[key] {
+			got = append(got, s.ID)
+		}
+		if !slices.Equal(got, ids) {
+			t.Errorf("group %q = %v, want %v", key, got, ids)
+		}
+	}
+
+	if _, err := eng.ListStoresGrouped(context.Background(), StoreSortDefault, "type"); !errors.Is(err, ErrValidation) {
+		t.Errorf("unknown grouping error = %v, want ErrValidation", err)
+	}
+}
+
+func TestPreviewScopeSwitch_ListsAffectedPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	realFS := fsops.NewRealFS()
+
+	// Both scopes have store foo: Makefile is identical, .env differs, and
+	// each scope tracks one path the other lacks
+	overlays := map[string]map[string]string{
+		stores.ScopeGlobal:    {"Makefile": "all:\n", ".env": "A=1\n", "global.sh": "g\n"},
+		stores.ScopeComponent: {"Makefile": "all:\n", ".env": "A=2\n", "component.sh": "c\n"},
+	}
+	repos := map[string]stores.StoreRepo{}
+	for scope, files := range overlays {
+		repo := stores.NewFileStoreRepo(realFS, filepath.Join(tmpDir, scope))
+		if err := repo.Create("foo", stores.NewStoreMeta("foo", scope, time.Now())); err != nil {
+			t.Fatal(err)
+		}
+		track := stores.NewTrackFile()
+		for name, content := range files {
+			track.Tracked = append(track.Tracked, stores.TrackedPath{Path: name, Kind: "file"})
+			if err := os.WriteFile(filepath.Join(repo.OverlayRoot("foo"), name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := repo.SaveTrack("foo", track); err != nil {
+			t.Fatal(err)
+		}
+		repos[scope] = repo
+	}
+
+	stateStore := newMockStateStore()
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.ActiveStore = "foo"
+	ws.ActiveStoreScope = stores.ScopeGlobal
+	stateStore.workspaces[state.ComputeWorkspaceID("fp1", ".")] = ws
+
+	eng := New(&trackGitRepo{root: repoDir, fingerprint: "fp1", workspacePath: "."},
+		repos[stores.ScopeGlobal], stateStore, realFS, hash.NewSHA256Hasher(), &mockClock{}, config.Paths{})
+	eng.globalStoreRepo = repos[stores.ScopeGlobal]
+	eng.componentStoreRepo = repos[stores.ScopeComponent]
+
+	result, err := eng.PreviewScopeSwitch(context.Background(), &PreviewScopeSwitchRequest{
+		CWD:     repoDir,
+		ToScope: stores.ScopeComponent,
+	})
+	if err != nil {
+		t.Fatalf("PreviewScopeSwitch failed: %v", err)
+	}
+
+	var got []string
+	for _, change := range result.Changes {
+		got = append(got, change.Path+":"+change.Change)
+	}
+	want := []string{".env:retargeted", "component.sh:added", "global.sh:removed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Changes = %v, want %v", got, want)
+	}
+	if result.FromScope != stores.ScopeGlobal {
+		t.Errorf("FromScope = %q, want the active store's scope", result.FromScope)
+	}
+	if len(ws.Paths) != 0 {
+		t.Errorf("workspace state was modified: %v", ws.Paths)
+	}
+	if entries, _ := os.ReadDir(repoDir); len(entries) != 0 {
+		t.Errorf("workspace was modified: %v", entries)
+	}
+}
@@ -2,6 +2,7 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,7 +15,9 @@ import (
 
 // StackApply applies all stores in the configured stack to the workspace.
 // This does not include the active store - only stores added via 'stack add'.
+// ApplyAll applies the stack and the active store together.
 func (e *Engine) StackApply(ctx context.Context, req *StackApplyRequest) (*StackApplyResult, error) {
+	req = withDefaultMode(e, req)
 	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover workspace: %w", err)
@@ -28,47 +31,33 @@ func (e *Engine) StackApply(ctx context.Context, req *StackApplyRequest) (*Stack
 		return nil, fmt.Errorf("%w: stack is empty (use 'stack add' first)", ErrValidation)
 	}
 
-	// If workspace state exists, verify mode matches
-	if workspaceState.Applied && workspaceState.Mode != req.Mode {
-		return nil, fmt.Errorf("%w: existing mode is %s, requested mode is %s", ErrValidation, workspaceState.Mode, req.Mode)
-	}
+	// Mode compatibility is checked per path by the planner against each
+	// PathOwnership.Type, so a workspace may mix symlink and copy paths.
 
 	// Build apply plan using only stack stores (no active store)
 	orderedStores := append([]string{}, workspaceState.Stack...)
 
 	// Resolve each stack store's scope and build a MultiStoreRepo
-	storeMapping := make(map[string]stores.StoreRepo)
-	for _, sid := range orderedStores {
-		locations, findErr := e.findStore(sid)
-		if findErr != nil {
-			return nil, fmt.Errorf("failed to find store %s: %w", sid, findErr)
-		}
-		if len(locations) > 0 {
-			// Prefer component scope if available
-			for _, loc := range locations {
-				if loc.Scope == stores.ScopeComponent {
-					storeMapping[sid] = loc.Repo
-					break
-				}
-			}
-			if _, ok := storeMapping[sid]; !ok {
-				storeMapping[sid] = locations[0].Repo
-			}
-		}
+	multiRepo, scopes, err := e.multiStoreRepo(orderedStores)
+	if err != nil {
+		return nil, err
 	}
-	multiRepo := stores.NewMultiStoreRepo(storeMapping, e.storeRepo)
 
 	// Always detect conflicts (force=false for detection)
-	plan, err := planner.BuildApplyPlan(
+	plan, err := planner.BuildApplyPlanWith(
 		workspaceState,
 		orderedStores,
 		req.Mode,
-		root,
+		filepath.Join(root, workspaceState.WorkspacePath),
 		multiRepo,
 		e.fs,
-		false, // Always detect conflicts in planning phase
+		planner.PlanOptions{Vars: req.Vars, Hasher: e.hasher}, // Always detect conflicts in planning phase
 	)
 	if err != nil {
+		if errors.Is(err, planner.ErrProtectedPath) || errors.Is(err, planner.ErrMissingRequiredFile) ||
+			errors.Is(err, planner.ErrInvalidCondition) {
+			return nil, fmt.Errorf("%w: %w", ErrValidation, err)
+		}
 		return nil, fmt.Errorf("failed to build apply plan: %w", err)
 	}
 
@@ -94,54 +83,106 @@ func (e *Engine) StackApply(ctx context.Context, req *StackApplyRequest) (*Stack
 		}, nil
 	}
 
-	// Apply overlays
+	if err := e.checkAppliedState(workspaceID, workspaceState); err != nil {
+		return nil, err
+	}
+	appliedOps, cancelErr := e.executeStorePlan(ctx, plan, workspaceState, req.Mode, multiRepo, scopes)
+	if appliedOps == nil {
+		return nil, cancelErr
+	}
+
+	if cancelErr == nil {
+		workspaceState.RefreshAppliedStores()
+	}
+
+	// Save even when cancelled so paths placed so far stay managed
+	if err := e.saveAppliedState(workspaceID, workspaceState, appliedOps, stackForceHint); err != nil {
+		return nil, err
+	}
+
+	return &StackApplyResult{
+		Plan:            plan,
+		Applied:         appliedOps,
+		WorkspaceID:     workspaceID,
+		RepoFingerprint: repoFingerprint,
+		WorkspacePath:   workspacePath,
+	}, cancelErr
+}
+
+// executeStorePlan runs a plan's operations for stores applied into the
+// workspace, recording ownership of each placed path in workspaceState. If
+// the context is cancelled it stops between operations and returns those
+// applied so far with the context's error; any other failure returns nil
+// operations. scopes maps each planned store to its resolved scope.
+func (e *Engine) executeStorePlan(ctx context.Context, plan *planner.ApplyPlan, workspaceState *state.WorkspaceState, mode string, repo stores.StoreRepo, scopes map[string]string) ([]planner.Operation, error) {
+	// Paths inside a compacted directory are recorded individually again
+	if err := e.expandCompactedForPlan(workspaceState, plan.Operations); err != nil {
+		return nil, err
+	}
+
 	appliedOps := []planner.Operation{}
+	executor := e.newOpExecutor(repo)
+	if err := executor.checkPlan(plan.Operations); err != nil {
+		return nil, err
+	}
 	for _, op := range plan.Operations {
-		if err := e.executeOperation(op); err != nil {
-			return nil, fmt.Errorf("failed to execute operation: %w", err)
+		if err := ctx.Err(); err != nil {
+			return appliedOps, err
+		}
+		if err := executor.execute(op); err != nil {
+			return nil, err
 		}
 		appliedOps = append(appliedOps, op)
 
 		// Update workspace state for non-remove operations
 		if op.Type != planner.OpRemove {
-			ownership := state.PathOwnership{
-				Store:     op.Store,
-				Type:      req.Mode,
-				Timestamp: e.clock.Now(),
-			}
-
-			// Compute checksum for copy mode (files only, not directories)
-			if req.Mode == "copy" {
-				info, err := e.fs.Lstat(op.DestPath)
-				if err == nil && !info.IsDir() {
-					checksum, err := e.hasher.HashFile(op.DestPath)
-					if err == nil {
-						ownership.Checksum = checksum
-					}
-				}
-			}
-
 			// Use relative path as key for workspace state
-			workspaceState.Paths[op.RelPath] = ownership
+			workspaceState.Paths[op.RelPath] = e.appliedOwnership(op, scopes[op.Store], mode)
 		} else {
 			// Remove operation - delete from workspace state
 			delete(workspaceState.Paths, op.RelPath)
 		}
 	}
+	return appliedOps, nil
+}
 
-	workspaceState.RefreshAppliedStores()
-
-	if err := e.stateStore.SaveWorkspace(workspaceID, workspaceState); err != nil {
-		return nil, fmt.Errorf("failed to save workspace state: %w", err)
+// multiStoreRepo resolves each store's scope, preferring component scope,
+// and returns a repo that serves every store from its own scope along with
+// the scope resolved for each store.
+func (e *Engine) multiStoreRepo(storeIDs []string) (stores.StoreRepo, map[string]string, error) {
+	storeMapping, scopes, err := e.storeRepoMapping(storeIDs)
+	if err != nil {
+		return nil, nil, err
 	}
+	return stores.NewMultiStoreRepo(storeMapping, e.storeRepo), scopes, nil
+}
 
-	return &StackApplyResult{
-		Plan:            plan,
-		Applied:         appliedOps,
-		WorkspaceID:     workspaceID,
-		RepoFingerprint: repoFingerprint,
-		WorkspacePath:   workspacePath,
-	}, nil
+// storeRepoMapping maps each store to the repo and scope it resolves to,
+// preferring component scope. Scope-qualified IDs such as "global/foo" only
+// resolve in their own scope, so both "global/foo" and "component/foo" can
+// be mapped side by side. Stores that are not found are left unmapped.
+func (e *Engine) storeRepoMapping(storeIDs []string) (map[string]stores.StoreRepo, map[string]string, error) {
+	storeMapping := make(map[string]stores.StoreRepo)
+	scopes := make(map[string]string)
+	for _, sid := range storeIDs {
+		locations, err := e.findStore(sid)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find store %s: %w", sid, err)
+		}
+		if len(locations) > 0 {
+			// Prefer component scope if available
+			loc := locations[0]
+			for _, l := range locations {
+				if l.Scope == stores.ScopeComponent {
+					loc = l
+					break
+				}
+			}
+			storeMapping[sid] = loc.Repo
+			scopes[sid] = loc.Scope
+		}
+	}
+	return storeMapping, scopes, nil
 }
 
 // StackUnapply removes only paths applied by the stack stores.
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/danieljhkim/monodev/internal/state"
+)
+
+// StackExportFileName is the conventional file name for a shared stack export.
+const StackExportFileName = ".monodev-stack.json"
+
+// stackExportSchemaVersion is the current version of the StackExport format.
+const stackExportSchemaVersion = 1
+
+// StackExport is the portable representation of a workspace's stack.
+type StackExport struct {
+	// SchemaVersion is the version of the export format
+	SchemaVersion int `json:"schemaVersion"`
+
+	// Stack is the ordered list of stack stores
+	Stack []string `json:"stack"`
+
+	// ActiveStore is the active store, if any
+	ActiveStore string `json:"activeStore,omitempty"`
+
+	// ActiveStoreScope is the scope of the active store, if known
+	ActiveStoreScope string `json:"activeStoreScope,omitempty"`
+
+	// Mode is the overlay mode the workspace was last applied with
+	Mode string `json:"mode,omitempty"`
+}
+
+// ExportStack writes the workspace's stack, active store, and mode to req.Writer.
+func (e *Engine) ExportStack(ctx context.Context, req *ExportStackRequest) error {
+	if req.Writer == nil {
+		return fmt.Errorf("%w: writer is required", ErrValidation)
+	}
+
+	_, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
+	if err != nil {
+		return fmt.Errorf("failed to discover workspace: %w", err)
+	}
+
+	workspaceID := state.ComputeWorkspaceID(repoFingerprint, workspacePath)
+	workspaceState, err := e.stateStore.LoadWorkspace(workspaceID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: workspace has no stack to export", ErrStateMissing)
+		}
+		return fmt.Errorf("failed to load workspace state: %w", err)
+	}
+
+	export := StackExport{
+		SchemaVersion:    stackExportSchemaVersion,
+		Stack:            workspaceState.Stack,
+		ActiveStore:      workspaceState.ActiveStore,
+		ActiveStoreScope: workspaceState.ActiveStoreScope,
+		Mode:             workspaceState.Mode,
+	}
+	if export.Stack == nil {
+		export.Stack = []string{}
+	}
+
+	enc := json.NewEncoder(req.Writer)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return fmt.Errorf("failed to write stack export: %w", err)
+	}
+
+	return nil
+}
+
+// ImportStack restores a stack exported by ExportStack into the current workspace.
+// Every referenced store must exist. When req.Apply is set, the imported stack
+// is applied with StackApply using the exported mode.
+func (e *Engine) ImportStack(ctx context.Context, req *ImportStackRequest) (*ImportStackResult, error) {
+	if req.Reader == nil {
+		return nil, fmt.Errorf("%w: reader is required", ErrValidation)
+	}
+
+	var export StackExport
+	if err := json.NewDecoder(req.Reader).Decode(&export); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse stack export: %v", ErrValidation, err)
+	}
+	if export.SchemaVersion != stackExportSchemaVersion {
+		return nil, fmt.Errorf("%w: unsupported stack export schema version %d", ErrValidation, export.SchemaVersion)
+	}
+
+	// Validate referenced stores before touching workspace state
+	for _, storeID := range export.Stack {
+		locations, err := e.findStore(storeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if store exists: %w", err)
+		}
+		if len(locations) == 0 {
+			return nil, fmt.Errorf("%w: store %s does not exist", ErrNotFound, storeID)
+		}
+	}
+	activeScope := ""
+	if export.ActiveStore != "" {
+		_, scope, err := e.resolveStoreRepo(export.ActiveStore, export.ActiveStoreScope)
+		if err != nil {
+			return nil, err
+		}
+		activeScope = scope
+	}
+
+	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover workspace: %w", err)
+	}
+
+	mode := export.Mode
+	if mode == "" {
+		mode = "copy"
+	}
+
+	workspaceState, workspaceID, err := e.LoadOrCreateWorkspaceState(root, repoFingerprint, workspacePath, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load or create workspace state: %w", err)
+	}
+
+	workspaceState.Stack = append([]string{}, export.Stack...)
+	if export.ActiveStore != "" {
+		workspaceState.ActiveStore = export.ActiveStore
+		workspaceState.ActiveStoreScope = activeScope
+	}
+
+	if err := e.stateStore.SaveWorkspace(workspaceID, workspaceState); err != nil {
+		return nil, fmt.Errorf("failed to save workspace state: %w", err)
+	}
+
+	result := &ImportStackResult{
+		Stack:       workspaceState.Stack,
+		ActiveStore: workspaceState.ActiveStore,
+		WorkspaceID: workspaceID,
+	}
+
+	if req.Apply && len(workspaceState.Stack) > 0 {
+		applyResult, err := e.StackApply(ctx, &StackApplyRequest{
+			CWD:   req.CWD,
+			Mode:  mode,
+			Force: req.Force,
+		})
+		result.Applied = applyResult
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieljhkim/monodev/internal/config"
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// setupStackExportStores creates two stores with one tracked file each.
+func setupStackExportStores(t *testing.T) (stores.StoreRepo, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	if err := os.MkdirAll(filepath.Join(repoDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	storeRepo := stores.NewFileStoreRepo(fsops.NewRealFS(), filepath.Join(tmpDir, "stores"))
+	for storeID, file := range map[string]string{"base": "base.mk", "team": "team.mk"} {
+		if err := storeRepo.Create(storeID, stores.NewStoreMeta(storeID, stores.ScopeGlobal, time.Now())); err != nil {
+			t.Fatal(err)
+		}
+		track := stores.NewTrackFile()
+		track.Tracked = []stores.TrackedPath{{Path: file, Kind: "file"}}
+		if err := storeRepo.SaveTrack(storeID, track); err != nil {
+			t.Fatal(err)
+		}
+		overlayFile := filepath.Join(storeRepo.OverlayRoot(storeID), file)
+		if err := os.MkdirAll(filepath.Dir(overlayFile), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(overlayFile, []byte(storeID), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return storeRepo, repoDir
+}
+
+func TestExportImportStack_RoundTrip(t *testing.T) {
+	storeRepo, repoDir := setupStackExportStores(t)
+	stateStore := newMockStateStore()
+	realFS := fsops.NewRealFS()
+
+	// Source workspace at the repo root with a two-store stack
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.Stack = []string{"base", "team"}
+	ws.ActiveStore = "team"
+	stateStore.workspaces[state.ComputeWorkspaceID("fp1", ".")] = ws
+
+	srcEng := New(&trackGitRepo{root: repoDir, fingerprint: "fp1", workspacePath: "."},
+		storeRepo, stateStore, realFS, &mockHasher{}, &mockClock{}, config.Paths{})
+
+	var buf bytes.Buffer
+	if err := srcEng.ExportStack(context.Background(), &ExportStackRequest{CWD: repoDir, Writer: &buf}); err != nil {
+		t.Fatalf("ExportStack failed: %v", err)
+	}
+
+	// Import into a fresh workspace in a subdirectory and apply
+	subDir := filepath.Join(repoDir, "sub")
+	dstEng := New(&trackGitRepo{root: repoDir, fingerprint: "fp1", workspacePath: "sub"},
+		storeRepo, stateStore, realFS, &mockHasher{}, &mockClock{}, config.Paths{})
+
+	result, err := dstEng.ImportStack(context.Background(), &ImportStackRequest{CWD: subDir, Reader: &buf, Apply: true})
+	if err != nil {
+		t.Fatalf("ImportStack failed: %v", err)
+	}
+
+	if strings.Join(result.Stack, ",") != "base,team" {
+		t.Errorf("Stack = %v, want [base team]", result.Stack)
+	}
+	if result.ActiveStore != "team" {
+		t.Errorf("ActiveStore = %q, want team", result.ActiveStore)
+	}
+	imported := stateStore.workspaces[state.ComputeWorkspaceID("fp1", "sub")]
+	if imported == nil || strings.Join(imported.Stack, ",") != "base,team" {
+		t.Fatalf("imported workspace state = %+v, want stack [base team]", imported)
+	}
+	if result.Applied == nil {
+		t.Fatal("expected StackApply result")
+	}
+	for _, name := range []string{"base.mk", "team.mk"} {
+		if _, err := os.Stat(filepath.Join(subDir, name)); err != nil {
+			t.Errorf("expected %s applied into imported workspace: %v", name, err)
+		}
+	}
+}
+
+func TestImportStack_RejectsMissingStore(t *testing.T) {
+	storeRepo, repoDir := setupStackExportStores(t)
+	stateStore := newMockStateStore()
+	eng := New(&trackGitRepo{root: repoDir, fingerprint: "fp1", workspacePath: "."},
+		storeRepo, stateStore, fsops.NewRealFS(), &mockHasher{}, &mockClock{}, config.Paths{})
+
+	input := strings.NewReader(`{"schemaVersion": 1, "stack": ["base", "ghost"]}`)
+	_, err := eng.ImportStack(context.Background(), &ImportStackRequest{CWD: repoDir, Reader: input})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if len(stateStore.workspaces) != 0 {
+		t.Error("expected no workspace state to be written")
+	}
+}
@@ -36,39 +36,67 @@ func (e *Engine) StackList(ctx context.Context, req *StackListRequest) (*StackLi
 }
 
 // StackAdd adds a store to the stack.
-func (e *Engine) StackAdd(ctx context.Context, req *StackAddRequest) error {
+// The store is appended unless Position selects an index, and the stack is
+// applied afterwards when Apply is set.
+func (e *Engine) StackAdd(ctx context.Context, req *StackAddRequest) (*StackAddResult, error) {
 	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
 	if err != nil {
-		return fmt.Errorf("failed to discover workspace: %w", err)
+		return nil, fmt.Errorf("failed to discover workspace: %w", err)
 	}
 	workspaceState, workspaceID, err := e.LoadOrCreateWorkspaceState(root, repoFingerprint, workspacePath, "copy")
 	if err != nil {
-		return fmt.Errorf("failed to load or create workspace state: %w", err)
+		return nil, fmt.Errorf("failed to load or create workspace state: %w", err)
 	}
 
 	// Verify store exists in either scope
 	locations, err := e.findStore(req.StoreID)
 	if err != nil {
-		return fmt.Errorf("failed to check if store exists: %w", err)
+		return nil, fmt.Errorf("failed to check if store exists: %w", err)
 	}
 	if len(locations) == 0 {
-		return fmt.Errorf("%w: store %s does not exist", ErrNotFound, req.StoreID)
+		return nil, fmt.Errorf("%w: store %s does not exist", ErrNotFound, req.StoreID)
 	}
 
 	// Check for duplicates
 	if slices.Contains(workspaceState.Stack, req.StoreID) {
-		return fmt.Errorf("%w: store %s is already in the stack", ErrValidation, req.StoreID)
+		return nil, fmt.Errorf("%w: store %s is already in the stack", ErrValidation, req.StoreID)
+	}
+
+	// Resolve the insert position (-1 appends)
+	position := len(workspaceState.Stack)
+	if req.Position != nil && *req.Position != -1 {
+		if *req.Position < 0 || *req.Position > len(workspaceState.Stack) {
+			return nil, fmt.Errorf("%w: position %d is out of range (0-%d, or -1 to append)", ErrValidation, *req.Position, len(workspaceState.Stack))
+		}
+		position = *req.Position
 	}
 
 	// Add to stack
-	workspaceState.Stack = append(workspaceState.Stack, req.StoreID)
+	workspaceState.Stack = slices.Insert(workspaceState.Stack, position, req.StoreID)
 
 	// Save workspace state
 	if err := e.stateStore.SaveWorkspace(workspaceID, workspaceState); err != nil {
-		return fmt.Errorf("failed to save workspace state: %w", err)
+		return nil, fmt.Errorf("failed to save workspace state: %w", err)
 	}
 
-	return nil
+	result := &StackAddResult{
+		Stack:    workspaceState.Stack,
+		Position: position,
+	}
+	if !req.Apply {
+		return result, nil
+	}
+
+	applied, err := e.StackApply(ctx, &StackApplyRequest{
+		CWD:   req.CWD,
+		Mode:  req.Mode,
+		Force: req.Force,
+	})
+	result.Applied = applied
+	if err != nil {
+		return result, fmt.Errorf("failed to apply stack: %w", err)
+	}
+	return result, nil
 }
 
 // StackPop removes a store from the stack.
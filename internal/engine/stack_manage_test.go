@@ -0,0 +1,210 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/state"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// setupStackAddEngine creates stores that each track shared.txt with their
+// own ID as content, and a workspace whose stack is initialStack.
+func setupStackAddEngine(t *testing.T, storeIDs, initialStack []string) (*Engine, string) {
+	t.Helper()
+	eng, repoDir := setupDiffEngine(t, nil, nil)
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, storeID := range storeIDs {
+		addTrackedStore(t, eng, storeID, map[string]string{"shared.txt": storeID})
+	}
+
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.Stack = initialStack
+	if err := eng.stateStore.SaveWorkspace(state.ComputeWorkspaceID("fp1", "."), ws); err != nil {
+		t.Fatal(err)
+	}
+	return eng, repoDir
+}
+
+func TestStackAdd_InsertAtFrontAndApply(t *testing.T) {
+	eng, repoDir := setupStackAddEngine(t, []string{"base", "team"}, []string{"base"})
+
+	front := 0
+	result, err := eng.StackAdd(context.Background(), &StackAddRequest{
+		CWD:      repoDir,
+		StoreID:  "team",
+		Position: &front,
+		Apply:    true,
+		Mode:     "copy",
+	})
+	if err != nil {
+		t.Fatalf("StackAdd failed: %v", err)
+	}
+
+	if want := []string{"team", "base"}; !slices.Equal(result.Stack, want) {
+		t.Errorf("Stack = %v, want %v", result.Stack, want)
+	}
+	if result.Position != 0 {
+		t.Errorf("Position = %d, want 0", result.Position)
+	}
+	if result.Applied == nil {
+		t.Fatal("expected stack to be applied")
+	}
+
+	// The store inserted at the front has the lowest precedence
+	data, err := os.ReadFile(filepath.Join(repoDir, "shared.txt"))
+	if err != nil {
+		t.Fatalf("expected shared.txt to be applied: %v", err)
+	}
+	if string(data) != "base" {
+		t.Errorf("shared.txt = %q, want %q", data, "base")
+	}
+}
+
+func TestStackAdd_DefaultAppends(t *testing.T) {
+	eng, repoDir := setupStackAddEngine(t, []string{"base", "team", "extra"}, []string{"base"})
+	ctx := context.Background()
+
+	appendPos := -1
+	for _, req := range []*StackAddRequest{
+		{CWD: repoDir, StoreID: "team"},
+		{CWD: repoDir, StoreID: "extra", Position: &appendPos},
+	} {
+		if _, err := eng.StackAdd(ctx, req); err != nil {
+			t.Fatalf("StackAdd(%s) failed: %v", req.StoreID, err)
+		}
+	}
+
+	list, err := eng.StackList(ctx, &StackListRequest{CWD: repoDir})
+	if err != nil {
+		t.Fatalf("StackList failed: %v", err)
+	}
+	if want := []string{"base", "team", "extra"}; !slices.Equal(list.Stack, want) {
+		t.Errorf("Stack = %v, want %v", list.Stack, want)
+	}
+}
+
+func TestStackAdd_RejectsInvalidPositionAndDuplicates(t *testing.T) {
+	eng, repoDir := setupStackAddEngine(t, []string{"base", "team"}, []string{"base"})
+	ctx := context.Background()
+
+	for _, position := range []int{2, -2} {
+		pos := position
+		_, err := eng.StackAdd(ctx, &StackAddRequest{CWD: repoDir, StoreID: "team", Position: &pos})
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("position %d: expected ErrValidation, got %v", position, err)
+		}
+	}
+
+	front := 0
+	if _, err := eng.StackAdd(ctx, &StackAddRequest{CWD: repoDir, StoreID: "base", Position: &front}); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected ErrValidation for duplicate store, got %v", err)
+	}
+
+	list, err := eng.StackList(ctx, &StackListRequest{CWD: repoDir})
+	if err != nil {
+		t.Fatalf("StackList failed: %v", err)
+	}
+	if want := []string{"base"}; !slices.Equal(list.Stack, want) {
+		t.Errorf("Stack = %v after rejected adds, want %v", list.Stack, want)
+	}
+}
+
+func TestPreviewStack_ComparesWithAppliedStack(t *testing.T) {
+	eng, repoDir := setupStackAddEngine(t, []string{"base", "team"}, []string{"base"})
+
+	// Each store also tracks a file of its own
+	for _, storeID := range []string{"base", "team"} {
+		track, err := eng.storeRepo.LoadTrack(storeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		track.Tracked = append(track.Tracked, stores.TrackedPath{Path: storeID + ".txt", Kind: "file"})
+		if err := eng.storeRepo.SaveTrack(storeID, track); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(eng.storeRepo.OverlayRoot(storeID), storeID+".txt"), []byte(storeID), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := eng.StackApply(context.Background(), &StackApplyRequest{CWD: repoDir, Mode: "copy"}); err != nil {
+		t.Fatalf("StackApply failed: %v", err)
+	}
+
+	result, err := eng.PreviewStack(context.Background(), &PreviewStackRequest{
+		CWD:    repoDir,
+		Stores: []string{"team"},
+		Mode:   "copy",
+	})
+	if err != nil {
+		t.Fatalf("PreviewStack failed: %v", err)
+	}
+
+	var got []string
+	for _, change := range result.Changes {
+		got = append(got, change.Path+":"+change.Change+":"+change.FromStore+">"+change.ToStore)
+	}
+	want := []string{"base.txt:removed:base>", "shared.txt:reowned:base>team", "team.txt:added:>team"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Changes = %v, want %v", got, want)
+	}
+
+	// Previewing is read-only
+	if _, err := os.Stat(filepath.Join(repoDir, "team.txt")); !os.IsNotExist(err) {
+		t.Errorf("preview should not install files, stat err = %v", err)
+	}
+
+	// Proposing the applied stack again changes nothing
+	result, err = eng.PreviewStack(context.Background(), &PreviewStackRequest{CWD: repoDir, Stores: []string{"base"}, Mode: "copy"})
+	if err != nil {
+		t.Fatalf("PreviewStack failed: %v", err)
+	}
+	if len(result.Changes) != 0 {
+		t.Errorf("expected no changes for the applied stack, got %+v", result.Changes)
+	}
+
+	if _, err := eng.PreviewStack(context.Background(), &PreviewStackRequest{CWD: repoDir, Stores: []string{"missing"}}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for unknown store, got %v", err)
+	}
+}
+
+func TestStackApply_EvaluatesConditionsWithVars(t *testing.T) {
+	eng, repoDir := setupStackAddEngine(t, []string{"base"}, []string{"base"})
+	storeRepo := stores.NewFileStoreRepo(fsops.NewRealFS(), filepath.Join(filepath.Dir(repoDir), "stores"))
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "shared.txt", Kind: "file", When: "DEBUG==1"}}
+	if err := storeRepo.SaveTrack("base", track); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	result, err := eng.StackApply(ctx, &StackApplyRequest{CWD: repoDir, Mode: "copy", DryRun: true})
+	if err != nil {
+		t.Fatalf("StackApply without vars failed: %v", err)
+	}
+	if len(result.Plan.Operations) != 0 {
+		t.Errorf("expected shared.txt to be skipped without DEBUG, got %+v", result.Plan.Operations)
+	}
+
+	if _, err := eng.StackApply(ctx, &StackApplyRequest{CWD: repoDir, Mode: "copy", Vars: map[string]string{"DEBUG": "1"}}); err != nil {
+		t.Fatalf("StackApply with vars failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, "shared.txt")); err != nil {
+		t.Errorf("expected shared.txt to be applied with DEBUG=1: %v", err)
+	}
+
+	track.Tracked[0].When = "DEBUG=1"
+	if err := storeRepo.SaveTrack("base", track); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eng.ApplyAll(ctx, &ApplyAllRequest{CWD: repoDir, Mode: "copy", Vars: map[string]string{"DEBUG": "1"}}); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected ErrValidation for an invalid condition, got %v", err)
+	}
+}
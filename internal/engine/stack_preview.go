@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/state"
+)
+
+// PreviewStack reports how the workspace's managed paths would change if the
+// proposed stack replaced what is applied now. The stores are planned
+// together against an empty workspace, and the path each store would end up
+// owning is compared with current ownership. Nothing is modified.
+func (e *Engine) PreviewStack(ctx context.Context, req *PreviewStackRequest) (*PreviewStackResult, error) {
+	if len(req.Stores) == 0 {
+		return nil, fmt.Errorf("%w: at least one store is required", ErrValidation)
+	}
+	mode := req.Mode
+	if mode == "" {
+		mode = e.defaultMode()
+	}
+
+	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover workspace: %w", err)
+	}
+	workspaceState, _, err := e.LoadOrCreateWorkspaceState(root, repoFingerprint, workspacePath, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workspace state: %w", err)
+	}
+
+	multiRepo, scopes, err := e.multiStoreRepo(req.Stores)
+	if err != nil {
+		return nil, err
+	}
+	for _, storeID := range req.Stores {
+		if _, ok := scopes[storeID]; !ok {
+			return nil, fmt.Errorf("%w: store '%s'", ErrNotFound, storeID)
+		}
+	}
+
+	// Force keeps files already in the workspace from showing up as
+	// conflicts; only the final install of each path matters
+	plan, err := planner.BuildApplyPlanWith(
+		state.NewWorkspaceState(repoFingerprint, workspacePath, mode),
+		req.Stores,
+		mode,
+		filepath.Join(root, workspacePath),
+		multiRepo,
+		e.fs,
+		planner.PlanOptions{Vars: req.Vars, Force: true, Hasher: e.hasher},
+	)
+	if err != nil {
+		if errors.Is(err, planner.ErrProtectedPath) || errors.Is(err, planner.ErrMissingRequiredFile) ||
+			errors.Is(err, planner.ErrInvalidCondition) {
+			return nil, fmt.Errorf("%w: %w", ErrValidation, err)
+		}
+		return nil, fmt.Errorf("failed to build apply plan: %w", err)
+	}
+	planned := make(map[string]string)
+	for _, op := range plan.Operations {
+		if op.Type == planner.OpRemove {
+			delete(planned, op.RelPath)
+		} else {
+			planned[op.RelPath] = op.Store
+		}
+	}
+
+	result := &PreviewStackResult{
+		Stores:  append([]string{}, req.Stores...),
+		Mode:    mode,
+		Changes: []StackPathChange{},
+	}
+	for _, relPath := range slices.Sorted(maps.Keys(planned)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		storeID := planned[relPath]
+		change := StackPathChange{Path: relPath, ToStore: storeID, ToScope: scopes[storeID]}
+		ownership, managed := workspaceState.Owner(relPath)
+		switch {
+		case !managed:
+			change.Change = StackChangeAdded
+		case ownership.Store != storeID || (ownership.StoreScope != "" && ownership.StoreScope != change.ToScope):
+			change.Change = StackChangeReowned
+			change.FromStore, change.FromScope = ownership.Store, ownership.StoreScope
+		default:
+			continue
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	for _, relPath := range slices.Sorted(maps.Keys(workspaceState.Paths)) {
+		if coversPlanned(relPath, workspaceState.Paths[relPath], planned) {
+			continue
+		}
+		ownership := workspaceState.Paths[relPath]
+		result.Changes = append(result.Changes, StackPathChange{
+			Path:      relPath,
+			Change:    StackChangeRemoved,
+			FromStore: ownership.Store,
+			FromScope: ownership.StoreScope,
+		})
+	}
+	slices.SortStableFunc(result.Changes, func(a, b StackPathChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return result, nil
+}
+
+// coversPlanned reports whether a managed path is installed by the plan. A
+// compacted directory is covered by any planned path inside it.
+func coversPlanned(relPath string, ownership state.PathOwnership, planned map[string]string) bool {
+	if _, ok := planned[relPath]; ok {
+		return true
+	}
+	if !ownership.Compacted {
+		return false
+	}
+	prefix := relPath + string(filepath.Separator)
+	for plannedPath := range planned {
+		if strings.HasPrefix(plannedPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/danieljhkim/monodev/internal/planner"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// stagingDirName is the hidden directory, created under the apply root,
+// where a staged install builds its result before swapping it into place.
+const stagingDirName = ".monodev-staging"
+
+// opExecutor executes apply operations together with the steps that finish
+// the files a copy places: resolving large-file pointers, normalizing line
+// endings, and setting modification times.
+type opExecutor struct {
+	e          *Engine
+	resolver   *blobResolver
+	normalizer *eolNormalizer
+	stamper    *mtimeStamper
+}
+
+// newOpExecutor creates an executor reading store settings from repo.
+func (e *Engine) newOpExecutor(repo stores.StoreRepo) *opExecutor {
+	return &opExecutor{
+		e:          e,
+		resolver:   e.newBlobResolver(),
+		normalizer: e.newEOLNormalizer(repo),
+		stamper:    e.newMtimeStamper(repo),
+	}
+}
+
+// checkPlan rejects, before anything runs, a plan whose operations cannot
+// be completed, such as copies of pointers whose blob is not cached.
+func (x *opExecutor) checkPlan(ops []planner.Operation) error {
+	return x.resolver.checkPlan(ops)
+}
+
+// execute runs op and finishes the files it placed.
+func (x *opExecutor) execute(op planner.Operation) error {
+	if err := x.resolver.check(op); err != nil {
+		return err
+	}
+	if err := x.e.executeOperation(op); err != nil {
+		return fmt.Errorf("failed to execute operation: %w", err)
+	}
+	if err := x.resolver.apply(op); err != nil {
+		return err
+	}
+	if err := x.normalizer.apply(op); err != nil {
+		return fmt.Errorf("failed to normalize copied files: %w", err)
+	}
+	return x.stamper.apply(op)
+}
+
+// stagedInstall holds the copies and symlinks of a plan built in a staging
+// directory, ready to be renamed into place.
+type stagedInstall struct {
+	e      *Engine
+	dir    string
+	staged map[int]string
+}
+
+// stageOperations builds every copy and symlink of the plan under a staging
+// directory in root, leaving the destinations untouched. Removals, backups
+// and directory creation are not staged; they run during the swap. On error
+// the staging directory is removed.
+func (e *Engine) stageOperations(ctx context.Context, root string, ops []planner.Operation, x *opExecutor) (*stagedInstall, error) {
+	s := &stagedInstall{e: e, dir: filepath.Join(root, stagingDirName), staged: make(map[int]string)}
+
+	// Clear anything left by an interrupted staged install
+	if err := e.fs.RemoveAll(s.dir); err != nil {
+		return nil, fmt.Errorf("failed to clear staging directory: %w", err)
+	}
+	if err := e.fs.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	for i, op := range ops {
+		if op.Type != planner.OpCopy && op.Type != planner.OpCreateSymlink {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			s.cleanup()
+			return nil, err
+		}
+		stagedOp := op
+		stagedOp.DestPath = filepath.Join(s.dir, strconv.Itoa(i))
+		if err := x.execute(stagedOp); err != nil {
+			s.cleanup()
+			return nil, fmt.Errorf("failed to stage %s: %w", op.RelPath, err)
+		}
+		s.staged[i] = stagedOp.DestPath
+	}
+	return s, nil
+}
+
+// install swaps the staged result of the i'th operation into place, or
+// executes the operation directly if it was not staged.
+func (s *stagedInstall) install(i int, op planner.Operation) error {
+	stagedPath, ok := s.staged[i]
+	if !ok {
+		return s.e.executeOperation(op)
+	}
+
+	// A destination still present belongs to the store being applied, so it
+	// is replaced just as a copy would overwrite it
+	exists, err := s.e.fs.Exists(op.DestPath)
+	if err != nil {
+		return fmt.Errorf("failed to check if path exists: %w", err)
+	}
+	if exists {
+		if err := s.e.fs.RemoveAll(op.DestPath); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", op.RelPath, err)
+		}
+	}
+	if err := s.e.fs.MkdirAll(filepath.Dir(op.DestPath), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	if err := s.e.fs.Rename(stagedPath, op.DestPath); err != nil {
+		return fmt.Errorf("failed to install %s: %w", op.RelPath, err)
+	}
+	return nil
+}
+
+// cleanup removes the staging directory and anything left in it.
+func (s *stagedInstall) cleanup() {
+	_ = s.e.fs.RemoveAll(s.dir)
+}
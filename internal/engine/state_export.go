@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danieljhkim/monodev/internal/state"
+)
+
+// stateBundleSchemaVersion is the current version of the StateBundle format.
+const stateBundleSchemaVersion = 1
+
+// StateBundle is the portable representation of every workspace state.
+type StateBundle struct {
+	// SchemaVersion is the version of the bundle format
+	SchemaVersion int `json:"schemaVersion"`
+
+	// ExportedAt is when the bundle was written
+	ExportedAt time.Time `json:"exportedAt"`
+
+	// Workspaces maps workspace IDs to their state
+	Workspaces map[string]*state.WorkspaceState `json:"workspaces"`
+}
+
+// ExportState writes the state of every workspace (in both scopes) to w as a
+// single JSON bundle, for backups or moving to another machine.
+func (e *Engine) ExportState(ctx context.Context, w io.Writer) error {
+	if w == nil {
+		return fmt.Errorf("%w: writer is required", ErrValidation)
+	}
+
+	sources, err := e.workspaceSources()
+	if err != nil {
+		return err
+	}
+
+	bundle := StateBundle{
+		SchemaVersion: stateBundleSchemaVersion,
+		ExportedAt:    e.clock.Now(),
+		Workspaces:    make(map[string]*state.WorkspaceState, len(sources)),
+	}
+	for _, src := range sources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ws, err := src.store.LoadWorkspace(src.id)
+		if err != nil {
+			// A state file removed since the directory was read is skipped
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to load workspace %s: %w", src.id, err)
+		}
+		bundle.Workspaces[src.id] = ws
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(bundle); err != nil {
+		return fmt.Errorf("failed to write state bundle: %w", err)
+	}
+
+	return nil
+}
+
+// ImportState restores the workspaces in a bundle written by ExportState.
+// Unless overwrite is set, nothing is imported if any workspace in the
+// bundle already has state.
+func (e *Engine) ImportState(ctx context.Context, r io.Reader, overwrite bool) (*ImportStateResult, error) {
+	if r == nil {
+		return nil, fmt.Errorf("%w: reader is required", ErrValidation)
+	}
+
+	var bundle StateBundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("%w: invalid state bundle: %v", ErrValidation, err)
+	}
+	if bundle.SchemaVersion > stateBundleSchemaVersion {
+		return nil, fmt.Errorf("%w: state bundle schema version %d is newer than supported version %d",
+			ErrValidation, bundle.SchemaVersion, stateBundleSchemaVersion)
+	}
+
+	ids := make([]string, 0, len(bundle.Workspaces))
+	for id, ws := range bundle.Workspaces {
+		if err := e.fs.ValidateIdentifier(id); err != nil {
+			return nil, fmt.Errorf("%w: invalid workspace ID %q: %v", ErrValidation, id, err)
+		}
+		if ws == nil {
+			return nil, fmt.Errorf("%w: workspace %s has no state", ErrValidation, id)
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Check every workspace before writing any, so a refused import changes nothing
+	result := &ImportStateResult{}
+	for _, id := range ids {
+		_, err := e.stateStore.LoadWorkspace(id)
+		if err == nil {
+			result.Overwritten = append(result.Overwritten, id)
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to load workspace %s: %w", id, err)
+		}
+	}
+	if len(result.Overwritten) > 0 && !overwrite {
+		return nil, fmt.Errorf("%w: workspaces already exist: %s (use overwrite to replace them)",
+			ErrValidation, strings.Join(result.Overwritten, ", "))
+	}
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := e.stateStore.SaveWorkspace(id, bundle.Workspaces[id]); err != nil {
+			return result, fmt.Errorf("failed to save workspace %s: %w", id, err)
+		}
+		result.Imported = append(result.Imported, id)
+	}
+
+	return result, nil
+}
+
+// workspaceSource is a workspace ID together with the state store whose
+// directory holds its state file.
+type workspaceSource struct {
+	id    string
+	store state.StateStore
+}
+
+// workspaceSources lists the workspaces with a state file in either scope,
+// sorted by ID. A workspace found in both scopes is taken from the global one.
+func (e *Engine) workspaceSources() ([]workspaceSource, error) {
+	type stateDir struct {
+		path  string
+		store state.StateStore
+	}
+	dirs := []stateDir{{path: e.configPaths.Workspaces, store: e.stateStore}}
+	if e.scopedPaths != nil && e.scopedPaths.Component != nil && e.componentStateStore != nil {
+		dirs = append(dirs, stateDir{path: e.scopedPaths.Component.Workspaces, store: e.componentStateStore})
+	}
+
+	seen := make(map[string]bool)
+	var sources []workspaceSource
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir.path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read workspaces directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			id := strings.TrimSuffix(entry.Name(), ".json")
+			if !seen[id] {
+				seen[id] = true
+				sources = append(sources, workspaceSource{id: id, store: dir.store})
+			}
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].id < sources[j].id })
+
+	return sources, nil
+}
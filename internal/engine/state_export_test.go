@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/config"
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/state"
+)
+
+// newStateExportEngine creates an engine backed by a file state store in a
+// fresh temp directory.
+func newStateExportEngine(t *testing.T) (*Engine, *state.FileStateStore) {
+	t.Helper()
+	workspacesDir := filepath.Join(t.TempDir(), "workspaces")
+	fs := fsops.NewRealFS()
+	stateStore := state.NewFileStateStore(fs, workspacesDir)
+	eng := &Engine{
+		fs:          fs,
+		stateStore:  stateStore,
+		clock:       &mockClock{},
+		configPaths: config.Paths{Workspaces: workspacesDir},
+	}
+	return eng, stateStore
+}
+
+func TestExportImportState_RoundTrip(t *testing.T) {
+	src, srcStore := newStateExportEngine(t)
+
+	ws1 := state.NewWorkspaceState("repo1", ".", "copy")
+	ws1.ActiveStore = "s1"
+	ws1.Stack = []string{"base"}
+	ws1.Paths["a.txt"] = state.PathOwnership{Store: "s1", Type: "copy", Checksum: "abc"}
+	ws2 := state.NewWorkspaceState("repo1", "services/api", "symlink")
+	ws2.ActiveStore = "s2"
+	if err := srcStore.SaveWorkspace("ws1", ws1); err != nil {
+		t.Fatal(err)
+	}
+	if err := srcStore.SaveWorkspace("ws2", ws2); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.ExportState(context.Background(), &buf); err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+
+	dst, dstStore := newStateExportEngine(t)
+	result, err := dst.ImportState(context.Background(), &buf, false)
+	if err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+	if len(result.Imported) != 2 || result.Imported[0] != "ws1" || result.Imported[1] != "ws2" {
+		t.Errorf("Imported = %v, want [ws1 ws2]", result.Imported)
+	}
+	if len(result.Overwritten) != 0 {
+		t.Errorf("Overwritten = %v, want none", result.Overwritten)
+	}
+
+	got1, err := dstStore.LoadWorkspace("ws1")
+	if err != nil {
+		t.Fatalf("ws1 not imported: %v", err)
+	}
+	if got1.ActiveStore != "s1" || len(got1.Stack) != 1 || got1.Paths["a.txt"].Checksum != "abc" {
+		t.Errorf("ws1 state not preserved: %+v", got1)
+	}
+	got2, err := dstStore.LoadWorkspace("ws2")
+	if err != nil {
+		t.Fatalf("ws2 not imported: %v", err)
+	}
+	if got2.WorkspacePath != "services/api" || got2.Mode != "symlink" || got2.ActiveStore != "s2" {
+		t.Errorf("ws2 state not preserved: %+v", got2)
+	}
+}
+
+func TestImportState_OverwriteGuard(t *testing.T) {
+	src, srcStore := newStateExportEngine(t)
+	incoming := state.NewWorkspaceState("repo1", ".", "copy")
+	incoming.ActiveStore = "incoming"
+	if err := srcStore.SaveWorkspace("ws1", incoming); err != nil {
+		t.Fatal(err)
+	}
+	if err := srcStore.SaveWorkspace("ws2", state.NewWorkspaceState("repo1", "other", "copy")); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := src.ExportState(context.Background(), &buf); err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+	bundle := buf.Bytes()
+
+	dst, dstStore := newStateExportEngine(t)
+	existing := state.NewWorkspaceState("repo1", ".", "copy")
+	existing.ActiveStore = "existing"
+	if err := dstStore.SaveWorkspace("ws1", existing); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := dst.ImportState(context.Background(), bytes.NewReader(bundle), false)
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if ws, _ := dstStore.LoadWorkspace("ws1"); ws.ActiveStore != "existing" {
+		t.Errorf("existing workspace was modified: %+v", ws)
+	}
+	if _, err := dstStore.LoadWorkspace("ws2"); err == nil {
+		t.Error("refused import should not write any workspace")
+	}
+
+	result, err := dst.ImportState(context.Background(), bytes.NewReader(bundle), true)
+	if err != nil {
+		t.Fatalf("ImportState with overwrite failed: %v", err)
+	}
+	if len(result.Overwritten) != 1 || result.Overwritten[0] != "ws1" {
+		t.Errorf("Overwritten = %v, want [ws1]", result.Overwritten)
+	}
+	if ws, _ := dstStore.LoadWorkspace("ws1"); ws.ActiveStore != "incoming" {
+		t.Errorf("ws1 not overwritten: %+v", ws)
+	}
+}
+
+func TestExportState_IncludesComponentOnlyWorkspaces(t *testing.T) {
+	eng, globalStore := newStateExportEngine(t)
+	componentDir := filepath.Join(t.TempDir(), "workspaces")
+	componentStore := state.NewFileStateStore(eng.fs, componentDir)
+	eng.scopedPaths = &config.ScopedPaths{Component: &config.Paths{Workspaces: componentDir}}
+	eng.componentStateStore = componentStore
+
+	if err := globalStore.SaveWorkspace("global-ws", state.NewWorkspaceState("repo1", ".", "copy")); err != nil {
+		t.Fatal(err)
+	}
+	if err := componentStore.SaveWorkspace("component-ws", state.NewWorkspaceState("repo1", "web", "symlink")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := eng.ExportState(context.Background(), &buf); err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+	var bundle StateBundle
+	if err := json.Unmarshal(buf.Bytes(), &bundle); err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Workspaces) != 2 {
+		t.Fatalf("Workspaces = %v, want both scopes", bundle.Workspaces)
+	}
+	if ws := bundle.Workspaces["component-ws"]; ws == nil || ws.WorkspacePath != "web" {
+		t.Errorf("component-ws = %+v, want the component-scope state", ws)
+	}
+}
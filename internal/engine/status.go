@@ -174,12 +174,11 @@ func (e *Engine) computeTrackedPathDetails(repo stores.StoreRepo, activeStoreID
 			IsModified: false,
 		}
 
-		// Check if applied (exists in workspace.Paths)
+		// Check if applied (owned in workspace state, possibly inside a
+		// compacted directory)
 		if workspaceState != nil {
-			if _, exists := workspaceState.Paths[trackedPath]; exists {
-				if workspaceState.Paths[trackedPath].Store == activeStoreID {
-					pathInfo.IsApplied = true
-				}
+			if ownership, exists := workspaceState.Owner(trackedPath); exists && ownership.Store == activeStoreID {
+				pathInfo.IsApplied = true
 			}
 		}
 
@@ -213,7 +212,7 @@ func (e *Engine) isPathModified(trackedPath, overlayRoot, kind string) bool {
 
 	if kind == "dir" {
 		// For directories, check if any files within are modified
-		dirFiles, err := e.compareDirPath(root, overlayRoot, workspacePath, storePath, trackedPath, false)
+		dirFiles, err := e.compareDirPath(e.hasher, root, overlayRoot, workspacePath, storePath, trackedPath, false)
 		if err != nil {
 			return false
 		}
@@ -226,7 +225,7 @@ func (e *Engine) isPathModified(trackedPath, overlayRoot, kind string) bool {
 	}
 
 	// For files, use comparePath
-	fileInfo := e.comparePath(workspacePath, storePath, trackedPath, kind, false)
+	fileInfo := e.comparePath(e.hasher, workspacePath, storePath, trackedPath, kind, false)
 	return fileInfo.Status == "modified" || fileInfo.Status == "added" || fileInfo.Status == "removed"
 }
 
@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/danieljhkim/monodev/internal/state"
 	"github.com/danieljhkim/monodev/internal/stores"
@@ -49,6 +54,21 @@ type CreateStoreRequest struct {
 
 	// TaskID links the store to an external task
 	TaskID string
+
+	// AnchorToCWD records the current workspace path on the store so that
+	// tracked paths resolve relative to it regardless of where track runs
+	AnchorToCWD bool
+
+	// RejectDuplicateName fails creation if another store in the target
+	// scope already has the same Name
+	RejectDuplicateName bool
+
+	// Requires lists the IDs of stores the new store depends on
+	Requires []string
+
+	// FromTemplate is an existing store whose overlay and tracked paths seed
+	// the new store. Its metadata is not copied.
+	FromTemplate string
 }
 
 // UpdateStoreRequest represents a request to update store metadata.
@@ -67,6 +87,90 @@ type UpdateStoreRequest struct {
 	Description *string
 	Owner       *string
 	TaskID      *string
+	Tags        *[]string
+	Requires    *[]string
+	Type        *string
+	Frozen      *bool
+}
+
+// FieldChange records one metadata field modified by an update. List
+// fields are rendered comma-separated.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// UpdateStoreResult represents the result of updating store metadata.
+type UpdateStoreResult struct {
+	// Changed lists the fields whose values differ after the update
+	Changed []FieldChange
+
+	// NoOp is true when the update changed nothing; the store is not saved
+	NoOp bool
+}
+
+// StoreFilter selects stores by metadata. Empty fields match any store.
+type StoreFilter struct {
+	// Owner matches stores with exactly this owner
+	Owner string
+
+	// Tag matches stores that carry this tag
+	Tag string
+
+	// Scope matches stores in this scope ("global" or "component")
+	Scope string
+
+	// Type matches stores of exactly this type
+	Type string
+}
+
+// Matches reports whether the store satisfies every set field of the filter.
+func (f StoreFilter) Matches(s stores.ScopedStore) bool {
+	if f.Scope != "" && s.Scope != f.Scope {
+		return false
+	}
+	if f.Owner != "" && s.Meta.Owner != f.Owner {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(s.Meta.Tags, f.Tag) {
+		return false
+	}
+	if f.Type != "" && s.Meta.Type != f.Type {
+		return false
+	}
+	return true
+}
+
+// BulkUpdateStoresRequest represents a request to update every store matching a filter.
+type BulkUpdateStoresRequest struct {
+	// CWD is the current working directory
+	CWD string
+
+	// Filter selects the stores to update
+	Filter StoreFilter
+
+	// Set holds the field updates applied to each store.
+	// Its StoreID and Scope are ignored.
+	Set UpdateStoreRequest
+}
+
+// StoreUpdateOutcome reports what happened to one store in a bulk update.
+type StoreUpdateOutcome struct {
+	StoreID string
+	Scope   string
+	Updated bool
+
+	// Skipped explains why the store was not updated (e.g. frozen)
+	Skipped string `json:",omitempty"`
+
+	// Error is set if updating the store failed
+	Error string `json:",omitempty"`
+}
+
+// BulkUpdateStoresResult represents the result of a bulk store update.
+type BulkUpdateStoresResult struct {
+	Outcomes []StoreUpdateOutcome
 }
 
 // StoreDetails contains detailed information about a store.
@@ -86,14 +190,35 @@ type ScopedStoreDetails struct {
 	// Meta is the store metadata
 	Meta *stores.StoreMeta
 
-	// TrackedPaths is the list of tracked paths
+	// TrackedPaths is the list of tracked paths, with repo defaults applied
 	TrackedPaths []stores.TrackedPath
+
+	// Ignore is the store's ignore patterns followed by the repo's
+	Ignore []string
+
+	// RequiredBy lists the stores (in any scope) whose Requires include this store
+	RequiredBy []string
+
+	// Changelog lists recorded changes to the tracked paths, oldest first
+	Changelog []stores.ChangelogEntry
+
+	// SizeBytes is the total size of the files in the overlay
+	SizeBytes int64
 }
 
 // UseStore selects a store as the active store for the current repository.
 // If there's existing workspace state for a different store, it will be cleared
 // to avoid inconsistent state where applied=true but for the wrong store.
 func (e *Engine) UseStore(ctx context.Context, req *UseStoreRequest) error {
+	// Accept scope-qualified IDs such as "global/foo"
+	storeID, scope, err := splitStoreID(req.StoreID, req.Scope)
+	if err != nil {
+		return err
+	}
+	normalized := *req
+	normalized.StoreID, normalized.Scope = storeID, scope
+	req = &normalized
+
 	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
 	if err != nil {
 		return fmt.Errorf("failed to discover workspace: %w", err)
@@ -102,7 +227,7 @@ func (e *Engine) UseStore(ctx context.Context, req *UseStoreRequest) error {
 	workspaceID := state.ComputeWorkspaceID(repoFingerprint, workspacePath)
 
 	// Verify store exists and resolve scope
-	_, resolvedScope, err := e.resolveStoreRepo(req.StoreID, req.Scope)
+	repo, resolvedScope, err := e.resolveStoreRepo(req.StoreID, req.Scope)
 	if err != nil {
 		return err
 	}
@@ -134,6 +259,9 @@ func (e *Engine) UseStore(ctx context.Context, req *UseStoreRequest) error {
 		return fmt.Errorf("failed to save workspace state: %w", err)
 	}
 
+	// Record recency; the store is already selected, so this is best-effort
+	_ = repo.MarkUsed(req.StoreID, e.clock.Now())
+
 	return nil
 }
 
@@ -159,6 +287,33 @@ func (e *Engine) CreateStore(ctx context.Context, req *CreateStoreRequest) error
 		return fmt.Errorf("failed to resolve scope %q: %w", scope, err)
 	}
 
+	if req.RejectDuplicateName {
+		existing, err := e.ListStores(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range existing {
+			if s.Scope == scope && s.Meta.Name == req.Name {
+				return fmt.Errorf("%w: store %s in %s scope already uses the name %q", ErrValidation, s.ID, scope, req.Name)
+			}
+		}
+	}
+
+	// Resolve the template before anything is created
+	var templateRepo stores.StoreRepo
+	var templateID string
+	if req.FromTemplate != "" {
+		var templateScope string
+		templateID, templateScope, err = splitStoreID(req.FromTemplate, "")
+		if err != nil {
+			return err
+		}
+		templateRepo, _, err = e.resolveStoreRepo(templateID, templateScope)
+		if err != nil {
+			return fmt.Errorf("failed to resolve template store: %w", err)
+		}
+	}
+
 	// Create store metadata
 	meta := stores.NewStoreMeta(req.Name, scope, e.clock.Now())
 	meta.Description = req.Description
@@ -167,17 +322,47 @@ func (e *Engine) CreateStore(ctx context.Context, req *CreateStoreRequest) error
 		meta.Owner = e.gitRepo.Username(req.CWD)
 	}
 	meta.TaskID = req.TaskID
+	if req.AnchorToCWD {
+		meta.Anchor = workspacePath
+	}
+	if len(req.Requires) > 0 {
+		if err := e.checkRequiresAcyclic(ctx, req.StoreID, req.Requires); err != nil {
+			return err
+		}
+		meta.Requires = append([]string(nil), req.Requires...)
+	}
 
 	// Validate metadata
 	if err := meta.Validate(); err != nil {
 		return fmt.Errorf("invalid store metadata: %w", err)
 	}
 
+	// A template too large for the scope is refused before the store exists
+	if templateRepo != nil {
+		seedSize, err := e.pathSize(templateRepo.OverlayRoot(templateID))
+		if err != nil {
+			return err
+		}
+		if err := e.checkStoreQuota(repo, req.StoreID, seedSize); err != nil {
+			return err
+		}
+	}
+
 	// Create the store
 	if err := repo.Create(req.StoreID, meta); err != nil {
 		return fmt.Errorf("failed to create store: %w", err)
 	}
 
+	if templateRepo != nil {
+		if err := e.seedFromTemplate(templateRepo, templateID, repo, req.StoreID); err != nil {
+			// Don't leave a half-seeded store behind
+			if delErr := repo.Delete(req.StoreID); delErr != nil {
+				return fmt.Errorf("%w (cleanup of store '%s' also failed: %v)", err, req.StoreID, delErr)
+			}
+			return err
+		}
+	}
+
 	// Load or create workspace state
 	workspaceState, err := e.stateStore.LoadWorkspace(workspaceID)
 	if err != nil {
@@ -202,6 +387,32 @@ func (e *Engine) CreateStore(ctx context.Context, req *CreateStoreRequest) error
 	return nil
 }
 
+// seedFromTemplate copies a template store's tracked paths and overlay
+// contents into a newly created store.
+func (e *Engine) seedFromTemplate(templateRepo stores.StoreRepo, templateID string, repo stores.StoreRepo, storeID string) error {
+	track, err := templateRepo.LoadTrack(templateID)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to load template track list: %w", err)
+	}
+	if err == nil {
+		if err := repo.SaveTrack(storeID, track); err != nil {
+			return fmt.Errorf("failed to save track list: %w", err)
+		}
+	}
+
+	templateOverlay := templateRepo.OverlayRoot(templateID)
+	exists, err := e.fs.Exists(templateOverlay)
+	if err != nil {
+		return fmt.Errorf("failed to check template overlay: %w", err)
+	}
+	if exists {
+		if err := e.fs.Copy(templateOverlay, repo.OverlayRoot(storeID)); err != nil {
+			return fmt.Errorf("failed to copy template overlay: %w", err)
+		}
+	}
+	return nil
+}
+
 // ListStores returns all available stores from both scopes.
 // Global stores are listed first, then component stores.
 func (e *Engine) ListStores(ctx context.Context) ([]stores.ScopedStore, error) {
@@ -213,9 +424,11 @@ func (e *Engine) ListStores(ctx context.Context) ([]stores.ScopedStore, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to list global stores: %w", err)
 		}
+		// Stores whose metadata cannot be read are left out of the listing
+		metas, _ := e.globalStoreRepo.LoadMetas(ids)
 		for _, id := range ids {
-			meta, err := e.globalStoreRepo.LoadMeta(id)
-			if err != nil {
+			meta, ok := metas[id]
+			if !ok {
 				continue
 			}
 			storeList = append(storeList, stores.ScopedStore{
@@ -232,9 +445,10 @@ func (e *Engine) ListStores(ctx context.Context) ([]stores.ScopedStore, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to list component stores: %w", err)
 		}
+		metas, _ := e.componentStoreRepo.LoadMetas(ids)
 		for _, id := range ids {
-			meta, err := e.componentStoreRepo.LoadMeta(id)
-			if err != nil {
+			meta, ok := metas[id]
+			if !ok {
 				continue
 			}
 			storeList = append(storeList, stores.ScopedStore{
@@ -248,6 +462,118 @@ func (e *Engine) ListStores(ctx context.Context) ([]stores.ScopedStore, error) {
 	return storeList, nil
 }
 
+// Store list orderings accepted by ListStoresSorted.
+const (
+	// StoreSortDefault keeps the ListStores order (global, then component)
+	StoreSortDefault = ""
+
+	// StoreSortRecent orders stores by most recently used first
+	StoreSortRecent = "recent"
+)
+
+// ListStoresSorted returns all available stores in the given order.
+// With StoreSortRecent, stores are ordered by LastUsedAt, falling back to
+// UpdatedAt for stores that have never been used.
+func (e *Engine) ListStoresSorted(ctx context.Context, sortBy string) ([]stores.ScopedStore, error) {
+	storeList, err := e.ListStores(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch sortBy {
+	case StoreSortDefault:
+	case StoreSortRecent:
+		sort.SliceStable(storeList, func(i, j int) bool {
+			return lastUsed(storeList[i].Meta).After(lastUsed(storeList[j].Meta))
+		})
+	default:
+		return nil, fmt.Errorf("%w: unknown store sort %q", ErrValidation, sortBy)
+	}
+
+	return storeList, nil
+}
+
+// Store list groupings accepted by GroupStores.
+const (
+	// StoreGroupNone keeps every store in a single group with an empty key
+	StoreGroupNone = ""
+
+	// StoreGroupOwner groups stores by Meta.Owner
+	StoreGroupOwner = "owner"
+
+	// StoreGroupScope groups stores by scope (global, component)
+	StoreGroupScope = "scope"
+)
+
+// StoreGroups is a store list partitioned by a grouping key.
+type StoreGroups struct {
+	// GroupBy is the grouping that produced the groups
+	GroupBy string `json:"groupBy"`
+
+	// Keys lists the group keys in display order: sorted, with the empty
+	// key (e.g. stores without an owner) last
+	Keys []string `json:"keys"`
+
+	// Groups maps each key to its stores, in the order they were listed
+	Groups map[string][]stores.ScopedStore `json:"groups"`
+}
+
+// ListStoresGrouped returns all available stores in the given order,
+// partitioned by groupBy (see GroupStores).
+func (e *Engine) ListStoresGrouped(ctx context.Context, sortBy, groupBy string) (*StoreGroups, error) {
+	storeList, err := e.ListStoresSorted(ctx, sortBy)
+	if err != nil {
+		return nil, err
+	}
+	return GroupStores(storeList, groupBy)
+}
+
+// GroupStores partitions storeList by owner or scope. Stores keep their
+// relative order within a group, so a sorted list yields sorted groups.
+func GroupStores(storeList []stores.ScopedStore, groupBy string) (*StoreGroups, error) {
+	var key func(stores.ScopedStore) string
+	switch groupBy {
+	case StoreGroupNone:
+		key = func(stores.ScopedStore) string { return "" }
+	case StoreGroupOwner:
+		key = func(s stores.ScopedStore) string { return s.Meta.Owner }
+	case StoreGroupScope:
+		key = func(s stores.ScopedStore) string { return s.Scope }
+	default:
+		return nil, fmt.Errorf("%w: unknown store grouping %q: must be owner or scope", ErrValidation, groupBy)
+	}
+
+	groups := &StoreGroups{
+		GroupBy: groupBy,
+		Keys:    []string{},
+		Groups:  make(map[string][]stores.ScopedStore),
+	}
+	for _, store := range storeList {
+		k := key(store)
+		if _, ok := groups.Groups[k]; !ok {
+			groups.Keys = append(groups.Keys, k)
+		}
+		groups.Groups[k] = append(groups.Groups[k], store)
+	}
+	sort.Slice(groups.Keys, func(i, j int) bool {
+		a, b := groups.Keys[i], groups.Keys[j]
+		if a == "" || b == "" {
+			return b == "" && a != ""
+		}
+		return a < b
+	})
+	return groups, nil
+}
+
+// lastUsed returns when a store was last used, or when it was last
+// modified if it has never been used.
+func lastUsed(meta *stores.StoreMeta) time.Time {
+	if meta.LastUsedAt != nil {
+		return *meta.LastUsedAt
+	}
+	return meta.UpdatedAt
+}
+
 // DescribeStore returns detailed information about a store.
 // If the store exists in both scopes, returns details for both.
 func (e *Engine) DescribeStore(ctx context.Context, storeID string) ([]ScopedStoreDetails, error) {
@@ -255,10 +581,17 @@ func (e *Engine) DescribeStore(ctx context.Context, storeID string) ([]ScopedSto
 	if err != nil {
 		return nil, err
 	}
+	// findStore accepted any scope qualifier; the rest works on the bare ID
+	storeID, _, _ = splitStoreID(storeID, "")
 	if len(locations) == 0 {
 		return nil, fmt.Errorf("%w: store '%s' not found", ErrNotFound, storeID)
 	}
 
+	requiredBy, err := e.findDependents(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
 	var results []ScopedStoreDetails
 	for _, loc := range locations {
 		meta, err := loc.Repo.LoadMeta(storeID)
@@ -269,16 +602,96 @@ func (e *Engine) DescribeStore(ctx context.Context, storeID string) ([]ScopedSto
 		if err != nil {
 			return nil, fmt.Errorf("failed to load track file (%s): %w", loc.Scope, err)
 		}
+		track = e.effectiveTrack(track)
+		size, err := e.StoreSize(storeID, loc.Scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure store (%s): %w", loc.Scope, err)
+		}
 		results = append(results, ScopedStoreDetails{
 			Scope:        loc.Scope,
 			Meta:         meta,
 			TrackedPaths: track.Tracked,
+			Ignore:       track.Ignore,
+			RequiredBy:   requiredBy,
+			Changelog:    track.Changelog,
+			SizeBytes:    size,
 		})
 	}
 
 	return results, nil
 }
 
+// findDependents returns the sorted IDs of stores across all scopes that
+// list storeID in their Requires.
+func (e *Engine) findDependents(ctx context.Context, storeID string) ([]string, error) {
+	storeList, err := e.ListStores(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var dependents []string
+	for _, s := range storeList {
+		if s.ID == storeID || seen[s.ID] {
+			continue
+		}
+		for _, req := range s.Meta.Requires {
+			if req == storeID {
+				seen[s.ID] = true
+				dependents = append(dependents, s.ID)
+				break
+			}
+		}
+	}
+	sort.Strings(dependents)
+
+	return dependents, nil
+}
+
+// checkRequiresAcyclic returns a validation error naming the cycle if giving
+// storeID the requirements in requires would make the dependency graph of
+// stores (across all scopes) cyclic.
+func (e *Engine) checkRequiresAcyclic(ctx context.Context, storeID string, requires []string) error {
+	storeList, err := e.ListStores(ctx)
+	if err != nil {
+		return err
+	}
+
+	graph := make(map[string][]string)
+	for _, s := range storeList {
+		graph[s.ID] = append(graph[s.ID], s.Meta.Requires...)
+	}
+	graph[storeID] = requires
+
+	// Depth-first search for a path from storeID back to itself
+	path := []string{storeID}
+	visited := make(map[string]bool)
+	var closesCycle func(id string) bool
+	closesCycle = func(id string) bool {
+		for _, dep := range graph[id] {
+			if dep == storeID {
+				path = append(path, dep)
+				return true
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			path = append(path, dep)
+			if closesCycle(dep) {
+				return true
+			}
+			path = path[:len(path)-1]
+		}
+		return false
+	}
+	if closesCycle(storeID) {
+		return fmt.Errorf("%w: requirements would create a cycle: %s", ErrValidation, strings.Join(path, " -> "))
+	}
+
+	return nil
+}
+
 // GetActiveStoreID returns the active store ID and scope for the given working directory.
 // Returns ErrNoActiveStore if no store is currently active.
 func (e *Engine) GetActiveStoreID(ctx context.Context, cwd string) (storeID, scope string, err error) {
@@ -304,33 +717,76 @@ func (e *Engine) GetActiveStoreID(ctx context.Context, cwd string) (storeID, sco
 }
 
 // UpdateStore updates metadata fields on an existing store.
-func (e *Engine) UpdateStore(ctx context.Context, req *UpdateStoreRequest) error {
+// The result lists each field whose value changed; an update that changes
+// nothing is reported as NoOp and leaves the store untouched.
+func (e *Engine) UpdateStore(ctx context.Context, req *UpdateStoreRequest) (*UpdateStoreResult, error) {
+	// Accept scope-qualified IDs such as "global/foo"
+	storeID, scope, err := splitStoreID(req.StoreID, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	normalized := *req
+	normalized.StoreID, normalized.Scope = storeID, scope
+	req = &normalized
+
 	// Resolve the store repo
 	repo, _, err := e.resolveStoreRepo(req.StoreID, req.Scope)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Load current metadata
 	meta, err := repo.LoadMeta(req.StoreID)
 	if err != nil {
-		return fmt.Errorf("failed to load store metadata: %w", err)
+		return nil, fmt.Errorf("failed to load store metadata: %w", err)
 	}
 
-	// Apply non-nil fields
-	if req.Description != nil {
-		meta.Description = *req.Description
+	// Apply non-nil fields, recording the ones that differ
+	result := &UpdateStoreResult{}
+	setString := func(field string, dst *string, v *string) {
+		if v != nil && *dst != *v {
+			result.Changed = append(result.Changed, FieldChange{Field: field, Old: *dst, New: *v})
+			*dst = *v
+		}
 	}
-	if req.Owner != nil {
-		meta.Owner = *req.Owner
+	setList := func(field string, dst *[]string, v *[]string) {
+		if v != nil && !slices.Equal(*dst, *v) {
+			result.Changed = append(result.Changed, FieldChange{
+				Field: field,
+				Old:   strings.Join(*dst, ","),
+				New:   strings.Join(*v, ","),
+			})
+			*dst = append([]string(nil), (*v)...)
+		}
+	}
+	setString("description", &meta.Description, req.Description)
+	setString("owner", &meta.Owner, req.Owner)
+	setString("taskId", &meta.TaskID, req.TaskID)
+	setList("tags", &meta.Tags, req.Tags)
+	setString("type", &meta.Type, req.Type)
+	if req.Frozen != nil && meta.Frozen != *req.Frozen {
+		result.Changed = append(result.Changed, FieldChange{
+			Field: "frozen",
+			Old:   strconv.FormatBool(meta.Frozen),
+			New:   strconv.FormatBool(*req.Frozen),
+		})
+		meta.Frozen = *req.Frozen
 	}
-	if req.TaskID != nil {
-		meta.TaskID = *req.TaskID
+	if req.Requires != nil {
+		if err := e.checkRequiresAcyclic(ctx, req.StoreID, *req.Requires); err != nil {
+			return nil, err
+		}
+		setList("requires", &meta.Requires, req.Requires)
+	}
+
+	if len(result.Changed) == 0 {
+		result.NoOp = true
+		return result, nil
 	}
 
 	// Validate
 	if err := meta.Validate(); err != nil {
-		return fmt.Errorf("invalid store metadata: %w", err)
+		return nil, fmt.Errorf("invalid store metadata: %w", err)
 	}
 
 	// Update timestamp
@@ -338,8 +794,45 @@ func (e *Engine) UpdateStore(ctx context.Context, req *UpdateStoreRequest) error
 
 	// Save
 	if err := repo.SaveMeta(req.StoreID, meta); err != nil {
-		return fmt.Errorf("failed to save store metadata: %w", err)
+		return nil, fmt.Errorf("failed to save store metadata: %w", err)
 	}
 
-	return nil
+	return result, nil
+}
+
+// UpdateStores applies the same metadata updates to every store matching the
+// filter. Each store is updated independently, so one failure does not stop
+// the others; frozen stores are skipped. Outcomes are reported per store.
+func (e *Engine) UpdateStores(ctx context.Context, req *BulkUpdateStoresRequest) (*BulkUpdateStoresResult, error) {
+	storeList, err := e.ListStores(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkUpdateStoresResult{}
+	for _, s := range storeList {
+		if !req.Filter.Matches(s) {
+			continue
+		}
+
+		outcome := StoreUpdateOutcome{StoreID: s.ID, Scope: s.Scope}
+		if s.Meta.Frozen {
+			outcome.Skipped = "store is frozen"
+			result.Outcomes = append(result.Outcomes, outcome)
+			continue
+		}
+
+		update := req.Set
+		update.CWD = req.CWD
+		update.StoreID = s.ID
+		update.Scope = s.Scope
+		if _, err := e.UpdateStore(ctx, &update); err != nil {
+			outcome.Error = err.Error()
+		} else {
+			outcome.Updated = true
+		}
+		result.Outcomes = append(result.Outcomes, outcome)
+	}
+
+	return result, nil
 }
@@ -3,6 +3,9 @@ package engine
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -53,7 +56,7 @@ func TestUpdateStore_Success(t *testing.T) {
 	eng := newScopedTestEngine(globalRepo, nil)
 
 	newOwner := "bob"
-	err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{
+	_, err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{
 		StoreID: "my-store",
 		Owner:   &newOwner,
 	})
@@ -79,7 +82,7 @@ func TestUpdateStore_PartialUpdate(t *testing.T) {
 
 	// Only update description; owner and task-id should be unchanged
 	newDesc := "updated description"
-	err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{
+	_, err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{
 		StoreID:     "my-store",
 		Description: &newDesc,
 	})
@@ -100,12 +103,79 @@ func TestUpdateStore_PartialUpdate(t *testing.T) {
 	}
 }
 
+func TestUpdateStore_NoOpReportsNothingChanged(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	meta := stores.NewStoreMeta("my-store", stores.ScopeGlobal, created)
+	meta.Owner = "alice"
+	meta.Tags = []string{"go"}
+	globalRepo.storeIDs["my-store"] = true
+	globalRepo.metas["my-store"] = meta
+
+	eng := newScopedTestEngine(globalRepo, nil)
+
+	owner, tags := "alice", []string{"go"}
+	result, err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{
+		StoreID: "my-store",
+		Owner:   &owner,
+		Tags:    &tags,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.NoOp || len(result.Changed) != 0 {
+		t.Errorf("result = %+v, want NoOp with no changes", result)
+	}
+	if !globalRepo.metas["my-store"].UpdatedAt.Equal(created) {
+		t.Errorf("UpdatedAt = %v, want unchanged by a no-op update", globalRepo.metas["my-store"].UpdatedAt)
+	}
+}
+
+func TestUpdateStore_ReportsEachChangedField(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	meta := stores.NewStoreMeta("my-store", stores.ScopeGlobal, time.Now())
+	meta.Owner = "alice"
+	meta.TaskID = "T-1"
+	meta.Tags = []string{"go"}
+	globalRepo.storeIDs["my-store"] = true
+	globalRepo.metas["my-store"] = meta
+
+	eng := newScopedTestEngine(globalRepo, nil)
+
+	owner, taskID, tags := "bob", "T-1", []string{"go", "web"}
+	result, err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{
+		StoreID: "my-store",
+		Owner:   &owner,
+		TaskID:  &taskID,
+		Tags:    &tags,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []FieldChange{
+		{Field: "owner", Old: "alice", New: "bob"},
+		{Field: "tags", Old: "go", New: "go,web"},
+	}
+	if result.NoOp {
+		t.Error("NoOp = true, want false")
+	}
+	if len(result.Changed) != len(want) {
+		t.Fatalf("Changed = %+v, want %+v", result.Changed, want)
+	}
+	for i, change := range want {
+		if result.Changed[i] != change {
+			t.Errorf("Changed[%d] = %+v, want %+v", i, result.Changed[i], change)
+		}
+	}
+}
+
 func TestUpdateStore_NotFound(t *testing.T) {
 	globalRepo := newScopedMockStoreRepo()
 	eng := newScopedTestEngine(globalRepo, nil)
 
 	newOwner := "bob"
-	err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{
+	_, err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{
 		StoreID: "nonexistent",
 		Owner:   &newOwner,
 	})
@@ -170,3 +240,298 @@ func TestDescribeStore_TrackedPathsType(t *testing.T) {
 		t.Errorf("TrackedPaths[1].Description = %s, want 'app config'", results[0].TrackedPaths[1].Description)
 	}
 }
+
+func TestUpdateStores_OwnerAcrossMatchingStores(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	componentRepo := newScopedMockStoreRepo()
+	addStore := func(repo *scopedMockStoreRepo, id, scope string, tags []string, frozen bool) {
+		meta := stores.NewStoreMeta(id, scope, time.Now())
+		meta.Owner = "alice"
+		meta.Tags = tags
+		meta.Frozen = frozen
+		repo.storeIDs[id] = true
+		repo.metas[id] = meta
+	}
+	addStore(globalRepo, "g1", stores.ScopeGlobal, []string{"team-a"}, false)
+	addStore(componentRepo, "c1", stores.ScopeComponent, []string{"team-a"}, false)
+	addStore(globalRepo, "frozen", stores.ScopeGlobal, []string{"team-a"}, true)
+	addStore(globalRepo, "other", stores.ScopeGlobal, []string{"team-b"}, false)
+
+	eng := newScopedTestEngine(globalRepo, componentRepo)
+
+	newOwner := "bob"
+	result, err := eng.UpdateStores(context.Background(), &BulkUpdateStoresRequest{
+		Filter: StoreFilter{Tag: "team-a"},
+		Set:    UpdateStoreRequest{Owner: &newOwner},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Outcomes) != 3 {
+		t.Fatalf("expected 3 outcomes, got %+v", result.Outcomes)
+	}
+	for _, o := range result.Outcomes {
+		switch o.StoreID {
+		case "g1", "c1":
+			if !o.Updated || o.Error != "" {
+				t.Errorf("%s outcome = %+v, want updated", o.StoreID, o)
+			}
+		case "frozen":
+			if o.Updated || o.Skipped == "" {
+				t.Errorf("frozen outcome = %+v, want skipped with reason", o)
+			}
+		default:
+			t.Errorf("unexpected outcome for %s", o.StoreID)
+		}
+	}
+
+	if globalRepo.metas["g1"].Owner != "bob" || componentRepo.metas["c1"].Owner != "bob" {
+		t.Error("expected matching stores to have owner 'bob'")
+	}
+	if globalRepo.metas["frozen"].Owner != "alice" {
+		t.Error("frozen store should keep its owner")
+	}
+	if globalRepo.metas["other"].Owner != "alice" {
+		t.Error("non-matching store should keep its owner")
+	}
+}
+
+func TestUpdateStores_FiltersByType(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	for id, storeType := range map[string]string{"cfg1": "config", "cfg2": "config", "tools": "toolchain"} {
+		meta := stores.NewStoreMeta(id, stores.ScopeGlobal, time.Now())
+		meta.Type = storeType
+		globalRepo.storeIDs[id] = true
+		globalRepo.metas[id] = meta
+	}
+	eng := newScopedTestEngine(globalRepo, nil)
+
+	newOwner := "bob"
+	result, err := eng.UpdateStores(context.Background(), &BulkUpdateStoresRequest{
+		Filter: StoreFilter{Type: "config"},
+		Set:    UpdateStoreRequest{Owner: &newOwner},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Outcomes) != 2 {
+		t.Fatalf("expected 2 outcomes, got %+v", result.Outcomes)
+	}
+	if globalRepo.metas["cfg1"].Owner != "bob" || globalRepo.metas["cfg2"].Owner != "bob" {
+		t.Error("expected config stores to have owner 'bob'")
+	}
+	if globalRepo.metas["tools"].Owner != "" {
+		t.Errorf("toolchain store owner = %q, want unchanged", globalRepo.metas["tools"].Owner)
+	}
+}
+
+func TestUpdateStore_FrozenExcludesFromBulkUpdates(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	for _, id := range []string{"a", "b"} {
+		meta := stores.NewStoreMeta(id, stores.ScopeGlobal, time.Now())
+		meta.Tags = []string{"team-a"}
+		globalRepo.storeIDs[id] = true
+		globalRepo.metas[id] = meta
+	}
+	eng := newScopedTestEngine(globalRepo, nil)
+
+	frozen := true
+	result, err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{StoreID: "a", Frozen: &frozen})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Changed) != 1 || result.Changed[0] != (FieldChange{Field: "frozen", Old: "false", New: "true"}) {
+		t.Errorf("Changed = %+v, want frozen false -> true", result.Changed)
+	}
+	if !globalRepo.metas["a"].Frozen {
+		t.Fatal("expected store 'a' to be frozen")
+	}
+
+	newOwner := "bob"
+	bulk, err := eng.UpdateStores(context.Background(), &BulkUpdateStoresRequest{
+		Filter: StoreFilter{Tag: "team-a"},
+		Set:    UpdateStoreRequest{Owner: &newOwner},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, o := range bulk.Outcomes {
+		if o.StoreID == "a" && (o.Updated || o.Skipped == "") {
+			t.Errorf("frozen outcome = %+v, want skipped", o)
+		}
+	}
+	if globalRepo.metas["a"].Owner != "" || globalRepo.metas["b"].Owner != "bob" {
+		t.Errorf("owners = %q, %q; want frozen store unchanged", globalRepo.metas["a"].Owner, globalRepo.metas["b"].Owner)
+	}
+}
+
+func TestUpdateStore_RequiresRejectsCycle(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	for id, requires := range map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil} {
+		meta := stores.NewStoreMeta(id, stores.ScopeGlobal, time.Now())
+		meta.Requires = requires
+		globalRepo.storeIDs[id] = true
+		globalRepo.metas[id] = meta
+	}
+	eng := newScopedTestEngine(globalRepo, nil)
+
+	requires := []string{"a"}
+	_, err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{StoreID: "c", Requires: &requires})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "c -> a -> b -> c") {
+		t.Errorf("error should name the cycle path, got %v", err)
+	}
+	if len(globalRepo.metas["c"].Requires) != 0 {
+		t.Errorf("rejected requirement was saved: %v", globalRepo.metas["c"].Requires)
+	}
+
+	self := []string{"c"}
+	if _, err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{StoreID: "c", Requires: &self}); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected ErrValidation for self-requirement, got %v", err)
+	}
+}
+
+func TestUpdateStore_RequiresAcceptsChain(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	for _, id := range []string{"a", "b", "c"} {
+		globalRepo.storeIDs[id] = true
+		globalRepo.metas[id] = stores.NewStoreMeta(id, stores.ScopeGlobal, time.Now())
+	}
+	globalRepo.metas["b"].Requires = []string{"c"}
+	eng := newScopedTestEngine(globalRepo, nil)
+
+	// a -> b -> c, plus a -> c directly (a diamond, not a cycle)
+	requires := []string{"b", "c"}
+	if _, err := eng.UpdateStore(context.Background(), &UpdateStoreRequest{StoreID: "a", Requires: &requires}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := globalRepo.metas["a"].Requires; len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Errorf("Requires = %v, want [b c]", got)
+	}
+}
+
+func TestCreateStore_RequiresRejectsCycle(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	// "ghost" records a requirement on a store ID that does not exist yet
+	meta := stores.NewStoreMeta("ghost", stores.ScopeGlobal, time.Now())
+	meta.Requires = []string{"new"}
+	globalRepo.storeIDs["ghost"] = true
+	globalRepo.metas["ghost"] = meta
+	eng := newScopedTestEngine(globalRepo, nil)
+
+	err := eng.CreateStore(context.Background(), &CreateStoreRequest{
+		CWD:      "/repo",
+		StoreID:  "new",
+		Name:     "new",
+		Scope:    stores.ScopeGlobal,
+		Requires: []string{"ghost"},
+	})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if _, created := globalRepo.metas["new"]; created {
+		t.Error("store should not be created when its requirements form a cycle")
+	}
+}
+
+func TestCreateStore_FromTemplate(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"Makefile": "all:\n", "conf/app.yaml": "a: 1\n"}, nil)
+	template, err := eng.storeRepo.LoadMeta("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.Owner = "template-owner"
+	template.Description = "starter layout"
+	template.Tags = []string{"starter"}
+	if err := eng.storeRepo.SaveMeta("s1", template); err != nil {
+		t.Fatal(err)
+	}
+
+	err = eng.CreateStore(context.Background(), &CreateStoreRequest{
+		CWD:          repoDir,
+		StoreID:      "task-42",
+		Name:         "task-42",
+		Scope:        stores.ScopeGlobal,
+		Owner:        "alice",
+		TaskID:       "T-42",
+		FromTemplate: "s1",
+	})
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+
+	// Overlay contents and tracked paths come from the template
+	data, err := os.ReadFile(filepath.Join(eng.storeRepo.OverlayRoot("task-42"), "conf", "app.yaml"))
+	if err != nil || string(data) != "a: 1\n" {
+		t.Errorf("overlay conf/app.yaml = %q, %v; want template content", data, err)
+	}
+	track, err := eng.storeRepo.LoadTrack("task-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(track.Tracked) != 2 {
+		t.Errorf("Tracked = %+v, want the template's 2 paths", track.Tracked)
+	}
+
+	// Metadata comes from the request only
+	meta, err := eng.storeRepo.LoadMeta("task-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Owner != "alice" || meta.TaskID != "T-42" || meta.Name != "task-42" {
+		t.Errorf("meta = %+v, want request metadata", meta)
+	}
+	if meta.Description != "" || len(meta.Tags) != 0 {
+		t.Errorf("template metadata leaked into new store: description %q, tags %v", meta.Description, meta.Tags)
+	}
+}
+
+func TestCreateStore_FailedSeedRemovesStore(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"Makefile": "all:\n"}, nil)
+	// An unreadable track list makes seeding fail after the store exists
+	trackPath := filepath.Join(filepath.Dir(eng.storeRepo.OverlayRoot("s1")), "track.json")
+	if err := os.WriteFile(trackPath, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := eng.CreateStore(context.Background(), &CreateStoreRequest{
+		CWD:          repoDir,
+		StoreID:      "task-42",
+		Name:         "task-42",
+		Scope:        stores.ScopeGlobal,
+		FromTemplate: "s1",
+	})
+	if err == nil {
+		t.Fatal("expected CreateStore to fail when seeding fails")
+	}
+	exists, err := eng.storeRepo.Exists("task-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("half-created store should be removed when seeding fails")
+	}
+}
+
+func TestCreateStore_FromMissingTemplate(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	eng := newScopedTestEngine(globalRepo, nil)
+
+	err := eng.CreateStore(context.Background(), &CreateStoreRequest{
+		CWD:          "/repo",
+		StoreID:      "new",
+		Name:         "new",
+		Scope:        stores.ScopeGlobal,
+		FromTemplate: "missing",
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, created := globalRepo.metas["new"]; created {
+		t.Error("store should not be created when the template is missing")
+	}
+}
@@ -3,8 +3,11 @@ package engine
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/danieljhkim/monodev/internal/state"
 	"github.com/danieljhkim/monodev/internal/stores"
@@ -26,6 +29,24 @@ type TrackRequest struct {
 
 	// Origin indicates how the paths were tracked (user, agent, other)
 	Origin string
+
+	// Note explains why the paths are in the store
+	Note string
+
+	// ExcludeFromDiff hides the tracked paths from diff output by default
+	ExcludeFromDiff bool
+
+	// LinkContents applies tracked directories entry by entry instead of as
+	// a whole (ignored for files)
+	LinkContents bool
+
+	// Mirror makes copy-mode apply remove extra files from tracked
+	// directories (ignored for files)
+	Mirror bool
+
+	// When limits applying the tracked paths to when a condition on the
+	// apply variables holds; see stores.TrackedPath.When
+	When string
 }
 
 // TrackResult represents the result of a track operation.
@@ -37,6 +58,41 @@ type TrackResult struct {
 	MissingPaths []string
 }
 
+// TrackPathsRequest represents a request to track the contents of a directory.
+type TrackPathsRequest struct {
+	// CWD is the current working directory
+	CWD string
+
+	// Dir is the directory to track (relative to CWD, absolute, or containing "..")
+	Dir string
+
+	// Recursive walks Dir and tracks each file individually.
+	// When false, Dir is tracked as a single "dir" entry.
+	Recursive bool
+
+	// Copy also copies the tracked content into the store's overlay
+	Copy bool
+}
+
+// TrackPathsResult represents the result of a bulk track operation.
+type TrackPathsResult struct {
+	// Added is the number of paths newly tracked
+	Added int
+
+	// Skipped is the number of paths that were already tracked
+	Skipped int
+
+	// AddedPaths lists the newly tracked workspace-relative paths
+	AddedPaths []string
+}
+
+// ListTrackedPathsRequest represents a request to list tracked paths across stores.
+type ListTrackedPathsRequest struct {
+	// Scope limits results to stores in this scope ("global" or "component").
+	// Empty means all scopes.
+	Scope string
+}
+
 // UntrackRequest represents a request to untrack paths.
 type UntrackRequest struct {
 	// CWD is the current working directory
@@ -57,6 +113,10 @@ type UntrackResult struct {
 
 // Track adds paths to the active store's track file.
 func (e *Engine) Track(ctx context.Context, req *TrackRequest) (*TrackResult, error) {
+	if _, err := (stores.TrackedPath{When: req.When}).Applies(nil); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidation, err)
+	}
+
 	// Discover repository
 	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
 	if err != nil {
@@ -92,6 +152,16 @@ func (e *Engine) Track(ctx context.Context, req *TrackRequest) (*TrackResult, er
 		return nil, fmt.Errorf("failed to load track file: %w", err)
 	}
 
+	// Resolve relative paths against the store's anchor, if it has one
+	baseDir := req.CWD
+	meta, err := repo.LoadMeta(activeStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load store metadata: %w", err)
+	}
+	if meta.Anchor != "" {
+		baseDir = filepath.Join(root, meta.Anchor)
+	}
+
 	// Add new paths (avoid duplicates)
 	pathSet := make(map[string]bool)
 	for _, tp := range track.Tracked {
@@ -101,16 +171,17 @@ func (e *Engine) Track(ctx context.Context, req *TrackRequest) (*TrackResult, er
 	result := &TrackResult{
 		ResolvedPaths: make(map[string]string),
 	}
+	var added []string
 
 	for _, userPath := range req.Paths {
-		// Resolve to workspace-relative path (relative to req.CWD, not repo root)
-		cwdRelPath, err := resolveToWorkspaceRelative(userPath, req.CWD, root)
+		// Resolve to workspace-relative path (relative to the base dir, not repo root)
+		cwdRelPath, err := resolveToWorkspaceRelative(userPath, baseDir, root)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve path %q: %w", userPath, err)
 		}
 
 		// Check if path exists in the workspace
-		absPath := filepath.Join(req.CWD, cwdRelPath)
+		absPath := filepath.Join(baseDir, cwdRelPath)
 		info, err := e.fs.Lstat(absPath)
 		if err != nil {
 			result.MissingPaths = append(result.MissingPaths, userPath)
@@ -132,19 +203,29 @@ func (e *Engine) Track(ctx context.Context, req *TrackRequest) (*TrackResult, er
 				origin = "user"
 			}
 			tp := stores.TrackedPath{
-				Path:        cwdRelPath,
-				Kind:        kind,
-				Role:        req.Role,
-				Description: req.Description,
-				CreatedAt:   &now,
-				UpdatedAt:   &now,
-				Origin:      origin,
+				Path:            cwdRelPath,
+				Kind:            kind,
+				Role:            req.Role,
+				Description:     req.Description,
+				Note:            req.Note,
+				CreatedAt:       &now,
+				UpdatedAt:       &now,
+				Origin:          origin,
+				ExcludeFromDiff: req.ExcludeFromDiff,
+				LinkContents:    req.LinkContents && kind == "dir",
+				Mirror:          req.Mirror && kind == "dir",
+				When:            req.When,
 			}
 			track.Tracked = append(track.Tracked, tp)
 			pathSet[cwdRelPath] = true
+			added = append(added, cwdRelPath)
 		}
 	}
 
+	if len(added) > 0 {
+		track.AppendChangelog(e.clock.Now(), "track "+strings.Join(added, ", "))
+	}
+
 	// Save updated track file
 	if err := repo.SaveTrack(activeStore, track); err != nil {
 		return nil, fmt.Errorf("failed to save track file: %w", err)
@@ -158,6 +239,232 @@ func (e *Engine) Track(ctx context.Context, req *TrackRequest) (*TrackResult, er
 	return result, nil
 }
 
+// TrackPaths tracks a directory in the active store, either as a single "dir"
+// entry or, when Recursive is set, as one "file" entry per file beneath it.
+// Files matched by the store's ignore patterns or the workspace's .monodevignore
+// are skipped, as are paths that are already tracked.
+func (e *Engine) TrackPaths(ctx context.Context, req *TrackPathsRequest) (*TrackPathsResult, error) {
+	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover workspace: %w", err)
+	}
+	workspaceID := state.ComputeWorkspaceID(repoFingerprint, workspacePath)
+
+	// Load workspace state to get active store
+	workspaceState, err := e.stateStore.LoadWorkspace(workspaceID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoActiveStore
+		}
+		return nil, fmt.Errorf("failed to load workspace state: %w", err)
+	}
+
+	if workspaceState.ActiveStore == "" {
+		return nil, ErrNoActiveStore
+	}
+
+	activeStore := workspaceState.ActiveStore
+
+	repo, err := e.activeStoreRepo(workspaceState)
+	if err != nil {
+		return nil, err
+	}
+
+	track, err := repo.LoadTrack(activeStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load track file: %w", err)
+	}
+
+	// Resolve relative paths against the store's anchor, if it has one
+	baseDir := req.CWD
+	meta, err := repo.LoadMeta(activeStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load store metadata: %w", err)
+	}
+	if meta.Anchor != "" {
+		baseDir = filepath.Join(root, meta.Anchor)
+	}
+
+	relDir, err := resolveToWorkspaceRelative(req.Dir, baseDir, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path %q: %w", req.Dir, err)
+	}
+	absDir := filepath.Join(baseDir, relDir)
+
+	info, err := e.fs.Lstat(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s does not exist in workspace", ErrNotFound, req.Dir)
+	}
+
+	ignore, err := loadIgnoreMatcher(e.fs, baseDir, e.effectiveTrack(track).Ignore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ignore patterns: %w", err)
+	}
+
+	// Collect candidate paths and their kinds
+	type candidate struct {
+		path string
+		kind string
+	}
+	var candidates []candidate
+	if !req.Recursive || !info.IsDir() {
+		kind := "file"
+		if info.IsDir() {
+			kind = "dir"
+		}
+		candidates = append(candidates, candidate{path: relDir, kind: kind})
+	} else {
+		err := e.fs.WalkDir(absDir, func(path string, d fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(baseDir, path)
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != absDir && ignore.Match(rel, true) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Name() == ignoreFileName || ignore.Match(rel, false) {
+				return nil
+			}
+			candidates = append(candidates, candidate{path: rel, kind: "file"})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %w", req.Dir, err)
+		}
+	}
+
+	pathSet := make(map[string]bool)
+	for _, tp := range track.Tracked {
+		pathSet[tp.Path] = true
+	}
+
+	overlayRoot := repo.OverlayRoot(activeStore)
+
+	// Refuse before copying anything if the copies would overflow the store
+	if req.Copy {
+		var growth int64
+		for _, c := range candidates {
+			if pathSet[c.path] {
+				continue
+			}
+			added, err := e.pathSize(filepath.Join(baseDir, c.path))
+			if err != nil {
+				return nil, err
+			}
+			replaced, err := e.pathSize(filepath.Join(overlayRoot, c.path))
+			if err != nil {
+				return nil, err
+			}
+			growth += added - replaced
+		}
+		if err := e.checkStoreQuota(repo, activeStore, growth); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &TrackPathsResult{}
+	for _, c := range candidates {
+		if pathSet[c.path] {
+			result.Skipped++
+			continue
+		}
+
+		if req.Copy {
+			if err := e.fs.Copy(filepath.Join(baseDir, c.path), filepath.Join(overlayRoot, c.path)); err != nil {
+				return nil, fmt.Errorf("failed to copy %s to overlay: %w", c.path, err)
+			}
+		}
+
+		now := e.clock.Now()
+		track.Tracked = append(track.Tracked, stores.TrackedPath{
+			Path:      c.path,
+			Kind:      c.kind,
+			CreatedAt: &now,
+			UpdatedAt: &now,
+			Origin:    "user",
+		})
+		pathSet[c.path] = true
+		result.Added++
+		result.AddedPaths = append(result.AddedPaths, c.path)
+	}
+
+	if result.Added == 0 {
+		return result, nil
+	}
+	track.AppendChangelog(e.clock.Now(), "track "+strings.Join(result.AddedPaths, ", "))
+
+	if err := repo.SaveTrack(activeStore, track); err != nil {
+		return nil, fmt.Errorf("failed to save track file: %w", err)
+	}
+
+	if err := e.touchStoreMetaIn(repo, activeStore); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ListTrackedPaths returns every tracked path from every store, with the store
+// that tracks it, sorted by path then store. Only track files are read.
+func (e *Engine) ListTrackedPaths(ctx context.Context, req *ListTrackedPathsRequest) ([]TrackedPathInfo, error) {
+	if req.Scope != "" && req.Scope != stores.ScopeGlobal && req.Scope != stores.ScopeComponent {
+		return nil, fmt.Errorf("%w: unknown scope %q", ErrValidation, req.Scope)
+	}
+
+	storeList, err := e.ListStores(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []TrackedPathInfo
+	for _, s := range storeList {
+		if req.Scope != "" && s.Scope != req.Scope {
+			continue
+		}
+
+		repo, err := e.storeRepoForScope(s.Scope)
+		if err != nil {
+			return nil, err
+		}
+		track, err := repo.LoadTrack(s.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load track file for store %s: %w", s.ID, err)
+		}
+
+		for _, tp := range track.Tracked {
+			infos = append(infos, TrackedPathInfo{
+				Path:    tp.Path,
+				StoreID: s.ID,
+				Scope:   s.Scope,
+				Kind:    tp.Kind,
+				Role:    tp.Role,
+				Origin:  tp.Origin,
+			})
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Path != infos[j].Path {
+			return infos[i].Path < infos[j].Path
+		}
+		if infos[i].StoreID != infos[j].StoreID {
+			return infos[i].StoreID < infos[j].StoreID
+		}
+		return infos[i].Scope < infos[j].Scope
+	})
+
+	return infos, nil
+}
+
 // Untrack removes paths from the active store's track file.
 func (e *Engine) Untrack(ctx context.Context, req *UntrackRequest) (*UntrackResult, error) {
 	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.CWD)
@@ -224,12 +531,18 @@ func (e *Engine) Untrack(ctx context.Context, req *UntrackRequest) (*UntrackResu
 
 	// Filter out paths to remove
 	newTracked := []stores.TrackedPath{}
+	var removed []string
 	for _, tp := range track.Tracked {
-		if !removeSet[tp.Path] {
-			newTracked = append(newTracked, tp)
+		if removeSet[tp.Path] {
+			removed = append(removed, tp.Path)
+			continue
 		}
+		newTracked = append(newTracked, tp)
 	}
 	track.Tracked = newTracked
+	if len(removed) > 0 {
+		track.AppendChangelog(e.clock.Now(), "untrack "+strings.Join(removed, ", "))
+	}
 
 	// Save updated track file
 	if err := repo.SaveTrack(activeStore, track); err != nil {
@@ -2,10 +2,14 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/danieljhkim/monodev/internal/clock"
 	"github.com/danieljhkim/monodev/internal/config"
 	"github.com/danieljhkim/monodev/internal/state"
 	"github.com/danieljhkim/monodev/internal/stores"
@@ -46,7 +50,11 @@ func (m *trackStoreRepo) LoadMeta(id string) (*stores.StoreMeta, error) {
 	now := time.Now()
 	return &stores.StoreMeta{Name: id, Scope: "global", CreatedAt: now, UpdatedAt: now}, nil
 }
+func (m *trackStoreRepo) LoadMetas(ids []string) (map[string]*stores.StoreMeta, error) {
+	return stores.LoadMetasSequential(m, ids)
+}
 func (m *trackStoreRepo) SaveMeta(id string, meta *stores.StoreMeta) error { return nil }
+func (m *trackStoreRepo) MarkUsed(id string, at time.Time) error           { return nil }
 func (m *trackStoreRepo) LoadTrack(id string) (*stores.TrackFile, error) {
 	if t, ok := m.tracks[id]; ok {
 		return t, nil
@@ -86,9 +94,13 @@ func (m *trackFileInfoFS) Lstat(name string) (os.FileInfo, error) {
 	}
 	return nil, os.ErrNotExist
 }
-func (m *trackFileInfoFS) Copy(src, dst string) error           { return nil }
-func (m *trackFileInfoFS) ValidateRelPath(relPath string) error { return nil }
-func (m *trackFileInfoFS) ValidateIdentifier(id string) error   { return nil }
+func (m *trackFileInfoFS) Copy(src, dst string) error                   { return nil }
+func (m *trackFileInfoFS) Rename(oldpath, newpath string) error         { return nil }
+func (m *trackFileInfoFS) Chtimes(string, time.Time, time.Time) error   { return nil }
+func (m *trackFileInfoFS) ValidateRelPath(relPath string) error         { return nil }
+func (m *trackFileInfoFS) ValidateIdentifier(id string) error           { return nil }
+func (m *trackFileInfoFS) ReadDir(path string) ([]os.DirEntry, error)   { return nil, nil }
+func (m *trackFileInfoFS) WalkDir(root string, fn fs.WalkDirFunc) error { return nil }
 
 type trackFakeFileInfo struct {
 	name  string
@@ -299,3 +311,298 @@ func TestUntrackRequest_HasCWDField(t *testing.T) {
 		t.Errorf("UntrackRequest.CWD = %s, want '/test/workspace'", req.CWD)
 	}
 }
+
+// TestCreateStore_AnchorToCWDRecordsWorkspacePath verifies the anchor is stored on the meta.
+func TestCreateStore_AnchorToCWDRecordsWorkspacePath(t *testing.T) {
+	gitRepo := &trackGitRepo{root: "/repo", fingerprint: "fp1", workspacePath: "packages/web"}
+	storeRepo := newScopedMockStoreRepo()
+	eng := New(gitRepo, storeRepo, newMockStateStore(), newTrackFileInfoFS(), &mockHasher{}, &mockClock{}, config.Paths{})
+
+	err := eng.CreateStore(context.Background(), &CreateStoreRequest{
+		CWD:         "/repo/packages/web",
+		StoreID:     "web-store",
+		Name:        "web-store",
+		Scope:       stores.ScopeGlobal,
+		AnchorToCWD: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := storeRepo.created["web-store"].Anchor; got != "packages/web" {
+		t.Errorf("Anchor = %q, want %q", got, "packages/web")
+	}
+}
+
+// TestTrack_ResolvesRelativeToAnchor verifies relative paths resolve against the
+// store's anchor rather than the directory track is run from.
+func TestTrack_ResolvesRelativeToAnchor(t *testing.T) {
+	gitRepo := &trackGitRepo{root: "/repo", fingerprint: "fp1", workspacePath: "packages"}
+	storeRepo := newScopedMockStoreRepo()
+	storeRepo.storeIDs["web-store"] = true
+	meta := stores.NewStoreMeta("web-store", stores.ScopeGlobal, time.Now())
+	meta.Anchor = "packages/web"
+	storeRepo.metas["web-store"] = meta
+
+	stateStore := newMockStateStore()
+	setupWorkspaceWithStore(stateStore, state.ComputeWorkspaceID("fp1", "packages"), "web-store")
+	fs := newTrackFileInfoFS("/repo/packages/web/src/index.ts")
+
+	eng := New(gitRepo, storeRepo, stateStore, fs, &mockHasher{}, &mockClock{}, config.Paths{})
+
+	result, err := eng.Track(context.Background(), &TrackRequest{
+		CWD:   "/repo/packages",
+		Paths: []string{"src/index.ts"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.MissingPaths) > 0 {
+		t.Fatalf("unexpected missing paths: %v", result.MissingPaths)
+	}
+
+	track := storeRepo.tracks["web-store"]
+	if track == nil || len(track.Tracked) != 1 {
+		t.Fatalf("expected 1 tracked path, got %v", track)
+	}
+	if got := track.Tracked[0].Path; got != "src/index.ts" {
+		t.Errorf("stored path = %q, want %q (anchor-relative)", got, "src/index.ts")
+	}
+}
+
+// setupTrackPathsEngine creates an engine over a real workspace directory with
+// the given untracked files and an active, empty store "s1".
+func setupTrackPathsEngine(t *testing.T, files map[string]string) (*Engine, stores.StoreRepo, string) {
+	t.Helper()
+	eng, repoDir := setupDiffEngine(t, nil, nil)
+	writeTestFiles(t, repoDir, files)
+	setupWorkspaceWithStore(eng.stateStore.(*mockStateStore), state.ComputeWorkspaceID("fp1", "."), "s1")
+	return eng, eng.storeRepo, repoDir
+}
+
+func TestTrackPaths_RecursiveTracksFilesAndSkipsExisting(t *testing.T) {
+	eng, storeRepo, repoDir := setupTrackPathsEngine(t, map[string]string{
+		"src/a.go":        "a",
+		"src/sub/b.go":    "b",
+		"src/debug.log":   "log",
+		"src/tmp/x.go":    "x",
+		".monodevignore":  "# local noise\n*.log\ntmp/\n",
+		"other/ignored.c": "c",
+	})
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "src/a.go", Kind: "file"}}
+	if err := storeRepo.SaveTrack("s1", track); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := eng.TrackPaths(context.Background(), &TrackPathsRequest{
+		CWD:       repoDir,
+		Dir:       "src",
+		Recursive: true,
+		Copy:      true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Added != 1 || result.Skipped != 1 {
+		t.Errorf("Added=%d Skipped=%d, want 1 and 1", result.Added, result.Skipped)
+	}
+	if len(result.AddedPaths) != 1 || result.AddedPaths[0] != "src/sub/b.go" {
+		t.Errorf("AddedPaths = %v, want [src/sub/b.go]", result.AddedPaths)
+	}
+
+	saved, err := storeRepo.LoadTrack("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Tracked) != 2 {
+		t.Fatalf("expected 2 tracked paths, got %v", saved.Tracked)
+	}
+	if tp := saved.Tracked[1]; tp.Path != "src/sub/b.go" || tp.Kind != "file" {
+		t.Errorf("tracked entry = %+v, want file src/sub/b.go", tp)
+	}
+
+	if _, err := os.Stat(filepath.Join(storeRepo.OverlayRoot("s1"), "src", "sub", "b.go")); err != nil {
+		t.Errorf("expected file copied into overlay: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(storeRepo.OverlayRoot("s1"), "src", "a.go")); !os.IsNotExist(err) {
+		t.Errorf("expected already-tracked file not to be copied, got err=%v", err)
+	}
+}
+
+func TestTrackPaths_NonRecursiveTracksSingleDirEntry(t *testing.T) {
+	eng, storeRepo, repoDir := setupTrackPathsEngine(t, map[string]string{
+		"config/a.yaml": "a",
+		"config/b.yaml": "b",
+	})
+
+	result, err := eng.TrackPaths(context.Background(), &TrackPathsRequest{
+		CWD: repoDir,
+		Dir: "config",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Added != 1 || result.Skipped != 0 {
+		t.Errorf("Added=%d Skipped=%d, want 1 and 0", result.Added, result.Skipped)
+	}
+
+	saved, err := storeRepo.LoadTrack("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Tracked) != 1 || saved.Tracked[0].Path != "config" || saved.Tracked[0].Kind != "dir" {
+		t.Errorf("tracked = %+v, want single dir entry for config", saved.Tracked)
+	}
+
+	// Tracking again skips the existing entry
+	result, err = eng.TrackPaths(context.Background(), &TrackPathsRequest{CWD: repoDir, Dir: "config"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Added != 0 || result.Skipped != 1 {
+		t.Errorf("second run Added=%d Skipped=%d, want 0 and 1", result.Added, result.Skipped)
+	}
+}
+
+func TestListTrackedPaths_AggregatesAcrossStores(t *testing.T) {
+	globalRepo := newScopedMockStoreRepo()
+	componentRepo := newScopedMockStoreRepo()
+	now := time.Now()
+
+	globalRepo.storeIDs["tools"] = true
+	globalRepo.metas["tools"] = stores.NewStoreMeta("tools", stores.ScopeGlobal, now)
+	globalRepo.tracks["tools"] = &stores.TrackFile{Tracked: []stores.TrackedPath{
+		{Path: "Makefile", Kind: "file", Role: "script", Origin: "user"},
+		{Path: ".vscode", Kind: "dir", Role: "config", Origin: "agent"},
+	}}
+
+	componentRepo.storeIDs["alpha"] = true
+	componentRepo.metas["alpha"] = stores.NewStoreMeta("alpha", stores.ScopeComponent, now)
+	componentRepo.tracks["alpha"] = &stores.TrackFile{Tracked: []stores.TrackedPath{
+		{Path: "Makefile", Kind: "file", Role: "script", Origin: "user"},
+	}}
+
+	eng := newScopedTestEngine(globalRepo, componentRepo)
+
+	infos, err := eng.ListTrackedPaths(context.Background(), &ListTrackedPathsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []TrackedPathInfo{
+		{Path: ".vscode", StoreID: "tools", Scope: stores.ScopeGlobal, Kind: "dir", Role: "config", Origin: "agent"},
+		{Path: "Makefile", StoreID: "alpha", Scope: stores.ScopeComponent, Kind: "file", Role: "script", Origin: "user"},
+		{Path: "Makefile", StoreID: "tools", Scope: stores.ScopeGlobal, Kind: "file", Role: "script", Origin: "user"},
+	}
+	if len(infos) != len(want) {
+		t.Fatalf("got %d paths, want %d: %+v", len(infos), len(want), infos)
+	}
+	for i := range want {
+		if infos[i] != want[i] {
+			t.Errorf("infos[%d] = %+v, want %+v", i, infos[i], want[i])
+		}
+	}
+
+	// Scope filter
+	infos, err = eng.ListTrackedPaths(context.Background(), &ListTrackedPathsRequest{Scope: stores.ScopeComponent})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(infos) != 1 || infos[0].StoreID != "alpha" {
+		t.Errorf("component-scoped infos = %+v, want only alpha", infos)
+	}
+}
+
+// TestTrack_RecordsNoteAndChangelog verifies that a note is stored on the new
+// path and the track is logged with the clock's time.
+func TestTrack_RecordsNoteAndChangelog(t *testing.T) {
+	gitRepo := &trackGitRepo{root: "/repo", fingerprint: "fp1", workspacePath: "."}
+	storeRepo := newTrackStoreRepo()
+	stateStore := newMockStateStore()
+	fs := newTrackFileInfoFS("/repo/a.txt", "/repo/b.txt")
+
+	workspaceID := state.ComputeWorkspaceID("fp1", ".")
+	setupWorkspaceWithStore(stateStore, workspaceID, "store1")
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	eng := newTrackEngine(gitRepo, storeRepo, stateStore, fs)
+	eng.clock = clock.NewFakeClock(now)
+
+	_, err := eng.Track(context.Background(), &TrackRequest{
+		CWD:   "/repo",
+		Paths: []string{"a.txt", "b.txt"},
+		Note:  "local dev certs",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	saved := storeRepo.savedTracks["store1"]
+	if saved == nil {
+		t.Fatal("expected SaveTrack to be called")
+	}
+	for _, tp := range saved.Tracked {
+		if tp.Note != "local dev certs" {
+			t.Errorf("%s note = %q, want %q", tp.Path, tp.Note, "local dev certs")
+		}
+	}
+	if len(saved.Changelog) != 1 {
+		t.Fatalf("expected 1 changelog entry, got %+v", saved.Changelog)
+	}
+	entry := saved.Changelog[0]
+	if !entry.At.Equal(now) {
+		t.Errorf("changelog At = %v, want %v", entry.At, now)
+	}
+	if entry.Message != "track a.txt, b.txt" {
+		t.Errorf("changelog Message = %q, want %q", entry.Message, "track a.txt, b.txt")
+	}
+}
+
+func TestTrackPaths_EnforcesStoreQuota(t *testing.T) {
+	eng, storeRepo, repoDir := setupTrackPathsEngine(t, map[string]string{
+		"a.txt": "aaaa",
+		"b.txt": "bbbb",
+		"c.txt": "cccc",
+	})
+	eng.SetMaxStoreBytes(stores.ScopeGlobal, 8)
+
+	// Filling the store exactly to its quota is allowed
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := eng.TrackPaths(context.Background(), &TrackPathsRequest{CWD: repoDir, Dir: name, Copy: true}); err != nil {
+			t.Fatalf("TrackPaths(%s) failed: %v", name, err)
+		}
+	}
+	size, err := eng.StoreSize("s1", stores.ScopeGlobal)
+	if err != nil {
+		t.Fatalf("StoreSize failed: %v", err)
+	}
+	if size != 8 {
+		t.Errorf("StoreSize = %d, want 8", size)
+	}
+
+	_, err = eng.TrackPaths(context.Background(), &TrackPathsRequest{CWD: repoDir, Dir: "c.txt", Copy: true})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(storeRepo.OverlayRoot("s1"), "c.txt")); !os.IsNotExist(err) {
+		t.Errorf("over-quota file should not be copied, stat err = %v", err)
+	}
+	track, err := storeRepo.LoadTrack("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tp := range track.Tracked {
+		if tp.Path == "c.txt" {
+			t.Error("over-quota path should not be tracked")
+		}
+	}
+
+	// Without a limit the same add succeeds
+	eng.SetMaxStoreBytes(stores.ScopeGlobal, 0)
+	if _, err := eng.TrackPaths(context.Background(), &TrackPathsRequest{CWD: repoDir, Dir: "c.txt", Copy: true}); err != nil {
+		t.Errorf("TrackPaths without quota failed: %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package engine
 
+import "time"
+
 // PathInfo contains information about an applied path.
 type PathInfo struct {
 	// Store is the store that owns this path
@@ -9,6 +11,28 @@ type PathInfo struct {
 	Type string
 }
 
+// AppliedPathInfo describes when and from which store a workspace path was
+// last applied.
+type AppliedPathInfo struct {
+	// Path is the workspace-relative path
+	Path string
+
+	// Store is the store that owns this path
+	Store string
+
+	// Type is the path type ("symlink" or "copy")
+	Type string
+
+	// AppliedAt is when the path was last applied
+	AppliedAt time.Time
+
+	// Checksum is the truncated checksum recorded for copied files
+	Checksum string
+
+	// AppliedVersion is the monodev version that last wrote the path
+	AppliedVersion string
+}
+
 // AppliedStoreInfo contains information about an applied store.
 type AppliedStoreInfo struct {
 	// StoreID is the store identifier
@@ -34,6 +58,21 @@ type TrackedPathInfo struct {
 
 	// IsModified indicates if the workspace version differs from the store overlay
 	IsModified bool
+
+	// StoreID is the store tracking this path (set by ListTrackedPaths)
+	StoreID string `json:",omitempty"`
+
+	// Scope is the scope of the store tracking this path (set by ListTrackedPaths)
+	Scope string `json:",omitempty"`
+
+	// Kind is "file" or "dir" (set by ListTrackedPaths)
+	Kind string `json:",omitempty"`
+
+	// Role categorizes the tracked path (set by ListTrackedPaths)
+	Role string `json:",omitempty"`
+
+	// Origin indicates how the path was tracked (set by ListTrackedPaths)
+	Origin string `json:",omitempty"`
 }
 
 // WorkspaceUsage describes how a workspace uses a store.
@@ -56,6 +95,76 @@ type WorkspaceInfo struct {
 	ActiveStore      string
 	StackCount       int
 	AppliedPathCount int
+	Tags             []string
+}
+
+// AuditFinding describes an overlay symlink that could lead outside its store.
+type AuditFinding struct {
+	// Path is the symlink's path relative to the overlay root
+	Path string
+
+	// Target is the symlink's target as stored
+	Target string
+
+	// Reason explains why the symlink is unsafe
+	Reason string
+}
+
+// Scope switch change kinds reported by PreviewScopeSwitch.
+const (
+	// ScopeChangeAdded marks a path only the target scope's store installs
+	ScopeChangeAdded = "added"
+
+	// ScopeChangeRemoved marks a path only the current scope's store installs
+	ScopeChangeRemoved = "removed"
+
+	// ScopeChangeRetargeted marks a path both install differently
+	ScopeChangeRetargeted = "retargeted"
+)
+
+// ScopeSwitchChange describes how one path would change when switching a
+// store's scope.
+type ScopeSwitchChange struct {
+	// Path is the workspace-relative destination path
+	Path string
+
+	// Change is ScopeChangeAdded, ScopeChangeRemoved or ScopeChangeRetargeted
+	Change string
+
+	// FromSource is the overlay source in the current scope (empty if added)
+	FromSource string
+
+	// ToSource is the overlay source in the target scope (empty if removed)
+	ToSource string
+}
+
+// Stack path change kinds reported by PreviewStack.
+const (
+	// StackChangeAdded marks a path the proposed stack would start managing
+	StackChangeAdded = "added"
+
+	// StackChangeRemoved marks a managed path the proposed stack does not install
+	StackChangeRemoved = "removed"
+
+	// StackChangeReowned marks a managed path another store would install
+	StackChangeReowned = "reowned"
+)
+
+// StackPathChange describes how one path would change under a proposed stack.
+type StackPathChange struct {
+	// Path is the workspace-relative path
+	Path string
+
+	// Change is StackChangeAdded, StackChangeRemoved or StackChangeReowned
+	Change string
+
+	// FromStore and FromScope identify the current owner (empty if added)
+	FromStore string
+	FromScope string
+
+	// ToStore and ToScope identify the proposed owner (empty if removed)
+	ToStore string
+	ToScope string
 }
 
 // DiffFileInfo contains information about a single diffed file.
@@ -63,9 +172,14 @@ type DiffFileInfo struct {
 	// Path is the relative path from workspace root
 	Path string
 
-	// Status is the diff status: "modified", "added", "removed", "unchanged"
+	// Status is the diff status: "modified", "added", "removed", "renamed",
+	// "unchanged"
 	Status string
 
+	// OldPath is the store-side path of a renamed file (empty otherwise);
+	// Path is then the workspace-side path
+	OldPath string
+
 	// WorkspaceHash is the hash of the file in the workspace (empty if doesn't exist)
 	WorkspaceHash string
 
@@ -83,4 +197,53 @@ type DiffFileInfo struct {
 
 	// IsDir indicates if the path is a directory
 	IsDir bool
+
+	// AppliedHash is the hash of the store file when it was last applied
+	// (empty if the workspace state has no record for the path)
+	AppliedHash string
+
+	// StoreChangedSinceApply is true when the store file differs from
+	// the content that was last applied to the workspace
+	StoreChangedSinceApply bool
+}
+
+// DiffSummary contains per-status counts for a diff operation.
+type DiffSummary struct {
+	// Added is the number of files present only in the workspace
+	Added int
+
+	// Removed is the number of files present only in the store overlay
+	Removed int
+
+	// Modified is the number of files whose content differs
+	Modified int
+
+	// Renamed is the number of files whose content moved to another path
+	Renamed int
+
+	// Unchanged is the number of files with identical content
+	Unchanged int
+
+	// StoreChanged is the number of files whose store content changed since apply
+	StoreChanged int
+
+	// Total is the number of files compared
+	Total int
+}
+
+// add records a file status in the summary.
+func (s *DiffSummary) add(status string) {
+	s.Total++
+	switch status {
+	case "added":
+		s.Added++
+	case "removed":
+		s.Removed++
+	case "modified":
+		s.Modified++
+	case "renamed":
+		s.Renamed++
+	default:
+		s.Unchanged++
+	}
 }
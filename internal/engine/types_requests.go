@@ -1,11 +1,14 @@
 package engine
 
+import "io"
+
 // ApplyRequest represents a request to apply store overlays.
 type ApplyRequest struct {
 	// CWD is the current working directory (workspace path)
 	CWD string
 
-	// Mode is the overlay mode ("symlink" or "copy")
+	// Mode is the overlay mode ("symlink" or "copy"). Empty uses MONODEV_MODE
+	// if set, otherwise copy.
 	Mode string
 
 	// Force allows overwriting conflicts
@@ -16,6 +19,71 @@ type ApplyRequest struct {
 
 	// StoreID is an optional store ID to apply instead of the active store
 	StoreID string
+
+	// TargetDir is an optional directory to apply into instead of CWD.
+	// The workspace identity is still derived from CWD, but workspace state
+	// is not modified since the applied files live outside the workspace.
+	TargetDir string
+
+	// StorePins maps store IDs to refs in the sync repository. A pinned
+	// store is applied as it was committed at that ref instead of from its
+	// local overlay. Pinned stores require copy mode.
+	StorePins map[string]string
+
+	// WarningsAsErrors fails the apply, before any changes, when planning
+	// produces warnings (such as a tracked path missing from the store)
+	WarningsAsErrors bool
+
+	// EmitScript, in dry run, receives a shell script with the commands
+	// equivalent to the planned operations
+	EmitScript io.Writer
+
+	// StagedInstall builds every copy and symlink in a hidden staging
+	// directory first and then renames them into place, so a failure while
+	// building leaves the workspace unchanged
+	StagedInstall bool
+
+	// RefuseOverlap fails the apply, before any changes, when a nested or
+	// enclosing workspace already manages a planned path. Otherwise such
+	// overlaps are reported as plan warnings.
+	RefuseOverlap bool
+
+	// OnlyNew installs only tracked paths the workspace does not already
+	// manage, leaving managed paths untouched even if they have drifted
+	OnlyNew bool
+
+	// DestPrefix is an optional workspace-relative directory that every
+	// tracked path is installed under, such as "vendor/tool". Overlay
+	// sources keep their tracked paths; ownership records the prefixed path.
+	DestPrefix string
+
+	// Resume completes an apply that was interrupted before it recorded
+	// state. The leading planned paths already placed on disk as planned,
+	// and owned by no store, are adopted into workspace ownership and
+	// skipped; only the rest are executed.
+	Resume bool
+
+	// WriteLock records the applied store's ID, scope and overlay hash in
+	// a monodev.lock file in the apply root after a successful apply,
+	// keeping the entries of other stores already in the file
+	WriteLock bool
+
+	// Locked fails the apply, before any changes, unless the store matches
+	// the version recorded in the apply root's monodev.lock
+	Locked bool
+
+	// Vars are the variables tracked paths' When conditions are evaluated
+	// against; a path whose condition does not hold is not applied
+	Vars map[string]string
+}
+
+// ApplyConfirmedRequest represents a request to apply a plan the user has
+// already reviewed, identified by its fingerprint.
+type ApplyConfirmedRequest struct {
+	ApplyRequest
+
+	// Fingerprint is the ApplyPlan.Fingerprint of the confirmed plan
+	Fingerprint string
 }
 
 // UnapplyRequest represents a request to unapply overlays.
@@ -30,6 +98,18 @@ type UnapplyRequest struct {
 	DryRun bool
 }
 
+// UnapplyRepoRequest represents a request to unapply every workspace of a repository.
+type UnapplyRepoRequest struct {
+	// RepoFingerprint selects the workspaces to unapply
+	RepoFingerprint string
+
+	// Force allows removing paths even if validation fails
+	Force bool
+
+	// DryRun reports what would be removed without removing anything
+	DryRun bool
+}
+
 // StatusRequest represents a request for workspace status.
 type StatusRequest struct {
 	// CWD is the current working directory
@@ -41,7 +121,8 @@ type StackApplyRequest struct {
 	// CWD is the current working directory (workspace path)
 	CWD string
 
-	// Mode is the overlay mode ("symlink" or "copy")
+	// Mode is the overlay mode ("symlink" or "copy"). Empty uses MONODEV_MODE
+	// if set, otherwise copy.
 	Mode string
 
 	// Force allows overwriting conflicts
@@ -49,6 +130,39 @@ type StackApplyRequest struct {
 
 	// DryRun performs planning only without making changes
 	DryRun bool
+
+	// Vars are the variables tracked paths' When conditions are evaluated
+	// against; a path whose condition does not hold is not applied
+	Vars map[string]string
+}
+
+// ApplyAllRequest represents a request to apply the stack and the active
+// store together.
+type ApplyAllRequest struct {
+	// CWD is the current working directory (workspace path)
+	CWD string
+
+	// Mode is the overlay mode ("symlink" or "copy"). Empty uses MONODEV_MODE
+	// if set, otherwise copy.
+	Mode string
+
+	// Force allows overwriting conflicts
+	Force bool
+
+	// DryRun performs planning only without making changes
+	DryRun bool
+
+	// WriteLock replaces the workspace's monodev.lock with an entry for
+	// every applied store after a successful apply
+	WriteLock bool
+
+	// Locked fails the apply, before any changes, unless every store
+	// matches the version recorded in the workspace's monodev.lock
+	Locked bool
+
+	// Vars are the variables tracked paths' When conditions are evaluated
+	// against; a path whose condition does not hold is not applied
+	Vars map[string]string
 }
 
 // StackUnapplyRequest represents a request to unapply the stack portion only.
@@ -76,6 +190,101 @@ type DeleteWorkspaceRequest struct {
 	WorkspaceID string
 	Force       bool
 	DryRun      bool
+
+	// Unapply removes every managed path from disk (deepest first) before
+	// deleting the state, so the workspace is fully torn down
+	Unapply bool
+
+	// CWD locates the workspace's repository on disk; required with Unapply
+	CWD string
+}
+
+// TagWorkspaceRequest represents a request to add and remove workspace tags.
+type TagWorkspaceRequest struct {
+	WorkspaceID string
+
+	// Add lists tags to add; tags already present are ignored
+	Add []string
+
+	// Remove lists tags to remove; tags not present are ignored
+	Remove []string
+}
+
+// CloneWorkspaceConfigRequest represents a request to copy one workspace's
+// stack, active store and mode to another workspace.
+type CloneWorkspaceConfigRequest struct {
+	// SourceCWD is a directory in the workspace to copy from
+	SourceCWD string
+
+	// TargetCWD is a directory in the workspace to configure
+	TargetCWD string
+
+	// Apply applies the cloned configuration to the target
+	Apply bool
+
+	// Force allows overwriting conflicts when Apply is set
+	Force bool
+}
+
+// RehomeRequest represents a request to move workspace state to a new path.
+type RehomeRequest struct {
+	// CWD is the current working directory (used to discover the repository)
+	CWD string
+
+	// OldPath is the workspace path before the move (repo-relative or absolute)
+	OldPath string
+
+	// NewPath is the workspace path after the move (repo-relative or absolute)
+	NewPath string
+}
+
+// PreviewScopeSwitchRequest represents a request to preview applying a
+// store from another scope.
+type PreviewScopeSwitchRequest struct {
+	// CWD is the current working directory (workspace path)
+	CWD string
+
+	// StoreID is the store to compare (default: active store)
+	StoreID string
+
+	// FromScope is the scope the store is applied from now. It defaults
+	// to the active store's scope when StoreID is empty.
+	FromScope string
+
+	// ToScope is the scope to preview applying the store from
+	ToScope string
+
+	// Vars are the variables tracked paths' When conditions are evaluated
+	// against, as they would be for the apply being previewed
+	Vars map[string]string
+}
+
+// PreviewStackRequest represents a request to preview applying a proposed
+// stack of stores.
+type PreviewStackRequest struct {
+	// CWD is the current working directory (workspace path)
+	CWD string
+
+	// Stores is the proposed stack, lowest precedence first. The stores
+	// need not be in the workspace's stack.
+	Stores []string
+
+	// Mode is the overlay mode to plan with (default: configured mode)
+	Mode string
+
+	// Vars are the variables tracked paths' When conditions are evaluated
+	// against, as they would be for the apply being previewed
+	Vars map[string]string
+}
+
+// ListStoresRequest represents a request to list stores.
+type ListStoresRequest struct {
+	// SortBy is the store order (see ListStoresSorted)
+	SortBy string
+
+	// IncludeHealth attaches a health summary to each store. It audits every
+	// overlay and scans every workspace, so it is off by default.
+	IncludeHealth bool
 }
 
 // DiffRequest represents a request to diff workspace files against store overlay.
@@ -94,6 +303,27 @@ type DiffRequest struct {
 
 	// NameStatus shows filenames with status indicators (M, A, D)
 	NameStatus bool
+
+	// ChangedOnly omits unchanged files from the result.
+	// The summary still counts every compared file.
+	ChangedOnly bool
+
+	// IncludeExcluded also compares tracked paths marked ExcludeFromDiff
+	IncludeExcluded bool
+
+	// SinceApply compares workspace files against the content recorded
+	// at apply time instead of the current store content. Paths without
+	// an apply record are compared against the store as usual.
+	SinceApply bool
+
+	// StoreRef, if set, compares against the store as committed at this
+	// ref of the sync repository rather than its current overlay
+	StoreRef string
+
+	// DetectRenames pairs a file present only in the store with a file
+	// present only in the workspace when their contents are identical,
+	// reporting them as a single "renamed" entry
+	DetectRenames bool
 }
 
 // StackListRequest represents a request to list the store stack.
@@ -109,6 +339,19 @@ type StackAddRequest struct {
 
 	// StoreID is the store to add to the stack
 	StoreID string
+
+	// Position is the stack index to insert at; 0 is the front (lowest
+	// precedence). Nil or -1 appends (highest precedence).
+	Position *int
+
+	// Apply runs StackApply after the store is added
+	Apply bool
+
+	// Mode is the overlay mode used when Apply is set ("symlink" or "copy")
+	Mode string
+
+	// Force allows overwriting conflicts when Apply is set
+	Force bool
 }
 
 // StackPopRequest represents a request to remove a store from the stack.
@@ -125,3 +368,50 @@ type StackClearRequest struct {
 	// CWD is the current working directory
 	CWD string
 }
+
+// ExportStackRequest represents a request to export the workspace's stack.
+type ExportStackRequest struct {
+	// CWD is the current working directory
+	CWD string
+
+	// Writer receives the JSON export
+	Writer io.Writer
+}
+
+// ImportStackRequest represents a request to import an exported stack.
+type ImportStackRequest struct {
+	// CWD is the current working directory
+	CWD string
+
+	// Reader supplies the JSON export
+	Reader io.Reader
+
+	// Apply runs StackApply after importing
+	Apply bool
+
+	// Force allows overwriting conflicts when Apply is set
+	Force bool
+}
+
+// ReconcileRequest represents a request to adopt existing workspace files
+// into a store's ownership.
+type ReconcileRequest struct {
+	// CWD is the current working directory
+	CWD string
+
+	// StoreID is the store whose tracked paths are adopted (default: active store)
+	StoreID string
+}
+
+// WhoOwnsRequest represents a request to explain the ownership of a path.
+type WhoOwnsRequest struct {
+	// CWD is the current working directory
+	CWD string
+
+	// RelPath is the workspace-relative path to look up
+	RelPath string
+
+	// Vars are the variables tracked paths' When conditions are evaluated
+	// against when working out which store would own the path
+	Vars map[string]string
+}
@@ -21,6 +21,14 @@ type ApplyResult struct {
 
 	// WorkspacePath is the relative path from repo root
 	WorkspacePath string
+
+	// Resolutions lists the choices available for each conflict.
+	// Only populated when apply stops on conflicts.
+	Resolutions []ConflictResolution
+
+	// Resumed lists the paths a Resume apply found already in place and
+	// skipped, sorted
+	Resumed []string
 }
 
 // UnapplyResult represents the result of unapplying overlays.
@@ -34,6 +42,25 @@ type UnapplyResult struct {
 	message string
 }
 
+// UnapplyRepoResult represents the result of unapplying a repository's workspaces.
+type UnapplyRepoResult struct {
+	// Workspaces lists each unapplied workspace, deepest first
+	Workspaces []UnappliedWorkspace
+}
+
+// UnappliedWorkspace reports what UnapplyRepo removed from one workspace.
+type UnappliedWorkspace struct {
+	WorkspaceID   string
+	WorkspacePath string
+
+	// Removed is the number of managed paths removed (or, for a dry run,
+	// that would be removed)
+	Removed int
+
+	// Deleted reports whether the emptied workspace state was deleted
+	Deleted bool
+}
+
 // StatusResult represents the current workspace status.
 type StatusResult struct {
 
@@ -137,6 +164,71 @@ type DescribeWorkspaceResult struct {
 	Stack         []string
 	AppliedStores []state.AppliedStore
 	Paths         map[string]state.PathOwnership
+
+	// AppliedPaths lists each owned path with its apply time, sorted by path
+	AppliedPaths []AppliedPathInfo
+
+	// Tags are the workspace's labels
+	Tags []string
+}
+
+// TagWorkspaceResult represents the result of changing a workspace's tags.
+type TagWorkspaceResult struct {
+	WorkspaceID string
+
+	// Tags are the workspace's tags after the change, sorted
+	Tags []string
+}
+
+// AuditStoreResult represents the result of auditing a store's overlay.
+type AuditStoreResult struct {
+	StoreID string
+	Scope   string
+
+	// Findings lists the overlay symlinks that could lead outside the store,
+	// in walk order; empty means the overlay is safe to apply
+	Findings []AuditFinding
+}
+
+// PreviewScopeSwitchResult represents the paths that would change if a
+// store were applied from another scope.
+type PreviewScopeSwitchResult struct {
+	StoreID   string
+	FromScope string
+	ToScope   string
+
+	// Mode is the overlay mode the comparison assumes
+	Mode string
+
+	// Changes lists the affected paths, sorted by path; paths that would
+	// be installed identically are omitted
+	Changes []ScopeSwitchChange
+}
+
+// PreviewStackResult represents the net effect of applying a proposed stack
+// on the workspace's managed paths.
+type PreviewStackResult struct {
+	// Stores is the proposed stack that was planned
+	Stores []string
+
+	// Mode is the overlay mode the comparison assumes
+	Mode string
+
+	// Changes lists the affected paths, sorted by path; paths the proposed
+	// stack would leave with the same owner are omitted
+	Changes []StackPathChange
+}
+
+// MergeStoresResult represents the result of merging a duplicate store.
+type MergeStoresResult struct {
+	// KeepID is the store that now stands in for the merged store
+	KeepID string
+
+	// MergedID is the deleted store
+	MergedID string
+
+	// Workspaces lists the IDs of rewritten workspaces, sorted
+	Workspaces []string
 }
 
 // DeleteWorkspaceResult represents the result of deleting a workspace.
@@ -146,6 +238,17 @@ type DeleteWorkspaceResult struct {
 	Deleted       bool
 	DryRun        bool
 	PathsRemoved  int
+
+	// Unapplied lists the paths removed from disk when Unapply was requested
+	Unapplied []string
+}
+
+// RehomeResult represents the result of rehoming a workspace.
+type RehomeResult struct {
+	OldWorkspaceID   string
+	NewWorkspaceID   string
+	OldWorkspacePath string
+	NewWorkspacePath string
 }
 
 // DiffResult represents the result of a diff operation.
@@ -158,6 +261,10 @@ type DiffResult struct {
 
 	// Files contains all diffed files with their status
 	Files []DiffFileInfo
+
+	// Summary counts every compared file by status, including unchanged
+	// files omitted by ChangedOnly
+	Summary DiffSummary
 }
 
 // StackListResult represents the result of listing the store stack.
@@ -169,8 +276,120 @@ type StackListResult struct {
 	ActiveStore string
 }
 
+// StackAddResult represents the result of adding a store to the stack.
+type StackAddResult struct {
+	// Stack is the stack after the store was added
+	Stack []string
+
+	// Position is the index the store was inserted at
+	Position int
+
+	// Applied is the stack apply result (nil unless Apply was requested)
+	Applied *StackApplyResult
+}
+
 // StackPopResult represents the result of removing a store from the stack.
 type StackPopResult struct {
 	// Removed is the store that was removed
 	Removed string
 }
+
+// CloneWorkspaceConfigResult represents the result of cloning a workspace's
+// configuration.
+type CloneWorkspaceConfigResult struct {
+	// SourceWorkspaceID is the workspace the configuration was copied from
+	SourceWorkspaceID string
+
+	// WorkspaceID is the target workspace
+	WorkspaceID string
+
+	// Stack is the target's stack after cloning
+	Stack []string
+
+	// ActiveStore is the target's active store after cloning
+	ActiveStore string
+
+	// Mode is the overlay mode copied from the source
+	Mode string
+
+	// StackApplied is the StackApply result (nil unless Apply was requested
+	// and the stack is non-empty)
+	StackApplied *StackApplyResult
+
+	// Applied is the active store's apply result (nil unless Apply was
+	// requested and an active store is set)
+	Applied *ApplyResult
+}
+
+// ImportStackResult represents the result of importing a stack.
+type ImportStackResult struct {
+	// Stack is the imported stack
+	Stack []string
+
+	// ActiveStore is the active store after import
+	ActiveStore string
+
+	// WorkspaceID is the computed workspace ID
+	WorkspaceID string
+
+	// Applied is the StackApply result (nil unless Apply was requested)
+	Applied *StackApplyResult
+}
+
+// ImportStateResult represents the result of importing a state bundle.
+type ImportStateResult struct {
+	// Imported lists the IDs of workspaces whose state was written
+	Imported []string
+
+	// Overwritten lists the IDs of imported workspaces that already had state
+	Overwritten []string
+}
+
+// CompactWorkspaceResult represents the result of compacting workspace state.
+type CompactWorkspaceResult struct {
+	// WorkspaceID is the compacted workspace
+	WorkspaceID string
+
+	// Removed is the number of path entries removed by compaction
+	Removed int
+
+	// Remaining is the number of path entries left
+	Remaining int
+}
+
+// ReconcileResult represents the result of adopting existing workspace files.
+type ReconcileResult struct {
+	// WorkspaceID is the reconciled workspace
+	WorkspaceID string
+
+	// StoreID is the store whose tracked paths were adopted
+	StoreID string
+
+	// Adopted lists the paths now recorded as managed by the store
+	Adopted []string
+
+	// Missing lists tracked paths with nothing at the destination
+	Missing []string
+
+	// Conflicts lists destinations that differ from the store overlay
+	Conflicts []planner.Conflict
+}
+
+// OwnershipResult represents the recorded and computed owners of a path.
+type OwnershipResult struct {
+	// RelPath is the workspace-relative path looked up
+	RelPath string
+
+	// Recorded is the ownership recorded in workspace state, or nil
+	Recorded *state.PathOwnership
+
+	// Candidates lists the stores that track the path, in apply order
+	Candidates []string
+
+	// Winner is the store that would own the path if the stack and the
+	// active store were reapplied, or empty if none tracks it
+	Winner string
+
+	// Pending is true when Winner differs from the recorded owner
+	Pending bool
+}
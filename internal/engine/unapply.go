@@ -69,21 +69,53 @@ func (e *Engine) Unapply(ctx context.Context, req *UnapplyRequest) (*UnapplyResu
 	}
 
 	// Step 5: Remove active store paths in deepest-first order
+	workspaceRoot := filepath.Join(root, workspacePath)
+	removed, err := e.removeManagedPaths(workspaceRoot, workspaceState, activeStorePaths, req.Force)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 6: Update workspace state
+	// do not delete workspace state if no paths remain
+	if len(workspaceState.Paths) > 0 {
+		// Still have paths from other stores - update state
+		workspaceState.Applied = false
+		workspaceState.PruneAppliedStores()
+	}
+
+	if err := e.stateStore.SaveWorkspace(workspaceID, workspaceState); err != nil {
+		return nil, fmt.Errorf("failed to save workspace state: %w", err)
+	}
+	return &UnapplyResult{
+		Removed:     removed,
+		WorkspaceID: workspaceID,
+	}, nil
+}
+
+// removeManagedPaths removes workspace-relative paths under workspaceRoot in
+// deepest-first order and drops them from the workspace state. Unless force
+// is set, each path is first validated against its recorded ownership.
+func (e *Engine) removeManagedPaths(workspaceRoot string, workspaceState *state.WorkspaceState, relPaths []string, force bool) ([]string, error) {
+	// Compacted directories are removed and validated path by path, so
+	// files the workspace does not manage inside them are left alone
+	relPaths, err := e.expandCompactedPaths(workspaceState, relPaths)
+	if err != nil {
+		return nil, err
+	}
+
 	// Sort paths by depth (deepest first)
-	sort.Slice(activeStorePaths, func(i, j int) bool {
+	sort.Slice(relPaths, func(i, j int) bool {
 		// Count path separators to determine depth
-		depthI := countPathSeparators(activeStorePaths[i])
-		depthJ := countPathSeparators(activeStorePaths[j])
+		depthI := countPathSeparators(relPaths[i])
+		depthJ := countPathSeparators(relPaths[j])
 		if depthI != depthJ {
 			return depthI > depthJ // Deeper paths first
 		}
-		return activeStorePaths[i] > activeStorePaths[j] // Alphabetically for same depth
+		return relPaths[i] > relPaths[j] // Alphabetically for same depth
 	})
 
-	workspaceRoot := filepath.Join(root, workspacePath)
-
 	removed := []string{}
-	for _, relPath := range activeStorePaths {
+	for _, relPath := range relPaths {
 		ownership := workspaceState.Paths[relPath]
 
 		// Validate relative path for safety
@@ -95,7 +127,7 @@ func (e *Engine) Unapply(ctx context.Context, req *UnapplyRequest) (*UnapplyResu
 		absPath := filepath.Join(workspaceRoot, relPath)
 
 		// Validate the path before removing (unless force)
-		if !req.Force {
+		if !force {
 			if err := e.validateManagedPath(absPath, ownership); err != nil {
 				return nil, fmt.Errorf("validation failed for %s: %w", relPath, err)
 			}
@@ -110,25 +142,33 @@ func (e *Engine) Unapply(ctx context.Context, req *UnapplyRequest) (*UnapplyResu
 		delete(workspaceState.Paths, relPath)
 		removed = append(removed, relPath)
 	}
+	return removed, nil
+}
 
-	// Step 6: Update workspace state
-	// do not delete workspace state if no paths remain
-	if len(workspaceState.Paths) > 0 {
-		// Still have paths from other stores - update state
-		workspaceState.Applied = false
-		workspaceState.PruneAppliedStores()
-	}
-
-	if err := e.stateStore.SaveWorkspace(workspaceID, workspaceState); err != nil {
-		return nil, fmt.Errorf("failed to save workspace state: %w", err)
+// expandCompactedPaths expands every compacted directory among relPaths in
+// workspace state and returns relPaths with each replaced by its contents.
+func (e *Engine) expandCompactedPaths(workspaceState *state.WorkspaceState, relPaths []string) ([]string, error) {
+	expanded := make([]string, 0, len(relPaths))
+	for len(relPaths) > 0 {
+		relPath := relPaths[0]
+		relPaths = relPaths[1:]
+		if !workspaceState.Paths[relPath].Compacted {
+			expanded = append(expanded, relPath)
+			continue
+		}
+		contents, err := workspaceState.ExpandDir(relPath, e.storeDirEntries)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand compacted ownership: %w", err)
+		}
+		// Directories expanded from a store listing may be compacted in turn
+		relPaths = append(relPaths, contents...)
 	}
-	return &UnapplyResult{
-		Removed:     removed,
-		WorkspaceID: workspaceID,
-	}, nil
+	return expanded, nil
 }
 
 // validateManagedPath validates that a path is still managed by monodev.
+// The path is checked against its own recorded ownership type, not the
+// workspace mode, so mixed-mode workspaces validate correctly.
 func (e *Engine) validateManagedPath(path string, ownership state.PathOwnership) error {
 	// Check if path exists
 	exists, err := e.fs.Exists(path)
@@ -140,6 +180,23 @@ func (e *Engine) validateManagedPath(path string, ownership state.PathOwnership)
 		return nil
 	}
 
+	info, err := e.fs.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat path: %w", err)
+	}
+	isSymlink := info.Mode()&os.ModeSymlink != 0
+
+	switch ownership.Type {
+	case "symlink":
+		if !isSymlink {
+			return fmt.Errorf("%w: expected symlink but found a regular path", ErrDrift)
+		}
+	case "copy":
+		if isSymlink {
+			return fmt.Errorf("%w: expected copy but found a symlink", ErrDrift)
+		}
+	}
+
 	// For copies, check the checksum to detect drift
 	if ownership.Checksum != "" {
 		currentHash, err := e.hasher.HashFile(path)
@@ -164,3 +221,78 @@ func countPathSeparators(path string) int {
 	}
 	return count
 }
+
+// UnapplyRepo unapplies every workspace of a repository, e.g. before its
+// .monodev directory is deleted. All managed paths of each workspace are
+// removed deepest-first, workspaces nested deeper in the repo go first,
+// and the emptied workspace state is deleted. Workspaces are located by
+// their recorded absolute path, so the repository need not be the one in
+// the current directory.
+func (e *Engine) UnapplyRepo(ctx context.Context, req *UnapplyRepoRequest) (*UnapplyRepoResult, error) {
+	if req.RepoFingerprint == "" {
+		return nil, fmt.Errorf("%w: repo fingerprint is required", ErrValidation)
+	}
+
+	list, err := e.ListWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// ListWorkspaces sorts by workspace path, so walking it backwards
+	// visits nested workspaces before the ones containing them
+	result := &UnapplyRepoResult{Workspaces: []UnappliedWorkspace{}}
+	for i := len(list.Workspaces) - 1; i >= 0; i-- {
+		info := list.Workspaces[i]
+		if info.Repo != req.RepoFingerprint {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		unapplied, err := e.unapplyRepoWorkspace(info.WorkspaceID, req)
+		if err != nil {
+			return result, err
+		}
+		result.Workspaces = append(result.Workspaces, *unapplied)
+	}
+	return result, nil
+}
+
+// unapplyRepoWorkspace removes every managed path of one workspace for
+// UnapplyRepo and deletes its state once empty.
+func (e *Engine) unapplyRepoWorkspace(workspaceID string, req *UnapplyRepoRequest) (*UnappliedWorkspace, error) {
+	ws, err := e.stateStore.LoadWorkspace(workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workspace %s: %w", workspaceID, err)
+	}
+	unapplied := &UnappliedWorkspace{WorkspaceID: workspaceID, WorkspacePath: ws.WorkspacePath}
+
+	relPaths := make([]string, 0, len(ws.Paths))
+	for relPath := range ws.Paths {
+		relPaths = append(relPaths, relPath)
+	}
+	if req.DryRun {
+		unapplied.Removed = len(relPaths)
+		return unapplied, nil
+	}
+	if len(relPaths) > 0 && ws.AbsolutePath == "" {
+		return nil, fmt.Errorf("%w: workspace %s has no recorded location", ErrValidation, workspaceID)
+	}
+
+	removed, err := e.removeManagedPaths(ws.AbsolutePath, ws, relPaths, req.Force)
+	if err != nil {
+		// Keep ownership of the paths that were not removed
+		if saveErr := e.stateStore.SaveWorkspace(workspaceID, ws); saveErr != nil {
+			return nil, fmt.Errorf("%w (and failed to save workspace state: %v)", err, saveErr)
+		}
+		return nil, err
+	}
+	unapplied.Removed = len(removed)
+
+	if err := e.stateStore.DeleteWorkspace(workspaceID); err != nil {
+		return nil, fmt.Errorf("failed to delete workspace state: %w", err)
+	}
+	unapplied.Deleted = true
+	return unapplied, nil
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/config"
+	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/state"
+)
+
+// setupMixedModeWorkspace creates a real workspace with one symlinked path and
+// one copied path, both owned by the active store.
+func setupMixedModeWorkspace(t *testing.T) (*Engine, *mockStateStore, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	overlayFile := filepath.Join(tmpDir, "overlay", "Makefile")
+	if err := os.MkdirAll(filepath.Dir(overlayFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overlayFile, []byte("all:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(overlayFile, filepath.Join(repoDir, "Makefile")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "config.yaml"), []byte("k: v\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := state.NewWorkspaceState("fp1", ".", "copy")
+	ws.Applied = true
+	ws.ActiveStore = "s1"
+	ws.Paths["Makefile"] = state.PathOwnership{Store: "s1", Type: "symlink"}
+	ws.Paths["config.yaml"] = state.PathOwnership{Store: "s1", Type: "copy"}
+
+	stateStore := newMockStateStore()
+	stateStore.workspaces[state.ComputeWorkspaceID("fp1", ".")] = ws
+
+	gitRepo := &trackGitRepo{root: repoDir, fingerprint: "fp1", workspacePath: "."}
+	eng := New(gitRepo, newTrackStoreRepo(), stateStore, fsops.NewRealFS(), &mockHasher{}, &mockClock{}, config.Paths{})
+	return eng, stateStore, repoDir
+}
+
+// TestUnapply_MixedModeWorkspaceValidatesEachPathByType verifies that a symlink
+// path and a copy path in the same workspace both validate by their own type.
+func TestUnapply_MixedModeWorkspaceValidatesEachPathByType(t *testing.T) {
+	eng, _, repoDir := setupMixedModeWorkspace(t)
+
+	result, err := eng.Unapply(context.Background(), &UnapplyRequest{CWD: repoDir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Removed) != 2 {
+		t.Errorf("Removed = %v, want both paths", result.Removed)
+	}
+	for _, name := range []string{"Makefile", "config.yaml"} {
+		if _, err := os.Lstat(filepath.Join(repoDir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got err=%v", name, err)
+		}
+	}
+}
+
+// TestUnapply_DetectsPathReplacedWithDifferentType verifies that a symlink path
+// replaced by a regular file is reported as drift.
+func TestUnapply_DetectsPathReplacedWithDifferentType(t *testing.T) {
+	eng, _, repoDir := setupMixedModeWorkspace(t)
+
+	makefile := filepath.Join(repoDir, "Makefile")
+	if err := os.Remove(makefile); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(makefile, []byte("user edits\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := eng.Unapply(context.Background(), &UnapplyRequest{CWD: repoDir})
+	if !errors.Is(err, ErrDrift) {
+		t.Fatalf("expected ErrDrift, got %v", err)
+	}
+	if _, err := os.Stat(makefile); err != nil {
+		t.Errorf("expected user file to be left in place: %v", err)
+	}
+}
+
+func TestUnapplyRepo_OnlyMatchingRepo(t *testing.T) {
+	tmpDir := t.TempDir()
+	fs := fsops.NewRealFS()
+	workspacesDir := filepath.Join(tmpDir, "workspaces")
+	stateStore := state.NewFileStateStore(fs, workspacesDir)
+
+	// Two workspaces of repo fp1 (root and a nested one) and one of fp2
+	workspaces := []struct {
+		repo, path, dir string
+	}{
+		{"fp1", ".", filepath.Join(tmpDir, "repo1")},
+		{"fp1", "sub", filepath.Join(tmpDir, "repo1", "sub")},
+		{"fp2", ".", filepath.Join(tmpDir, "repo2")},
+	}
+	for _, w := range workspaces {
+		if err := os.MkdirAll(w.dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		ws := state.NewWorkspaceState(w.repo, w.path, "copy")
+		ws.AbsolutePath = w.dir
+		for _, name := range []string{"a.txt", "b.txt"} {
+			if err := os.WriteFile(filepath.Join(w.dir, name), []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+			ws.Paths[name] = state.PathOwnership{Store: "s1", Type: "copy"}
+		}
+		if err := stateStore.SaveWorkspace(state.ComputeWorkspaceID(w.repo, w.path), ws); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eng := New(&mockGitRepo{}, &mockStoreRepo{}, stateStore, fs, &mockHasher{}, &mockClock{}, config.Paths{Workspaces: workspacesDir})
+
+	dryRun, err := eng.UnapplyRepo(context.Background(), &UnapplyRepoRequest{RepoFingerprint: "fp1", DryRun: true})
+	if err != nil {
+		t.Fatalf("UnapplyRepo dry run failed: %v", err)
+	}
+	if len(dryRun.Workspaces) != 2 || dryRun.Workspaces[0].Removed != 2 || dryRun.Workspaces[0].Deleted {
+		t.Fatalf("dry run = %+v, want two workspaces with 2 paths each, nothing deleted", dryRun.Workspaces)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "repo1", "a.txt")); err != nil {
+		t.Fatalf("dry run removed a file: %v", err)
+	}
+
+	result, err := eng.UnapplyRepo(context.Background(), &UnapplyRepoRequest{RepoFingerprint: "fp1"})
+	if err != nil {
+		t.Fatalf("UnapplyRepo failed: %v", err)
+	}
+	if len(result.Workspaces) != 2 {
+		t.Fatalf("unapplied %d workspaces, want 2", len(result.Workspaces))
+	}
+	if result.Workspaces[0].WorkspacePath != "sub" || result.Workspaces[1].WorkspacePath != "." {
+		t.Errorf("order = %s, %s; want the nested workspace first", result.Workspaces[0].WorkspacePath, result.Workspaces[1].WorkspacePath)
+	}
+	for _, unapplied := range result.Workspaces {
+		if unapplied.Removed != 2 || !unapplied.Deleted {
+			t.Errorf("workspace %s: removed %d, deleted %v; want 2 removed and deleted", unapplied.WorkspacePath, unapplied.Removed, unapplied.Deleted)
+		}
+	}
+
+	for _, w := range workspaces {
+		_, statErr := os.Stat(filepath.Join(w.dir, "a.txt"))
+		_, loadErr := stateStore.LoadWorkspace(state.ComputeWorkspaceID(w.repo, w.path))
+		if w.repo == "fp1" {
+			if !os.IsNotExist(statErr) || !os.IsNotExist(loadErr) {
+				t.Errorf("%s/%s: file err %v, state err %v; want both removed", w.repo, w.path, statErr, loadErr)
+			}
+		} else if statErr != nil || loadErr != nil {
+			t.Errorf("%s/%s: file err %v, state err %v; want other repo untouched", w.repo, w.path, statErr, loadErr)
+		}
+	}
+
+	if _, err := eng.UnapplyRepo(context.Background(), &UnapplyRepoRequest{}); !errors.Is(err, ErrValidation) {
+		t.Errorf("UnapplyRepo without fingerprint error = %v, want ErrValidation", err)
+	}
+}
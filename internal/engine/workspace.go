@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
+
+	"github.com/danieljhkim/monodev/internal/state"
 )
 
 // ListWorkspaces enumerates all workspace state files and returns summary information.
@@ -49,6 +52,7 @@ func (e *Engine) ListWorkspaces(ctx context.Context) (*ListWorkspacesResult, err
 				ActiveStore:      ws.ActiveStore,
 				StackCount:       len(ws.Stack),
 				AppliedPathCount: len(ws.Paths),
+				Tags:             ws.Tags,
 			})
 		}
 	}
@@ -86,16 +90,117 @@ func (e *Engine) DescribeWorkspace(ctx context.Context, workspaceID string) (*De
 		Stack:         ws.Stack,
 		AppliedStores: ws.AppliedStores,
 		Paths:         ws.Paths,
+		AppliedPaths:  appliedPaths(ws),
+		Tags:          ws.Tags,
 	}, nil
 }
 
+// ListWorkspacesByTag lists the workspaces carrying tag, in ListWorkspaces
+// order.
+func (e *Engine) ListWorkspacesByTag(ctx context.Context, tag string) (*ListWorkspacesResult, error) {
+	result, err := e.ListWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Workspaces = slices.DeleteFunc(result.Workspaces, func(info WorkspaceInfo) bool {
+		return !slices.Contains(info.Tags, tag)
+	})
+	return result, nil
+}
+
+// TagWorkspace adds and removes tags on a workspace. Tags are trimmed and
+// kept sorted and unique; a tag both added and removed ends up removed.
+func (e *Engine) TagWorkspace(ctx context.Context, req *TagWorkspaceRequest) (*TagWorkspaceResult, error) {
+	ws, err := e.stateStore.LoadWorkspace(req.WorkspaceID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: workspace '%s' not found", ErrNotFound, req.WorkspaceID)
+		}
+		return nil, fmt.Errorf("failed to load workspace: %w", err)
+	}
+
+	tags := slices.Clone(ws.Tags)
+	for _, tag := range req.Add {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, fmt.Errorf("%w: tags must not be empty", ErrValidation)
+		}
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	tags = slices.Compact(tags)
+	for _, tag := range req.Remove {
+		tags = slices.DeleteFunc(tags, func(t string) bool { return t == strings.TrimSpace(tag) })
+	}
+	if len(tags) == 0 {
+		tags = nil
+	}
+
+	if !slices.Equal(tags, ws.Tags) {
+		ws.Tags = tags
+		if err := e.stateStore.SaveWorkspace(req.WorkspaceID, ws); err != nil {
+			return nil, fmt.Errorf("failed to save workspace state: %w", err)
+		}
+	}
+	return &TagWorkspaceResult{WorkspaceID: req.WorkspaceID, Tags: tags}, nil
+}
+
+// DescribeWorkspaceByPath describes the workspace identified by a repo
+// fingerprint and repo-relative workspace path, computing its ID directly.
+// Like DescribeWorkspace it needs no git repository, so it works from any
+// directory.
+func (e *Engine) DescribeWorkspaceByPath(ctx context.Context, repoFingerprint, workspacePath string) (*DescribeWorkspaceResult, error) {
+	if repoFingerprint == "" {
+		return nil, fmt.Errorf("%w: repo fingerprint is required", ErrValidation)
+	}
+	if workspacePath == "" {
+		workspacePath = "."
+	}
+	if filepath.IsAbs(workspacePath) {
+		return nil, fmt.Errorf("%w: workspace path %s must be relative to the repository root", ErrValidation, workspacePath)
+	}
+	workspacePath = filepath.Clean(workspacePath)
+	if workspacePath == ".." || strings.HasPrefix(workspacePath, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%w: workspace path %s is outside the repository", ErrValidation, workspacePath)
+	}
+
+	return e.DescribeWorkspace(ctx, state.ComputeWorkspaceID(repoFingerprint, workspacePath))
+}
+
+// checksumDisplayLen is how many characters of a checksum describe shows.
+const checksumDisplayLen = 12
+
+// appliedPaths lists the workspace's owned paths sorted by path.
+func appliedPaths(ws *state.WorkspaceState) []AppliedPathInfo {
+	paths := make([]AppliedPathInfo, 0, len(ws.Paths))
+	for relPath, ownership := range ws.Paths {
+		checksum := ownership.Checksum
+		if len(checksum) > checksumDisplayLen {
+			checksum = checksum[:checksumDisplayLen]
+		}
+		paths = append(paths, AppliedPathInfo{
+			Path:           relPath,
+			Store:          ownership.Store,
+			Type:           ownership.Type,
+			AppliedAt:      ownership.Timestamp,
+			Checksum:       checksum,
+			AppliedVersion: ownership.AppliedVersion,
+		})
+	}
+	slices.SortFunc(paths, func(a, b AppliedPathInfo) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return paths
+}
+
 // DeleteWorkspace deletes a workspace state file.
 // Algorithm steps:
 // 1. Load workspace state (error if not found)
 // 2. If DryRun: return preview of what would be deleted
-// 3. If Applied==true && len(Paths)>0 && !Force: error with message to unapply first
-// 4. Otherwise: call stateStore.DeleteWorkspace(workspaceID)
-// 5. Return result with deletion status
+// 3. If Applied==true && len(Paths)>0 && !Force && !Unapply: error with message to unapply first
+// 4. If Unapply: remove all managed paths from disk, deepest first
+// 5. Call stateStore.DeleteWorkspace(workspaceID)
+// 6. Return result with deletion status
 func (e *Engine) DeleteWorkspace(ctx context.Context, req *DeleteWorkspaceRequest) (*DeleteWorkspaceResult, error) {
 	// Step 1: Load workspace state
 	ws, err := e.stateStore.LoadWorkspace(req.WorkspaceID)
@@ -120,21 +225,243 @@ func (e *Engine) DeleteWorkspace(ctx context.Context, req *DeleteWorkspaceReques
 	}
 
 	// Step 3: Check if workspace has applied paths and force is not set
-	if ws.Applied && len(ws.Paths) > 0 && !req.Force {
+	if ws.Applied && len(ws.Paths) > 0 && !req.Force && !req.Unapply {
 		return nil, fmt.Errorf("workspace '%s' has %d applied path(s); unapply first or use --force", req.WorkspaceID, len(ws.Paths))
 	}
 
-	// Step 4: Delete workspace
+	// Step 4: Remove managed files before their ownership record is lost
+	var unapplied []string
+	if req.Unapply {
+		unapplied, err = e.unapplyWorkspace(req, ws)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Step 5: Delete workspace
 	if err := e.stateStore.DeleteWorkspace(req.WorkspaceID); err != nil {
 		return nil, fmt.Errorf("failed to delete workspace: %w", err)
 	}
 
-	// Step 5: Return result
+	// Step 6: Return result
 	return &DeleteWorkspaceResult{
 		WorkspaceID:   req.WorkspaceID,
 		WorkspacePath: ws.WorkspacePath,
 		Deleted:       true,
 		DryRun:        false,
 		PathsRemoved:  pathsRemoved,
+		Unapplied:     unapplied,
 	}, nil
 }
+
+// unapplyWorkspace removes every managed path of the workspace being deleted.
+// The repository is located from req.CWD and must be the one the workspace
+// belongs to.
+func (e *Engine) unapplyWorkspace(req *DeleteWorkspaceRequest, ws *state.WorkspaceState) ([]string, error) {
+	if req.CWD == "" {
+		return nil, fmt.Errorf("%w: unapply requires the workspace's repository directory", ErrValidation)
+	}
+	root, fingerprint, _, err := e.DiscoverWorkspace(req.CWD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover workspace: %w", err)
+	}
+	if state.ComputeWorkspaceID(fingerprint, ws.WorkspacePath) != req.WorkspaceID {
+		return nil, fmt.Errorf("%w: workspace '%s' does not belong to the repository at %s", ErrValidation, req.WorkspaceID, root)
+	}
+
+	relPaths := make([]string, 0, len(ws.Paths))
+	for relPath := range ws.Paths {
+		relPaths = append(relPaths, relPath)
+	}
+	return e.removeManagedPaths(filepath.Join(root, ws.WorkspacePath), ws, relPaths, req.Force)
+}
+
+// RehomeWorkspace moves a workspace's state to the ID derived from a new
+// workspace path, e.g. after a component directory is moved within the repo.
+// Applied paths are workspace-relative, so they carry over unchanged.
+// Algorithm steps:
+// 1. Resolve old and new workspace paths relative to the repo root
+// 2. Load state at the old ID (error if missing)
+// 3. Error if state already exists at the new ID
+// 4. Save state under the new ID, then delete the old state
+func (e *Engine) RehomeWorkspace(ctx context.Context, req *RehomeRequest) (*RehomeResult, error) {
+	root, repoFingerprint, _, err := e.DiscoverWorkspace(req.CWD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover workspace: %w", err)
+	}
+
+	// Step 1: Resolve workspace paths
+	oldPath, err := e.repoRelWorkspacePath(root, req.OldPath)
+	if err != nil {
+		return nil, err
+	}
+	newPath, err := e.repoRelWorkspacePath(root, req.NewPath)
+	if err != nil {
+		return nil, err
+	}
+	if oldPath == newPath {
+		return nil, fmt.Errorf("%w: old and new workspace paths are both %s", ErrValidation, oldPath)
+	}
+
+	oldID := state.ComputeWorkspaceID(repoFingerprint, oldPath)
+	newID := state.ComputeWorkspaceID(repoFingerprint, newPath)
+
+	// Step 2: Load existing state
+	ws, err := e.stateStore.LoadWorkspace(oldID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: no workspace state for %s", ErrNotFound, oldPath)
+		}
+		return nil, fmt.Errorf("failed to load workspace: %w", err)
+	}
+
+	// Step 3: Refuse to overwrite state at the destination
+	if _, err := e.stateStore.LoadWorkspace(newID); err == nil {
+		return nil, fmt.Errorf("%w: workspace state already exists for %s", ErrValidation, newPath)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to check workspace state for %s: %w", newPath, err)
+	}
+
+	// Step 4: Move the state
+	ws.WorkspacePath = newPath
+	ws.AbsolutePath = filepath.Join(root, newPath)
+	if err := e.stateStore.SaveWorkspace(newID, ws); err != nil {
+		return nil, fmt.Errorf("failed to save workspace state: %w", err)
+	}
+	if err := e.stateStore.DeleteWorkspace(oldID); err != nil {
+		return nil, fmt.Errorf("failed to delete old workspace state: %w", err)
+	}
+
+	return &RehomeResult{
+		OldWorkspaceID:   oldID,
+		NewWorkspaceID:   newID,
+		OldWorkspacePath: oldPath,
+		NewWorkspacePath: newPath,
+	}, nil
+}
+
+// repoRelWorkspacePath normalizes a workspace path to the repo-relative form
+// used in workspace IDs. Absolute paths are made relative to root.
+func (e *Engine) repoRelWorkspacePath(root, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("%w: workspace path is required", ErrValidation)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	rel, err := e.gitRepo.RelPath(root, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute workspace path: %w", err)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: workspace path %s is outside the repository", ErrValidation, path)
+	}
+	return rel, nil
+}
+
+// CloneWorkspaceConfig copies a workspace's stack, active store and mode to
+// another workspace, so one component's setup can be replicated onto
+// another. Applied paths are not copied; set Apply to apply the cloned
+// configuration to the target.
+// Algorithm steps:
+// 1. Load the source workspace state (error if missing)
+// 2. Validate the referenced stores resolve from the target
+// 3. Set stack, active store and mode on the target state
+// 4. Optionally apply the stack and active store to the target
+func (e *Engine) CloneWorkspaceConfig(ctx context.Context, req *CloneWorkspaceConfigRequest) (*CloneWorkspaceConfigResult, error) {
+	_, srcFingerprint, srcPath, err := e.DiscoverWorkspace(req.SourceCWD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover source workspace: %w", err)
+	}
+	root, repoFingerprint, workspacePath, err := e.DiscoverWorkspace(req.TargetCWD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover target workspace: %w", err)
+	}
+
+	srcID := state.ComputeWorkspaceID(srcFingerprint, srcPath)
+	workspaceID := state.ComputeWorkspaceID(repoFingerprint, workspacePath)
+	if srcID == workspaceID {
+		return nil, fmt.Errorf("%w: source and target are the same workspace", ErrValidation)
+	}
+
+	// Step 1: Load the source configuration
+	src, err := e.stateStore.LoadWorkspace(srcID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: no workspace state for %s", ErrNotFound, req.SourceCWD)
+		}
+		return nil, fmt.Errorf("failed to load source workspace: %w", err)
+	}
+
+	// Step 2: Validate referenced stores before touching the target
+	for _, storeID := range src.Stack {
+		locations, err := e.findStore(storeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if store exists: %w", err)
+		}
+		if len(locations) == 0 {
+			return nil, fmt.Errorf("%w: store %s does not exist", ErrNotFound, storeID)
+		}
+	}
+	activeScope := ""
+	if src.ActiveStore != "" {
+		_, scope, err := e.resolveStoreRepo(src.ActiveStore, src.ActiveStoreScope)
+		if err != nil {
+			return nil, err
+		}
+		activeScope = scope
+	}
+
+	mode := src.Mode
+	if mode == "" {
+		mode = "copy"
+	}
+
+	// Step 3: Configure the target
+	workspaceState, _, err := e.LoadOrCreateWorkspaceState(root, repoFingerprint, workspacePath, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load or create workspace state: %w", err)
+	}
+	workspaceState.Stack = append([]string{}, src.Stack...)
+	workspaceState.ActiveStore = src.ActiveStore
+	workspaceState.ActiveStoreScope = activeScope
+	workspaceState.Mode = mode
+
+	if err := e.stateStore.SaveWorkspace(workspaceID, workspaceState); err != nil {
+		return nil, fmt.Errorf("failed to save workspace state: %w", err)
+	}
+
+	result := &CloneWorkspaceConfigResult{
+		SourceWorkspaceID: srcID,
+		WorkspaceID:       workspaceID,
+		Stack:             workspaceState.Stack,
+		ActiveStore:       workspaceState.ActiveStore,
+		Mode:              mode,
+	}
+	if !req.Apply {
+		return result, nil
+	}
+
+	// Step 4: Apply the stack, then the active store on top
+	if len(workspaceState.Stack) > 0 {
+		result.StackApplied, err = e.StackApply(ctx, &StackApplyRequest{
+			CWD:   req.TargetCWD,
+			Mode:  mode,
+			Force: req.Force,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to apply stack: %w", err)
+		}
+	}
+	if workspaceState.ActiveStore != "" {
+		result.Applied, err = e.Apply(ctx, &ApplyRequest{
+			CWD:   req.TargetCWD,
+			Mode:  mode,
+			Force: req.Force,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to apply active store: %w", err)
+		}
+	}
+	return result, nil
+}
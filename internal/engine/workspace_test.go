@@ -2,12 +2,16 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/danieljhkim/monodev/internal/config"
 	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/gitx"
 	"github.com/danieljhkim/monodev/internal/state"
 )
 
@@ -239,6 +243,41 @@ func TestDescribeWorkspace_Found(t *testing.T) {
 	}
 }
 
+func TestDescribeWorkspace_AppliedPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateStore := state.NewFileStateStore(fsops.NewRealFS(), tmpDir)
+	eng := &Engine{stateStore: stateStore}
+
+	copiedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	linkedAt := time.Date(2024, 3, 2, 11, 30, 0, 0, time.UTC)
+	ws := state.NewWorkspaceState("repo1", ".", "copy")
+	ws.Paths["z.txt"] = state.PathOwnership{Store: "store1", Type: "copy", Timestamp: copiedAt, Checksum: "0123456789abcdef0123"}
+	ws.Paths["a/link"] = state.PathOwnership{Store: "store2", Type: "symlink", Timestamp: linkedAt}
+	if err := stateStore.SaveWorkspace("workspace1", ws); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := eng.DescribeWorkspace(context.Background(), "workspace1")
+	if err != nil {
+		t.Fatalf("DescribeWorkspace failed: %v", err)
+	}
+
+	want := []AppliedPathInfo{
+		{Path: "a/link", Store: "store2", Type: "symlink", AppliedAt: linkedAt},
+		{Path: "z.txt", Store: "store1", Type: "copy", AppliedAt: copiedAt, Checksum: "0123456789ab"},
+	}
+	if len(result.AppliedPaths) != len(want) {
+		t.Fatalf("AppliedPaths = %+v, want %+v", result.AppliedPaths, want)
+	}
+	for i := range want {
+		got := result.AppliedPaths[i]
+		if got.Path != want[i].Path || got.Store != want[i].Store || got.Type != want[i].Type ||
+			!got.AppliedAt.Equal(want[i].AppliedAt) || got.Checksum != want[i].Checksum {
+			t.Errorf("AppliedPaths[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
 func TestDescribeWorkspace_NotFound(t *testing.T) {
 	// Setup
 	tmpDir := t.TempDir()
@@ -266,6 +305,40 @@ func TestDescribeWorkspace_NotFound(t *testing.T) {
 	}
 }
 
+func TestDescribeWorkspaceByPath_OutsideRepo(t *testing.T) {
+	stateStore := state.NewFileStateStore(fsops.NewRealFS(), t.TempDir())
+	gitRepo := gitx.NewFakeGitRepo("/repo", "fp1")
+	gitRepo.SetError(errors.New("not a git repository"))
+	eng := &Engine{gitRepo: gitRepo, stateStore: stateStore}
+
+	ws := state.NewWorkspaceState("fp1", filepath.Join("services", "api"), "copy")
+	ws.ActiveStore = "store1"
+	if err := stateStore.SaveWorkspace(state.ComputeWorkspaceID("fp1", filepath.Join("services", "api")), ws); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unclean spellings resolve to the same workspace
+	result, err := eng.DescribeWorkspaceByPath(context.Background(), "fp1", "services/./api/")
+	if err != nil {
+		t.Fatalf("DescribeWorkspaceByPath failed: %v", err)
+	}
+	if result.ActiveStore != "store1" || result.WorkspaceID != state.ComputeWorkspaceID("fp1", filepath.Join("services", "api")) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if _, err := eng.DescribeWorkspaceByPath(context.Background(), "fp1", "other"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown path error = %v, want ErrNotFound", err)
+	}
+	for _, path := range []string{"../escape", "/abs/path"} {
+		if _, err := eng.DescribeWorkspaceByPath(context.Background(), "fp1", path); !errors.Is(err, ErrValidation) {
+			t.Errorf("path %q error = %v, want ErrValidation", path, err)
+		}
+	}
+	if _, err := eng.DescribeWorkspaceByPath(context.Background(), "", "services/api"); !errors.Is(err, ErrValidation) {
+		t.Errorf("missing fingerprint error = %v, want ErrValidation", err)
+	}
+}
+
 func TestDeleteWorkspace_Success(t *testing.T) {
 	// Setup
 	tmpDir := t.TempDir()
@@ -501,3 +574,313 @@ func TestDeleteWorkspace_NotFound(t *testing.T) {
 		t.Errorf("DeleteWorkspace() result = %v, want nil", result)
 	}
 }
+
+func TestDeleteWorkspace_Unapply(t *testing.T) {
+	for _, unapply := range []bool{true, false} {
+		name := "plain delete leaves files"
+		if unapply {
+			name = "unapply removes files"
+		}
+		t.Run(name, func(t *testing.T) {
+			eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n", "dir/b.txt": "b\n"}, nil)
+			if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}); err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+
+			workspaceID := state.ComputeWorkspaceID("fp1", ".")
+			result, err := eng.DeleteWorkspace(context.Background(), &DeleteWorkspaceRequest{
+				WorkspaceID: workspaceID,
+				Force:       !unapply,
+				Unapply:     unapply,
+				CWD:         repoDir,
+			})
+			if err != nil {
+				t.Fatalf("DeleteWorkspace failed: %v", err)
+			}
+			if !result.Deleted {
+				t.Error("expected workspace to be deleted")
+			}
+			if _, err := eng.stateStore.LoadWorkspace(workspaceID); !os.IsNotExist(err) {
+				t.Errorf("expected workspace state to be gone, got %v", err)
+			}
+
+			for _, rel := range []string{"a.txt", "dir/b.txt"} {
+				_, err := os.Lstat(filepath.Join(repoDir, rel))
+				if unapply && !os.IsNotExist(err) {
+					t.Errorf("%s should be removed, got %v", rel, err)
+				}
+				if !unapply && err != nil {
+					t.Errorf("%s should be left on disk, got %v", rel, err)
+				}
+			}
+			if unapply && len(result.Unapplied) != 2 {
+				t.Errorf("Unapplied = %v, want 2 paths", result.Unapplied)
+			}
+		})
+	}
+}
+
+func TestDeleteWorkspace_UnapplyRequiresMatchingRepo(t *testing.T) {
+	eng, repoDir := setupDiffEngine(t, map[string]string{"a.txt": "a\n"}, nil)
+	if _, err := eng.Apply(context.Background(), &ApplyRequest{CWD: repoDir, StoreID: "s1", Mode: "copy"}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	workspaceID := state.ComputeWorkspaceID("fp1", ".")
+	if err := eng.stateStore.SaveWorkspace("other", mustLoadWorkspace(t, eng, workspaceID)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := eng.DeleteWorkspace(context.Background(), &DeleteWorkspaceRequest{WorkspaceID: "other", Unapply: true, CWD: repoDir})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(repoDir, "a.txt")); err != nil {
+		t.Errorf("a.txt should be left on disk, got %v", err)
+	}
+}
+
+func mustLoadWorkspace(t *testing.T, eng *Engine, workspaceID string) *state.WorkspaceState {
+	t.Helper()
+	ws, err := eng.stateStore.LoadWorkspace(workspaceID)
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	return ws
+}
+
+func TestRehomeWorkspace_MovesState(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	workspacesDir := filepath.Join(tmpDir, "workspaces")
+	fs := fsops.NewRealFS()
+	stateStore := state.NewFileStateStore(fs, workspacesDir)
+
+	eng := &Engine{
+		gitRepo:    gitx.NewFakeGitRepo("/repo", "fp1"),
+		stateStore: stateStore,
+	}
+
+	oldID := state.ComputeWorkspaceID("fp1", "services/api")
+	ws := state.NewWorkspaceState("fp1", "services/api", "copy")
+	ws.Paths["Makefile"] = state.PathOwnership{Store: "s1", Type: "copy"}
+	if err := stateStore.SaveWorkspace(oldID, ws); err != nil {
+		t.Fatal(err)
+	}
+
+	// Execute
+	result, err := eng.RehomeWorkspace(context.Background(), &RehomeRequest{
+		CWD:     "/repo",
+		OldPath: "services/api",
+		NewPath: "/repo/apps/api",
+	})
+
+	// Verify
+	if err != nil {
+		t.Fatalf("RehomeWorkspace() error = %v", err)
+	}
+	newID := state.ComputeWorkspaceID("fp1", "apps/api")
+	if result.OldWorkspaceID != oldID || result.NewWorkspaceID != newID {
+		t.Errorf("result = %+v, want IDs %s -> %s", result, oldID, newID)
+	}
+	if _, err := stateStore.LoadWorkspace(oldID); !os.IsNotExist(err) {
+		t.Errorf("old workspace state still present, err = %v", err)
+	}
+	moved, err := stateStore.LoadWorkspace(newID)
+	if err != nil {
+		t.Fatalf("failed to load rehomed workspace: %v", err)
+	}
+	if moved.WorkspacePath != "apps/api" {
+		t.Errorf("WorkspacePath = %q, want apps/api", moved.WorkspacePath)
+	}
+	if _, ok := moved.Paths["Makefile"]; !ok {
+		t.Error("rehomed workspace lost its applied paths")
+	}
+}
+
+func TestRehomeWorkspace_DestinationExists(t *testing.T) {
+	// Setup
+	stateStore := newMockStateStore()
+	eng := &Engine{
+		gitRepo:    gitx.NewFakeGitRepo("/repo", "fp1"),
+		stateStore: stateStore,
+	}
+	for _, p := range []string{"old", "new"} {
+		id := state.ComputeWorkspaceID("fp1", p)
+		if err := stateStore.SaveWorkspace(id, state.NewWorkspaceState("fp1", p, "copy")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Execute
+	_, err := eng.RehomeWorkspace(context.Background(), &RehomeRequest{CWD: "/repo", OldPath: "old", NewPath: "new"})
+
+	// Verify
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("RehomeWorkspace() error = %v, want ErrValidation", err)
+	}
+	if _, err := stateStore.LoadWorkspace(state.ComputeWorkspaceID("fp1", "old")); err != nil {
+		t.Errorf("old workspace state should be untouched: %v", err)
+	}
+}
+
+func TestRehomeWorkspace_NotFound(t *testing.T) {
+	eng := &Engine{
+		gitRepo:    gitx.NewFakeGitRepo("/repo", "fp1"),
+		stateStore: newMockStateStore(),
+	}
+
+	_, err := eng.RehomeWorkspace(context.Background(), &RehomeRequest{CWD: "/repo", OldPath: "old", NewPath: "new"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("RehomeWorkspace() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTagWorkspace_FilterListByTag(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	workspacesDir := filepath.Join(tmpDir, "workspaces")
+	if err := os.MkdirAll(workspacesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := fsops.NewRealFS()
+	stateStore := state.NewFileStateStore(fs, workspacesDir)
+	configPaths := config.Paths{Workspaces: workspacesDir}
+
+	eng := &Engine{
+		stateStore:  stateStore,
+		configPaths: configPaths,
+	}
+
+	for _, id := range []string{"workspace1", "workspace2", "workspace3"} {
+		ws := state.NewWorkspaceState("repo1", "path/to/"+id, "copy")
+		if err := stateStore.SaveWorkspace(id, ws); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Execute
+	ctx := context.Background()
+	if _, err := eng.TagWorkspace(ctx, &TagWorkspaceRequest{WorkspaceID: "workspace1", Add: []string{"experiment", "prod-like"}}); err != nil {
+		t.Fatalf("TagWorkspace() error = %v", err)
+	}
+	if _, err := eng.TagWorkspace(ctx, &TagWorkspaceRequest{WorkspaceID: "workspace2", Add: []string{"experiment"}}); err != nil {
+		t.Fatalf("TagWorkspace() error = %v", err)
+	}
+	result, err := eng.TagWorkspace(ctx, &TagWorkspaceRequest{WorkspaceID: "workspace1", Remove: []string{"experiment"}})
+	if err != nil {
+		t.Fatalf("TagWorkspace() error = %v", err)
+	}
+
+	// Verify
+	if len(result.Tags) != 1 || result.Tags[0] != "prod-like" {
+		t.Errorf("Tags = %v, want [prod-like]", result.Tags)
+	}
+
+	list, err := eng.ListWorkspacesByTag(ctx, "experiment")
+	if err != nil {
+		t.Fatalf("ListWorkspacesByTag() error = %v", err)
+	}
+	if len(list.Workspaces) != 1 || list.Workspaces[0].WorkspaceID != "workspace2" {
+		t.Errorf("ListWorkspacesByTag(experiment) = %+v, want only workspace2", list.Workspaces)
+	}
+
+	desc, err := eng.DescribeWorkspace(ctx, "workspace1")
+	if err != nil {
+		t.Fatalf("DescribeWorkspace() error = %v", err)
+	}
+	if len(desc.Tags) != 1 || desc.Tags[0] != "prod-like" {
+		t.Errorf("DescribeWorkspace() Tags = %v, want [prod-like]", desc.Tags)
+	}
+}
+
+func TestTagWorkspace_NotFound(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	fs := fsops.NewRealFS()
+	stateStore := state.NewFileStateStore(fs, tmpDir)
+
+	eng := &Engine{
+		stateStore: stateStore,
+	}
+
+	// Execute
+	_, err := eng.TagWorkspace(context.Background(), &TagWorkspaceRequest{WorkspaceID: "missing", Add: []string{"experiment"}})
+
+	// Verify
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("TagWorkspace() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCloneWorkspaceConfig_CopiesStackAndApplies(t *testing.T) {
+	storeRepo, repoDir := setupStackExportStores(t)
+	stateStore := newMockStateStore()
+	eng := New(gitx.NewFakeGitRepo(repoDir, "fp1"),
+		storeRepo, stateStore, fsops.NewRealFS(), &mockHasher{}, &mockClock{}, config.Paths{})
+
+	src := state.NewWorkspaceState("fp1", ".", "copy")
+	src.Stack = []string{"base", "team"}
+	src.ActiveStore = "team"
+	stateStore.workspaces[state.ComputeWorkspaceID("fp1", ".")] = src
+
+	subDir := filepath.Join(repoDir, "sub")
+	result, err := eng.CloneWorkspaceConfig(context.Background(), &CloneWorkspaceConfigRequest{
+		SourceCWD: repoDir,
+		TargetCWD: subDir,
+		Apply:     true,
+	})
+	if err != nil {
+		t.Fatalf("CloneWorkspaceConfig failed: %v", err)
+	}
+
+	target := stateStore.workspaces[state.ComputeWorkspaceID("fp1", "sub")]
+	if target == nil {
+		t.Fatal("expected target workspace state to be saved")
+	}
+	if result.WorkspaceID != state.ComputeWorkspaceID("fp1", "sub") {
+		t.Errorf("WorkspaceID = %q, want the target's ID", result.WorkspaceID)
+	}
+	if strings.Join(target.Stack, ",") != "base,team" || target.ActiveStore != "team" || target.Mode != "copy" {
+		t.Errorf("target state = stack %v, active %q, mode %q; want [base team], team, copy",
+			target.Stack, target.ActiveStore, target.Mode)
+	}
+	if result.StackApplied == nil || result.Applied == nil {
+		t.Fatal("expected stack and active store apply results")
+	}
+	for _, name := range []string{"base.mk", "team.mk"} {
+		if _, err := os.Stat(filepath.Join(subDir, name)); err != nil {
+			t.Errorf("expected %s applied into target workspace: %v", name, err)
+		}
+		if _, ok := target.Paths[name]; !ok {
+			t.Errorf("expected target to own %s", name)
+		}
+	}
+
+	// The source is left as it was
+	if len(src.Paths) != 0 {
+		t.Errorf("source paths = %v, want none", src.Paths)
+	}
+}
+
+func TestCloneWorkspaceConfig_RejectsMissingStore(t *testing.T) {
+	storeRepo, repoDir := setupStackExportStores(t)
+	stateStore := newMockStateStore()
+	eng := New(gitx.NewFakeGitRepo(repoDir, "fp1"),
+		storeRepo, stateStore, fsops.NewRealFS(), &mockHasher{}, &mockClock{}, config.Paths{})
+
+	src := state.NewWorkspaceState("fp1", ".", "copy")
+	src.Stack = []string{"base", "ghost"}
+	stateStore.workspaces[state.ComputeWorkspaceID("fp1", ".")] = src
+
+	_, err := eng.CloneWorkspaceConfig(context.Background(), &CloneWorkspaceConfigRequest{
+		SourceCWD: repoDir,
+		TargetCWD: filepath.Join(repoDir, "sub"),
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, ok := stateStore.workspaces[state.ComputeWorkspaceID("fp1", "sub")]; ok {
+		t.Error("expected no target workspace state to be written")
+	}
+}
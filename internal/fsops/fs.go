@@ -14,9 +14,11 @@ package fsops
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // FS provides an abstraction for filesystem operations.
@@ -43,12 +45,21 @@ type FS interface {
 	// Copy copies a file or directory from src to dst.
 	Copy(src, dst string) error
 
+	// Rename moves oldpath to newpath, replacing newpath if it is a file.
+	Rename(oldpath, newpath string) error
+
+	// Chtimes sets the access and modification times of path.
+	Chtimes(path string, atime, mtime time.Time) error
+
 	// AtomicWrite writes data to path atomically using temp file + rename.
 	AtomicWrite(path string, data []byte, perm os.FileMode) error
 
 	// ReadFile reads the entire contents of a file.
 	ReadFile(path string) ([]byte, error)
 
+	// ReadDir returns the entries of a directory sorted by name.
+	ReadDir(path string) ([]os.DirEntry, error)
+
 	// Exists checks if a path exists.
 	Exists(path string) (bool, error)
 
@@ -57,16 +68,36 @@ type FS interface {
 
 	// ValidateIdentifier validates an identifier for safety.
 	ValidateIdentifier(id string) error
+
+	// WalkDir walks the file tree rooted at root, calling fn for each entry.
+	// Symlinks are reported but not followed.
+	WalkDir(root string, fn fs.WalkDirFunc) error
+}
+
+// DepthLimiter is implemented by filesystems whose directory copies are
+// bounded by a maximum nesting depth.
+type DepthLimiter interface {
+	// SetMaxDepth sets the nesting limit. Zero or less uses DefaultMaxDepth.
+	SetMaxDepth(depth int)
 }
 
 // RealFS implements FS using actual OS operations.
-type RealFS struct{}
+type RealFS struct {
+	// MaxDepth limits directory nesting when copying directories.
+	// Zero uses DefaultMaxDepth.
+	MaxDepth int
+}
 
 // NewRealFS creates a new RealFS.
 func NewRealFS() *RealFS {
 	return &RealFS{}
 }
 
+// SetMaxDepth sets MaxDepth.
+func (fs *RealFS) SetMaxDepth(depth int) {
+	fs.MaxDepth = depth
+}
+
 // Lstat returns file info without following symlinks.
 func (fs *RealFS) Lstat(path string) (os.FileInfo, error) {
 	return os.Lstat(path)
@@ -127,7 +158,24 @@ func (fs *RealFS) Copy(src, dst string) error {
 	return fs.copyFile(src, dst, srcInfo.Mode())
 }
 
+// Rename moves oldpath to newpath, replacing newpath if it is a file.
+func (fs *RealFS) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+// Chtimes sets the access and modification times of path.
+func (fs *RealFS) Chtimes(path string, atime, mtime time.Time) error {
+	return os.Chtimes(path, atime, mtime)
+}
+
+// copyBufferSize is the chunk size used when streaming file contents.
+const copyBufferSize = 1 << 20
+
 // copyFile copies a single file from src to dst.
+// Contents are streamed in fixed-size chunks so large files are never held in
+// memory. All-zero chunks are skipped with a seek, which preserves holes on
+// filesystems that support sparse files. The destination gets the source's
+// permission bits and modification time.
 func (fs *RealFS) copyFile(src, dst string, mode os.FileMode) error {
 	// Defensive check: verify source is not a directory
 	srcInfo, err := os.Lstat(src)
@@ -146,6 +194,12 @@ func (fs *RealFS) copyFile(src, dst string, mode os.FileMode) error {
 		_ = srcFile.Close()
 	}()
 
+	// Stat the opened file so a symlinked source reports the target's mtime
+	openedInfo, err := srcFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat source: %w", err)
+	}
+
 	// Create parent directory if needed
 	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
 		return fmt.Errorf("failed to create parent directory: %w", err)
@@ -155,53 +209,93 @@ func (fs *RealFS) copyFile(src, dst string, mode os.FileMode) error {
 	if err != nil {
 		return fmt.Errorf("failed to create destination: %w", err)
 	}
-	defer func() {
+
+	if err := copySparse(dstFile, srcFile); err != nil {
 		_ = dstFile.Close()
-	}()
+		return err
+	}
+	if err := dstFile.Chmod(mode.Perm()); err != nil {
+		_ = dstFile.Close()
+		return fmt.Errorf("failed to set destination mode: %w", err)
+	}
+	if err := dstFile.Sync(); err != nil {
+		_ = dstFile.Close()
+		return fmt.Errorf("failed to sync destination: %w", err)
+	}
+	if err := dstFile.Close(); err != nil {
+		return fmt.Errorf("failed to close destination: %w", err)
+	}
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return fmt.Errorf("failed to copy file contents: %w", err)
+	// Set mtime last so no further writes disturb it
+	if err := os.Chtimes(dst, openedInfo.ModTime(), openedInfo.ModTime()); err != nil {
+		return fmt.Errorf("failed to set destination times: %w", err)
 	}
 
-	return dstFile.Sync()
+	return nil
 }
 
-// copyDir recursively copies a directory from src to dst.
-func (fs *RealFS) copyDir(src, dst string) error {
-	srcInfo, err := os.Stat(src)
-	if err != nil {
-		return fmt.Errorf("failed to stat source directory: %w", err)
+// copySparse streams src into dst, seeking over all-zero chunks instead of
+// writing them. dst must be empty and positioned at offset 0.
+func copySparse(dst *os.File, src io.Reader) error {
+	buf := make([]byte, copyBufferSize)
+	var size int64
+	for {
+		n, readErr := io.ReadFull(src, buf)
+		if n > 0 {
+			chunk := buf[:n]
+			if isZeroChunk(chunk) {
+				if _, err := dst.Seek(int64(n), io.SeekCurrent); err != nil {
+					return fmt.Errorf("failed to seek destination: %w", err)
+				}
+			} else if _, err := dst.Write(chunk); err != nil {
+				return fmt.Errorf("failed to copy file contents: %w", err)
+			}
+			size += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to copy file contents: %w", readErr)
+		}
 	}
 
-	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
-		return fmt.Errorf("failed to create destination directory: %w", err)
+	// Extend the file to its full length in case it ends with a hole
+	if err := dst.Truncate(size); err != nil {
+		return fmt.Errorf("failed to set destination size: %w", err)
 	}
+	return nil
+}
 
-	entries, err := os.ReadDir(src)
-	if err != nil {
-		return fmt.Errorf("failed to read source directory: %w", err)
+// isZeroChunk reports whether every byte in b is zero.
+func isZeroChunk(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
 	}
+	return true
+}
 
-	for _, entry := range entries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
+// copyDir recursively copies a directory from src to dst.
+// Symlinked directories are followed, with loops and excessive nesting
+// reported as errors (see WalkFollow).
+func (fs *RealFS) copyDir(src, dst string) error {
+	return WalkFollow(src, fs.MaxDepth, func(path string, info os.FileInfo) error {
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dst, rel)
 
-		if entry.IsDir() {
-			if err := fs.copyDir(srcPath, dstPath); err != nil {
-				return err
-			}
-		} else {
-			info, err := entry.Info()
-			if err != nil {
-				return fmt.Errorf("failed to get entry info: %w", err)
-			}
-			if err := fs.copyFile(srcPath, dstPath, info.Mode()); err != nil {
-				return err
+		if info.IsDir() {
+			if err := os.MkdirAll(dstPath, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("failed to create destination directory: %w", err)
 			}
+			return nil
 		}
-	}
-
-	return nil
+		return fs.copyFile(path, dstPath, info.Mode())
+	})
 }
 
 // AtomicWrite writes data to path atomically using temp file + rename.
@@ -319,3 +413,13 @@ func (fs *RealFS) ValidateIdentifier(id string) error {
 
 	return nil
 }
+
+// ReadDir returns the entries of a directory sorted by name.
+func (fs *RealFS) ReadDir(path string) ([]os.DirEntry, error) {
+	return os.ReadDir(path)
+}
+
+// WalkDir walks the file tree rooted at root using filepath.WalkDir.
+func (fs *RealFS) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, fn)
+}
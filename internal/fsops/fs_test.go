@@ -1,9 +1,13 @@
 package fsops
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestRealFS_ValidateRelPath(t *testing.T) {
@@ -354,3 +358,84 @@ func TestRealFS_Remove(t *testing.T) {
 		}
 	})
 }
+
+func TestRealFS_CopyLargeFile(t *testing.T) {
+	fs := &RealFS{}
+	tmpDir := t.TempDir()
+
+	// Build a multi-chunk source with data, a zero region, and a trailing hole
+	src := filepath.Join(tmpDir, "large.bin")
+	f, err := os.OpenFile(src, os.O_CREATE|os.O_WRONLY, 0750)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	data := make([]byte, copyBufferSize+123)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(3*copyBufferSize, io.SeekCurrent); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(data[:4096]); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(6 * copyBufferSize); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(src, 0750); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(src, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(tmpDir, "nested", "copy.bin")
+	if err := fs.Copy(src, dst); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	srcSum, srcSize := streamSum(t, src)
+	dstSum, dstSize := streamSum(t, dst)
+	if srcSize != dstSize {
+		t.Errorf("size = %d, want %d", dstSize, srcSize)
+	}
+	if !bytes.Equal(srcSum, dstSum) {
+		t.Error("copied content differs from source")
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("mode = %v, want 0750", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("mtime = %v, want %v", info.ModTime(), mtime)
+	}
+}
+
+// streamSum hashes a file without reading it fully into memory.
+func streamSum(t *testing.T, path string) ([]byte, int64) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h.Sum(nil), n
+}
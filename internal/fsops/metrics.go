@@ -0,0 +1,210 @@
+package fsops
+
+import (
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// FSMetrics is a point-in-time copy of the operations recorded by a MetricsFS.
+type FSMetrics struct {
+	// Calls is the number of calls per FS method name (e.g. "Copy")
+	Calls map[string]int
+
+	// Durations is the total time spent per FS method name
+	Durations map[string]time.Duration
+
+	// BytesCopied is the total size of regular files written by Copy
+	BytesCopied int64
+
+	// BytesWritten is the total size of data written by AtomicWrite
+	BytesWritten int64
+
+	// BytesRead is the total size of data returned by ReadFile
+	BytesRead int64
+}
+
+// MetricsFS wraps an FS and records call counts, durations, and byte totals
+// for each operation. It never changes the behavior of the wrapped FS.
+// It is safe for concurrent use.
+type MetricsFS struct {
+	inner FS
+
+	mu      sync.Mutex
+	metrics FSMetrics
+}
+
+// NewMetricsFS creates a MetricsFS that forwards every call to inner.
+func NewMetricsFS(inner FS) *MetricsFS {
+	return &MetricsFS{
+		inner: inner,
+		metrics: FSMetrics{
+			Calls:     make(map[string]int),
+			Durations: make(map[string]time.Duration),
+		},
+	}
+}
+
+// Snapshot returns a copy of the metrics recorded so far.
+func (m *MetricsFS) Snapshot() FSMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := m.metrics
+	snapshot.Calls = make(map[string]int, len(m.metrics.Calls))
+	for method, n := range m.metrics.Calls {
+		snapshot.Calls[method] = n
+	}
+	snapshot.Durations = make(map[string]time.Duration, len(m.metrics.Durations))
+	for method, d := range m.metrics.Durations {
+		snapshot.Durations[method] = d
+	}
+	return snapshot
+}
+
+// record tallies one call to method that started at start.
+func (m *MetricsFS) record(method string, start time.Time) {
+	elapsed := time.Since(start)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metrics.Calls[method]++
+	m.metrics.Durations[method] += elapsed
+}
+
+// addBytes adds n to the byte counter selected by field.
+func (m *MetricsFS) addBytes(field *int64, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*field += n
+}
+
+// Lstat returns file info without following symlinks.
+func (m *MetricsFS) Lstat(path string) (os.FileInfo, error) {
+	defer m.record("Lstat", time.Now())
+	return m.inner.Lstat(path)
+}
+
+// Readlink reads the target of a symlink.
+func (m *MetricsFS) Readlink(path string) (string, error) {
+	defer m.record("Readlink", time.Now())
+	return m.inner.Readlink(path)
+}
+
+// MkdirAll creates a directory and all parent directories.
+func (m *MetricsFS) MkdirAll(path string, perm os.FileMode) error {
+	defer m.record("MkdirAll", time.Now())
+	return m.inner.MkdirAll(path, perm)
+}
+
+// Remove removes a file or empty directory.
+func (m *MetricsFS) Remove(path string) error {
+	defer m.record("Remove", time.Now())
+	return m.inner.Remove(path)
+}
+
+// RemoveAll removes a path and all its contents.
+func (m *MetricsFS) RemoveAll(path string) error {
+	defer m.record("RemoveAll", time.Now())
+	return m.inner.RemoveAll(path)
+}
+
+// Symlink creates a symbolic link from newname to oldname.
+func (m *MetricsFS) Symlink(oldname, newname string) error {
+	defer m.record("Symlink", time.Now())
+	return m.inner.Symlink(oldname, newname)
+}
+
+// Rename moves oldpath to newpath, replacing newpath if it is a file.
+func (m *MetricsFS) Rename(oldpath, newpath string) error {
+	defer m.record("Rename", time.Now())
+	return m.inner.Rename(oldpath, newpath)
+}
+
+// Chtimes sets the access and modification times of path.
+func (m *MetricsFS) Chtimes(path string, atime, mtime time.Time) error {
+	defer m.record("Chtimes", time.Now())
+	return m.inner.Chtimes(path, atime, mtime)
+}
+
+// Copy copies a file or directory from src to dst.
+// The copied size is measured from dst once the copy succeeds.
+func (m *MetricsFS) Copy(src, dst string) error {
+	start := time.Now()
+	err := m.inner.Copy(src, dst)
+	m.record("Copy", start)
+	if err != nil {
+		return err
+	}
+
+	var copied int64
+	_ = m.inner.WalkDir(dst, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			copied += info.Size()
+		}
+		return nil
+	})
+	m.addBytes(&m.metrics.BytesCopied, copied)
+	return nil
+}
+
+// AtomicWrite writes data to path atomically using temp file + rename.
+func (m *MetricsFS) AtomicWrite(path string, data []byte, perm os.FileMode) error {
+	defer m.record("AtomicWrite", time.Now())
+	if err := m.inner.AtomicWrite(path, data, perm); err != nil {
+		return err
+	}
+	m.addBytes(&m.metrics.BytesWritten, int64(len(data)))
+	return nil
+}
+
+// ReadFile reads the entire contents of a file.
+func (m *MetricsFS) ReadFile(path string) ([]byte, error) {
+	defer m.record("ReadFile", time.Now())
+	data, err := m.inner.ReadFile(path)
+	m.addBytes(&m.metrics.BytesRead, int64(len(data)))
+	return data, err
+}
+
+// ReadDir returns the entries of a directory sorted by name.
+func (m *MetricsFS) ReadDir(path string) ([]os.DirEntry, error) {
+	defer m.record("ReadDir", time.Now())
+	return m.inner.ReadDir(path)
+}
+
+// Exists checks if a path exists.
+func (m *MetricsFS) Exists(path string) (bool, error) {
+	defer m.record("Exists", time.Now())
+	return m.inner.Exists(path)
+}
+
+// ValidateRelPath validates a relative path for safety.
+// Validation does not touch the filesystem, so it is not recorded.
+func (m *MetricsFS) ValidateRelPath(relPath string) error {
+	return m.inner.ValidateRelPath(relPath)
+}
+
+// ValidateIdentifier validates an identifier for safety.
+// Validation does not touch the filesystem, so it is not recorded.
+func (m *MetricsFS) ValidateIdentifier(id string) error {
+	return m.inner.ValidateIdentifier(id)
+}
+
+// WalkDir walks the file tree rooted at root, calling fn for each entry.
+// The recorded duration includes time spent in fn.
+func (m *MetricsFS) WalkDir(root string, fn fs.WalkDirFunc) error {
+	defer m.record("WalkDir", time.Now())
+	return m.inner.WalkDir(root, fn)
+}
+
+// SetMaxDepth forwards the nesting limit to the wrapped FS if it is a
+// DepthLimiter.
+func (m *MetricsFS) SetMaxDepth(depth int) {
+	if limiter, ok := m.inner.(DepthLimiter); ok {
+		limiter.SetMaxDepth(depth)
+	}
+}
@@ -0,0 +1,99 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMetricsFS_RecordsCallsAndBytes(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewMetricsFS(NewRealFS())
+
+	srcDir := filepath.Join(tmpDir, "src")
+	if err := m.MkdirAll(filepath.Join(srcDir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AtomicWrite(filepath.Join(srcDir, "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AtomicWrite(filepath.Join(srcDir, "nested", "b.txt"), []byte("world!!"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Copying the directory copies both files (5 + 7 bytes)
+	if err := m.Copy(srcDir, filepath.Join(tmpDir, "dst")); err != nil {
+		t.Fatal(err)
+	}
+	// Copying a single file adds its size again
+	if err := m.Copy(filepath.Join(srcDir, "a.txt"), filepath.Join(tmpDir, "a-copy.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ReadFile(filepath.Join(tmpDir, "dst", "nested", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := m.Exists(filepath.Join(tmpDir, "dst")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Validation is pure and not recorded
+	_ = m.ValidateRelPath("a.txt")
+
+	got := m.Snapshot()
+	wantCalls := map[string]int{"MkdirAll": 1, "AtomicWrite": 2, "Copy": 2, "ReadFile": 1, "Exists": 3}
+	if len(got.Calls) != len(wantCalls) {
+		t.Errorf("Calls = %v, want %v", got.Calls, wantCalls)
+	}
+	for method, n := range wantCalls {
+		if got.Calls[method] != n {
+			t.Errorf("Calls[%s] = %d, want %d", method, got.Calls[method], n)
+		}
+		if _, ok := got.Durations[method]; !ok {
+			t.Errorf("missing duration for %s", method)
+		}
+	}
+	if got.BytesCopied != 17 {
+		t.Errorf("BytesCopied = %d, want 17", got.BytesCopied)
+	}
+	if got.BytesWritten != 12 {
+		t.Errorf("BytesWritten = %d, want 12", got.BytesWritten)
+	}
+	if got.BytesRead != 7 {
+		t.Errorf("BytesRead = %d, want 7", got.BytesRead)
+	}
+}
+
+func TestMetricsFS_FailedCopyCountsCallOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewMetricsFS(NewRealFS())
+
+	if err := m.Copy(filepath.Join(tmpDir, "missing"), filepath.Join(tmpDir, "dst")); err == nil {
+		t.Fatal("expected error copying a missing source")
+	}
+	if _, err := os.Lstat(filepath.Join(tmpDir, "dst")); !os.IsNotExist(err) {
+		t.Errorf("destination should not exist, got %v", err)
+	}
+
+	got := m.Snapshot()
+	if got.Calls["Copy"] != 1 {
+		t.Errorf("Calls[Copy] = %d, want 1", got.Calls["Copy"])
+	}
+	if got.BytesCopied != 0 {
+		t.Errorf("BytesCopied = %d, want 0", got.BytesCopied)
+	}
+}
+
+func TestMetricsFS_SnapshotIsACopy(t *testing.T) {
+	m := NewMetricsFS(NewRealFS())
+	if _, err := m.Exists(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := m.Snapshot()
+	snapshot.Calls["Exists"] = 100
+
+	if got := m.Snapshot().Calls["Exists"]; got != 1 {
+		t.Errorf("Calls[Exists] = %d after mutating a snapshot, want 1", got)
+	}
+}
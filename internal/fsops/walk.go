@@ -0,0 +1,83 @@
+package fsops
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultMaxDepth is the default directory nesting limit for WalkFollow.
+const DefaultMaxDepth = 64
+
+var (
+	// ErrSymlinkLoop indicates a symlinked directory resolves to one of its ancestors.
+	ErrSymlinkLoop = errors.New("symlink loop detected")
+
+	// ErrMaxDepth indicates a directory tree is nested deeper than allowed.
+	ErrMaxDepth = errors.New("maximum directory depth exceeded")
+)
+
+// WalkFollowFunc is called for each path visited by WalkFollow. The info
+// describes the symlink target when path is a symlink that resolves.
+type WalkFollowFunc func(path string, info os.FileInfo) error
+
+// WalkFollow walks the tree rooted at root in lexical order, following
+// symlinked directories. Each directory's resolved path is recorded while its
+// subtree is walked, so a symlink pointing back at an ancestor returns
+// ErrSymlinkLoop instead of recursing forever. Directories nested more than
+// maxDepth levels below root return ErrMaxDepth. A maxDepth of zero or less
+// uses DefaultMaxDepth. Dangling symlinks are reported with their Lstat info.
+func WalkFollow(root string, maxDepth int, fn WalkFollowFunc) error {
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	return walkFollow(root, 0, maxDepth, make(map[string]bool), fn)
+}
+
+func walkFollow(path string, depth, maxDepth int, ancestors map[string]bool, fn WalkFollowFunc) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		// Report dangling symlinks as-is rather than failing the walk
+		linfo, lerr := os.Lstat(path)
+		if lerr != nil || linfo.Mode()&os.ModeSymlink == 0 {
+			return err
+		}
+		return fn(path, linfo)
+	}
+
+	if !info.IsDir() {
+		return fn(path, info)
+	}
+
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	if ancestors[realPath] {
+		return fmt.Errorf("%w: %s resolves to ancestor %s", ErrSymlinkLoop, path, realPath)
+	}
+	if depth > maxDepth {
+		return fmt.Errorf("%w: %s is nested more than %d levels deep", ErrMaxDepth, path, maxDepth)
+	}
+
+	if err := fn(path, info); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", path, err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	ancestors[realPath] = true
+	defer delete(ancestors, realPath)
+	for _, entry := range entries {
+		if err := walkFollow(filepath.Join(path, entry.Name()), depth+1, maxDepth, ancestors, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package fsops
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalkFollow_FollowsSymlinkedDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "target")
+	root := filepath.Join(tmpDir, "root")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(root, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	err := WalkFollow(root, 0, func(path string, info os.FileInfo) error {
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkFollow failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join("linked", "a.txt") {
+		t.Errorf("files = %v, want [linked/a.txt]", files)
+	}
+}
+
+func TestWalkFollow_SymlinkLoop(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("..", filepath.Join(root, "a", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	err := WalkFollow(root, 0, func(string, os.FileInfo) error { return nil })
+	if !errors.Is(err, ErrSymlinkLoop) {
+		t.Fatalf("expected ErrSymlinkLoop, got %v", err)
+	}
+}
+
+func TestWalkFollow_MaxDepth(t *testing.T) {
+	root := t.TempDir()
+	deep := filepath.Join(root, strings.Repeat("d/", 5))
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	walk := func(maxDepth int) error {
+		return WalkFollow(root, maxDepth, func(string, os.FileInfo) error { return nil })
+	}
+	if err := walk(3); !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("expected ErrMaxDepth with limit 3, got %v", err)
+	}
+	if err := walk(5); err != nil {
+		t.Fatalf("expected walk within limit to succeed, got %v", err)
+	}
+}
+
+func TestRealFS_CopyDirSymlinkLoop(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "f.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(src, filepath.Join(src, "self")); err != nil {
+		t.Fatal(err)
+	}
+
+	err := NewRealFS().Copy(src, filepath.Join(tmpDir, "dst"))
+	if !errors.Is(err, ErrSymlinkLoop) {
+		t.Fatalf("expected ErrSymlinkLoop, got %v", err)
+	}
+}
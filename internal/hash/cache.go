@@ -0,0 +1,130 @@
+package hash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/danieljhkim/monodev/internal/fsops"
+)
+
+// CacheFileName is the name of the per-store hash cache file.
+const CacheFileName = ".hashcache.json"
+
+// cacheEntry records the hash of a file along with the size and modification
+// time it had when hashed.
+type cacheEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Hash    string `json:"hash"`
+}
+
+// CachingHasher wraps a Hasher and reuses previously computed hashes for
+// regular files under root whose size and modification time are unchanged.
+// Entries are keyed by their path relative to root, so the cache stays valid
+// if the store moves; files outside root are always hashed afresh. Entries
+// are loaded from and persisted to a JSON cache file; call Save to write new
+// entries.
+type CachingHasher struct {
+	inner   Hasher
+	fs      fsops.FS
+	root    string
+	path    string
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+// NewCachingHasher creates a CachingHasher for the files under root, backed
+// by the cache file at path. A missing or unreadable cache file starts an
+// empty cache.
+func NewCachingHasher(inner Hasher, fs fsops.FS, root, path string) *CachingHasher {
+	c := &CachingHasher{
+		inner:   inner,
+		fs:      fs,
+		root:    filepath.Clean(root),
+		path:    path,
+		entries: make(map[string]cacheEntry),
+	}
+	if data, err := fs.ReadFile(path); err == nil {
+		// A corrupt cache is simply rebuilt
+		_ = json.Unmarshal(data, &c.entries)
+	}
+	return c
+}
+
+// HashFile returns the cached hash for path if its size and modification time
+// match the cached entry, and otherwise hashes it with the wrapped Hasher.
+func (c *CachingHasher) HashFile(path string) (string, error) {
+	key, ok := c.key(path)
+	if !ok {
+		return c.inner.HashFile(path)
+	}
+	info, err := c.fs.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return c.inner.HashFile(path)
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && entry.Size == info.Size() && entry.ModTime == info.ModTime().UnixNano() {
+		return entry.Hash, nil
+	}
+
+	hash, err := c.inner.HashFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Hash: hash}
+	c.dirty = true
+	c.mu.Unlock()
+	return hash, nil
+}
+
+// key returns the cache key of path, its slash-separated path relative to
+// root, and false if path is not under root.
+func (c *CachingHasher) key(path string) (string, bool) {
+	rel, err := filepath.Rel(c.root, filepath.Clean(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// Save drops entries for files that no longer exist under root and writes
+// the cache file atomically if any entries changed.
+func (c *CachingHasher) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		_, err := c.fs.Lstat(filepath.Join(c.root, filepath.FromSlash(key)))
+		if err == nil {
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to check cached file %s: %w", key, err)
+		}
+		delete(c.entries, key)
+		c.dirty = true
+	}
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal hash cache: %w", err)
+	}
+	if err := c.fs.AtomicWrite(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write hash cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,90 @@
+package hash
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danieljhkim/monodev/internal/fsops"
+)
+
+func TestCachingHasher_InvalidatesOnChange(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "f.txt")
+	cachePath := filepath.Join(tmpDir, CacheFileName)
+	if err := os.WriteFile(file, []byte("one"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := NewFakeHasher()
+	fake.SetHash(file, "h1")
+	c := NewCachingHasher(fake, fsops.NewRealFS(), tmpDir, cachePath)
+	if h, _ := c.HashFile(file); h != "h1" {
+		t.Fatalf("hash = %q, want h1", h)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A reloaded cache serves the stored hash without consulting the inner hasher
+	fake.SetHash(file, "h2")
+	reloaded := NewCachingHasher(fake, fsops.NewRealFS(), tmpDir, cachePath)
+	if h, _ := reloaded.HashFile(file); h != "h1" {
+		t.Errorf("cached hash = %q, want h1", h)
+	}
+
+	// Changing mtime invalidates the entry
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := reloaded.HashFile(file); h != "h2" {
+		t.Errorf("hash after change = %q, want h2", h)
+	}
+}
+
+func TestCachingHasher_KeepsOnlyStoreFiles(t *testing.T) {
+	storeDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "workspace.txt")
+	kept := filepath.Join(storeDir, "overlay", "kept.txt")
+	gone := filepath.Join(storeDir, "overlay", "gone.txt")
+	cachePath := filepath.Join(storeDir, CacheFileName)
+	for _, path := range []string{outside, kept, gone} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewCachingHasher(NewFakeHasher(), fsops.NewRealFS(), storeDir, cachePath)
+	for _, path := range []string{outside, kept, gone} {
+		if _, err := c.HashFile(path); err != nil {
+			t.Fatalf("HashFile(%s) failed: %v", path, err)
+		}
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries map[string]cacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("cache entries = %v, want only overlay/kept.txt", entries)
+	}
+	if _, ok := entries["overlay/kept.txt"]; !ok {
+		t.Errorf("cache entries = %v, want the store-relative key overlay/kept.txt", entries)
+	}
+}
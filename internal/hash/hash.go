@@ -9,6 +9,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"os"
 )
@@ -37,8 +38,13 @@ func (h *SHA256Hasher) HashFile(path string) (string, error) {
 		_ = file.Close()
 	}()
 
+	return h.HashReader(file)
+}
+
+// HashReader computes the SHA-256 hash of everything read from r.
+func (h *SHA256Hasher) HashReader(r io.Reader) (string, error) {
 	hasher := sha256.New()
-	if _, err := io.Copy(hasher, file); err != nil {
+	if _, err := io.Copy(hasher, r); err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
@@ -49,6 +55,9 @@ func (h *SHA256Hasher) HashFile(path string) (string, error) {
 // FakeHasher implements Hasher with deterministic hashes for testing.
 type FakeHasher struct {
 	hashes map[string]string
+
+	// contentDerived hashes file contents for paths without a preset hash
+	contentDerived bool
 }
 
 // NewFakeHasher creates a new FakeHasher.
@@ -58,6 +67,15 @@ func NewFakeHasher() *FakeHasher {
 	}
 }
 
+// NewContentFakeHasher creates a FakeHasher that derives hashes from file
+// contents, so identical files hash equal and edits change the hash without
+// SetHash calls. Hashes set with SetHash still take precedence.
+func NewContentFakeHasher() *FakeHasher {
+	h := NewFakeHasher()
+	h.contentDerived = true
+	return h
+}
+
 // SetHash sets the hash for a specific path (for testing).
 func (h *FakeHasher) SetHash(path, hash string) {
 	h.hashes[path] = hash
@@ -68,6 +86,30 @@ func (h *FakeHasher) HashFile(path string) (string, error) {
 	if hash, ok := h.hashes[path]; ok {
 		return hash, nil
 	}
-	// Default hash if not set
-	return "fakehash", nil
+	if !h.contentDerived {
+		// Default hash if not set
+		return "fakehash", nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return h.HashReader(file)
+}
+
+// HashReader returns an FNV-1a hash of everything read from r when content
+// derived, and the default fake hash otherwise.
+func (h *FakeHasher) HashReader(r io.Reader) (string, error) {
+	if !h.contentDerived {
+		return "fakehash", nil
+	}
+	hasher := fnv.New64a()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
@@ -3,6 +3,7 @@ package hash
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -180,3 +181,65 @@ func TestFakeHasher(t *testing.T) {
 		}
 	})
 }
+
+func TestContentFakeHasher(t *testing.T) {
+	tmpDir := t.TempDir()
+	hasher := NewContentFakeHasher()
+
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	hashOf := func(path string) string {
+		hash, err := hasher.HashFile(path)
+		if err != nil {
+			t.Fatalf("HashFile failed: %v", err)
+		}
+		return hash
+	}
+
+	t.Run("identical contents hash equal", func(t *testing.T) {
+		a := write("a.txt", "same content")
+		b := write("b.txt", "same content")
+		if hashOf(a) != hashOf(b) {
+			t.Errorf("identical files hashed differently: %s vs %s", hashOf(a), hashOf(b))
+		}
+	})
+
+	t.Run("modification changes the hash", func(t *testing.T) {
+		path := write("mod.txt", "before")
+		before := hashOf(path)
+		write("mod.txt", "after")
+		if after := hashOf(path); after == before {
+			t.Errorf("hash unchanged after modification: %s", after)
+		}
+	})
+
+	t.Run("HashReader matches HashFile", func(t *testing.T) {
+		path := write("reader.txt", "streamed")
+		fromReader, err := hasher.HashReader(strings.NewReader("streamed"))
+		if err != nil {
+			t.Fatalf("HashReader failed: %v", err)
+		}
+		if fromReader != hashOf(path) {
+			t.Errorf("HashReader = %s, HashFile = %s", fromReader, hashOf(path))
+		}
+	})
+
+	t.Run("explicit hash overrides content", func(t *testing.T) {
+		path := write("override.txt", "content")
+		hasher.SetHash(path, "preset")
+		if got := hashOf(path); got != "preset" {
+			t.Errorf("expected preset hash, got %s", got)
+		}
+	})
+
+	t.Run("missing file is an error", func(t *testing.T) {
+		if _, err := hasher.HashFile(filepath.Join(tmpDir, "missing.txt")); err == nil {
+			t.Error("expected error for missing file")
+		}
+	})
+}
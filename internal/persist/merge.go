@@ -0,0 +1,301 @@
+package persist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danieljhkim/monodev/internal/hash"
+	"github.com/danieljhkim/monodev/internal/stores"
+)
+
+// baselinesDir returns the directory holding last-synced checksum baselines.
+// It lives outside the persist directory so baselines are never committed.
+func baselinesDir(persistRoot string) string {
+	return filepath.Join(persistRoot, ".monodev", "baselines")
+}
+
+// baselinePath returns the path to a store's baseline checksum file.
+func baselinePath(persistRoot, storeID string) string {
+	return filepath.Join(baselinesDir(persistRoot), storeID+".json")
+}
+
+// MergeResult describes the outcome of a per-file three-way merge.
+type MergeResult struct {
+	// Updated lists files taken from the remote snapshot (relative to the store dir)
+	Updated []string
+
+	// Kept lists locally modified files preserved because the remote didn't change them
+	Kept []string
+
+	// Conflicts lists files changed both locally and remotely since the baseline.
+	// The local version is left in place for each conflict.
+	Conflicts []string
+}
+
+// LoadBaseline loads the last-synced checksums for a store.
+// Returns an empty map if no baseline has been recorded.
+func (s *SnapshotManager) LoadBaseline(storeID string, persistRoot string) (map[string]string, error) {
+	data, err := s.fs.ReadFile(baselinePath(persistRoot, storeID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	baseline := map[string]string{}
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// SaveBaseline records checksums as the last-synced state of a store.
+func (s *SnapshotManager) SaveBaseline(storeID string, persistRoot string, checksums map[string]string) error {
+	if err := s.fs.ValidateIdentifier(storeID); err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+
+	data, err := json.MarshalIndent(checksums, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+
+	if err := s.fs.MkdirAll(baselinesDir(persistRoot), 0755); err != nil {
+		return fmt.Errorf("failed to create baselines directory: %w", err)
+	}
+
+	if err := s.fs.AtomicWrite(baselinePath(persistRoot, storeID), data, 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// RecordBaseline records the current persisted snapshot of a store as its baseline.
+func (s *SnapshotManager) RecordBaseline(storeID string, persistRoot string, hasher hash.Hasher) error {
+	checksums, err := checksumDir(persistStoreDir(persistRoot, storeID), hasher)
+	if err != nil {
+		return fmt.Errorf("failed to checksum persisted store: %w", err)
+	}
+	return s.SaveBaseline(storeID, persistRoot, checksums)
+}
+
+// RecordBaselinePaths records the baseline after DematerializePaths restored
+// only the overlay paths under prefixes. Files outside the overlay, and
+// overlay files under each prefix the persisted store has, take their
+// persisted checksums; every other entry keeps its previous baseline, so
+// paths that were not restored are not taken to match the remote. An empty
+// prefix list records the whole snapshot like RecordBaseline.
+func (s *SnapshotManager) RecordBaselinePaths(storeID string, persistRoot string, storeRepo stores.StoreRepo, hasher hash.Hasher, prefixes []string) error {
+	if len(prefixes) == 0 {
+		return s.RecordBaseline(storeID, persistRoot, hasher)
+	}
+
+	srcPath := persistStoreDir(persistRoot, storeID)
+	overlayName := filepath.Base(storeRepo.OverlayRoot(storeID))
+	var restored []string
+	for _, prefix := range prefixes {
+		exists, err := s.fs.Exists(filepath.Join(srcPath, overlayName, prefix))
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", prefix, err)
+		}
+		// DematerializePaths leaves a prefix the snapshot lacks untouched
+		if exists {
+			restored = append(restored, prefix)
+		}
+	}
+	covered := restoredBy(overlayName, restored)
+
+	remoteSums, err := checksumDir(srcPath, hasher)
+	if err != nil {
+		return fmt.Errorf("failed to checksum persisted store: %w", err)
+	}
+	baseline, err := s.LoadBaseline(storeID, persistRoot)
+	if err != nil {
+		return err
+	}
+	for rel := range baseline {
+		if covered(rel) {
+			delete(baseline, rel)
+		}
+	}
+	for rel, sum := range remoteSums {
+		if covered(rel) {
+			baseline[rel] = sum
+		}
+	}
+	return s.SaveBaseline(storeID, persistRoot, baseline)
+}
+
+// Merge performs a per-file three-way merge of a persisted store into the local
+// store, using the recorded baseline as the common ancestor. Files changed only
+// remotely are taken from the persist directory, files changed only locally are
+// kept, and files changed on both sides are reported as conflicts and left as-is.
+// No content-level merging is performed.
+//
+// If the store doesn't exist locally, Merge falls back to Dematerialize.
+// On success the baseline is updated to the remote checksums, except for
+// conflicted files which keep their previous baseline.
+func (s *SnapshotManager) Merge(storeID string, persistRoot string, storeRepo stores.StoreRepo, hasher hash.Hasher) (*MergeResult, error) {
+	if err := s.fs.ValidateIdentifier(storeID); err != nil {
+		return nil, fmt.Errorf("invalid store ID: %w", err)
+	}
+
+	srcPath := persistStoreDir(persistRoot, storeID)
+	exists, err := s.fs.Exists(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if persist store exists: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("store %q not found in persist directory at %s", storeID, srcPath)
+	}
+
+	remoteSums, err := checksumDir(srcPath, hasher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum persisted store: %w", err)
+	}
+
+	dstPath := filepath.Dir(storeRepo.OverlayRoot(storeID))
+	localExists, err := s.fs.Exists(dstPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check destination: %w", err)
+	}
+	if !localExists {
+		if err := s.Dematerialize(storeID, persistRoot, storeRepo); err != nil {
+			return nil, err
+		}
+		if err := s.SaveBaseline(storeID, persistRoot, remoteSums); err != nil {
+			return nil, err
+		}
+		return &MergeResult{Updated: sortedKeys(remoteSums)}, nil
+	}
+
+	localSums, err := checksumDir(dstPath, hasher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum local store: %w", err)
+	}
+
+	baseline, err := s.LoadBaseline(storeID, persistRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]struct{}, len(localSums)+len(remoteSums))
+	for p := range localSums {
+		paths[p] = struct{}{}
+	}
+	for p := range remoteSums {
+		paths[p] = struct{}{}
+	}
+
+	result := &MergeResult{}
+	newBaseline := make(map[string]string, len(remoteSums))
+	for p, sum := range remoteSums {
+		newBaseline[p] = sum
+	}
+
+	for _, rel := range sortedKeys(paths) {
+		local, remote, base := localSums[rel], remoteSums[rel], baseline[rel]
+
+		switch {
+		case local == remote:
+			// Both sides agree (including both absent)
+		case local == base:
+			// Only the remote changed: take it
+			dst := filepath.Join(dstPath, filepath.FromSlash(rel))
+			if remote == "" {
+				if err := s.fs.Remove(dst); err != nil && !os.IsNotExist(err) {
+					return nil, fmt.Errorf("failed to remove %s: %w", rel, err)
+				}
+			} else if err := s.fs.Copy(filepath.Join(srcPath, filepath.FromSlash(rel)), dst); err != nil {
+				return nil, fmt.Errorf("failed to copy %s: %w", rel, err)
+			}
+			result.Updated = append(result.Updated, rel)
+		case remote == base:
+			// Only the local changed: keep it
+			result.Kept = append(result.Kept, rel)
+		default:
+			result.Conflicts = append(result.Conflicts, rel)
+			if base == "" {
+				delete(newBaseline, rel)
+			} else {
+				newBaseline[rel] = base
+			}
+		}
+	}
+
+	if err := s.SaveBaseline(storeID, persistRoot, newBaseline); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// restoredBy returns a filter reporting whether a slash-separated path
+// relative to the store directory is restored by DematerializePaths with
+// prefixes: files outside the overlay always are, overlay files only under
+// one of the prefixes.
+func restoredBy(overlayName string, prefixes []string) func(rel string) bool {
+	overlayPrefixes := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		overlayPrefixes = append(overlayPrefixes, path.Join(overlayName, filepath.ToSlash(filepath.Clean(prefix))))
+	}
+	return func(rel string) bool {
+		if !strings.HasPrefix(rel, overlayName+"/") {
+			return true
+		}
+		for _, prefix := range overlayPrefixes {
+			if rel == prefix || strings.HasPrefix(rel, prefix+"/") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// checksumDir returns checksums of all regular files under dir, keyed by
+// slash-separated relative path. A missing dir yields an empty map.
+func checksumDir(dir string, hasher hash.Hasher) (map[string]string, error) {
+	sums := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sum, err := hasher.HashFile(path)
+		if err != nil {
+			return err
+		}
+		sums[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/danieljhkim/monodev/internal/fsops"
 	"github.com/danieljhkim/monodev/internal/hash"
@@ -27,6 +29,16 @@ func persistStoresDir(persistRoot string) string {
 	return filepath.Join(persistRoot, ".monodev", "persist", "stores")
 }
 
+// PersistedStoresPath is the directory holding persisted stores, relative
+// to the .monodev work tree of the persistence repository, in git (slash) form.
+const PersistedStoresPath = "persist/stores"
+
+// PersistedStorePath returns the path of a persisted store relative to the
+// .monodev work tree of the persistence repository, in git (slash) form.
+func PersistedStorePath(storeID string) string {
+	return PersistedStoresPath + "/" + storeID
+}
+
 // persistStoreDir returns the path to a specific store in the persist directory.
 func persistStoreDir(persistRoot, storeID string) string {
 	return filepath.Join(persistStoresDir(persistRoot), storeID)
@@ -69,6 +81,11 @@ func (s *SnapshotManager) Materialize(storeID string, storeRepo stores.StoreRepo
 		return fmt.Errorf("failed to copy store: %w", err)
 	}
 
+	// The hash cache holds machine-local paths and is never persisted
+	if err := s.fs.RemoveAll(filepath.Join(dstPath, hash.CacheFileName)); err != nil {
+		return fmt.Errorf("failed to remove hash cache from snapshot: %w", err)
+	}
+
 	return nil
 }
 
@@ -112,9 +129,88 @@ func (s *SnapshotManager) Dematerialize(storeID string, persistRoot string, stor
 	return nil
 }
 
-// Verify verifies the integrity of a store in the persist directory using checksums.
-// This is optional for v1 and can be used with the --verify flag.
-func (s *SnapshotManager) Verify(storeID string, persistRoot string, hasher hash.Hasher) error {
+// DematerializePaths copies a store from .monodev/persist/stores/<store-id>/
+// to ~/.monodev/stores/<store-id>/ like Dematerialize, but restores only the
+// overlay paths under the given prefixes (overlay-relative, matched on whole
+// path segments). Everything outside the overlay, such as meta and track
+// files, is still copied in full. Local overlay files outside the prefixes
+// are left untouched. An empty prefix list restores the whole store.
+func (s *SnapshotManager) DematerializePaths(storeID string, persistRoot string, storeRepo stores.StoreRepo, prefixes []string) error {
+	if len(prefixes) == 0 {
+		return s.Dematerialize(storeID, persistRoot, storeRepo)
+	}
+
+	// Validate store ID and prefixes
+	if err := s.fs.ValidateIdentifier(storeID); err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+	for _, prefix := range prefixes {
+		if err := s.fs.ValidateRelPath(prefix); err != nil {
+			return fmt.Errorf("invalid path filter %q: %w", prefix, err)
+		}
+	}
+
+	srcPath := persistStoreDir(persistRoot, storeID)
+	exists, err := s.fs.Exists(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to check if persist store exists: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("store %q not found in persist directory at %s", storeID, srcPath)
+	}
+
+	overlayRoot := storeRepo.OverlayRoot(storeID)
+	dstPath := filepath.Dir(overlayRoot)
+	overlayName := filepath.Base(overlayRoot)
+	if err := s.fs.MkdirAll(overlayRoot, 0755); err != nil {
+		return fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	// Copy everything but the overlay in full
+	entries, err := os.ReadDir(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read persist store: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == overlayName {
+			continue
+		}
+		if err := s.fs.Copy(filepath.Join(srcPath, entry.Name()), filepath.Join(dstPath, entry.Name())); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", entry.Name(), err)
+		}
+	}
+
+	// Replace each filtered overlay path with its persisted version
+	for _, prefix := range prefixes {
+		src := filepath.Join(srcPath, overlayName, prefix)
+		dst := filepath.Join(overlayRoot, prefix)
+		srcExists, err := s.fs.Exists(src)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", prefix, err)
+		}
+		if !srcExists {
+			continue
+		}
+		if err := s.fs.RemoveAll(dst); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", prefix, err)
+		}
+		if err := s.fs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("failed to create parent of %s: %w", prefix, err)
+		}
+		if err := s.fs.Copy(src, dst); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", prefix, err)
+		}
+	}
+
+	return nil
+}
+
+// Verify checks, by checksum, that a pulled store's local files match its
+// snapshot in the persist directory. With prefixes, only the overlay paths
+// DematerializePaths restored for them are compared. Paths in skip, relative
+// to the store directory (such as files a merge kept), are not compared.
+// Pass a hash.CachingHasher to reuse the hashes of unchanged files.
+func (s *SnapshotManager) Verify(storeID string, persistRoot string, storeRepo stores.StoreRepo, hasher hash.Hasher, prefixes []string, skip []string) error {
 	// Validate store ID
 	if err := s.fs.ValidateIdentifier(storeID); err != nil {
 		return fmt.Errorf("invalid store ID: %w", err)
@@ -132,9 +228,28 @@ func (s *SnapshotManager) Verify(storeID string, persistRoot string, hasher hash
 		return fmt.Errorf("store %q not found in persist directory", storeID)
 	}
 
-	// TODO: Implement checksum verification
-	// For v1, we can just check that the directory exists and contains the expected files.
-	// Full checksum verification can be added later.
+	remoteSums, err := checksumDir(storePath, hasher)
+	if err != nil {
+		return fmt.Errorf("failed to checksum persisted store: %w", err)
+	}
+
+	overlayRoot := storeRepo.OverlayRoot(storeID)
+	localPath := filepath.Dir(overlayRoot)
+	covered := restoredBy(filepath.Base(overlayRoot), prefixes)
+	var mismatched []string
+	for _, rel := range sortedKeys(remoteSums) {
+		if (len(prefixes) > 0 && !covered(rel)) || slices.Contains(skip, rel) {
+			continue
+		}
+		local, err := hasher.HashFile(filepath.Join(localPath, filepath.FromSlash(rel)))
+		if err != nil || local != remoteSums[rel] {
+			mismatched = append(mismatched, rel)
+		}
+	}
+	if len(mismatched) > 0 {
+		return fmt.Errorf("%d files of store %q do not match the persisted snapshot: %s",
+			len(mismatched), storeID, strings.Join(mismatched, ", "))
+	}
 
 	return nil
 }
@@ -154,7 +269,7 @@ func (s *SnapshotManager) ListPersistedStores(persistRoot string) ([]string, err
 	}
 
 	// Read directory entries
-	entries, err := os.ReadDir(storesDir)
+	entries, err := s.fs.ReadDir(storesDir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []string{}, nil
@@ -3,6 +3,7 @@ package persist
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -295,18 +296,77 @@ func TestSnapshotManager_Verify(t *testing.T) {
 
 		// Verify
 		hasher := hash.NewSHA256Hasher()
-		err := mgr.Verify(storeID, persistRoot, hasher)
+		err := mgr.Verify(storeID, persistRoot, repo, hasher, nil, nil)
 		if err != nil {
 			t.Errorf("Verify failed: %v", err)
 		}
 	})
 
+	t.Run("reports files that differ from the snapshot", func(t *testing.T) {
+		storesDir, persistRoot, _, repo, mgr := setupTestEnv(t)
+		defer func() { _ = os.RemoveAll(filepath.Dir(storesDir)) }()
+
+		storeID := "test-store"
+		createTestStore(t, repo, storeID)
+		if err := mgr.Materialize(storeID, repo, persistRoot); err != nil {
+			t.Fatalf("Materialize failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(repo.OverlayRoot(storeID), "subdir", "nested.txt"), []byte("local edit"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		hasher := hash.NewSHA256Hasher()
+		err := mgr.Verify(storeID, persistRoot, repo, hasher, nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "overlay/subdir/nested.txt") {
+			t.Errorf("expected a mismatch for overlay/subdir/nested.txt, got %v", err)
+		}
+
+		// Paths outside the restored prefixes, or kept on purpose, are not compared
+		if err := mgr.Verify(storeID, persistRoot, repo, hasher, []string{"test.txt"}, nil); err != nil {
+			t.Errorf("Verify with a prefix failed: %v", err)
+		}
+		if err := mgr.Verify(storeID, persistRoot, repo, hasher, nil, []string{"overlay/subdir/nested.txt"}); err != nil {
+			t.Errorf("Verify skipping the edited file failed: %v", err)
+		}
+	})
+
+	t.Run("reuses cached hashes", func(t *testing.T) {
+		storesDir, persistRoot, _, repo, mgr := setupTestEnv(t)
+		defer func() { _ = os.RemoveAll(filepath.Dir(storesDir)) }()
+
+		storeID := "test-store"
+		createTestStore(t, repo, storeID)
+		if err := mgr.Materialize(storeID, repo, persistRoot); err != nil {
+			t.Fatalf("Materialize failed: %v", err)
+		}
+
+		cachePath := filepath.Join(filepath.Dir(repo.OverlayRoot(storeID)), hash.CacheFileName)
+		counter := &countingHasher{Hasher: hash.NewSHA256Hasher()}
+		cache := hash.NewCachingHasher(counter, fsops.NewRealFS(), filepath.Dir(cachePath), cachePath)
+		if err := mgr.Verify(storeID, persistRoot, repo, cache, nil, nil); err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+		if err := cache.Save(); err != nil {
+			t.Fatal(err)
+		}
+		first := counter.calls
+
+		cache = hash.NewCachingHasher(counter, fsops.NewRealFS(), filepath.Dir(cachePath), cachePath)
+		if err := mgr.Verify(storeID, persistRoot, repo, cache, nil, nil); err != nil {
+			t.Fatalf("second Verify failed: %v", err)
+		}
+		// Only the persisted copies, which live outside the store, are hashed again
+		if counter.calls-first != first/2 {
+			t.Errorf("second Verify hashed %d files, want %d", counter.calls-first, first/2)
+		}
+	})
+
 	t.Run("returns error for non-existent store", func(t *testing.T) {
 		storesDir, persistRoot, _, _, mgr := setupTestEnv(t)
 		defer func() { _ = os.RemoveAll(filepath.Dir(storesDir)) }()
 
 		hasher := hash.NewSHA256Hasher()
-		err := mgr.Verify("nonexistent", persistRoot, hasher)
+		err := mgr.Verify("nonexistent", persistRoot, nil, hasher, nil, nil)
 		if err == nil {
 			t.Error("Expected error for non-existent store, got nil")
 		}
@@ -317,7 +377,7 @@ func TestSnapshotManager_Verify(t *testing.T) {
 		defer func() { _ = os.RemoveAll(filepath.Dir(storesDir)) }()
 
 		hasher := hash.NewSHA256Hasher()
-		err := mgr.Verify("../invalid", persistRoot, hasher)
+		err := mgr.Verify("../invalid", persistRoot, nil, hasher, nil, nil)
 		if err == nil {
 			t.Error("Expected error for invalid store ID, got nil")
 		}
@@ -472,3 +532,63 @@ func TestSnapshotManager_Roundtrip(t *testing.T) {
 		}
 	})
 }
+
+func TestSnapshotManager_RecordBaselinePaths(t *testing.T) {
+	storesDir, persistRoot, _, repo, mgr := setupTestEnv(t)
+	defer func() { _ = os.RemoveAll(filepath.Dir(storesDir)) }()
+
+	storeID := "test-store"
+	createTestStore(t, repo, storeID)
+	if err := mgr.Materialize(storeID, repo, persistRoot); err != nil {
+		t.Fatalf("Materialize failed: %v", err)
+	}
+
+	previous := map[string]string{
+		"overlay/test.txt":          "old-test",
+		"overlay/subdir/nested.txt": "old-nested",
+		"overlay/subdir/gone.txt":   "old-gone",
+	}
+	if err := mgr.SaveBaseline(storeID, persistRoot, previous); err != nil {
+		t.Fatal(err)
+	}
+
+	hasher := hash.NewSHA256Hasher()
+	if err := mgr.RecordBaselinePaths(storeID, persistRoot, repo, hasher, []string{"subdir"}); err != nil {
+		t.Fatalf("RecordBaselinePaths failed: %v", err)
+	}
+	baseline, err := mgr.LoadBaseline(storeID, persistRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Outside the filter, the previous baseline stands
+	if got := baseline["overlay/test.txt"]; got != "old-test" {
+		t.Errorf("overlay/test.txt baseline = %q, want it kept", got)
+	}
+	// Under the filter, the baseline matches the snapshot
+	want, err := hasher.HashFile(filepath.Join(persistRoot, ".monodev", "persist", "stores", storeID, "overlay", "subdir", "nested.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := baseline["overlay/subdir/nested.txt"]; got != want {
+		t.Errorf("overlay/subdir/nested.txt baseline = %q, want %q", got, want)
+	}
+	if _, ok := baseline["overlay/subdir/gone.txt"]; ok {
+		t.Error("a restored path missing from the snapshot should leave the baseline")
+	}
+	// Files outside the overlay are always restored
+	if _, ok := baseline["meta.json"]; !ok {
+		t.Errorf("expected meta.json in the baseline, got %v", baseline)
+	}
+}
+
+// countingHasher counts HashFile calls on the wrapped hasher.
+type countingHasher struct {
+	hash.Hasher
+	calls int
+}
+
+func (h *countingHasher) HashFile(path string) (string, error) {
+	h.calls++
+	return h.Hasher.HashFile(path)
+}
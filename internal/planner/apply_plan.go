@@ -1,14 +1,40 @@
 package planner
 
 import (
+	"errors"
 	"fmt"
+	iofs "io/fs"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/danieljhkim/monodev/internal/fsops"
+	"github.com/danieljhkim/monodev/internal/hash"
 	"github.com/danieljhkim/monodev/internal/state"
 	"github.com/danieljhkim/monodev/internal/stores"
 )
 
+// ErrProtectedPath indicates a tracked path would be applied inside a
+// directory monodev must not modify, such as .monodev or .git.
+var ErrProtectedPath = errors.New("protected path")
+
+// ErrInvalidDestPrefix indicates a destination prefix that is not a clean
+// relative path inside the apply root.
+var ErrInvalidDestPrefix = errors.New("invalid destination prefix")
+
+// ErrInvalidDestTemplate indicates a tracked path's Dest references store
+// metadata that is unknown or unset.
+var ErrInvalidDestTemplate = errors.New("invalid destination template")
+
+// ErrInvalidCondition indicates a tracked path's When condition is malformed.
+var ErrInvalidCondition = errors.New("invalid condition")
+
+// ErrMissingRequiredFile indicates a tracked directory is present in the
+// store overlay but lacks one of its RequiredFiles.
+var ErrMissingRequiredFile = errors.New("missing required file")
+
 // BuildApplyPlan generates a deterministic plan to apply store overlays.
 func BuildApplyPlan(
 	workspace *state.WorkspaceState,
@@ -19,17 +45,102 @@ func BuildApplyPlan(
 	fs fsops.FS,
 	force bool,
 ) (*ApplyPlan, error) {
-	plan := NewApplyPlan(orderedStores)
-	checker := NewConflictChecker(fs, workspace, force)
-
 	// applyRoot is where tracked paths will be placed.
 	// For subdirectory workspaces, paths are applied relative to the workspace dir.
 	applyRoot := filepath.Join(repoRoot, workspace.WorkspacePath)
 
+	return BuildApplyPlanAt(workspace, orderedStores, mode, applyRoot, storeRepo, fs, force)
+}
+
+// BuildApplyPlanAt generates a deterministic plan that places tracked paths
+// under applyRoot instead of the workspace directory.
+// Destination paths are guaranteed not to escape applyRoot.
+func BuildApplyPlanAt(
+	workspace *state.WorkspaceState,
+	orderedStores []string,
+	mode string,
+	applyRoot string,
+	storeRepo stores.StoreRepo,
+	fs fsops.FS,
+	force bool,
+) (*ApplyPlan, error) {
+	return buildApplyPlan(workspace, orderedStores, mode, applyRoot, storeRepo, fs, PlanOptions{Force: force})
+}
+
+// PlanOptions holds the optional settings of BuildApplyPlanWith. The zero
+// value plans like BuildApplyPlanAt without force.
+type PlanOptions struct {
+	// DestPrefix places every destination under this relative path inside
+	// applyRoot. Overlay sources keep their tracked paths; operation
+	// RelPaths, and so ownership keys, include the prefix. The prefix
+	// directory is created along with the first path placed in it.
+	DestPrefix string
+
+	// Vars are the variables tracked paths' When conditions are evaluated
+	// against. Paths whose condition does not hold are skipped.
+	Vars map[string]string
+
+	// Force plans replacements for conflicting destinations
+	Force bool
+
+	// OnlyNew restricts the plan to tracked paths the workspace does not
+	// already manage. Managed paths are skipped entirely, even if they have
+	// drifted, so a store that gained files can be installed incrementally
+	// without touching what is in place.
+	OnlyNew bool
+
+	// Hasher verifies pinned TrackedPath checksums. Nil uses SHA-256.
+	Hasher hash.Hasher
+}
+
+// BuildApplyPlanWith is BuildApplyPlanAt with the settings in opts.
+func BuildApplyPlanWith(
+	workspace *state.WorkspaceState,
+	orderedStores []string,
+	mode string,
+	applyRoot string,
+	storeRepo stores.StoreRepo,
+	fs fsops.FS,
+	opts PlanOptions,
+) (*ApplyPlan, error) {
+	if opts.DestPrefix != "" {
+		if err := fs.ValidateRelPath(opts.DestPrefix); err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidDestPrefix, opts.DestPrefix, err)
+		}
+		cleanPrefix, err := normalizeRelPath(opts.DestPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidDestPrefix, opts.DestPrefix, err)
+		}
+		opts.DestPrefix = cleanPrefix
+	}
+	return buildApplyPlan(workspace, orderedStores, mode, applyRoot, storeRepo, fs, opts)
+}
+
+// buildApplyPlan implements BuildApplyPlanWith once opts.DestPrefix is
+// normalized.
+func buildApplyPlan(
+	workspace *state.WorkspaceState,
+	orderedStores []string,
+	mode string,
+	applyRoot string,
+	storeRepo stores.StoreRepo,
+	fs fsops.FS,
+	opts PlanOptions,
+) (*ApplyPlan, error) {
+	plan := NewApplyPlan(orderedStores)
+	checker := NewConflictChecker(fs, workspace, opts.Force)
+	hasher := opts.Hasher
+	if hasher == nil {
+		hasher = hash.NewSHA256Hasher()
+	}
+
 	// Track which paths have been claimed by which stores
 	// This helps with store-to-store precedence
 	pathOwners := make(map[string]string)
 
+	// Mirrored directories, planned once every store has claimed its paths
+	var mirrors []mirrorDir
+
 	// For each store in order
 	for _, storeID := range orderedStores {
 		// Load the track file for this store
@@ -41,19 +152,79 @@ func BuildApplyPlan(
 		// Get the overlay root for this store
 		overlayRoot := storeRepo.OverlayRoot(storeID)
 
+		// Metadata is only needed by destinations that reference it
+		var meta *stores.StoreMeta
+		metaLoaded := false
+
+		// Spellings such as "./foo", "foo/" and "foo" name one path, which
+		// is planned once per store
+		planned := make(map[string]bool)
+
 		// For each tracked path in this store
 		for _, trackedPath := range track.Tracked {
-			// trackedPath.Path is workspace-relative (relative to the workspace root)
-			relPath := trackedPath.Path
+			// Validate relative paths for safety to prevent path traversal
+			if err := fs.ValidateRelPath(trackedPath.Path); err != nil {
+				return nil, fmt.Errorf("invalid tracked path %q in store %s: %w", trackedPath.Path, storeID, err)
+			}
+			cleanPath, err := normalizeRelPath(trackedPath.Path)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tracked path %q in store %s: %w", trackedPath.Path, storeID, err)
+			}
+			applies, err := trackedPath.Applies(opts.Vars)
+			if err != nil {
+				return nil, fmt.Errorf("%w: tracked path %q in store %s: %w", ErrInvalidCondition, trackedPath.Path, storeID, err)
+			}
+			if !applies {
+				continue
+			}
+			if trackedPath.HasDestVariables() {
+				if !metaLoaded {
+					meta, err = storeRepo.LoadMeta(storeID)
+					if err != nil {
+						return nil, fmt.Errorf("failed to load metadata for store %s: %w", storeID, err)
+					}
+					metaLoaded = true
+				}
+				expanded, err := trackedPath.ExpandDest(meta)
+				if err != nil {
+					return nil, fmt.Errorf("%w: tracked path %q in store %s: %w", ErrInvalidDestTemplate, trackedPath.Path, storeID, err)
+				}
+				trackedPath.Dest = expanded
+			}
+			if trackedPath.Dest != "" {
+				if err := fs.ValidateRelPath(trackedPath.Dest); err != nil {
+					return nil, fmt.Errorf("invalid destination %q for tracked path %q in store %s: %w", trackedPath.Dest, trackedPath.Path, storeID, err)
+				}
+				cleanDest, err := normalizeRelPath(trackedPath.Dest)
+				if err != nil {
+					return nil, fmt.Errorf("invalid destination %q for tracked path %q in store %s: %w", trackedPath.Dest, trackedPath.Path, storeID, err)
+				}
+				trackedPath.Dest = cleanDest
+			}
+			trackedPath.Path = cleanPath
 
-			// Validate relative path for safety to prevent path traversal
-			if err := fs.ValidateRelPath(relPath); err != nil {
-				return nil, fmt.Errorf("invalid tracked path %q in store %s: %w", relPath, storeID, err)
+			// trackedPath.Path is workspace-relative (relative to the workspace root)
+			// and locates the source in the overlay. relPath is where it is installed,
+			// which differs when the tracked path sets Dest or a prefix is given.
+			relPath := filepath.Join(opts.DestPrefix, trackedPath.Destination())
+			if planned[relPath] {
+				continue
+			}
+			planned[relPath] = true
+			if opts.OnlyNew && isManaged(workspace, relPath) {
+				continue
 			}
 
 			// Compute absolute source and destination paths for FS operations
-			sourcePath := filepath.Join(overlayRoot, relPath)
+			sourcePath := filepath.Join(overlayRoot, trackedPath.Path)
 			destPath := filepath.Join(applyRoot, relPath)
+			if !isWithinRoot(applyRoot, destPath) {
+				return nil, fmt.Errorf("invalid tracked path %q in store %s: escapes apply root %s", relPath, storeID, applyRoot)
+			}
+			// Never overwrite monodev's own state or git metadata, even with force
+			if dir := protectedDir(relPath); dir != "" {
+				return nil, fmt.Errorf("%w: tracked path %q in store %s targets %s", ErrProtectedPath, trackedPath.Path, storeID, dir)
+			}
 
 			// Check if source path exists in store
 			sourceExists, err := fs.Exists(sourcePath)
@@ -61,77 +232,422 @@ func BuildApplyPlan(
 				return nil, fmt.Errorf("failed to check source path %s: %w", sourcePath, err)
 			}
 			if !sourceExists {
-				// Warn and skip paths that don't exist in the store overlay
-				plan.AddWarning(fmt.Sprintf("tracked path %s not found in store %s (skipping)", trackedPath.Path, storeID))
+				// Warn and skip required paths that don't exist in the store
+				// overlay; optional paths are skipped silently
+				if trackedPath.IsRequired() {
+					plan.AddWarning(fmt.Sprintf("tracked path %s not found in store %s (skipping)", trackedPath.Path, storeID))
+				}
 				continue
 			}
 
+			// A present directory must be complete, even when it is optional
+			for _, required := range trackedPath.RequiredFiles {
+				if err := fs.ValidateRelPath(required); err != nil {
+					return nil, fmt.Errorf("invalid required file %q for tracked path %q in store %s: %w", required, trackedPath.Path, storeID, err)
+				}
+				exists, err := fs.Exists(filepath.Join(sourcePath, required))
+				if err != nil {
+					return nil, fmt.Errorf("failed to check required file %s: %w", required, err)
+				}
+				if !exists {
+					return nil, fmt.Errorf("%w: tracked path %q in store %s lacks %s", ErrMissingRequiredFile, trackedPath.Path, storeID, required)
+				}
+			}
+
 			// Use the kind from the tracked path metadata
 			pathType := "file"
 			if trackedPath.Kind == "dir" {
 				pathType = "directory"
 			}
 
-			// Check for conflicts (checker now works with relative paths)
-			conflict := checker.CheckPath(relPath, destPath, pathType, mode, storeID)
-			if conflict != nil {
-				plan.AddConflict(*conflict)
-				continue
+			// A pinned source must be untampered before it is linked or copied
+			if trackedPath.Checksum != "" && trackedPath.Kind != "dir" {
+				actual, err := hasher.HashFile(sourcePath)
+				if err != nil {
+					return nil, fmt.Errorf("failed to hash %s in store %s: %w", trackedPath.Path, storeID, err)
+				}
+				if actual != trackedPath.Checksum {
+					plan.AddConflict(Conflict{
+						Path:     relPath,
+						Reason:   fmt.Sprintf("Overlay source in store %s does not match its pinned checksum", storeID),
+						Existing: actual,
+						Incoming: trackedPath.Checksum,
+					})
+					continue
+				}
 			}
 
-			// Check if this path was already claimed by an earlier store
-			// Use relPath as the key for tracking ownership
-			if previousStore, exists := pathOwners[relPath]; exists {
-				// Later store takes precedence - add remove operation first
-				removeOp := Operation{
-					Type:       OpRemove,
-					SourcePath: "",
-					DestPath:   destPath,
-					RelPath:    relPath,
-					Store:      previousStore,
+			if trackedPath.Kind != "dir" || !trackedPath.LinkContents {
+				planPath(plan, checker, pathOwners, relPath, sourcePath, destPath, pathType, mode, storeID, opts.Force, fs)
+				if trackedPath.Kind == "dir" && trackedPath.Mirror && mode != "symlink" {
+					mirrors = append(mirrors, mirrorDir{relPath: relPath, sourcePath: sourcePath, destPath: destPath, storeID: storeID})
 				}
-				plan.AddOperation(removeOp)
-			} else if force {
-				// When force is enabled, check if destination exists (unmanaged or from previous apply)
-				// If so, we need to remove it first before creating the new overlay
-				destExists, err := fs.Exists(destPath)
-				if err == nil && destExists {
-					removeOp := Operation{
-						Type:       OpRemove,
-						SourcePath: "",
-						DestPath:   destPath,
-						RelPath:    relPath,
-						Store:      "", // unknown/unmanaged
+				continue
+			}
+
+			// Keep the workspace directory real and install each top-level
+			// entry individually, so users can add their own files alongside.
+			// Anything else at the directory's place (such as a link to the
+			// whole directory) would redirect the entries, so it must go first.
+			destIsDir := false
+			if info, err := fs.Lstat(destPath); err == nil && info.IsDir() {
+				destIsDir = true
+			} else if err == nil {
+				if !opts.Force {
+					existing := "file"
+					if info.Mode()&os.ModeSymlink != 0 {
+						existing = "symlink"
 					}
-					plan.AddOperation(removeOp)
+					plan.AddConflict(Conflict{
+						Path:     relPath,
+						Reason:   "Linking directory contents requires a real directory at destination",
+						Existing: existing,
+						Incoming: "directory",
+					})
+					continue
 				}
+				owner, _ := workspace.Owner(relPath)
+				plan.AddOperation(Operation{
+					Type:     OpRemove,
+					DestPath: destPath,
+					RelPath:  relPath,
+					Store:    owner.Store,
+					Reason:   "replace: directory contents need a real directory (force)",
+				})
 			}
 
-			// Add the create operation
-			var op Operation
-			if mode == "symlink" {
-				op = Operation{
-					Type:       OpCreateSymlink,
+			entries, err := overlayEntries(fs, sourcePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list tracked directory %s in store %s: %w", trackedPath.Path, storeID, err)
+			}
+			if len(entries) == 0 && !destIsDir {
+				// No entries to install, so create the empty directory itself
+				plan.AddOperation(Operation{
+					Type:       OpMkdir,
 					SourcePath: sourcePath,
 					DestPath:   destPath,
 					RelPath:    relPath,
 					Store:      storeID,
+					Reason:     fmt.Sprintf("create: empty directory tracked by %s", storeID),
+				})
+				pathOwners[relPath] = storeID
+				continue
+			}
+			for _, entry := range entries {
+				if opts.OnlyNew && isManaged(workspace, filepath.Join(relPath, entry.Name())) {
+					continue
 				}
-			} else {
-				op = Operation{
-					Type:       OpCopy,
-					SourcePath: sourcePath,
-					DestPath:   destPath,
-					RelPath:    relPath,
-					Store:      storeID,
+				entryType := "file"
+				if entry.IsDir() {
+					entryType = "directory"
 				}
+				planPath(plan, checker, pathOwners,
+					filepath.Join(relPath, entry.Name()),
+					filepath.Join(sourcePath, entry.Name()),
+					filepath.Join(destPath, entry.Name()),
+					entryType, mode, storeID, opts.Force, fs)
 			}
-			plan.AddOperation(op)
+		}
+	}
 
-			// Mark this path as claimed by this store (use relative path)
-			pathOwners[relPath] = storeID
+	for _, m := range mirrors {
+		if err := planMirrorRemovals(plan, workspace, pathOwners, m, fs); err != nil {
+			return nil, err
 		}
 	}
 
+	if mode == "symlink" {
+		dropUnchangedSymlinks(plan, workspace, fs)
+	}
+
 	return plan, nil
 }
+
+// mirrorDir is a tracked directory whose extra workspace files are removed.
+type mirrorDir struct {
+	relPath    string
+	sourcePath string
+	destPath   string
+	storeID    string
+}
+
+// planMirrorRemovals adds removes for paths inside a mirrored workspace
+// directory that have no counterpart in the overlay. The directory must be
+// recorded as owned by the mirroring store and still be claimed by it after
+// every store was planned; paths that any store installs or the workspace
+// records are left alone.
+func planMirrorRemovals(plan *ApplyPlan, workspace *state.WorkspaceState, pathOwners map[string]string, m mirrorDir, fs fsops.FS) error {
+	if owner, _ := workspace.Owner(m.relPath); pathOwners[m.relPath] != m.storeID || owner.Store != m.storeID {
+		return nil
+	}
+	info, err := fs.Lstat(m.destPath)
+	if err != nil || !info.IsDir() {
+		return nil
+	}
+
+	err = fs.WalkDir(m.destPath, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == m.destPath {
+			return nil
+		}
+		rel, err := filepath.Rel(m.destPath, path)
+		if err != nil {
+			return err
+		}
+		relPath := filepath.Join(m.relPath, rel)
+		if _, claimed := pathOwners[relPath]; claimed || isManaged(workspace, relPath) {
+			if d.IsDir() {
+				return iofs.SkipDir
+			}
+			return nil
+		}
+		if _, err := fs.Lstat(filepath.Join(m.sourcePath, rel)); err == nil {
+			return nil
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+
+		plan.AddOperation(Operation{
+			Type:     OpRemove,
+			DestPath: path,
+			RelPath:  relPath,
+			Store:    m.storeID,
+			Reason:   fmt.Sprintf("mirror: not in store %s", m.storeID),
+		})
+		if d.IsDir() {
+			return iofs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mirror %s from store %s: %w", m.relPath, m.storeID, err)
+	}
+	return nil
+}
+
+// dropUnchangedSymlinks removes the operations for paths whose managed
+// symlink already points at the overlay source the plan would link, so
+// reapplying in symlink mode does not churn the workspace. A path qualifies
+// only if its operations are removes followed by a final link, and the
+// recorded owner is the store making that link.
+func dropUnchangedSymlinks(plan *ApplyPlan, workspace *state.WorkspaceState, fs fsops.FS) {
+	final := make(map[string]Operation)
+	mixed := make(map[string]bool)
+	for _, op := range plan.Operations {
+		if op.Type != OpRemove && op.Type != OpCreateSymlink {
+			mixed[op.RelPath] = true
+		}
+		final[op.RelPath] = op
+	}
+
+	unchanged := make(map[string]bool)
+	for relPath, op := range final {
+		if mixed[relPath] || op.Type != OpCreateSymlink {
+			continue
+		}
+		ownership, ok := workspace.Owner(relPath)
+		if !ok || ownership.Type != "symlink" || ownership.Store != op.Store {
+			continue
+		}
+		target, err := fs.Readlink(op.DestPath)
+		if err != nil || filepath.Clean(target) != filepath.Clean(op.SourcePath) {
+			continue
+		}
+		unchanged[relPath] = true
+	}
+	if len(unchanged) == 0 {
+		return
+	}
+
+	kept := plan.Operations[:0]
+	for _, op := range plan.Operations {
+		if !unchanged[op.RelPath] {
+			kept = append(kept, op)
+		}
+	}
+	plan.Operations = kept
+
+	relPaths := make([]string, 0, len(unchanged))
+	for relPath := range unchanged {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+	for _, relPath := range relPaths {
+		plan.AddNote(fmt.Sprintf("unchanged: %s already links to %s", relPath, final[relPath].SourcePath))
+	}
+}
+
+// planPath adds the operations that install a single overlay path, or
+// records a conflict if it cannot be installed.
+func planPath(
+	plan *ApplyPlan,
+	checker *ConflictChecker,
+	pathOwners map[string]string,
+	relPath, sourcePath, destPath, pathType, mode, storeID string,
+	force bool,
+	fs fsops.FS,
+) {
+	// Check for conflicts (checker now works with relative paths)
+	conflict := checker.CheckPath(relPath, destPath, pathType, mode, storeID)
+	if conflict != nil {
+		plan.AddConflict(*conflict)
+		return
+	}
+
+	// Check if this path was already claimed by an earlier store
+	// Use relPath as the key for tracking ownership
+	reason := fmt.Sprintf("create: tracked by %s", storeID)
+	if previousStore, exists := pathOwners[relPath]; exists {
+		// Later store takes precedence - surface the silent override
+		plan.AddWarning(fmt.Sprintf("path %s is tracked by both %s and %s; %s overrides %s", relPath, previousStore, storeID, storeID, previousStore))
+		reason = fmt.Sprintf("override: %s supersedes %s", storeID, previousStore)
+
+		// Add remove operation first
+		removeOp := Operation{
+			Type:       OpRemove,
+			SourcePath: "",
+			DestPath:   destPath,
+			RelPath:    relPath,
+			Store:      previousStore,
+			Reason:     reason,
+		}
+		plan.AddOperation(removeOp)
+	} else if force {
+		// When force is enabled, check if destination exists (unmanaged or from previous apply)
+		// If so, we need to remove it first before creating the new overlay
+		destExists, err := fs.Exists(destPath)
+		if err == nil && destExists {
+			removeOp := Operation{
+				Type:       OpRemove,
+				SourcePath: "",
+				DestPath:   destPath,
+				RelPath:    relPath,
+				Store:      "", // unknown/unmanaged
+				Reason:     "replace: existing path removed (force)",
+			}
+			plan.AddOperation(removeOp)
+			reason = fmt.Sprintf("replace: tracked by %s (force)", storeID)
+		}
+	}
+
+	// Add the create operation
+	var op Operation
+	if mode == "symlink" {
+		op = Operation{
+			Type:       OpCreateSymlink,
+			SourcePath: sourcePath,
+			DestPath:   destPath,
+			RelPath:    relPath,
+			Store:      storeID,
+			Reason:     reason,
+		}
+	} else if pathType == "directory" && isEmptyDir(fs, sourcePath) {
+		// Copying an empty directory would leave nothing behind to record
+		op = Operation{
+			Type:       OpMkdir,
+			SourcePath: sourcePath,
+			DestPath:   destPath,
+			RelPath:    relPath,
+			Store:      storeID,
+			Reason:     reason,
+		}
+	} else {
+		op = Operation{
+			Type:       OpCopy,
+			SourcePath: sourcePath,
+			DestPath:   destPath,
+			RelPath:    relPath,
+			Store:      storeID,
+			Reason:     reason,
+		}
+	}
+	plan.AddOperation(op)
+
+	// Mark this path as claimed by this store (use relative path)
+	pathOwners[relPath] = storeID
+}
+
+// isManaged reports whether the workspace records ownership of relPath,
+// including paths inside a compacted directory.
+func isManaged(workspace *state.WorkspaceState, relPath string) bool {
+	_, ok := workspace.Owner(relPath)
+	return ok
+}
+
+// normalizeRelPath returns the canonical form of a tracked relative path, so
+// every spelling of a path yields the same destination and ownership key.
+// Absolute paths and paths that climb out with ".." are rejected.
+func normalizeRelPath(relPath string) (string, error) {
+	slashed := filepath.ToSlash(relPath)
+	if path.IsAbs(slashed) || filepath.IsAbs(relPath) {
+		return "", fmt.Errorf("path must be relative, got %q", relPath)
+	}
+	cleaned := path.Clean(slashed)
+	if cleaned == "." {
+		return "", fmt.Errorf("path %q names the root itself", relPath)
+	}
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("path %q escapes the root", relPath)
+	}
+	return filepath.FromSlash(cleaned), nil
+}
+
+// isEmptyDir reports whether dir is a directory with no entries.
+func isEmptyDir(fsys fsops.FS, dir string) bool {
+	info, err := fsys.Lstat(dir)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	entries, err := overlayEntries(fsys, dir)
+	return err == nil && len(entries) == 0
+}
+
+// overlayEntries returns the immediate children of dir in lexical order.
+func overlayEntries(fsys fsops.FS, dir string) ([]iofs.DirEntry, error) {
+	var entries []iofs.DirEntry
+	err := fsys.WalkDir(dir, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		entries = append(entries, d)
+		if d.IsDir() {
+			return iofs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// isWithinRoot reports whether path is root itself or lies beneath it.
+func isWithinRoot(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// protectedDirs are directory names apply must never write into.
+var protectedDirs = map[string]bool{
+	".monodev": true,
+	".git":     true,
+}
+
+// protectedDir returns the protected directory name that relPath lies in
+// (at any depth), or "" if the path is safe to apply.
+func protectedDir(relPath string) string {
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Clean(relPath)), "/") {
+		if protectedDirs[part] {
+			return part
+		}
+	}
+	return ""
+}
@@ -1,10 +1,15 @@
 package planner
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/danieljhkim/monodev/internal/hash"
 	"github.com/danieljhkim/monodev/internal/state"
 	"github.com/danieljhkim/monodev/internal/stores"
 )
@@ -50,9 +55,14 @@ func (m *mockStoreRepo) Exists(id string) (bool, error)                     { re
 func (m *mockStoreRepo) Create(id string, meta *stores.StoreMeta) error     { return nil }
 func (m *mockStoreRepo) LoadMeta(id string) (*stores.StoreMeta, error)      { return nil, nil }
 func (m *mockStoreRepo) SaveMeta(id string, meta *stores.StoreMeta) error   { return nil }
+func (m *mockStoreRepo) MarkUsed(id string, at time.Time) error             { return nil }
 func (m *mockStoreRepo) SaveTrack(id string, track *stores.TrackFile) error { return nil }
 func (m *mockStoreRepo) Delete(id string) error                             { return nil }
 
+func (m *mockStoreRepo) LoadMetas(ids []string) (map[string]*stores.StoreMeta, error) {
+	return stores.LoadMetasSequential(m, ids)
+}
+
 func TestBuildApplyPlan_SingleStore(t *testing.T) {
 	fs := newMockFS()
 	storeRepo := newMockStoreRepo()
@@ -157,6 +167,47 @@ func TestBuildApplyPlan_MultipleStores_Precedence(t *testing.T) {
 	}
 }
 
+func TestBuildApplyPlan_OverlappingStoresWarns(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track1 := stores.NewTrackFile()
+	track1.Tracked = []stores.TrackedPath{
+		{Path: "Makefile", Kind: "file"},
+		{Path: "base.mk", Kind: "file"},
+	}
+	storeRepo.setTrack("base", track1)
+	storeRepo.setOverlayRoot("base", "/stores/base/overlay")
+
+	track2 := stores.NewTrackFile()
+	track2.Tracked = []stores.TrackedPath{
+		{Path: "Makefile", Kind: "file"},
+	}
+	storeRepo.setTrack("team", track2)
+	storeRepo.setOverlayRoot("team", "/stores/team/overlay")
+
+	fs.setExists("/stores/base/overlay/Makefile", true)
+	fs.setExists("/stores/base/overlay/base.mk", true)
+	fs.setExists("/stores/team/overlay/Makefile", true)
+
+	plan, err := BuildApplyPlan(workspace, []string{"base", "team"}, "copy", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+
+	if len(plan.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %v", plan.Conflicts)
+	}
+	if len(plan.Warnings) != 1 {
+		t.Fatalf("expected exactly 1 warning, got %v", plan.Warnings)
+	}
+	want := "path Makefile is tracked by both base and team; team overrides base"
+	if plan.Warnings[0] != want {
+		t.Errorf("warning = %q, want %q", plan.Warnings[0], want)
+	}
+}
+
 func TestBuildApplyPlan_ConflictDetection(t *testing.T) {
 	fs := newMockFS()
 	storeRepo := newMockStoreRepo()
@@ -673,3 +724,974 @@ func TestBuildApplyPlan_PathOwnershipTracking(t *testing.T) {
 		t.Errorf("expected script.sh from store2, got %q", storeMap["script.sh"])
 	}
 }
+
+func TestBuildApplyPlanAt_UsesApplyRoot(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", "packages/web", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "Makefile", Kind: "file"},
+	}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+	fs.setExists("/stores/store1/overlay/Makefile", true)
+
+	plan, err := BuildApplyPlanAt(workspace, []string{"store1"}, "copy", "/scratch", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlanAt failed: %v", err)
+	}
+
+	if len(plan.Operations) != 1 {
+		t.Fatalf("expected 1 operation, got %d", len(plan.Operations))
+	}
+	if plan.Operations[0].DestPath != "/scratch/Makefile" {
+		t.Errorf("expected DestPath='/scratch/Makefile', got %q", plan.Operations[0].DestPath)
+	}
+}
+
+func TestBuildApplyPlanAt_RejectsEscapingPath(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "../outside.txt", Kind: "file"},
+	}
+	storeRepo.setTrack("store1", track)
+
+	_, err := BuildApplyPlanAt(workspace, []string{"store1"}, "copy", "/scratch", storeRepo, fs, false)
+	if err == nil {
+		t.Fatal("expected error for tracked path escaping the apply root")
+	}
+}
+
+func TestBuildApplyPlan_DestOverride(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "config/app.yaml", Kind: "file", Dest: ".config/app.yaml"},
+	}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+	fs.setExists("/stores/store1/overlay/config/app.yaml", true)
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+
+	if len(plan.Operations) != 1 {
+		t.Fatalf("expected 1 operation, got %d", len(plan.Operations))
+	}
+	op := plan.Operations[0]
+	if op.SourcePath != "/stores/store1/overlay/config/app.yaml" {
+		t.Errorf("SourcePath = %q, want overlay path", op.SourcePath)
+	}
+	if op.DestPath != "/workspace/.config/app.yaml" {
+		t.Errorf("DestPath = %q, want /workspace/.config/app.yaml", op.DestPath)
+	}
+	if op.RelPath != ".config/app.yaml" {
+		t.Errorf("RelPath = %q, want destination path", op.RelPath)
+	}
+}
+
+func TestBuildApplyPlan_DestOverrideConflict(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "config/app.yaml", Kind: "file", Dest: ".config/app.yaml"},
+	}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+	fs.setExists("/stores/store1/overlay/config/app.yaml", true)
+	// Unmanaged file at the source path must not matter; the destination does
+	fs.setExists("/workspace/config/app.yaml", true)
+	fs.setExists("/workspace/.config/app.yaml", true)
+	fs.setLstat("/workspace/.config/app.yaml", &mockFileInfo{name: "app.yaml"})
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+
+	if len(plan.Conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d", len(plan.Conflicts))
+	}
+	if plan.Conflicts[0].Path != ".config/app.yaml" {
+		t.Errorf("conflict path = %q, want destination", plan.Conflicts[0].Path)
+	}
+}
+
+func TestBuildApplyPlan_DestOverrideEscapesRoot(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "config/app.yaml", Kind: "file", Dest: "../outside.yaml"},
+	}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+	fs.setExists("/stores/store1/overlay/config/app.yaml", true)
+
+	if _, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false); err == nil {
+		t.Fatal("expected error for destination outside the workspace")
+	}
+}
+
+func TestBuildApplyPlan_RejectsProtectedPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		tracked stores.TrackedPath
+	}{
+		{name: "monodev dir", tracked: stores.TrackedPath{Path: ".monodev/remote.json", Kind: "file"}},
+		{name: "git dir", tracked: stores.TrackedPath{Path: ".git", Kind: "dir"}},
+		{name: "nested git dir", tracked: stores.TrackedPath{Path: "vendor/lib/.git/config", Kind: "file"}},
+		{name: "dest override", tracked: stores.TrackedPath{Path: "state.json", Kind: "file", Dest: ".monodev/state.json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newMockFS()
+			storeRepo := newMockStoreRepo()
+			workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+			track := stores.NewTrackFile()
+			track.Tracked = []stores.TrackedPath{{Path: "Makefile", Kind: "file"}, tt.tracked}
+			storeRepo.setTrack("store1", track)
+			storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+			fs.setExists("/stores/store1/overlay/Makefile", true)
+			fs.setExists(filepath.Join("/stores/store1/overlay", tt.tracked.Path), true)
+
+			// Force does not bypass the guard
+			_, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, true)
+			if !errors.Is(err, ErrProtectedPath) {
+				t.Fatalf("expected ErrProtectedPath, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.tracked.Path) {
+				t.Errorf("error %q should name tracked path %q", err, tt.tracked.Path)
+			}
+		})
+	}
+}
+
+func TestBuildApplyPlan_AllowsSimilarNames(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: ".gitignore", Kind: "file"},
+		{Path: ".monodevignore", Kind: "file"},
+	}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+	fs.setExists("/stores/store1/overlay/.gitignore", true)
+	fs.setExists("/stores/store1/overlay/.monodevignore", true)
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	if len(plan.Operations) != 2 {
+		t.Errorf("expected 2 operations, got %d", len(plan.Operations))
+	}
+}
+
+func TestBuildApplyPlan_LinkWholeDirectory(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "symlink")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "scripts", Kind: "dir"}}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+	fs.setExists("/stores/store1/overlay/scripts", true)
+	fs.setDir("/stores/store1/overlay/scripts",
+		&mockFileInfo{name: "build.sh"},
+		&mockFileInfo{name: "lib", isDir: true},
+	)
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "symlink", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+
+	if len(plan.Operations) != 1 {
+		t.Fatalf("expected 1 operation, got %d: %+v", len(plan.Operations), plan.Operations)
+	}
+	op := plan.Operations[0]
+	if op.Type != OpCreateSymlink || op.RelPath != "scripts" || op.SourcePath != "/stores/store1/overlay/scripts" {
+		t.Errorf("expected one symlink for the whole directory, got %+v", op)
+	}
+}
+
+func TestBuildApplyPlan_LinkDirectoryContents(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "symlink")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "scripts", Kind: "dir", LinkContents: true}}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+	fs.setExists("/stores/store1/overlay/scripts", true)
+	fs.setDir("/stores/store1/overlay/scripts",
+		&mockFileInfo{name: "build.sh"},
+		&mockFileInfo{name: "lib", isDir: true},
+	)
+	// A user-owned sibling in a real workspace directory is left alone
+	fs.setExists("/workspace/scripts", true)
+	fs.setLstat("/workspace/scripts", &mockFileInfo{name: "scripts", isDir: true})
+	fs.setExists("/workspace/scripts/mine.sh", true)
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "symlink", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	if plan.HasConflicts() {
+		t.Fatalf("unexpected conflicts: %+v", plan.Conflicts)
+	}
+
+	want := []Operation{
+		{Type: OpCreateSymlink, SourcePath: "/stores/store1/overlay/scripts/build.sh", DestPath: "/workspace/scripts/build.sh", RelPath: "scripts/build.sh", Store: "store1", Reason: "create: tracked by store1"},
+		{Type: OpCreateSymlink, SourcePath: "/stores/store1/overlay/scripts/lib", DestPath: "/workspace/scripts/lib", RelPath: "scripts/lib", Store: "store1", Reason: "create: tracked by store1"},
+	}
+	if len(plan.Operations) != len(want) {
+		t.Fatalf("expected %d operations, got %d: %+v", len(want), len(plan.Operations), plan.Operations)
+	}
+	for i := range want {
+		if plan.Operations[i] != want[i] {
+			t.Errorf("operation %d = %+v, want %+v", i, plan.Operations[i], want[i])
+		}
+	}
+}
+
+func TestBuildApplyPlan_LinkDirectoryContentsReplacesWholeLink(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "symlink")
+	workspace.Paths["scripts"] = state.PathOwnership{Store: "store1", Type: "symlink"}
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "scripts", Kind: "dir", LinkContents: true}}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+	fs.setExists("/stores/store1/overlay/scripts", true)
+	fs.setDir("/stores/store1/overlay/scripts", &mockFileInfo{name: "build.sh"})
+	// A previous whole-directory link occupies the destination
+	fs.setExists("/workspace/scripts", true)
+	fs.setLstat("/workspace/scripts", &mockFileInfo{name: "scripts", mode: os.ModeSymlink})
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "symlink", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	if len(plan.Conflicts) != 1 || plan.Conflicts[0].Path != "scripts" || plan.Conflicts[0].Existing != "symlink" {
+		t.Fatalf("expected symlink conflict at scripts, got %+v", plan.Conflicts)
+	}
+
+	plan, err = BuildApplyPlan(workspace, []string{"store1"}, "symlink", "/workspace", storeRepo, fs, true)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	if len(plan.Operations) < 2 || plan.Operations[0].Type != OpRemove || plan.Operations[0].RelPath != "scripts" {
+		t.Fatalf("expected the whole-directory link to be removed first, got %+v", plan.Operations)
+	}
+	last := plan.Operations[len(plan.Operations)-1]
+	if last.Type != OpCreateSymlink || last.RelPath != "scripts/build.sh" {
+		t.Errorf("expected entry symlink last, got %+v", last)
+	}
+}
+
+func TestBuildApplyPlan_OperationReasons(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track1 := stores.NewTrackFile()
+	track1.Tracked = []stores.TrackedPath{
+		{Path: "shared.txt", Kind: "file"},
+		{Path: "only1.txt", Kind: "file"},
+	}
+	track2 := stores.NewTrackFile()
+	track2.Tracked = []stores.TrackedPath{{Path: "shared.txt", Kind: "file"}}
+	storeRepo.setTrack("store1", track1)
+	storeRepo.setTrack("store2", track2)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+	storeRepo.setOverlayRoot("store2", "/stores/store2/overlay")
+	fs.setExists("/stores/store1/overlay/shared.txt", true)
+	fs.setExists("/stores/store1/overlay/only1.txt", true)
+	fs.setExists("/stores/store2/overlay/shared.txt", true)
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1", "store2"}, "copy", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+
+	type opKey struct{ typ, relPath, store string }
+	reasons := make(map[opKey]string)
+	for _, op := range plan.Operations {
+		reasons[opKey{op.Type, op.RelPath, op.Store}] = op.Reason
+	}
+
+	want := map[opKey]string{
+		{OpCopy, "shared.txt", "store1"}:   "create: tracked by store1",
+		{OpCopy, "only1.txt", "store1"}:    "create: tracked by store1",
+		{OpRemove, "shared.txt", "store1"}: "override: store2 supersedes store1",
+		{OpCopy, "shared.txt", "store2"}:   "override: store2 supersedes store1",
+	}
+	for key, reason := range want {
+		got, ok := reasons[key]
+		if !ok {
+			t.Errorf("missing operation %+v", key)
+			continue
+		}
+		if got != reason {
+			t.Errorf("reason for %+v = %q, want %q", key, got, reason)
+		}
+	}
+}
+
+func TestBuildApplyPlan_ForceReplaceReason(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "a.txt", Kind: "file"}}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+	fs.setExists("/stores/store1/overlay/a.txt", true)
+	fs.setExists("/workspace/a.txt", true)
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, true)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	if len(plan.Operations) != 2 {
+		t.Fatalf("expected remove and copy, got %+v", plan.Operations)
+	}
+	if !strings.HasPrefix(plan.Operations[0].Reason, "replace:") || !strings.HasPrefix(plan.Operations[1].Reason, "replace:") {
+		t.Errorf("expected replace reasons, got %q and %q", plan.Operations[0].Reason, plan.Operations[1].Reason)
+	}
+}
+
+func TestBuildApplyPlan_EmptyTrackedDirectory(t *testing.T) {
+	tests := []struct {
+		mode   string
+		wantOp string
+	}{
+		{mode: "copy", wantOp: OpMkdir},
+		{mode: "symlink", wantOp: OpCreateSymlink},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			fs := newMockFS()
+			storeRepo := newMockStoreRepo()
+			workspace := state.NewWorkspaceState("repo1", ".", tt.mode)
+
+			track := stores.NewTrackFile()
+			track.Tracked = []stores.TrackedPath{{Path: "cache", Kind: "dir"}}
+			storeRepo.setTrack("store1", track)
+			storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+			fs.setExists("/stores/store1/overlay/cache", true)
+			fs.setLstat("/stores/store1/overlay/cache", &mockFileInfo{name: "cache", isDir: true})
+			fs.setDir("/stores/store1/overlay/cache")
+
+			plan, err := BuildApplyPlan(workspace, []string{"store1"}, tt.mode, "/workspace", storeRepo, fs, false)
+			if err != nil {
+				t.Fatalf("BuildApplyPlan failed: %v", err)
+			}
+			if len(plan.Operations) != 1 {
+				t.Fatalf("expected 1 operation, got %d: %+v", len(plan.Operations), plan.Operations)
+			}
+			op := plan.Operations[0]
+			if op.Type != tt.wantOp {
+				t.Errorf("operation type = %q, want %q", op.Type, tt.wantOp)
+			}
+			if op.DestPath != "/workspace/cache" || op.RelPath != "cache" {
+				t.Errorf("unexpected operation paths: %+v", op)
+			}
+		})
+	}
+}
+
+func TestBuildApplyPlan_LinkDirectoryContentsEmpty(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "symlink")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "scripts", Kind: "dir", LinkContents: true}}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+	fs.setExists("/stores/store1/overlay/scripts", true)
+	fs.setDir("/stores/store1/overlay/scripts")
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "symlink", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+
+	want := Operation{Type: OpMkdir, SourcePath: "/stores/store1/overlay/scripts", DestPath: "/workspace/scripts", RelPath: "scripts", Store: "store1", Reason: "create: empty directory tracked by store1"}
+	if len(plan.Operations) != 1 || plan.Operations[0] != want {
+		t.Fatalf("operations = %+v, want [%+v]", plan.Operations, want)
+	}
+}
+
+func TestBuildApplyPlan_RequiredFilesMissing(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	optional := false
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "config", Kind: "dir", Required: &optional, RequiredFiles: []string{"settings.json", "keys/id.pub"}}}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+	// The directory is present but only half populated
+	fs.setExists("/stores/store1/overlay/config", true)
+	fs.setExists("/stores/store1/overlay/config/settings.json", true)
+
+	_, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false)
+	if !errors.Is(err, ErrMissingRequiredFile) {
+		t.Fatalf("expected ErrMissingRequiredFile, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "keys/id.pub") {
+		t.Errorf("error should name the missing file, got %v", err)
+	}
+}
+
+func TestBuildApplyPlan_OptionalDirectoryAbsent(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	optional := false
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{{Path: "config", Kind: "dir", Required: &optional, RequiredFiles: []string{"settings.json"}}}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	if len(plan.Operations) != 0 {
+		t.Errorf("expected no operations, got %+v", plan.Operations)
+	}
+	if len(plan.Warnings) != 0 {
+		t.Errorf("expected an absent optional directory to be skipped silently, got %v", plan.Warnings)
+	}
+}
+
+func TestBuildApplyPlan_NormalizesPathSpellings(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "./config/app.yaml", Kind: "file"},
+		{Path: "config/app.yaml", Kind: "file"},
+		{Path: "config//app.yaml", Kind: "file"},
+		{Path: "scripts/", Kind: "dir"},
+		{Path: "scripts", Kind: "dir"},
+	}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+	fs.setExists("/stores/store1/overlay/config/app.yaml", true)
+	fs.setExists("/stores/store1/overlay/scripts", true)
+	fs.setLstat("/stores/store1/overlay/scripts", &mockFileInfo{name: "scripts", isDir: true})
+	fs.setDir("/stores/store1/overlay/scripts", &mockFileInfo{name: "run.sh"})
+
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	if len(plan.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", plan.Warnings)
+	}
+
+	want := map[string]string{
+		"config/app.yaml": "/workspace/config/app.yaml",
+		"scripts":         "/workspace/scripts",
+	}
+	if len(plan.Operations) != len(want) {
+		t.Fatalf("expected %d operations, got %d: %+v", len(want), len(plan.Operations), plan.Operations)
+	}
+	for _, op := range plan.Operations {
+		dest, ok := want[op.RelPath]
+		if !ok {
+			t.Errorf("unexpected operation for %q", op.RelPath)
+			continue
+		}
+		if op.Type != OpCopy || op.DestPath != dest {
+			t.Errorf("operation for %q = %+v, want copy to %s", op.RelPath, op, dest)
+		}
+	}
+}
+
+func TestBuildApplyPlan_RejectsEscapingPaths(t *testing.T) {
+	for _, tc := range []stores.TrackedPath{
+		{Path: "/etc/passwd", Kind: "file"},
+		{Path: "../outside.txt", Kind: "file"},
+		{Path: "a/../../outside.txt", Kind: "file"},
+		{Path: "./", Kind: "dir"},
+		{Path: "ok.txt", Kind: "file", Dest: "../outside.txt"},
+	} {
+		t.Run(tc.Path+"->"+tc.Dest, func(t *testing.T) {
+			fs := newMockFS()
+			storeRepo := newMockStoreRepo()
+			track := stores.NewTrackFile()
+			track.Tracked = []stores.TrackedPath{tc}
+			storeRepo.setTrack("store1", track)
+			storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+			workspace := state.NewWorkspaceState("repo1", ".", "copy")
+			if _, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false); err == nil {
+				t.Error("expected an error for a path outside the workspace")
+			}
+		})
+	}
+}
+
+func TestBuildApplyPlanWith_OnlyNewSkipsManagedPaths(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	// Two paths were applied earlier; one has since drifted in the workspace
+	for _, managed := range []string{"Makefile", "README.md"} {
+		workspace.Paths[managed] = state.PathOwnership{Store: "store1", Type: "copy", Checksum: "stale"}
+		fs.setExists("/workspace/"+managed, true)
+		fs.setLstat("/workspace/"+managed, &mockFileInfo{name: managed, isDir: false})
+	}
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "Makefile", Kind: "file"},
+		{Path: "README.md", Kind: "file"},
+		{Path: "new.sh", Kind: "file"},
+	}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+	for _, p := range []string{"Makefile", "README.md", "new.sh"} {
+		fs.setExists("/stores/store1/overlay/"+p, true)
+	}
+
+	plan, err := BuildApplyPlanWith(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, PlanOptions{Force: true, OnlyNew: true})
+	if err != nil {
+		t.Fatalf("BuildApplyPlanWith failed: %v", err)
+	}
+
+	if plan.HasConflicts() {
+		t.Fatalf("unexpected conflicts: %+v", plan.Conflicts)
+	}
+	if len(plan.Operations) != 1 {
+		t.Fatalf("expected 1 operation, got %d: %+v", len(plan.Operations), plan.Operations)
+	}
+	op := plan.Operations[0]
+	if op.Type != OpCopy || op.RelPath != "new.sh" {
+		t.Errorf("expected copy of new.sh, got %s of %s", op.Type, op.RelPath)
+	}
+}
+
+func TestBuildApplyPlanWith_OnlyNewSkipsPathsInCompactedDirectory(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+	workspace.Paths["conf"] = state.PathOwnership{
+		Store:     "store1",
+		Type:      "copy",
+		Compacted: true,
+		Contents: map[string]state.PathOwnership{
+			"app.yaml": {Store: "store1", Type: "copy"},
+		},
+	}
+	fs.setExists("/workspace/conf/app.yaml", true)
+	fs.setLstat("/workspace/conf/app.yaml", &mockFileInfo{name: "app.yaml", isDir: false})
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "conf/app.yaml", Kind: "file"},
+		{Path: "new.sh", Kind: "file"},
+	}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+	for _, p := range []string{"conf/app.yaml", "new.sh"} {
+		fs.setExists("/stores/store1/overlay/"+p, true)
+	}
+
+	plan, err := BuildApplyPlanWith(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, PlanOptions{Force: true, OnlyNew: true})
+	if err != nil {
+		t.Fatalf("BuildApplyPlanWith failed: %v", err)
+	}
+	if len(plan.Operations) != 1 || plan.Operations[0].RelPath != "new.sh" {
+		t.Errorf("expected only new.sh to be planned, got %+v", plan.Operations)
+	}
+}
+
+func TestBuildApplyPlanWith_PlacesUnderPrefix(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "Makefile", Kind: "file"},
+		{Path: "scripts/run.sh", Kind: "file"},
+	}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+	fs.setExists("/stores/store1/overlay/Makefile", true)
+	fs.setExists("/stores/store1/overlay/scripts/run.sh", true)
+
+	plan, err := BuildApplyPlanWith(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, PlanOptions{DestPrefix: "./sub/"})
+	if err != nil {
+		t.Fatalf("BuildApplyPlanWith failed: %v", err)
+	}
+
+	if len(plan.Operations) != 2 {
+		t.Fatalf("expected 2 operations, got %d: %+v", len(plan.Operations), plan.Operations)
+	}
+	want := map[string]struct{ source, dest string }{
+		"sub/Makefile":       {"/stores/store1/overlay/Makefile", "/workspace/sub/Makefile"},
+		"sub/scripts/run.sh": {"/stores/store1/overlay/scripts/run.sh", "/workspace/sub/scripts/run.sh"},
+	}
+	for _, op := range plan.Operations {
+		w, ok := want[op.RelPath]
+		if !ok {
+			t.Errorf("unexpected RelPath %q", op.RelPath)
+			continue
+		}
+		if op.SourcePath != w.source {
+			t.Errorf("%s: expected SourcePath=%q, got %q", op.RelPath, w.source, op.SourcePath)
+		}
+		if op.DestPath != w.dest {
+			t.Errorf("%s: expected DestPath=%q, got %q", op.RelPath, w.dest, op.DestPath)
+		}
+	}
+
+	// Ownership is keyed by the prefixed path, so a later only-new plan
+	// recognizes it as managed
+	workspace.Paths["sub/Makefile"] = state.PathOwnership{Store: "store1", Type: "copy"}
+	plan, err = BuildApplyPlanWith(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, PlanOptions{DestPrefix: "sub", OnlyNew: true})
+	if err != nil {
+		t.Fatalf("BuildApplyPlanWith failed: %v", err)
+	}
+	if len(plan.Operations) != 1 || plan.Operations[0].RelPath != "sub/scripts/run.sh" {
+		t.Errorf("expected only sub/scripts/run.sh to be planned, got %+v", plan.Operations)
+	}
+}
+
+func TestBuildApplyPlanWith_RejectsInvalidPrefix(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "Makefile", Kind: "file"},
+	}
+	storeRepo.setTrack("store1", track)
+	fs.setExists("/stores/store1/overlay/Makefile", true)
+
+	for _, prefix := range []string{"../outside", "/abs", "."} {
+		_, err := BuildApplyPlanWith(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, PlanOptions{DestPrefix: prefix})
+		if !errors.Is(err, ErrInvalidDestPrefix) {
+			t.Errorf("prefix %q: expected ErrInvalidDestPrefix, got %v", prefix, err)
+		}
+	}
+
+	_, err := BuildApplyPlanWith(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, PlanOptions{DestPrefix: ".git"})
+	if !errors.Is(err, ErrProtectedPath) {
+		t.Errorf("prefix .git: expected ErrProtectedPath, got %v", err)
+	}
+}
+
+func TestBuildApplyPlan_QualifiedStoresSharingID(t *testing.T) {
+	fs := newMockFS()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	// Both scopes hold a store named foo tracking the same path
+	globalRepo := newMockStoreRepo()
+	componentRepo := newMockStoreRepo()
+	for scope, repo := range map[string]*mockStoreRepo{"global": globalRepo, "component": componentRepo} {
+		track := stores.NewTrackFile()
+		track.Tracked = []stores.TrackedPath{{Path: "Makefile", Kind: "file"}}
+		repo.setTrack("foo", track)
+		repo.setOverlayRoot("foo", "/"+scope+"/foo/overlay")
+		fs.setExists("/"+scope+"/foo/overlay/Makefile", true)
+		fs.setLstat("/"+scope+"/foo/overlay/Makefile", &mockFileInfo{name: "Makefile"})
+	}
+	storeRepo := stores.NewMultiStoreRepo(map[string]stores.StoreRepo{
+		"global/foo":    globalRepo,
+		"component/foo": componentRepo,
+	}, nil)
+
+	plan, err := BuildApplyPlan(workspace, []string{"global/foo", "component/foo"}, "copy", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+
+	var last Operation
+	for _, op := range plan.Operations {
+		if op.RelPath == "Makefile" && op.Type == OpCopy {
+			last = op
+		}
+	}
+	if last.Store != "component/foo" || last.SourcePath != "/component/foo/overlay/Makefile" {
+		t.Errorf("Makefile last copied from %q (%s), want component/foo", last.Store, last.SourcePath)
+	}
+	if len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], "component/foo overrides global/foo") {
+		t.Errorf("warnings = %v, want component/foo overriding global/foo", plan.Warnings)
+	}
+}
+
+func TestBuildApplyPlanWith_SymlinkVerifiesPinnedChecksum(t *testing.T) {
+	const pinned = "pinned-hash"
+
+	for _, tc := range []struct {
+		name     string
+		actual   string
+		conflict bool
+	}{
+		{"matching", pinned, false},
+		{"tampered", "tampered-hash", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := newMockFS()
+			storeRepo := newMockStoreRepo()
+			workspace := state.NewWorkspaceState("repo1", ".", "symlink")
+
+			track := stores.NewTrackFile()
+			track.Tracked = []stores.TrackedPath{{Path: "Makefile", Kind: "file", Checksum: pinned}}
+			storeRepo.setTrack("store1", track)
+			fs.setExists("/stores/store1/overlay/Makefile", true)
+			hasher := hash.NewFakeHasher()
+			hasher.SetHash("/stores/store1/overlay/Makefile", tc.actual)
+
+			plan, err := BuildApplyPlanWith(workspace, []string{"store1"}, "symlink", "/workspace", storeRepo, fs,
+				PlanOptions{Hasher: hasher})
+			if err != nil {
+				t.Fatalf("BuildApplyPlanWith failed: %v", err)
+			}
+			if tc.conflict {
+				if len(plan.Conflicts) != 1 || plan.Conflicts[0].Incoming != pinned {
+					t.Fatalf("conflicts = %+v, want a pinned checksum mismatch", plan.Conflicts)
+				}
+				if len(plan.Operations) != 0 {
+					t.Errorf("planned %d operations for a tampered source", len(plan.Operations))
+				}
+				return
+			}
+			if plan.HasConflicts() {
+				t.Fatalf("unexpected conflicts: %+v", plan.Conflicts)
+			}
+			if len(plan.Operations) != 1 || plan.Operations[0].Type != OpCreateSymlink {
+				t.Errorf("operations = %+v, want one symlink", plan.Operations)
+			}
+		})
+	}
+}
+
+func TestBuildApplyPlan_ReapplyUnchangedSymlink(t *testing.T) {
+	setup := func(target string) (*mockFS, *mockStoreRepo, *state.WorkspaceState) {
+		fs := newMockFS()
+		storeRepo := newMockStoreRepo()
+		workspace := state.NewWorkspaceState("repo1", ".", "symlink")
+		workspace.Paths["Makefile"] = state.PathOwnership{Store: "store1", Type: "symlink"}
+
+		track := stores.NewTrackFile()
+		track.Tracked = []stores.TrackedPath{{Path: "Makefile", Kind: "file"}}
+		storeRepo.setTrack("store1", track)
+		storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+		fs.setExists("/stores/store1/overlay/Makefile", true)
+		fs.setExists("/workspace/Makefile", true)
+		fs.setLstat("/workspace/Makefile", &mockFileInfo{name: "Makefile", isDir: false})
+		fs.setReadlink("/workspace/Makefile", target, nil)
+		return fs, storeRepo, workspace
+	}
+
+	for _, force := range []bool{false, true} {
+		fs, storeRepo, workspace := setup("/stores/store1/overlay/Makefile")
+		plan, err := BuildApplyPlan(workspace, []string{"store1"}, "symlink", "/workspace", storeRepo, fs, force)
+		if err != nil {
+			t.Fatalf("BuildApplyPlan(force=%v) failed: %v", force, err)
+		}
+		if len(plan.Operations) != 0 {
+			t.Errorf("force=%v: expected no operations for an unchanged link, got %+v", force, plan.Operations)
+		}
+		if len(plan.Notes) != 1 || !strings.Contains(plan.Notes[0], "Makefile") {
+			t.Errorf("force=%v: expected a note for Makefile, got %v", force, plan.Notes)
+		}
+	}
+
+	// A link pointing elsewhere is still replaced
+	fs, storeRepo, workspace := setup("/stores/old/overlay/Makefile")
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "symlink", "/workspace", storeRepo, fs, true)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	if len(plan.Operations) != 2 || len(plan.Notes) != 0 {
+		t.Errorf("expected remove + create for a retargeted link, got %+v (notes %v)", plan.Operations, plan.Notes)
+	}
+}
+
+func TestBuildApplyPlan_MirrorRemovesExtraFiles(t *testing.T) {
+	setup := func(mirror bool, owned bool) (*mockFS, *mockStoreRepo, *state.WorkspaceState) {
+		fs := newMockFS()
+		storeRepo := newMockStoreRepo()
+		workspace := state.NewWorkspaceState("repo1", ".", "copy")
+		if owned {
+			workspace.Paths["config"] = state.PathOwnership{Store: "store1", Type: "copy"}
+		}
+
+		track := stores.NewTrackFile()
+		track.Tracked = []stores.TrackedPath{{Path: "config", Kind: "dir", Mirror: mirror}}
+		storeRepo.setTrack("store1", track)
+		storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+
+		fs.setExists("/stores/store1/overlay/config", true)
+		fs.setLstat("/stores/store1/overlay/config", &mockFileInfo{name: "config", isDir: true})
+		fs.setDir("/stores/store1/overlay/config", &mockFileInfo{name: "app.yaml"})
+		fs.setLstat("/stores/store1/overlay/config/app.yaml", &mockFileInfo{name: "app.yaml"})
+
+		fs.setExists("/workspace/config", true)
+		fs.setLstat("/workspace/config", &mockFileInfo{name: "config", isDir: true})
+		fs.setDir("/workspace/config",
+			&mockFileInfo{name: "app.yaml"},
+			&mockFileInfo{name: "stale.yaml"},
+		)
+		return fs, storeRepo, workspace
+	}
+
+	fs, storeRepo, workspace := setup(true, true)
+	plan, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	want := []Operation{
+		{Type: OpCopy, SourcePath: "/stores/store1/overlay/config", DestPath: "/workspace/config", RelPath: "config", Store: "store1", Reason: "create: tracked by store1"},
+		{Type: OpRemove, DestPath: "/workspace/config/stale.yaml", RelPath: "config/stale.yaml", Store: "store1", Reason: "mirror: not in store store1"},
+	}
+	if len(plan.Operations) != len(want) {
+		t.Fatalf("expected %d operations, got %d: %+v", len(want), len(plan.Operations), plan.Operations)
+	}
+	for i := range want {
+		if plan.Operations[i] != want[i] {
+			t.Errorf("operation %d = %+v, want %+v", i, plan.Operations[i], want[i])
+		}
+	}
+
+	// Without Mirror, extra files are left behind
+	fs, storeRepo, workspace = setup(false, true)
+	plan, err = BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	if len(plan.Operations) != 1 || plan.Operations[0].Type != OpCopy {
+		t.Errorf("expected only the copy without mirror, got %+v", plan.Operations)
+	}
+
+	// A directory the store does not own is never mirrored
+	fs, storeRepo, workspace = setup(true, false)
+	plan, err = BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, true)
+	if err != nil {
+		t.Fatalf("BuildApplyPlan failed: %v", err)
+	}
+	for _, op := range plan.Operations {
+		if op.RelPath == "config/stale.yaml" {
+			t.Errorf("unexpected mirror removal for an unowned directory: %+v", plan.Operations)
+		}
+	}
+}
+
+func TestBuildApplyPlanWith_WhenConditions(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "always.conf", Kind: "file"},
+		{Path: "debug.conf", Kind: "file", When: "DEBUG"},
+		{Path: "debug1.conf", Kind: "file", When: "DEBUG==1"},
+		{Path: "local.conf", Kind: "file", When: "!CI"},
+	}
+	storeRepo.setTrack("store1", track)
+	storeRepo.setOverlayRoot("store1", "/stores/store1/overlay")
+	for _, tp := range track.Tracked {
+		fs.setExists("/stores/store1/overlay/"+tp.Path, true)
+	}
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		want []string
+	}{
+		{"no vars", nil, []string{"always.conf", "local.conf"}},
+		{"debug set", map[string]string{"DEBUG": "1"}, []string{"always.conf", "debug.conf", "debug1.conf", "local.conf"}},
+		{"debug other value", map[string]string{"DEBUG": "2"}, []string{"always.conf", "debug.conf", "local.conf"}},
+		{"ci set", map[string]string{"CI": "true"}, []string{"always.conf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := BuildApplyPlanWith(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, PlanOptions{Vars: tt.vars})
+			if err != nil {
+				t.Fatalf("BuildApplyPlanWith failed: %v", err)
+			}
+			var got []string
+			for _, op := range plan.Operations {
+				got = append(got, op.RelPath)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("planned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildApplyPlan_InvalidWhenCondition(t *testing.T) {
+	fs := newMockFS()
+	storeRepo := newMockStoreRepo()
+	workspace := state.NewWorkspaceState("repo1", ".", "copy")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "debug.conf", Kind: "file", When: "DEBUG=1"},
+	}
+	storeRepo.setTrack("store1", track)
+	fs.setExists("/stores/store1/overlay/debug.conf", true)
+
+	_, err := BuildApplyPlan(workspace, []string{"store1"}, "copy", "/workspace", storeRepo, fs, false)
+	if !errors.Is(err, ErrInvalidCondition) {
+		t.Fatalf("expected ErrInvalidCondition, got %v", err)
+	}
+}
@@ -46,8 +46,9 @@ func (c *ConflictChecker) CheckPath(relPath, destPath, incomingType, incomingMod
 		return nil
 	}
 
-	// Path exists - check if it's managed by monodev (use relative path for lookup)
-	ownership, isManaged := c.workspace.Paths[relPath]
+	// Path exists - check if it's managed by monodev (use relative path for lookup).
+	// Paths inside a compacted directory are managed by its owner.
+	ownership, isManaged := c.workspace.Owner(relPath)
 
 	if !isManaged {
 		// Unmanaged path exists - this is a conflict unless force is enabled
@@ -148,7 +149,7 @@ func (c *ConflictChecker) CheckPath(relPath, destPath, incomingType, incomingMod
 
 // IsPathManaged returns true if the relative path is managed by monodev.
 func (c *ConflictChecker) IsPathManaged(relPath string) bool {
-	_, isManaged := c.workspace.Paths[relPath]
+	_, isManaged := c.workspace.Owner(relPath)
 	return isManaged
 }
 
@@ -156,7 +157,7 @@ func (c *ConflictChecker) IsPathManaged(relPath string) bool {
 // Returns nil if the path is not managed.
 // relPath should be relative from workspace root.
 func (c *ConflictChecker) GetOwnership(relPath string) *state.PathOwnership {
-	if ownership, ok := c.workspace.Paths[relPath]; ok {
+	if ownership, ok := c.workspace.Owner(relPath); ok {
 		return &ownership
 	}
 	return nil
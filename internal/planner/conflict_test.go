@@ -1,7 +1,9 @@
 package planner
 
 import (
+	"io/fs"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -14,6 +16,8 @@ type mockFS struct {
 	lstat       map[string]os.FileInfo
 	readlink    map[string]string
 	readlinkErr map[string]error
+	dirs        map[string][]os.FileInfo
+	files       map[string][]byte
 }
 
 func newMockFS() *mockFS {
@@ -22,6 +26,8 @@ func newMockFS() *mockFS {
 		lstat:       make(map[string]os.FileInfo),
 		readlink:    make(map[string]string),
 		readlinkErr: make(map[string]error),
+		dirs:        make(map[string][]os.FileInfo),
+		files:       make(map[string][]byte),
 	}
 }
 
@@ -41,6 +47,11 @@ func (m *mockFS) setReadlink(path string, target string, err error) {
 	}
 }
 
+// setDir registers the immediate children reported when walking dir.
+func (m *mockFS) setDir(dir string, entries ...os.FileInfo) {
+	m.dirs[dir] = entries
+}
+
 func (m *mockFS) Exists(path string) (bool, error) {
 	if exists, ok := m.exists[path]; ok {
 		return exists, nil
@@ -71,11 +82,44 @@ func (m *mockFS) Remove(path string) error                                     {
 func (m *mockFS) RemoveAll(path string) error                                  { return nil }
 func (m *mockFS) Symlink(oldname, newname string) error                        { return nil }
 func (m *mockFS) Copy(src, dst string) error                                   { return nil }
+func (m *mockFS) Rename(oldpath, newpath string) error                         { return nil }
+func (m *mockFS) Chtimes(path string, atime, mtime time.Time) error            { return nil }
 func (m *mockFS) AtomicWrite(path string, data []byte, perm os.FileMode) error { return nil }
-func (m *mockFS) ReadFile(path string) ([]byte, error)                         { return nil, nil }
+func (m *mockFS) ReadFile(path string) ([]byte, error)                         { return m.files[path], nil }
 func (m *mockFS) ValidateRelPath(relPath string) error                         { return nil }
 func (m *mockFS) ValidateIdentifier(id string) error                           { return nil }
 
+// ReadDir returns the children registered with setDir.
+func (m *mockFS) ReadDir(path string) ([]os.DirEntry, error) {
+	entries, ok := m.dirs[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	dirEntries := make([]os.DirEntry, 0, len(entries))
+	for _, info := range entries {
+		dirEntries = append(dirEntries, fs.FileInfoToDirEntry(info))
+	}
+	return dirEntries, nil
+}
+
+// WalkDir visits root and the children registered with setDir, one level deep.
+func (m *mockFS) WalkDir(root string, fn fs.WalkDirFunc) error {
+	entries, ok := m.dirs[root]
+	if !ok {
+		return nil
+	}
+	if err := fn(root, fs.FileInfoToDirEntry(&mockFileInfo{name: filepath.Base(root), isDir: true}), nil); err != nil {
+		return err
+	}
+	for _, info := range entries {
+		err := fn(filepath.Join(root, info.Name()), fs.FileInfoToDirEntry(info), nil)
+		if err != nil && err != fs.SkipDir {
+			return err
+		}
+	}
+	return nil
+}
+
 // mockFileInfo is a simple implementation of os.FileInfo
 type mockFileInfo struct {
 	name  string
@@ -598,3 +642,25 @@ func TestConflictChecker_CheckPath_SuspiciousSymlink(t *testing.T) {
 		t.Errorf("expected no conflict with force, got: %v", conflictForce)
 	}
 }
+
+func TestConflictChecker_CheckPath_MixedModeWorkspaceUsesPathType(t *testing.T) {
+	fs := newMockFS()
+	fs.setExists("/workspace/Makefile", true)
+	fs.setLstat("/workspace/Makefile", &mockFileInfo{name: "Makefile", isDir: false})
+	fs.setReadlink("/workspace/Makefile", "/store1/overlay/Makefile", nil)
+	fs.setExists("/workspace/config.yaml", true)
+	fs.setLstat("/workspace/config.yaml", &mockFileInfo{name: "config.yaml", isDir: false})
+
+	// Workspace mode says copy, but Makefile was applied as a symlink
+	workspace := state.NewWorkspaceState("repo1", "workspace", "copy")
+	workspace.Paths["Makefile"] = state.PathOwnership{Store: "store1", Type: "symlink"}
+	workspace.Paths["config.yaml"] = state.PathOwnership{Store: "store1", Type: "copy"}
+	checker := NewConflictChecker(fs, workspace, false)
+
+	if conflict := checker.CheckPath("Makefile", "/workspace/Makefile", "file", "symlink", "store1"); conflict != nil {
+		t.Errorf("unexpected conflict for symlink path: %+v", conflict)
+	}
+	if conflict := checker.CheckPath("config.yaml", "/workspace/config.yaml", "file", "copy", "store1"); conflict != nil {
+		t.Errorf("unexpected conflict for copy path: %+v", conflict)
+	}
+}
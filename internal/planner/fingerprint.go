@@ -0,0 +1,53 @@
+package planner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// ComputeFingerprint returns a hash identifying what executing the plan would
+// do: its stores, operations, conflicts and warnings, plus the checksum that
+// sourceChecksum reports for each operation's source (so edits to overlay
+// content change the fingerprint even when the operations do not).
+// Operation reasons are display-only and excluded.
+func (p *ApplyPlan) ComputeFingerprint(sourceChecksum func(path string) string) string {
+	h := sha256.New()
+	field := func(w io.Writer, s string) {
+		// Length-prefix each field so adjacent values cannot run together
+		fmt.Fprintf(w, "%d:%s;", len(s), s)
+	}
+
+	for _, s := range p.Stores {
+		field(h, s)
+	}
+	for _, op := range p.Operations {
+		field(h, "op")
+		field(h, op.Type)
+		field(h, op.DestPath)
+		field(h, op.RelPath)
+		field(h, op.Store)
+		switch {
+		case op.Type == OpBackup:
+			field(h, op.SourcePath)
+		case op.SourcePath != "":
+			// Overlay sources may be a temporary copy of a pinned store,
+			// so identify them by content rather than location
+			field(h, sourceChecksum(op.SourcePath))
+		}
+	}
+	for _, c := range p.Conflicts {
+		field(h, "conflict")
+		field(h, c.Path)
+		field(h, c.Reason)
+		field(h, c.Existing)
+		field(h, c.Incoming)
+	}
+	for _, w := range p.Warnings {
+		field(h, "warning")
+		field(h, w)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,42 @@
+package planner
+
+import "testing"
+
+func TestApplyPlan_ComputeFingerprint(t *testing.T) {
+	checksums := map[string]string{"/stores/s/overlay/a.txt": "sum1"}
+	checksum := func(path string) string { return checksums[path] }
+
+	newPlan := func() *ApplyPlan {
+		plan := NewApplyPlan([]string{"store1"})
+		plan.AddOperation(Operation{Type: OpCopy, SourcePath: "/stores/s/overlay/a.txt", DestPath: "/ws/a.txt", RelPath: "a.txt", Store: "store1", Reason: "create: tracked by store1"})
+		return plan
+	}
+
+	base := newPlan().ComputeFingerprint(checksum)
+	if base != newPlan().ComputeFingerprint(checksum) {
+		t.Fatal("fingerprint should be deterministic")
+	}
+
+	reworded := newPlan()
+	reworded.Operations[0].Reason = "something else"
+	if reworded.ComputeFingerprint(checksum) != base {
+		t.Error("operation reason should not affect the fingerprint")
+	}
+
+	extra := newPlan()
+	extra.AddOperation(Operation{Type: OpRemove, DestPath: "/ws/b.txt", RelPath: "b.txt"})
+	if extra.ComputeFingerprint(checksum) == base {
+		t.Error("an added operation should change the fingerprint")
+	}
+
+	conflicted := newPlan()
+	conflicted.AddConflict(Conflict{Path: "a.txt", Reason: "path exists but is not managed by monodev"})
+	if conflicted.ComputeFingerprint(checksum) == base {
+		t.Error("a conflict should change the fingerprint")
+	}
+
+	checksums["/stores/s/overlay/a.txt"] = "sum2"
+	if newPlan().ComputeFingerprint(checksum) == base {
+		t.Error("changed source content should change the fingerprint")
+	}
+}
@@ -13,11 +13,19 @@ type ApplyPlan struct {
 
 	// Warnings is a list of non-fatal issues encountered during planning
 	Warnings []string
+
+	// Notes records paths the plan leaves alone because they are already
+	// in place, for display only
+	Notes []string
+
+	// Fingerprint identifies the plan's effect (see ComputeFingerprint).
+	// Empty unless the caller computed it.
+	Fingerprint string
 }
 
 // Operation represents a single filesystem operation to execute.
 type Operation struct {
-	// Type is the operation type: "copy", "remove"
+	// Type is the operation type: "copy", "remove", "backup"
 	// Note: "create_symlink" is deprecated but kept for backward compatibility
 	Type string
 
@@ -32,6 +40,10 @@ type Operation struct {
 
 	// Store is the ID of the store contributing this operation
 	Store string
+
+	// Reason explains why the operation is planned, for display only
+	// (e.g. "create: tracked by store1")
+	Reason string
 }
 
 // Conflict represents a conflict detected during planning.
@@ -55,6 +67,13 @@ const (
 	OpCreateSymlink = "create_symlink"
 	OpCopy          = "copy"
 	OpRemove        = "remove"
+
+	// OpBackup moves the existing SourcePath aside to DestPath before it is replaced
+	OpBackup = "backup"
+
+	// OpMkdir creates DestPath as an empty directory, for tracked directories
+	// that have no contents to copy
+	OpMkdir = "mkdir"
 )
 
 // NewApplyPlan creates a new empty ApplyPlan.
@@ -64,6 +83,7 @@ func NewApplyPlan(stores []string) *ApplyPlan {
 		Operations: []Operation{},
 		Conflicts:  []Conflict{},
 		Warnings:   []string{},
+		Notes:      []string{},
 	}
 }
 
@@ -86,3 +106,8 @@ func (p *ApplyPlan) AddConflict(conflict Conflict) {
 func (p *ApplyPlan) AddWarning(msg string) {
 	p.Warnings = append(p.Warnings, msg)
 }
+
+// AddNote adds an informational note to the plan.
+func (p *ApplyPlan) AddNote(msg string) {
+	p.Notes = append(p.Notes, msg)
+}
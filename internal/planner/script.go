@@ -0,0 +1,52 @@
+package planner
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// WriteScript writes a POSIX shell script with the commands equivalent to
+// the plan's operations, for review. Every path is single-quoted, so the
+// script is safe to run whatever the paths contain.
+func (p *ApplyPlan) WriteScript(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\nset -e\n")
+
+	for _, op := range p.Operations {
+		b.WriteString("\n")
+		if op.Reason != "" {
+			// Keep the comment on one line so it cannot start a command
+			fmt.Fprintf(&b, "# %s\n", strings.NewReplacer("\n", " ", "\r", " ").Replace(op.Reason))
+		}
+
+		switch op.Type {
+		case OpCreateSymlink:
+			fmt.Fprintf(&b, "mkdir -p %s\n", shellQuote(filepath.Dir(op.DestPath)))
+			fmt.Fprintf(&b, "ln -s %s %s\n", shellQuote(op.SourcePath), shellQuote(op.DestPath))
+		case OpCopy:
+			fmt.Fprintf(&b, "mkdir -p %s\n", shellQuote(filepath.Dir(op.DestPath)))
+			fmt.Fprintf(&b, "cp -R %s %s\n", shellQuote(op.SourcePath), shellQuote(op.DestPath))
+		case OpRemove:
+			fmt.Fprintf(&b, "rm -rf %s\n", shellQuote(op.DestPath))
+		case OpBackup:
+			fmt.Fprintf(&b, "mv %s %s\n", shellQuote(op.SourcePath), shellQuote(op.DestPath))
+		case OpMkdir:
+			fmt.Fprintf(&b, "mkdir -p %s\n", shellQuote(op.DestPath))
+		default:
+			return fmt.Errorf("unknown operation type: %s", op.Type)
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write script: %w", err)
+	}
+	return nil
+}
+
+// shellQuote quotes s for a POSIX shell. Inside single quotes nothing is
+// special except the quote itself, which is closed, escaped, and reopened.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,53 @@
+package planner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyPlan_WriteScript(t *testing.T) {
+	plan := NewApplyPlan([]string{"store1"})
+	plan.AddOperation(Operation{Type: OpRemove, DestPath: "/ws/old.txt", RelPath: "old.txt", Reason: "replace: existing path removed (force)"})
+	plan.AddOperation(Operation{Type: OpCreateSymlink, SourcePath: "/stores/s/overlay/bin/run.sh", DestPath: "/ws/bin/run.sh", RelPath: "bin/run.sh", Store: "store1", Reason: "create: tracked by store1"})
+	plan.AddOperation(Operation{Type: OpCopy, SourcePath: "/stores/s/overlay/it's here.txt", DestPath: "/ws/it's here.txt", RelPath: "it's here.txt", Store: "store1"})
+	plan.AddOperation(Operation{Type: OpMkdir, DestPath: "/ws/cache", RelPath: "cache", Store: "store1"})
+
+	var b strings.Builder
+	if err := plan.WriteScript(&b); err != nil {
+		t.Fatalf("WriteScript failed: %v", err)
+	}
+	script := b.String()
+
+	want := []string{
+		"#!/bin/sh\nset -e\n",
+		"# replace: existing path removed (force)\nrm -rf '/ws/old.txt'\n",
+		"mkdir -p '/ws/bin'\nln -s '/stores/s/overlay/bin/run.sh' '/ws/bin/run.sh'\n",
+		`cp -R '/stores/s/overlay/it'\''s here.txt' '/ws/it'\''s here.txt'` + "\n",
+		"mkdir -p '/ws/cache'\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(script, w) {
+			t.Errorf("script missing %q:\n%s", w, script)
+		}
+	}
+
+	// Commands appear in plan order
+	if strings.Index(script, "rm -rf") > strings.Index(script, "ln -s") || strings.Index(script, "ln -s") > strings.Index(script, "cp -R") {
+		t.Errorf("commands out of plan order:\n%s", script)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":          "'plain'",
+		"with space":     "'with space'",
+		"$(rm -rf ~)":    "'$(rm -rf ~)'",
+		"it's":           `'it'\''s'`,
+		"line\nbreak; x": "'line\nbreak; x'",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -19,17 +19,34 @@ const (
 
 	// RemoteConfigFileName is the name of the remote config file
 	RemoteConfigFileName = "remote.json"
+
+	// RemoteKindGit syncs through a Git remote of the main repository
+	RemoteKindGit = "git"
+
+	// RemoteKindDir syncs into a plain directory (see DirPersistence)
+	RemoteKindDir = "dir"
 )
 
 // RemoteConfig represents the configuration for remote persistence operations.
 // It's stored repo-locally at .monodev/remote.json.
 type RemoteConfig struct {
+	// Kind selects the sync backend: RemoteKindGit (default when empty) or
+	// RemoteKindDir
+	Kind string `json:"kind,omitempty"`
+
+	// Dir is the target directory when Kind is RemoteKindDir
+	Dir string `json:"dir,omitempty"`
+
 	// Remote is the name of the Git remote to use (e.g., "origin")
 	Remote string `json:"remote"`
 
 	// Branch is the orphan branch name for persistence (e.g., "monodev/persist")
 	Branch string `json:"branch"`
 
+	// SyncStores limits push/pull of "all stores" to these store IDs.
+	// Empty means every store is synced.
+	SyncStores []string `json:"sync_stores,omitempty"`
+
 	// UpdatedAt is the last time this configuration was modified
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -43,6 +60,35 @@ func DefaultRemoteConfig() *RemoteConfig {
 	}
 }
 
+// Persistence returns the sync backend selected by Kind: git for a Git
+// remote, or a DirPersistence over Dir for a directory remote.
+func (c *RemoteConfig) Persistence(git GitPersistence, fs fsops.FS) (GitPersistence, error) {
+	switch c.Kind {
+	case "", RemoteKindGit:
+		return git, nil
+	case RemoteKindDir:
+		if c.Dir == "" {
+			return nil, fmt.Errorf("directory remote has no directory configured")
+		}
+		return NewDirPersistence(fs, c.Dir), nil
+	default:
+		return nil, fmt.Errorf("unknown remote kind %q: must be %s or %s", c.Kind, RemoteKindGit, RemoteKindDir)
+	}
+}
+
+// AllowsStore reports whether storeID is covered by the SyncStores allowlist.
+func (c *RemoteConfig) AllowsStore(storeID string) bool {
+	if len(c.SyncStores) == 0 {
+		return true
+	}
+	for _, id := range c.SyncStores {
+		if id == storeID {
+			return true
+		}
+	}
+	return false
+}
+
 // RemoteConfigStore is an interface for loading and saving remote configuration.
 type RemoteConfigStore interface {
 	// Load reads the remote configuration from the specified repo root.
@@ -0,0 +1,216 @@
+package remote
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/danieljhkim/monodev/internal/fsops"
+)
+
+// persistDirName is the directory under the .monodev work tree that holds
+// materialized stores. A directory remote mirrors it under the same name.
+const persistDirName = "persist"
+
+// DirPersistence implements GitPersistence against a plain directory instead
+// of a git remote, for setups without git hosting. Push copies the paths
+// passed to Commit into the target directory and Fetch mirrors the whole
+// persist directory back. There is no history: commits are not recorded,
+// and refs other than the configured branch cannot be read.
+type DirPersistence struct {
+	fs  fsops.FS
+	dir string
+
+	// pending lists the paths, relative to the persist directory, that the
+	// next Push copies
+	pending []string
+}
+
+// NewDirPersistence creates a DirPersistence that syncs into dir.
+func NewDirPersistence(fs fsops.FS, dir string) *DirPersistence {
+	return &DirPersistence{fs: fs, dir: dir}
+}
+
+// localPersistDir returns the persist directory in the .monodev work tree.
+func (d *DirPersistence) localPersistDir(repoRoot string) string {
+	return filepath.Join(repoRoot, ".monodev", persistDirName)
+}
+
+// remotePersistDir returns the persist directory in the target directory.
+func (d *DirPersistence) remotePersistDir() string {
+	return filepath.Join(d.dir, persistDirName)
+}
+
+// EnsureRepo creates the local persist directory.
+func (d *DirPersistence) EnsureRepo(repoRoot, branch string) error {
+	if err := d.fs.MkdirAll(d.localPersistDir(repoRoot), 0755); err != nil {
+		return fmt.Errorf("failed to create persist directory: %w", err)
+	}
+	return nil
+}
+
+// Commit records the paths the next Push copies. A directory remote keeps
+// no history, so the message is unused.
+func (d *DirPersistence) Commit(repoRoot, message string, paths []string) error {
+	local := d.localPersistDir(repoRoot)
+	for _, p := range paths {
+		rel, err := filepath.Rel(local, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("cannot commit %s: outside the persist directory %s", p, local)
+		}
+		d.pending = append(d.pending, rel)
+	}
+	return nil
+}
+
+// Push replaces each committed path in the target directory with the local
+// copy, leaving everything else in the target untouched. Unless force is
+// set, it fails with ErrRemoteAhead before changing anything if the target
+// holds content under those paths that is missing locally.
+func (d *DirPersistence) Push(repoRoot, remote, branch string, force bool) error {
+	local := d.localPersistDir(repoRoot)
+	if !force {
+		for _, rel := range d.pending {
+			extra, err := d.remoteOnly(filepath.Join(local, rel), filepath.Join(d.remotePersistDir(), rel))
+			if err != nil {
+				return fmt.Errorf("failed to push to %s: %w", d.dir, err)
+			}
+			if extra != "" {
+				return fmt.Errorf("%w: %s; pull first or push with force", ErrRemoteAhead, extra)
+			}
+		}
+	}
+
+	for _, rel := range d.pending {
+		src := filepath.Join(local, rel)
+		dst := filepath.Join(d.remotePersistDir(), rel)
+		exists, err := d.fs.Exists(src)
+		if err != nil {
+			return fmt.Errorf("failed to push to %s: %w", d.dir, err)
+		}
+		if !exists {
+			err = d.fs.RemoveAll(dst)
+		} else {
+			err = d.mirror(src, dst)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to push to %s: %w", d.dir, err)
+		}
+	}
+	d.pending = nil
+	return nil
+}
+
+// Fetch replaces the local persist directory with the target directory's.
+func (d *DirPersistence) Fetch(repoRoot, remote, branch string) error {
+	if err := d.mirror(d.remotePersistDir(), d.localPersistDir(repoRoot)); err != nil {
+		return fmt.Errorf("failed to fetch from %s: %w", d.dir, err)
+	}
+	return nil
+}
+
+// Checkout does nothing; Fetch already updated the work tree.
+func (d *DirPersistence) Checkout(repoRoot, branch string) error {
+	return nil
+}
+
+// GetRemoteURL returns the target directory.
+func (d *DirPersistence) GetRemoteURL(repoRoot, remoteName string) (string, error) {
+	return d.dir, nil
+}
+
+// SetRemote does nothing; the target directory is fixed at construction.
+func (d *DirPersistence) SetRemote(repoRoot, remoteName, url string) error {
+	return nil
+}
+
+// CheckoutPathAt is not supported, since a directory remote has no refs.
+func (d *DirPersistence) CheckoutPathAt(repoRoot, ref, path, dest string) error {
+	return fmt.Errorf("directory remote %s has no history to read ref %q from", d.dir, ref)
+}
+
+// ListDirsAt returns nothing, since a directory remote has no refs. Fetch
+// already places every remote store in the work tree.
+func (d *DirPersistence) ListDirsAt(repoRoot, ref, path string) ([]string, error) {
+	return nil, nil
+}
+
+// GC does nothing; a directory remote has no objects to prune.
+func (d *DirPersistence) GC(repoRoot string, aggressive bool) error {
+	return nil
+}
+
+// remoteOnly returns the first path under dst, in lexical order, that has
+// no counterpart under src, or "" if there is none.
+func (d *DirPersistence) remoteOnly(src, dst string) (string, error) {
+	dstExists, err := d.fs.Exists(dst)
+	if err != nil || !dstExists {
+		return "", err
+	}
+	srcExists, err := d.fs.Exists(src)
+	if err != nil {
+		return "", err
+	}
+	if !srcExists {
+		return dst, nil
+	}
+
+	dstInfo, err := d.fs.Lstat(dst)
+	if err != nil {
+		return "", err
+	}
+	srcInfo, err := d.fs.Lstat(src)
+	if err != nil {
+		return "", err
+	}
+	if !dstInfo.IsDir() {
+		return "", nil
+	}
+	if !srcInfo.IsDir() {
+		// The local file would replace the remote directory's contents
+		return dst, nil
+	}
+
+	entries, err := d.fs.ReadDir(dst)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		extra, err := d.remoteOnly(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()))
+		if err != nil || extra != "" {
+			return extra, err
+		}
+	}
+	return "", nil
+}
+
+// mirror replaces dst with a copy of src. The copy is built beside dst and
+// renamed into place, so a failed copy leaves dst as it was. A missing src
+// yields an empty dst.
+func (d *DirPersistence) mirror(src, dst string) error {
+	staging := dst + ".incoming"
+	if err := d.fs.RemoveAll(staging); err != nil {
+		return err
+	}
+	if err := d.fs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	exists, err := d.fs.Exists(src)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := d.fs.Copy(src, staging); err != nil {
+			_ = d.fs.RemoveAll(staging)
+			return err
+		}
+	} else if err := d.fs.MkdirAll(staging, 0755); err != nil {
+		return err
+	}
+
+	if err := d.fs.RemoveAll(dst); err != nil {
+		return err
+	}
+	return d.fs.Rename(staging, dst)
+}
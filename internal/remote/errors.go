@@ -22,4 +22,8 @@ var (
 	// ErrFingerprintMismatch is returned when a workspace ref's repo fingerprint
 	// doesn't match the current repository.
 	ErrFingerprintMismatch = errors.New("workspace repository fingerprint mismatch")
+
+	// ErrRemoteAhead is returned when a push would overwrite or remove
+	// content in a directory remote that is missing locally.
+	ErrRemoteAhead = errors.New("remote has content missing locally")
 )
@@ -1,12 +1,15 @@
 package remote
 
 import (
+	"archive/tar"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -56,6 +59,20 @@ type GitPersistence interface {
 
 	// SetRemote configures a remote in the persistence repository.
 	SetRemote(repoRoot, remoteName, url string) error
+
+	// CheckoutPathAt writes the tree at path (relative to the .monodev work
+	// tree) as it existed at ref into dest, without touching the work tree
+	// or index. dest mirrors path, so dest/<x> holds path/<x>.
+	CheckoutPathAt(repoRoot, ref, path, dest string) error
+
+	// ListDirsAt returns the names of the directories directly under path
+	// (relative to the .monodev work tree) as it existed at ref. A path
+	// missing at ref has none.
+	ListDirsAt(repoRoot, ref, path string) ([]string, error)
+
+	// GC compacts the persistence repository and prunes unreachable
+	// objects. Aggressive trades a slower run for a smaller repository.
+	GC(repoRoot string, aggressive bool) error
 }
 
 // RealGitPersistence is the production implementation using exec.Command.
@@ -280,6 +297,129 @@ func (g *RealGitPersistence) SetRemote(repoRoot, remoteName, url string) error {
 	return nil
 }
 
+// CheckoutPathAt extracts path at ref into dest using git archive.
+func (g *RealGitPersistence) CheckoutPathAt(repoRoot, ref, path, dest string) error {
+	if err := validateGitRef(ref, "ref"); err != nil {
+		return err
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+
+	cmd := exec.Command("git", "archive", "--format=tar", ref, "--", path)
+	cmd.Dir = repoRoot
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("GIT_DIR=%s", g.gitDir(repoRoot)),
+		fmt.Sprintf("GIT_WORK_TREE=%s", g.workTree(repoRoot)),
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to read %s at %s: %w", path, ref, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to read %s at %s: %w", path, ref, err)
+	}
+
+	extractErr := extractTree(tar.NewReader(stdout), path, dest)
+	// Drain whatever extraction left unread so git can exit
+	_, _ = io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("failed to read %s at %s: %w\nstderr: %s", path, ref, err, stderr.String())
+	}
+	return extractErr
+}
+
+// ListDirsAt lists the directories under path at ref using git ls-tree.
+func (g *RealGitPersistence) ListDirsAt(repoRoot, ref, path string) ([]string, error) {
+	if err := validateGitRef(ref, "ref"); err != nil {
+		return nil, err
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+
+	out, err := g.runGit(repoRoot, "ls-tree", "-d", "--name-only", ref, "--", path+"/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s at %s: %w", path, ref, err)
+	}
+	var dirs []string
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		dirs = append(dirs, strings.TrimPrefix(line, path+"/"))
+	}
+	return dirs, nil
+}
+
+// GC runs git gc on the persistence repository, pruning loose objects immediately.
+func (g *RealGitPersistence) GC(repoRoot string, aggressive bool) error {
+	if _, err := os.Stat(g.gitDir(repoRoot)); err != nil {
+		return fmt.Errorf("persistence repository not initialized: %w", err)
+	}
+
+	args := []string{"gc", "--prune=now", "--quiet"}
+	if aggressive {
+		args = append(args, "--aggressive")
+	}
+	if _, err := g.runGit(repoRoot, args...); err != nil {
+		return fmt.Errorf("failed to gc: %w", err)
+	}
+
+	return nil
+}
+
+// extractTree writes the archive entries under prefix into dest, stripping
+// the prefix. Entries that would resolve outside dest are rejected.
+func extractTree(tr *tar.Reader, prefix, dest string) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		name := strings.TrimSuffix(hdr.Name, "/")
+		if name != prefix && !strings.HasPrefix(name, prefix+"/") {
+			continue
+		}
+		target := filepath.Join(dest, filepath.FromSlash(strings.TrimPrefix(name, prefix)))
+		if rel, err := filepath.Rel(dest, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %q escapes destination", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return fmt.Errorf("failed to create symlink: %w", err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return fmt.Errorf("failed to create file: %w", err)
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				_ = f.Close()
+				return fmt.Errorf("failed to write file: %w", err)
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("failed to close file: %w", err)
+			}
+		}
+	}
+}
+
 // FakeGitPersistence is a test double that tracks operations without executing them.
 type FakeGitPersistence struct {
 	EnsureRepoCalls []EnsureRepoCall
@@ -290,6 +430,10 @@ type FakeGitPersistence struct {
 	GetRemoteCalls  []GetRemoteCall
 	SetRemoteCalls  []SetRemoteCall
 
+	CheckoutPathAtCalls []CheckoutPathAtCall
+	ListDirsAtCalls     []ListDirsAtCall
+	GCCalls             []GCCall
+
 	// Configurable responses
 	EnsureRepoErr error
 	CommitErr     error
@@ -299,6 +443,11 @@ type FakeGitPersistence struct {
 	RemoteURL     string
 	GetRemoteErr  error
 	SetRemoteErr  error
+	GCErr         error
+
+	// RefTrees holds the committed files per ref, keyed by path relative
+	// to the .monodev work tree, for CheckoutPathAt and ListDirsAt
+	RefTrees map[string]map[string]string
 }
 
 type EnsureRepoCall struct {
@@ -341,6 +490,24 @@ type SetRemoteCall struct {
 	URL        string
 }
 
+type CheckoutPathAtCall struct {
+	RepoRoot string
+	Ref      string
+	Path     string
+	Dest     string
+}
+
+type ListDirsAtCall struct {
+	RepoRoot string
+	Ref      string
+	Path     string
+}
+
+type GCCall struct {
+	RepoRoot   string
+	Aggressive bool
+}
+
 // NewFakeGitPersistence creates a new FakeGitPersistence.
 func NewFakeGitPersistence() *FakeGitPersistence {
 	return &FakeGitPersistence{
@@ -408,3 +575,72 @@ func (f *FakeGitPersistence) SetRemote(repoRoot, remoteName, url string) error {
 	})
 	return f.SetRemoteErr
 }
+
+// CheckoutPathAt writes the files recorded in RefTrees[ref] under path into dest.
+func (f *FakeGitPersistence) CheckoutPathAt(repoRoot, ref, path, dest string) error {
+	f.CheckoutPathAtCalls = append(f.CheckoutPathAtCalls, CheckoutPathAtCall{
+		RepoRoot: repoRoot,
+		Ref:      ref,
+		Path:     path,
+		Dest:     dest,
+	})
+
+	tree, ok := f.RefTrees[ref]
+	if !ok {
+		return fmt.Errorf("unknown ref %q", ref)
+	}
+	prefix := filepath.ToSlash(filepath.Clean(path)) + "/"
+	found := false
+	for name, content := range tree {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		found = true
+		target := filepath.Join(dest, filepath.FromSlash(strings.TrimPrefix(name, prefix)))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("path %q not found at ref %q", path, ref)
+	}
+	return nil
+}
+
+// ListDirsAt lists the directories under path in RefTrees[ref]. A ref
+// without a recorded tree has none.
+func (f *FakeGitPersistence) ListDirsAt(repoRoot, ref, path string) ([]string, error) {
+	f.ListDirsAtCalls = append(f.ListDirsAtCalls, ListDirsAtCall{
+		RepoRoot: repoRoot,
+		Ref:      ref,
+		Path:     path,
+	})
+
+	prefix := filepath.ToSlash(filepath.Clean(path)) + "/"
+	seen := make(map[string]bool)
+	var dirs []string
+	for name := range f.RefTrees[ref] {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		dir, _, isNested := strings.Cut(rest, "/")
+		if isNested && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func (f *FakeGitPersistence) GC(repoRoot string, aggressive bool) error {
+	f.GCCalls = append(f.GCCalls, GCCall{
+		RepoRoot:   repoRoot,
+		Aggressive: aggressive,
+	})
+	return f.GCErr
+}
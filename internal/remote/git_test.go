@@ -1,6 +1,12 @@
 package remote
 
-import "testing"
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestValidateGitRef(t *testing.T) {
 	tests := []struct {
@@ -39,3 +45,50 @@ func TestValidateGitRef(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractTree(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	entries := []struct {
+		name    string
+		content string
+		dir     bool
+	}{
+		{name: "persist/stores/s1/", dir: true},
+		{name: "persist/stores/s1/meta.json", content: "{}"},
+		{name: "persist/stores/s1/overlay/a.txt", content: "hello"},
+		{name: "persist/stores/s10/other.txt", content: "not ours"},
+	}
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if e.dir {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := t.TempDir()
+	if err := extractTree(tar.NewReader(&buf), "persist/stores/s1", dest); err != nil {
+		t.Fatalf("extractTree failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, "overlay", "a.txt"))
+	if err != nil || string(data) != "hello" {
+		t.Errorf("overlay/a.txt = %q, %v; want \"hello\"", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "meta.json")); err != nil {
+		t.Errorf("expected meta.json: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "other.txt")); !os.IsNotExist(err) {
+		t.Errorf("entries of a sibling path should be skipped, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AuditLogFileName is the name of the audit log written under the state root.
+const AuditLogFileName = "audit.log"
+
+// Audit operations recorded in AuditRecord.Op.
+const (
+	AuditOpSave   = "save"
+	AuditOpDelete = "delete"
+)
+
+// AuditRecord describes one workspace state mutation.
+type AuditRecord struct {
+	// Time is when the mutation was made
+	Time time.Time `json:"time"`
+
+	// Op is AuditOpSave or AuditOpDelete
+	Op string `json:"op"`
+
+	// WorkspaceID identifies the mutated workspace state
+	WorkspaceID string `json:"workspaceId"`
+
+	// Added lists managed paths the mutation added, sorted
+	Added []string `json:"added,omitempty"`
+
+	// Removed lists managed paths the mutation removed, sorted
+	Removed []string `json:"removed,omitempty"`
+
+	// PreviousActiveStore and ActiveStore are set when the active store changed
+	PreviousActiveStore string `json:"previousActiveStore,omitempty"`
+	ActiveStore         string `json:"activeStore,omitempty"`
+}
+
+// AuditSink receives a record for every workspace state mutation.
+type AuditSink interface {
+	// Record stores rec. It is called after the mutation succeeded.
+	Record(rec AuditRecord) error
+}
+
+// NopAuditSink discards audit records.
+type NopAuditSink struct{}
+
+// Record does nothing.
+func (NopAuditSink) Record(AuditRecord) error { return nil }
+
+// FileAuditSink appends audit records to a file as line-delimited JSON.
+// It is safe for concurrent use within a process.
+type FileAuditSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileAuditSink creates a sink appending to path.
+func NewFileAuditSink(path string) *FileAuditSink {
+	return &FileAuditSink{path: path}
+}
+
+// Record appends rec as one JSON line. The log is only ever appended to.
+func (s *FileAuditSink) Record(rec AuditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+	return nil
+}
+
+// newAuditRecord summarizes the change from previous to next, either of
+// which may be nil, made at now.
+func newAuditRecord(now time.Time, op, id string, previous, next *WorkspaceState) AuditRecord {
+	rec := AuditRecord{Time: now.UTC(), Op: op, WorkspaceID: id}
+
+	var before, after map[string]PathOwnership
+	var beforeStore, afterStore string
+	if previous != nil {
+		before, beforeStore = previous.Paths, previous.ActiveStore
+	}
+	if next != nil {
+		after, afterStore = next.Paths, next.ActiveStore
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			rec.Added = append(rec.Added, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			rec.Removed = append(rec.Removed, path)
+		}
+	}
+	slices.Sort(rec.Added)
+	slices.Sort(rec.Removed)
+
+	if beforeStore != afterStore {
+		rec.PreviousActiveStore, rec.ActiveStore = beforeStore, afterStore
+	}
+	return rec
+}
@@ -0,0 +1,266 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// sep is the separator used in workspace-relative path keys.
+const sep = string(filepath.Separator)
+
+// DirEntries lists the names of the entries a store provides directly under
+// dir (a workspace-relative path). It returns false when the store's
+// directory cannot be listed, which keeps the directory expanded.
+type DirEntries func(store, dir string) ([]string, bool)
+
+// WorkspaceEntries lists the names of the entries present on disk directly
+// under dir (a workspace-relative path). It returns false when the directory
+// cannot be listed, which keeps the directory expanded.
+type WorkspaceEntries func(dir string) ([]string, bool)
+
+// Compact collapses directories whose entries are all owned by the same store
+// with the same type, and exactly match both the store's own directory
+// listing and what is on disk, into a single directory ownership entry
+// marked Compacted. The ownership of each collapsed path, checksums
+// included, is kept in the entry's Contents. Nested directories are
+// collapsed first, so a fully covered tree ends up as one entry.
+//
+// Directories compacted earlier are expanded and checked again, so one that
+// gained unmanaged files stays expanded. It returns the change in the number
+// of path entries: positive when entries were removed, negative when earlier
+// compactions no longer hold.
+//
+// Compaction is undone by the next apply of the store, which records each
+// path again (see ExpandCompacted).
+func (ws *WorkspaceState) Compact(entries DirEntries, present WorkspaceEntries) (int, error) {
+	before := len(ws.Paths)
+	if err := ws.ExpandAllCompacted(entries); err != nil {
+		return 0, err
+	}
+
+	for {
+		dir, ok := ws.nextCompactable(entries, present)
+		if !ok {
+			return before - len(ws.Paths), nil
+		}
+
+		children := ws.childPaths(dir)
+		first := ws.Paths[children[0]]
+		merged := PathOwnership{
+			Store:      first.Store,
+			StoreScope: first.StoreScope,
+			Type:       first.Type,
+			Timestamp:  first.Timestamp,
+			Compacted:  true,
+			Contents:   make(map[string]PathOwnership),
+		}
+		for _, child := range children {
+			ownership := ws.Paths[child]
+			if ownership.Timestamp.After(merged.Timestamp) {
+				merged.Timestamp = ownership.Timestamp
+			}
+			name := strings.TrimPrefix(child, dir+sep)
+			if ownership.Compacted {
+				// A nested compacted directory is flattened into this one
+				for rel, inner := range ownership.Contents {
+					merged.Contents[filepath.Join(name, rel)] = inner
+				}
+			} else {
+				merged.Contents[name] = ownership
+			}
+			delete(ws.Paths, child)
+		}
+		ws.Paths[dir] = merged
+	}
+}
+
+// nextCompactable returns the deepest directory that can be compacted.
+func (ws *WorkspaceState) nextCompactable(entries DirEntries, present WorkspaceEntries) (string, bool) {
+	seen := make(map[string]bool)
+	var dirs []string
+	for p := range ws.Paths {
+		dir := filepath.Dir(p)
+		if dir == "." || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+
+	// Deepest first, then by name for determinism
+	slices.SortFunc(dirs, func(a, b string) int {
+		if da, db := strings.Count(a, sep), strings.Count(b, sep); da != db {
+			return db - da
+		}
+		return strings.Compare(a, b)
+	})
+
+	for _, dir := range dirs {
+		if ws.canCompact(dir, entries, present) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// canCompact reports whether every entry under dir is a direct child owned
+// by one store with one type, and those children are exactly the store's
+// entries for dir and exactly the entries on disk, so the compacted entry
+// stands in for nothing the workspace does not manage.
+func (ws *WorkspaceState) canCompact(dir string, entries DirEntries, present WorkspaceEntries) bool {
+	if _, owned := ws.Paths[dir]; owned {
+		return false
+	}
+
+	var store, scope, typ string
+	var names []string
+	for p, ownership := range ws.Paths {
+		if !strings.HasPrefix(p, dir+sep) {
+			continue
+		}
+		name := strings.TrimPrefix(p, dir+sep)
+		if strings.ContainsRune(name, filepath.Separator) {
+			// A deeper entry that was not compacted keeps dir expanded
+			return false
+		}
+		if store == "" {
+			store, scope, typ = ownership.Store, ownership.StoreScope, ownership.Type
+		} else if ownership.Store != store || ownership.StoreScope != scope || ownership.Type != typ {
+			return false
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return false
+	}
+	slices.Sort(names)
+
+	for _, list := range []func() ([]string, bool){
+		func() ([]string, bool) { return entries(store, dir) },
+		func() ([]string, bool) { return present(dir) },
+	} {
+		want, ok := list()
+		if !ok || len(want) != len(names) {
+			return false
+		}
+		want = slices.Clone(want)
+		slices.Sort(want)
+		if !slices.Equal(names, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// childPaths returns the sorted paths recorded directly under dir.
+func (ws *WorkspaceState) childPaths(dir string) []string {
+	var children []string
+	for p := range ws.Paths {
+		if strings.HasPrefix(p, dir+sep) {
+			children = append(children, p)
+		}
+	}
+	slices.Sort(children)
+	return children
+}
+
+// Owner returns the ownership of relPath: its own entry if recorded, or else
+// its entry in the compacted directory that covers it.
+func (ws *WorkspaceState) Owner(relPath string) (PathOwnership, bool) {
+	if ownership, ok := ws.Paths[relPath]; ok {
+		return ownership, true
+	}
+	for dir := filepath.Dir(relPath); dir != "." && dir != sep; dir = filepath.Dir(dir) {
+		ownership, ok := ws.Paths[dir]
+		if !ok || !ownership.Compacted {
+			continue
+		}
+		if ownership.Contents == nil {
+			// Compacted before contents were recorded
+			return ownership, true
+		}
+		inner, ok := ownership.Contents[strings.TrimPrefix(relPath, dir+sep)]
+		return inner, ok
+	}
+	return PathOwnership{}, false
+}
+
+// ExpandCompacted replaces the compacted directory entries covering relPath
+// with entries for their contents, so that a new apply can record relPath
+// without dropping ownership of its siblings. Entries compacted before their
+// contents were recorded are expanded from the store listing in entries.
+func (ws *WorkspaceState) ExpandCompacted(relPath string, entries DirEntries) error {
+	var ancestors []string
+	for dir := filepath.Dir(relPath); dir != "." && dir != sep; dir = filepath.Dir(dir) {
+		ancestors = append(ancestors, dir)
+	}
+
+	// Expand outermost first; directories expanded from a store listing
+	// stay marked Compacted so the next ancestor down is expanded in turn
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if _, err := ws.ExpandDir(ancestors[i], entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpandAllCompacted replaces every compacted directory entry with entries
+// for its contents.
+func (ws *WorkspaceState) ExpandAllCompacted(entries DirEntries) error {
+	for {
+		var dirs []string
+		for p, ownership := range ws.Paths {
+			if ownership.Compacted {
+				dirs = append(dirs, p)
+			}
+		}
+		if len(dirs) == 0 {
+			return nil
+		}
+		for _, dir := range dirs {
+			if _, err := ws.ExpandDir(dir, entries); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ExpandDir replaces the compacted entry recorded at dir with entries for
+// its contents and returns the paths recorded in its place, sorted. It does
+// nothing if dir is not a compacted entry.
+func (ws *WorkspaceState) ExpandDir(dir string, entries DirEntries) ([]string, error) {
+	ownership, ok := ws.Paths[dir]
+	if !ok || !ownership.Compacted {
+		return nil, nil
+	}
+
+	expanded := make(map[string]PathOwnership)
+	if ownership.Contents != nil {
+		for rel, inner := range ownership.Contents {
+			expanded[filepath.Join(dir, rel)] = inner
+		}
+	} else {
+		names, ok := entries(ownership.Store, dir)
+		if !ok {
+			return nil, fmt.Errorf("cannot expand compacted directory %s: store %s does not list it", dir, ownership.Store)
+		}
+		for _, name := range names {
+			child := filepath.Join(dir, name)
+			inner := ownership
+			_, inner.Compacted = entries(ownership.Store, child)
+			expanded[child] = inner
+		}
+	}
+
+	delete(ws.Paths, dir)
+	paths := make([]string, 0, len(expanded))
+	for p, inner := range expanded {
+		ws.Paths[p] = inner
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
@@ -0,0 +1,182 @@
+package state
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeDirEntries serves store directory listings from a map keyed by dir.
+func fakeDirEntries(listings map[string][]string) DirEntries {
+	return func(store, dir string) ([]string, bool) {
+		names, ok := listings[dir]
+		return names, ok
+	}
+}
+
+// fakeWorkspaceEntries serves on-disk directory listings from a map keyed by dir.
+func fakeWorkspaceEntries(listings map[string][]string) WorkspaceEntries {
+	return func(dir string) ([]string, bool) {
+		names, ok := listings[dir]
+		return names, ok
+	}
+}
+
+func sortedPaths(ws *WorkspaceState) []string {
+	paths := make([]string, 0, len(ws.Paths))
+	for p := range ws.Paths {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func TestCompact_FullyCoveredDirectory(t *testing.T) {
+	ws := NewWorkspaceState("repo1", ".", "copy")
+	now := time.Now()
+	for _, p := range []string{"conf/a", "conf/b", "conf/sub/x", "conf/sub/y", "other.txt"} {
+		ws.Paths[filepath.FromSlash(p)] = PathOwnership{Store: "s1", Type: "copy", Timestamp: now, Checksum: "sum-" + p}
+	}
+	listings := map[string][]string{
+		"conf":                       {"a", "b", "sub"},
+		filepath.Join("conf", "sub"): {"y", "x"},
+	}
+
+	removed, err := ws.Compact(fakeDirEntries(listings), fakeWorkspaceEntries(listings))
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	if removed != 3 {
+		t.Errorf("removed = %d, want 3", removed)
+	}
+	if got, want := sortedPaths(ws), []string{"conf", "other.txt"}; !slices.Equal(got, want) {
+		t.Fatalf("Paths = %v, want %v", got, want)
+	}
+	conf := ws.Paths["conf"]
+	if !conf.Compacted || conf.Store != "s1" || conf.Type != "copy" {
+		t.Errorf("conf ownership = %+v, want compacted s1/copy", conf)
+	}
+
+	// Paths inside the compacted directory are still owned
+	if owner, ok := ws.Owner(filepath.Join("conf", "sub", "x")); !ok || owner.Store != "s1" || owner.Checksum != "sum-conf/sub/x" {
+		t.Errorf("Owner(conf/sub/x) = %+v, %v; want s1 with its checksum", owner, ok)
+	}
+	if _, ok := ws.Owner(filepath.Join("conf", "unmanaged")); ok {
+		t.Error("a path the compacted directory does not hold should not be owned")
+	}
+	if _, ok := ws.Owner("unrelated.txt"); ok {
+		t.Error("unrelated path should not be owned")
+	}
+
+	// Expanding for a nested path restores every entry on the way down
+	if err := ws.ExpandCompacted(filepath.Join("conf", "sub", "x"), fakeDirEntries(listings)); err != nil {
+		t.Fatalf("ExpandCompacted failed: %v", err)
+	}
+	want := []string{"conf/a", "conf/b", "conf/sub/x", "conf/sub/y", "other.txt"}
+	for i := range want {
+		want[i] = filepath.FromSlash(want[i])
+	}
+	if got := sortedPaths(ws); !slices.Equal(got, want) {
+		t.Errorf("Paths after expand = %v, want %v", got, want)
+	}
+	if got := ws.Paths[filepath.Join("conf", "a")].Checksum; got != "sum-conf/a" {
+		t.Errorf("conf/a checksum after expand = %q, want it kept", got)
+	}
+}
+
+func TestCompact_UnmanagedFileOnDiskStaysExpanded(t *testing.T) {
+	ws := NewWorkspaceState("repo1", ".", "copy")
+	ws.Paths[filepath.Join("conf", "a")] = PathOwnership{Store: "s1", Type: "copy"}
+	ws.Paths[filepath.Join("conf", "b")] = PathOwnership{Store: "s1", Type: "copy"}
+	store := map[string][]string{"conf": {"a", "b"}}
+
+	removed, err := ws.Compact(fakeDirEntries(store), fakeWorkspaceEntries(map[string][]string{"conf": {"a", "b", "notes.txt"}}))
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if removed != 0 || len(ws.Paths) != 2 {
+		t.Errorf("removed = %d, Paths = %v; want conf left expanded", removed, sortedPaths(ws))
+	}
+
+	// A directory compacted earlier is expanded again once it holds an
+	// unmanaged file
+	if _, err := ws.Compact(fakeDirEntries(store), fakeWorkspaceEntries(store)); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if !ws.Paths["conf"].Compacted {
+		t.Fatalf("expected conf to be compacted, got %v", sortedPaths(ws))
+	}
+	removed, err = ws.Compact(fakeDirEntries(store), fakeWorkspaceEntries(map[string][]string{"conf": {"a", "b", "notes.txt"}}))
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if removed != -1 || ws.Paths["conf"].Compacted {
+		t.Errorf("removed = %d, Paths = %v; want conf expanded again", removed, sortedPaths(ws))
+	}
+}
+
+func TestExpandCompacted_UnlistableStoreDirectory(t *testing.T) {
+	ws := NewWorkspaceState("repo1", ".", "copy")
+	// Compacted before contents were recorded
+	ws.Paths["conf"] = PathOwnership{Store: "s1", Type: "copy", Compacted: true}
+
+	err := ws.ExpandCompacted(filepath.Join("conf", "a"), fakeDirEntries(nil))
+	if err == nil {
+		t.Fatal("expected an error when the store cannot list the directory")
+	}
+	if !ws.Paths["conf"].Compacted {
+		t.Error("the compacted entry should be kept when it cannot be expanded")
+	}
+}
+
+func TestCompact_PartiallyCoveredDirectoryStaysExpanded(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths map[string]PathOwnership
+	}{
+		{
+			name: "store has more entries",
+			paths: map[string]PathOwnership{
+				"conf/a": {Store: "s1", Type: "copy"},
+			},
+		},
+		{
+			name: "mixed stores",
+			paths: map[string]PathOwnership{
+				"conf/a": {Store: "s1", Type: "copy"},
+				"conf/b": {Store: "s2", Type: "copy"},
+			},
+		},
+		{
+			name: "mixed types",
+			paths: map[string]PathOwnership{
+				"conf/a": {Store: "s1", Type: "copy"},
+				"conf/b": {Store: "s1", Type: "symlink"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := NewWorkspaceState("repo1", ".", "copy")
+			for p, ownership := range tt.paths {
+				ws.Paths[filepath.FromSlash(p)] = ownership
+			}
+
+			listings := map[string][]string{"conf": {"a", "b"}}
+			removed, err := ws.Compact(fakeDirEntries(listings), fakeWorkspaceEntries(listings))
+			if err != nil {
+				t.Fatalf("Compact failed: %v", err)
+			}
+
+			if removed != 0 {
+				t.Errorf("removed = %d, want 0", removed)
+			}
+			if len(ws.Paths) != len(tt.paths) {
+				t.Errorf("Paths = %v, want unchanged", sortedPaths(ws))
+			}
+		})
+	}
+}
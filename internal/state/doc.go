@@ -9,4 +9,5 @@
 //   - WorkspaceID: Unique identifier derived from repo fingerprint and path
 //   - PathOwnership: Tracks which store owns each managed path
 //   - StateStore: Interface for persisting and loading workspace state
+//   - AuditSink: Receives a record of every state save and delete (audit.log)
 package state
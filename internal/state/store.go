@@ -1,39 +1,140 @@
 package state
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/danieljhkim/monodev/internal/clock"
 	"github.com/danieljhkim/monodev/internal/fsops"
 )
 
+// ErrStateConflict is returned by SaveWorkspace when the state file changed
+// on disk after the state being saved was loaded. The operation can be
+// retried against the new state.
+var ErrStateConflict = errors.New("workspace state changed on disk since it was loaded")
+
+// ErrSchemaTooNew is returned by LoadWorkspace when the state file was
+// written by a newer monodev with a schema this build does not understand.
+var ErrSchemaTooNew = errors.New("workspace state schema is too new")
+
 // StateStore provides an interface for persisting workspace state.
 type StateStore interface {
 	// LoadWorkspace loads the workspace state for the given workspace ID.
-	// Returns os.ErrNotExist if the state doesn't exist.
+	// Returns os.ErrNotExist if the state doesn't exist, and ErrSchemaTooNew
+	// if it was written with a newer schema than this build supports.
 	LoadWorkspace(id string) (*WorkspaceState, error)
 
 	// SaveWorkspace saves the workspace state atomically.
+	// Returns ErrStateConflict if the state was loaded from id and the
+	// stored state has changed since.
 	SaveWorkspace(id string, state *WorkspaceState) error
 
+	// CheckWorkspace reports ErrStateConflict if state was loaded from id and
+	// the stored state has changed since, without saving anything. Callers
+	// use it to detect a conflict before making changes SaveWorkspace would
+	// then fail to record.
+	CheckWorkspace(id string, state *WorkspaceState) error
+
 	// DeleteWorkspace deletes the workspace state file.
 	DeleteWorkspace(id string) error
+
+	// LoadWorkspaceIfChanged loads the workspace state only if it was modified
+	// after sinceModTime, reporting whether it was reloaded. A zero sinceModTime
+	// always reloads. Returns os.ErrNotExist if the state doesn't exist.
+	LoadWorkspaceIfChanged(id string, sinceModTime time.Time) (*WorkspaceState, bool, error)
 }
 
 // FileStateStore implements StateStore using JSON files on disk.
 type FileStateStore struct {
 	fs            fsops.FS
 	workspacesDir string
+	audit         AuditSink
+	auditFailed   func(error)
+	clock         clock.Clock
+	fileMode      os.FileMode
+}
+
+// defaultStateFileMode is the mode state files are written with unless
+// WithFileMode overrides it.
+const defaultStateFileMode os.FileMode = 0644
+
+// FileStateStoreOption configures a FileStateStore.
+type FileStateStoreOption func(*FileStateStore)
+
+// WithFileMode sets the mode state files are written with. The workspaces
+// directory is created with the same mode plus execute wherever it grants
+// read, so 0600 yields a 0700 directory.
+func WithFileMode(mode os.FileMode) FileStateStoreOption {
+	return func(s *FileStateStore) {
+		s.fileMode = mode.Perm()
+	}
+}
+
+// WithClock sets the clock audit records are timestamped with.
+func WithClock(clk clock.Clock) FileStateStoreOption {
+	return func(s *FileStateStore) {
+		s.clock = clk
+	}
+}
+
+// WithAuditErrorHandler sets the function called when the audit sink fails
+// to record a mutation that was already made. By default a warning is
+// written to stderr.
+func WithAuditErrorHandler(handle func(error)) FileStateStoreOption {
+	return func(s *FileStateStore) {
+		s.auditFailed = handle
+	}
+}
+
+// warnAuditFailed reports a failed audit record on stderr.
+func warnAuditFailed(err error) {
+	fmt.Fprintf(os.Stderr, "warning: %v\n", err)
 }
 
-// NewFileStateStore creates a new FileStateStore.
-func NewFileStateStore(fs fsops.FS, workspacesDir string) *FileStateStore {
-	return &FileStateStore{
+// NewFileStateStore creates a new FileStateStore. Mutations are not audited
+// until SetAuditSink is called.
+func NewFileStateStore(fs fsops.FS, workspacesDir string, opts ...FileStateStoreOption) *FileStateStore {
+	s := &FileStateStore{
 		fs:            fs,
 		workspacesDir: workspacesDir,
+		audit:         NopAuditSink{},
+		auditFailed:   warnAuditFailed,
+		clock:         &clock.RealClock{},
+		fileMode:      defaultStateFileMode,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// dirMode returns the mode for the workspaces directory, derived from the
+// file mode by adding execute wherever read is granted.
+func (s *FileStateStore) dirMode() os.FileMode {
+	return s.fileMode | (s.fileMode&0444)>>2
+}
+
+// SetAuditSink sets the sink that receives a record for every save and
+// delete. A nil sink disables auditing.
+func (s *FileStateStore) SetAuditSink(sink AuditSink) {
+	if sink == nil {
+		sink = NopAuditSink{}
+	}
+	s.audit = sink
+}
+
+// decodeState parses stored state, returning nil if data is not valid state.
+func decodeState(data []byte) *WorkspaceState {
+	var state WorkspaceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+	return &state
 }
 
 // LoadWorkspace loads the workspace state for the given workspace ID.
@@ -52,33 +153,131 @@ func (s *FileStateStore) LoadWorkspace(id string) (*WorkspaceState, error) {
 	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal workspace state: %w", err)
 	}
+	if state.SchemaVersion > WorkspaceSchemaVersion {
+		return nil, fmt.Errorf("%w: %s has version %d, this monodev supports up to %d; upgrade monodev to use it",
+			ErrSchemaTooNew, path, state.SchemaVersion, WorkspaceSchemaVersion)
+	}
+	state.loaded = stateVersion{id: id, sum: sha256.Sum256(data)}
 
 	return &state, nil
 }
 
 // SaveWorkspace saves the workspace state atomically.
+// State loaded from the same id is only saved if the file is unchanged since
+// the load (or a previous save of that state); otherwise ErrStateConflict is
+// returned so concurrent changes are not overwritten. The check and write are
+// not atomic, so this narrows rather than closes the race.
+// The save is then recorded with the audit sink; a failure to record it does
+// not fail the save, which has already been made.
 func (s *FileStateStore) SaveWorkspace(id string, state *WorkspaceState) error {
 	path := filepath.Join(s.workspacesDir, id+".json")
 
+	current, err := s.fs.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read workspace state: %w", err)
+	}
+	exists := err == nil
+	if exists && state.loaded.id == id && sha256.Sum256(current) != state.loaded.sum {
+		return fmt.Errorf("%w: %s", ErrStateConflict, id)
+	}
+	var previous *WorkspaceState
+	if exists {
+		previous = decodeState(current)
+	}
+
+	state.SchemaVersion = WorkspaceSchemaVersion
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal workspace state: %w", err)
 	}
 
-	if err := s.fs.AtomicWrite(path, data, 0644); err != nil {
+	if err := s.fs.MkdirAll(s.workspacesDir, s.dirMode()); err != nil {
+		return fmt.Errorf("failed to create workspaces directory: %w", err)
+	}
+	if err := s.fs.AtomicWrite(path, data, s.fileMode); err != nil {
 		return fmt.Errorf("failed to write workspace state: %w", err)
 	}
+	state.loaded = stateVersion{id: id, sum: sha256.Sum256(data)}
+
+	s.record(newAuditRecord(s.clock.Now(), AuditOpSave, id, previous, state))
 
 	return nil
 }
 
-// DeleteWorkspace deletes the workspace state file.
+// CheckWorkspace reports ErrStateConflict if state was loaded from id and
+// the state file has changed since. A missing state file is not a conflict,
+// as SaveWorkspace would create it.
+func (s *FileStateStore) CheckWorkspace(id string, state *WorkspaceState) error {
+	if state.loaded.id != id {
+		return nil
+	}
+	path := filepath.Join(s.workspacesDir, id+".json")
+
+	current, err := s.fs.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read workspace state: %w", err)
+	}
+	if sha256.Sum256(current) != state.loaded.sum {
+		return fmt.Errorf("%w: %s", ErrStateConflict, id)
+	}
+	return nil
+}
+
+// LoadWorkspaceIfChanged reloads the workspace state only when the state file's
+// mtime is after sinceModTime. Callers should pass the time recorded just
+// before their previous load.
+func (s *FileStateStore) LoadWorkspaceIfChanged(id string, sinceModTime time.Time) (*WorkspaceState, bool, error) {
+	path := filepath.Join(s.workspacesDir, id+".json")
+
+	info, err := s.fs.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, os.ErrNotExist
+		}
+		return nil, false, fmt.Errorf("failed to stat workspace state: %w", err)
+	}
+
+	if !sinceModTime.IsZero() && !info.ModTime().After(sinceModTime) {
+		return nil, false, nil
+	}
+
+	state, err := s.LoadWorkspace(id)
+	if err != nil {
+		return nil, false, err
+	}
+	return state, true, nil
+}
+
+// DeleteWorkspace deletes the workspace state file. Deleting state that
+// exists is recorded with the audit sink, whose failure does not fail the
+// delete.
 func (s *FileStateStore) DeleteWorkspace(id string) error {
 	path := filepath.Join(s.workspacesDir, id+".json")
 
-	if err := s.fs.Remove(path); err != nil && !os.IsNotExist(err) {
+	var previous *WorkspaceState
+	if data, err := s.fs.ReadFile(path); err == nil {
+		previous = decodeState(data)
+	}
+
+	if err := s.fs.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
 		return fmt.Errorf("failed to delete workspace state: %w", err)
 	}
 
+	s.record(newAuditRecord(s.clock.Now(), AuditOpDelete, id, previous, nil))
+
 	return nil
 }
+
+// record passes rec to the audit sink, reporting a failure to the audit
+// error handler since the mutation it describes has already been made.
+func (s *FileStateStore) record(rec AuditRecord) {
+	if err := s.audit.Record(rec); err != nil && s.auditFailed != nil {
+		s.auditFailed(fmt.Errorf("failed to record audit entry for workspace %s: %w", rec.WorkspaceID, err))
+	}
+}
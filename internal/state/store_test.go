@@ -0,0 +1,339 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieljhkim/monodev/internal/clock"
+	"github.com/danieljhkim/monodev/internal/fsops"
+)
+
+func TestFileStateStore_LoadWorkspaceIfChanged(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStateStore(fsops.NewRealFS(), dir)
+
+	ws := NewWorkspaceState("repo1", ".", "copy")
+	ws.ActiveStore = "s1"
+	if err := store.SaveWorkspace("ws1", ws); err != nil {
+		t.Fatalf("SaveWorkspace failed: %v", err)
+	}
+
+	path := filepath.Join(dir, "ws1.json")
+	written := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, written, written); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("unchanged is not reloaded", func(t *testing.T) {
+		got, reloaded, err := store.LoadWorkspaceIfChanged("ws1", written.Add(time.Second))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reloaded || got != nil {
+			t.Errorf("got (%v, %v), want (nil, false)", got, reloaded)
+		}
+	})
+
+	t.Run("changed is reloaded", func(t *testing.T) {
+		got, reloaded, err := store.LoadWorkspaceIfChanged("ws1", written.Add(-time.Second))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reloaded || got == nil || got.ActiveStore != "s1" {
+			t.Errorf("got (%+v, %v), want reloaded state", got, reloaded)
+		}
+	})
+
+	t.Run("zero time always reloads", func(t *testing.T) {
+		_, reloaded, err := store.LoadWorkspaceIfChanged("ws1", time.Time{})
+		if err != nil || !reloaded {
+			t.Errorf("got reloaded=%v err=%v, want reloaded", reloaded, err)
+		}
+	})
+
+	t.Run("missing returns ErrNotExist", func(t *testing.T) {
+		_, _, err := store.LoadWorkspaceIfChanged("nope", time.Time{})
+		if !os.IsNotExist(err) {
+			t.Errorf("expected not-exist error, got %v", err)
+		}
+	})
+}
+
+func TestFileStateStore_SaveWorkspaceDetectsConcurrentChange(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStateStore(fsops.NewRealFS(), dir)
+	if err := store.SaveWorkspace("ws1", NewWorkspaceState("repo1", ".", "copy")); err != nil {
+		t.Fatalf("SaveWorkspace failed: %v", err)
+	}
+
+	ours, err := store.LoadWorkspace("ws1")
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+
+	// Another process saves its own change in between
+	theirs, err := store.LoadWorkspace("ws1")
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	theirs.ActiveStore = "theirs"
+	if err := store.SaveWorkspace("ws1", theirs); err != nil {
+		t.Fatalf("concurrent SaveWorkspace failed: %v", err)
+	}
+
+	ours.ActiveStore = "ours"
+	if err := store.SaveWorkspace("ws1", ours); !errors.Is(err, ErrStateConflict) {
+		t.Fatalf("expected ErrStateConflict, got %v", err)
+	}
+	got, err := store.LoadWorkspace("ws1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ActiveStore != "theirs" {
+		t.Errorf("concurrent change was overwritten: ActiveStore = %q", got.ActiveStore)
+	}
+
+	// The writer's own state can be saved repeatedly
+	theirs.Applied = true
+	if err := store.SaveWorkspace("ws1", theirs); err != nil {
+		t.Errorf("second save of the same state failed: %v", err)
+	}
+
+	// Saving under a different ID is not a conflict
+	if err := store.SaveWorkspace("ws2", ours); err != nil {
+		t.Errorf("save under a new ID failed: %v", err)
+	}
+}
+
+// recordingAuditSink collects audit records in memory.
+type recordingAuditSink struct {
+	records []AuditRecord
+}
+
+func (s *recordingAuditSink) Record(rec AuditRecord) error {
+	s.records = append(s.records, rec)
+	return nil
+}
+
+func TestFileStateStore_AuditsMutations(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+	store := NewFileStateStore(fsops.NewRealFS(), t.TempDir(), WithClock(clock.NewFakeClock(now)))
+	sink := &recordingAuditSink{}
+	store.SetAuditSink(sink)
+
+	ws := NewWorkspaceState("repo1", ".", "copy")
+	ws.ActiveStore = "s1"
+	ws.Paths["a.txt"] = PathOwnership{Store: "s1", Type: "copy"}
+	if err := store.SaveWorkspace("ws1", ws); err != nil {
+		t.Fatalf("SaveWorkspace failed: %v", err)
+	}
+
+	ws.ActiveStore = "s2"
+	delete(ws.Paths, "a.txt")
+	ws.Paths["b.txt"] = PathOwnership{Store: "s2", Type: "copy"}
+	if err := store.SaveWorkspace("ws1", ws); err != nil {
+		t.Fatalf("second SaveWorkspace failed: %v", err)
+	}
+
+	if err := store.DeleteWorkspace("ws1"); err != nil {
+		t.Fatalf("DeleteWorkspace failed: %v", err)
+	}
+	// Deleting missing state is not a mutation
+	if err := store.DeleteWorkspace("ws1"); err != nil {
+		t.Fatalf("repeated DeleteWorkspace failed: %v", err)
+	}
+
+	if len(sink.records) != 3 {
+		t.Fatalf("expected 3 audit records, got %+v", sink.records)
+	}
+	want := []AuditRecord{
+		{Op: AuditOpSave, WorkspaceID: "ws1", Added: []string{"a.txt"}, ActiveStore: "s1"},
+		{Op: AuditOpSave, WorkspaceID: "ws1", Added: []string{"b.txt"}, Removed: []string{"a.txt"}, PreviousActiveStore: "s1", ActiveStore: "s2"},
+		{Op: AuditOpDelete, WorkspaceID: "ws1", Removed: []string{"b.txt"}, PreviousActiveStore: "s2"},
+	}
+	for i, got := range sink.records {
+		if !got.Time.Equal(now) || got.Time.Location() != time.UTC {
+			t.Errorf("record %d time = %v, want %v in UTC", i, got.Time, now)
+		}
+		got.Time = time.Time{}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("record %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+// failingAuditSink rejects every record.
+type failingAuditSink struct{}
+
+func (failingAuditSink) Record(AuditRecord) error { return errors.New("disk full") }
+
+func TestFileStateStore_AuditFailureDoesNotFailMutation(t *testing.T) {
+	var reported []error
+	store := NewFileStateStore(fsops.NewRealFS(), t.TempDir(),
+		WithAuditErrorHandler(func(err error) { reported = append(reported, err) }))
+	store.SetAuditSink(failingAuditSink{})
+
+	if err := store.SaveWorkspace("ws1", NewWorkspaceState("repo1", ".", "copy")); err != nil {
+		t.Fatalf("SaveWorkspace failed: %v", err)
+	}
+	if _, err := store.LoadWorkspace("ws1"); err != nil {
+		t.Fatalf("state should be saved despite the audit failure: %v", err)
+	}
+	if err := store.DeleteWorkspace("ws1"); err != nil {
+		t.Fatalf("DeleteWorkspace failed: %v", err)
+	}
+	if len(reported) != 2 || !strings.Contains(reported[0].Error(), "disk full") {
+		t.Errorf("expected both audit failures to be reported, got %v", reported)
+	}
+}
+
+func TestFileAuditSink_AppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), AuditLogFileName)
+	store := NewFileStateStore(fsops.NewRealFS(), t.TempDir())
+	store.SetAuditSink(NewFileAuditSink(path))
+
+	if err := store.SaveWorkspace("ws1", NewWorkspaceState("repo1", ".", "copy")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteWorkspace("ws1"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", data)
+	}
+	for i, op := range []string{AuditOpSave, AuditOpDelete} {
+		var rec AuditRecord
+		if err := json.Unmarshal([]byte(lines[i]), &rec); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+		if rec.Op != op || rec.WorkspaceID != "ws1" {
+			t.Errorf("line %d = %+v, want op %s for ws1", i, rec, op)
+		}
+	}
+}
+
+func TestFileStateStore_FileMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []FileStateStoreOption
+		wantFile os.FileMode
+		wantDir  os.FileMode
+	}{
+		{name: "default", wantFile: 0644, wantDir: 0755},
+		{name: "configured", opts: []FileStateStoreOption{WithFileMode(0600)}, wantFile: 0600, wantDir: 0700},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "workspaces")
+			store := NewFileStateStore(fsops.NewRealFS(), dir, tt.opts...)
+
+			if err := store.SaveWorkspace("ws1", NewWorkspaceState("repo1", ".", "copy")); err != nil {
+				t.Fatalf("SaveWorkspace failed: %v", err)
+			}
+
+			info, err := os.Stat(filepath.Join(dir, "ws1.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != tt.wantFile {
+				t.Errorf("file mode = %o, want %o", got, tt.wantFile)
+			}
+
+			// The process umask can only clear bits, so check none were added.
+			dirInfo, err := os.Stat(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dirInfo.Mode().Perm(); got&^tt.wantDir != 0 {
+				t.Errorf("directory mode = %o, want at most %o", got, tt.wantDir)
+			}
+		})
+	}
+}
+
+func TestFileStateStore_LoadWorkspaceSchemaVersion(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStateStore(fsops.NewRealFS(), dir)
+
+	write := func(id, data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("future", `{"schemaVersion": 99, "repo": "r1", "workspacePath": ".", "paths": {}}`)
+	write("legacy", `{"repo": "r1", "workspacePath": ".", "paths": {}}`)
+
+	_, err := store.LoadWorkspace("future")
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "99") || !strings.Contains(err.Error(), strconv.Itoa(WorkspaceSchemaVersion)) {
+		t.Errorf("error %q should name the file and supported versions", err)
+	}
+
+	ws, err := store.LoadWorkspace("legacy")
+	if err != nil {
+		t.Fatalf("LoadWorkspace(legacy) failed: %v", err)
+	}
+	if err := store.SaveWorkspace("legacy", ws); err != nil {
+		t.Fatalf("SaveWorkspace failed: %v", err)
+	}
+	ws, err = store.LoadWorkspace("legacy")
+	if err != nil {
+		t.Fatalf("LoadWorkspace after save failed: %v", err)
+	}
+	if ws.SchemaVersion != WorkspaceSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d after save", ws.SchemaVersion, WorkspaceSchemaVersion)
+	}
+}
+
+func TestFileStateStore_CheckWorkspace(t *testing.T) {
+	store := NewFileStateStore(fsops.NewRealFS(), t.TempDir())
+	if err := store.SaveWorkspace("ws1", NewWorkspaceState("repo1", ".", "copy")); err != nil {
+		t.Fatalf("SaveWorkspace failed: %v", err)
+	}
+	ours, err := store.LoadWorkspace("ws1")
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	if err := store.CheckWorkspace("ws1", ours); err != nil {
+		t.Errorf("unchanged state should pass the check, got %v", err)
+	}
+
+	theirs, err := store.LoadWorkspace("ws1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs.ActiveStore = "theirs"
+	if err := store.SaveWorkspace("ws1", theirs); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CheckWorkspace("ws1", ours); !errors.Is(err, ErrStateConflict) {
+		t.Errorf("expected ErrStateConflict, got %v", err)
+	}
+	if err := store.CheckWorkspace("ws2", ours); err != nil {
+		t.Errorf("state loaded from another ID is not a conflict, got %v", err)
+	}
+	got, err := store.LoadWorkspace("ws1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ActiveStore != "theirs" {
+		t.Errorf("CheckWorkspace must not write, ActiveStore = %q", got.ActiveStore)
+	}
+}
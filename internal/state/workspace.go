@@ -1,10 +1,17 @@
 package state
 
-import "time"
+import (
+	"crypto/sha256"
+	"time"
+)
 
 // WorkspaceState represents the state of overlays applied to a workspace.
 // This is the authoritative record of what monodev has modified in a workspace.
 type WorkspaceState struct {
+	// SchemaVersion is the version of the state file format. It is set to
+	// WorkspaceSchemaVersion on save; zero means the file predates versioning.
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+
 	// Repo is the fingerprint of the git repository
 	Repo string `json:"repo"`
 
@@ -17,7 +24,10 @@ type WorkspaceState struct {
 	// Applied indicates whether overlays are currently applied
 	Applied bool `json:"applied"`
 
-	// Mode is the overlay mode ("symlink" or "copy")
+	// Mode is the overlay mode of the most recent apply ("symlink" or "copy").
+	//
+	// Deprecated: a workspace may mix modes; use PathOwnership.Type for
+	// per-path decisions.
 	Mode string `json:"mode"`
 
 	// Stack is the ordered list of stores applied (excluding active store)
@@ -32,8 +42,25 @@ type WorkspaceState struct {
 	// ActiveStoreScope records which scope the active store belongs to
 	ActiveStoreScope string `json:"activeStoreScope,omitempty"`
 
+	// Tags are free-form labels for grouping workspaces, sorted and unique
+	Tags []string `json:"tags,omitempty"`
+
 	// Paths maps destination paths to their ownership information
 	Paths map[string]PathOwnership `json:"paths"`
+
+	// loaded identifies the stored state this value was read from or last
+	// written as, for conflict detection in FileStateStore
+	loaded stateVersion
+}
+
+// WorkspaceSchemaVersion is the newest WorkspaceState schema this build
+// reads and the one it writes.
+const WorkspaceSchemaVersion = 1
+
+// stateVersion identifies a stored workspace state file's content.
+type stateVersion struct {
+	id  string
+	sum [sha256.Size]byte
 }
 
 type AppliedStore struct {
@@ -49,7 +76,12 @@ type PathOwnership struct {
 	// Store is the ID of the store that contributed this path
 	Store string `json:"store"`
 
-	// Type is how the path was applied ("symlink" or "copy")
+	// StoreScope is the scope Store was resolved in (global or component),
+	// so stores sharing an ID across scopes keep distinct ownership
+	StoreScope string `json:"storeScope,omitempty"`
+
+	// Type is how the path was applied ("symlink" or "copy").
+	// This is the source of truth for per-path validation.
 	Type string `json:"type"`
 
 	// Timestamp is when the path was applied
@@ -57,6 +89,23 @@ type PathOwnership struct {
 
 	// Checksum is the hash of the file (only used in copy mode)
 	Checksum string `json:"checksum,omitempty"`
+
+	// SourceChecksum is the hash of the store overlay file at apply time
+	// (files only). Diff compares it with the current overlay content to
+	// detect store edits made after the path was applied.
+	SourceChecksum string `json:"sourceChecksum,omitempty"`
+
+	// AppliedVersion is the monodev version that last wrote the path
+	AppliedVersion string `json:"appliedVersion,omitempty"`
+
+	// Compacted marks an entry recorded by WorkspaceState.Compact rather
+	// than by an apply. A compacted directory stands in for all of its contents.
+	Compacted bool `json:"compacted,omitempty"`
+
+	// Contents, for a compacted directory, holds the ownership of each path
+	// it stands in for, keyed by path relative to the directory, so their
+	// checksums survive compaction
+	Contents map[string]PathOwnership `json:"contents,omitempty"`
 }
 
 // NewWorkspaceState creates a new empty WorkspaceState.
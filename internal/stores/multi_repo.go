@@ -1,9 +1,15 @@
 package stores
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // MultiStoreRepo wraps multiple StoreRepo instances and routes operations
 // by store ID. This is used when a stack contains stores from both scopes.
+// Mapped IDs may be scope-qualified (see ParseQualifiedID), so "global/foo"
+// and "component/foo" can be routed side by side; the wrapped repo is
+// always called with the bare ID.
 type MultiStoreRepo struct {
 	// mapping maps store IDs to their StoreRepo
 	mapping map[string]StoreRepo
@@ -20,11 +26,16 @@ func NewMultiStoreRepo(mapping map[string]StoreRepo, fallback StoreRepo) *MultiS
 	}
 }
 
-func (m *MultiStoreRepo) repoFor(id string) StoreRepo {
-	if repo, ok := m.mapping[id]; ok {
-		return repo
+// route returns the repo serving id and the ID to call it with.
+func (m *MultiStoreRepo) route(id string) (StoreRepo, string) {
+	repo, ok := m.mapping[id]
+	if !ok {
+		return m.fallback, id
 	}
-	return m.fallback
+	if _, bare, err := ParseQualifiedID(id); err == nil {
+		return repo, bare
+	}
+	return repo, id
 }
 
 func (m *MultiStoreRepo) List() ([]string, error) {
@@ -58,35 +69,51 @@ func (m *MultiStoreRepo) List() ([]string, error) {
 }
 
 func (m *MultiStoreRepo) Exists(id string) (bool, error) {
-	return m.repoFor(id).Exists(id)
+	repo, id := m.route(id)
+	return repo.Exists(id)
 }
 
 func (m *MultiStoreRepo) Create(id string, meta *StoreMeta) error {
-	return m.repoFor(id).Create(id, meta)
+	repo, id := m.route(id)
+	return repo.Create(id, meta)
 }
 
 func (m *MultiStoreRepo) LoadMeta(id string) (*StoreMeta, error) {
-	return m.repoFor(id).LoadMeta(id)
+	repo, id := m.route(id)
+	return repo.LoadMeta(id)
+}
+
+func (m *MultiStoreRepo) LoadMetas(ids []string) (map[string]*StoreMeta, error) {
+	return LoadMetasSequential(m, ids)
 }
 
 func (m *MultiStoreRepo) SaveMeta(id string, meta *StoreMeta) error {
-	return m.repoFor(id).SaveMeta(id, meta)
+	repo, id := m.route(id)
+	return repo.SaveMeta(id, meta)
+}
+
+func (m *MultiStoreRepo) MarkUsed(id string, at time.Time) error {
+	repo, id := m.route(id)
+	return repo.MarkUsed(id, at)
 }
 
 func (m *MultiStoreRepo) LoadTrack(id string) (*TrackFile, error) {
-	return m.repoFor(id).LoadTrack(id)
+	repo, id := m.route(id)
+	return repo.LoadTrack(id)
 }
 
 func (m *MultiStoreRepo) SaveTrack(id string, track *TrackFile) error {
-	return m.repoFor(id).SaveTrack(id, track)
+	repo, id := m.route(id)
+	return repo.SaveTrack(id, track)
 }
 
 func (m *MultiStoreRepo) OverlayRoot(id string) string {
-	return m.repoFor(id).OverlayRoot(id)
+	repo, id := m.route(id)
+	return repo.OverlayRoot(id)
 }
 
 func (m *MultiStoreRepo) Delete(id string) error {
-	repo := m.repoFor(id)
+	repo, id := m.route(id)
 	if repo == nil {
 		return fmt.Errorf("no repo found for store %s", id)
 	}
@@ -0,0 +1,54 @@
+package stores
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// Line-ending policies for NormalizeRule.EOL.
+const (
+	EOLLF   = "lf"
+	EOLCRLF = "crlf"
+	EOLNone = "none"
+)
+
+// EOLFor returns the line-ending policy for a store-relative path, or ""
+// when no rule matches. Later rules take precedence over earlier ones.
+func (tf *TrackFile) EOLFor(relPath string) string {
+	relPath = filepath.ToSlash(relPath)
+	base := relPath[strings.LastIndex(relPath, "/")+1:]
+
+	eol := ""
+	for _, rule := range tf.Normalize {
+		pattern, target := rule.Pattern, base
+		if strings.Contains(pattern, "/") {
+			pattern, target = strings.TrimPrefix(pattern, "/"), relPath
+		}
+		if ok, _ := filepath.Match(pattern, target); ok {
+			eol = rule.EOL
+		}
+	}
+	if eol == EOLNone {
+		return ""
+	}
+	return eol
+}
+
+// NormalizeEOL rewrites the line endings of text data to eol ("lf" or
+// "crlf"). Binary data (containing a NUL byte) and unknown policies are
+// returned unchanged.
+func NormalizeEOL(data []byte, eol string) []byte {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return data
+	}
+	switch eol {
+	case EOLLF:
+		return bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	case EOLCRLF:
+		lf := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+		return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+	default:
+		return data
+	}
+}
@@ -0,0 +1,50 @@
+package stores
+
+import "testing"
+
+func TestTrackFile_EOLFor(t *testing.T) {
+	tf := NewTrackFile()
+	tf.Normalize = []NormalizeRule{
+		{Pattern: "*.sh", EOL: EOLLF},
+		{Pattern: "scripts/*.bat", EOL: EOLCRLF},
+		{Pattern: "vendor.sh", EOL: EOLNone},
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"build.sh", EOLLF},
+		{"tools/build.sh", EOLLF},
+		{"scripts/run.bat", EOLCRLF},
+		{"other/run.bat", ""},
+		{"vendor.sh", ""},
+		{"README.md", ""},
+	}
+	for _, tt := range tests {
+		if got := tf.EOLFor(tt.path); got != tt.want {
+			t.Errorf("EOLFor(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeEOL(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		eol  string
+		want string
+	}{
+		{"crlf to lf", "a\r\nb\r\n", EOLLF, "a\nb\n"},
+		{"lf to crlf", "a\nb\r\n", EOLCRLF, "a\r\nb\r\n"},
+		{"binary unchanged", "a\r\n\x00", EOLLF, "a\r\n\x00"},
+		{"unknown policy unchanged", "a\r\n", "auto", "a\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(NormalizeEOL([]byte(tt.data), tt.eol)); got != tt.want {
+				t.Errorf("NormalizeEOL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package stores
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/danieljhkim/monodev/internal/fsops"
+)
+
+const (
+	// PointerVersion identifies an overlay file that stands in for content
+	// kept in the blob cache
+	PointerVersion = "monodev-pointer/v1"
+
+	// PointerAlgoSHA256 is the only supported pointer hash algorithm
+	PointerAlgoSHA256 = "sha256"
+
+	// MaxPointerSize bounds the size of a pointer file; larger files are
+	// never treated as pointers
+	MaxPointerSize = 512
+)
+
+// ErrBlobMissing is returned when a pointer's content is not in the blob cache.
+var ErrBlobMissing = errors.New("blob missing from cache")
+
+// Pointer is the small placeholder stored in the overlay for a large file.
+type Pointer struct {
+	// Version is always PointerVersion
+	Version string `json:"version"`
+
+	// Algo is the hash algorithm used to address the content
+	Algo string `json:"algo"`
+
+	// Hash is the hex-encoded content hash
+	Hash string `json:"hash"`
+
+	// Size is the content size in bytes
+	Size int64 `json:"size"`
+}
+
+// Encode returns the pointer file contents.
+func (p *Pointer) Encode() []byte {
+	data, _ := json.Marshal(p)
+	return append(data, '\n')
+}
+
+// ParsePointer decodes pointer file contents. It reports false for anything
+// that is not a well-formed pointer, so ordinary files pass through.
+func ParsePointer(data []byte) (*Pointer, bool) {
+	if len(data) > MaxPointerSize || !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil, false
+	}
+	var p Pointer
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, false
+	}
+	if p.Version != PointerVersion || p.Algo != PointerAlgoSHA256 || len(p.Hash) != sha256.Size*2 || p.Size < 0 {
+		return nil, false
+	}
+	if _, err := hex.DecodeString(p.Hash); err != nil {
+		return nil, false
+	}
+	return &p, true
+}
+
+// BlobCache is a local content-addressed store for large-file contents,
+// laid out as <root>/<algo>/<hash[:2]>/<hash>.
+type BlobCache struct {
+	fs   fsops.FS
+	root string
+}
+
+// NewBlobCache creates a blob cache rooted at root.
+func NewBlobCache(fs fsops.FS, root string) *BlobCache {
+	return &BlobCache{fs: fs, root: root}
+}
+
+// Path returns where the pointer's content is kept.
+func (c *BlobCache) Path(p *Pointer) string {
+	return filepath.Join(c.root, p.Algo, p.Hash[:2], p.Hash)
+}
+
+// Put stores data in the cache and returns the pointer addressing it.
+// Content already present is not rewritten.
+func (c *BlobCache) Put(data []byte) (*Pointer, error) {
+	sum := sha256.Sum256(data)
+	p := &Pointer{
+		Version: PointerVersion,
+		Algo:    PointerAlgoSHA256,
+		Hash:    hex.EncodeToString(sum[:]),
+		Size:    int64(len(data)),
+	}
+	path := c.Path(p)
+	exists, err := c.fs.Exists(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check blob %s: %w", p.Hash, err)
+	}
+	if exists {
+		return p, nil
+	}
+	if err := c.fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	if err := c.fs.AtomicWrite(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write blob %s: %w", p.Hash, err)
+	}
+	return p, nil
+}
+
+// Check returns ErrBlobMissing when the content addressed by p is not
+// cached, without reading it.
+func (c *BlobCache) Check(p *Pointer) error {
+	exists, err := c.fs.Exists(c.Path(p))
+	if err != nil {
+		return fmt.Errorf("failed to check blob %s: %w", p.Hash, err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s:%s", ErrBlobMissing, p.Algo, p.Hash)
+	}
+	return nil
+}
+
+// Get returns the content addressed by p, verifying its hash and size.
+// It returns ErrBlobMissing when the content is not cached.
+func (c *BlobCache) Get(p *Pointer) ([]byte, error) {
+	path := c.Path(p)
+	exists, err := c.fs.Exists(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check blob %s: %w", p.Hash, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s:%s", ErrBlobMissing, p.Algo, p.Hash)
+	}
+	data, err := c.fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", p.Hash, err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != p.Hash || int64(len(data)) != p.Size {
+		return nil, fmt.Errorf("blob %s is corrupt: content does not match its hash", p.Hash)
+	}
+	return data, nil
+}
@@ -0,0 +1,48 @@
+package stores
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/danieljhkim/monodev/internal/fsops"
+)
+
+func TestBlobCache_PutGetRoundTrip(t *testing.T) {
+	cache := NewBlobCache(fsops.NewRealFS(), filepath.Join(t.TempDir(), "blobs"))
+
+	pointer, err := cache.Put([]byte("large content"))
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	parsed, ok := ParsePointer(pointer.Encode())
+	if !ok || *parsed != *pointer {
+		t.Fatalf("ParsePointer(Encode()) = %+v, %v; want %+v", parsed, ok, pointer)
+	}
+
+	data, err := cache.Get(parsed)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if string(data) != "large content" {
+		t.Errorf("Get = %q, want original content", data)
+	}
+
+	parsed.Hash = "00" + parsed.Hash[2:]
+	if _, err := cache.Get(parsed); !errors.Is(err, ErrBlobMissing) {
+		t.Errorf("Get of unknown hash error = %v, want ErrBlobMissing", err)
+	}
+}
+
+func TestParsePointer_RejectsOrdinaryFiles(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"plain text\n",
+		`{"version":"other","algo":"sha256","hash":"00","size":1}`,
+		`{"name":"package.json"}`,
+	} {
+		if _, ok := ParsePointer([]byte(data)); ok {
+			t.Errorf("ParsePointer(%q) reported a pointer", data)
+		}
+	}
+}
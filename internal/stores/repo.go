@@ -16,6 +16,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/danieljhkim/monodev/internal/fsops"
 )
@@ -34,9 +38,17 @@ type StoreRepo interface {
 	// LoadMeta loads the metadata for a store.
 	LoadMeta(id string) (*StoreMeta, error)
 
+	// LoadMetas loads the metadata for several stores. Metas that load are
+	// returned even when others fail; the failures are reported together
+	// as a *MetaLoadError.
+	LoadMetas(ids []string) (map[string]*StoreMeta, error)
+
 	// SaveMeta saves the metadata for a store.
 	SaveMeta(id string, meta *StoreMeta) error
 
+	// MarkUsed sets only the LastUsedAt field of a store's metadata to at.
+	MarkUsed(id string, at time.Time) error
+
 	// LoadTrack loads the track file for a store.
 	LoadTrack(id string) (*TrackFile, error)
 
@@ -50,6 +62,54 @@ type StoreRepo interface {
 	Delete(id string) error
 }
 
+// MetaLoadError reports the stores whose metadata failed to load in a batch.
+type MetaLoadError struct {
+	// Errors maps each failed store ID to its load error
+	Errors map[string]error
+}
+
+func (e *MetaLoadError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("%s: %v", id, e.Errors[id])
+	}
+	return fmt.Sprintf("failed to load metadata for %d stores: %s", len(ids), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual load errors.
+func (e *MetaLoadError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// LoadMetasSequential implements StoreRepo.LoadMetas by calling
+// repo.LoadMeta for each ID in turn. Repos without a faster batch
+// load can delegate to it.
+func LoadMetasSequential(repo StoreRepo, ids []string) (map[string]*StoreMeta, error) {
+	metas := make(map[string]*StoreMeta, len(ids))
+	failed := make(map[string]error)
+	for _, id := range ids {
+		meta, err := repo.LoadMeta(id)
+		if err != nil {
+			failed[id] = err
+			continue
+		}
+		metas[id] = meta
+	}
+	if len(failed) > 0 {
+		return metas, &MetaLoadError{Errors: failed}
+	}
+	return metas, nil
+}
+
 // FileStoreRepo implements StoreRepo using files on disk.
 type FileStoreRepo struct {
 	fs        fsops.FS
@@ -66,7 +126,7 @@ func NewFileStoreRepo(fs fsops.FS, storesDir string) *FileStoreRepo {
 
 // List returns all store IDs.
 func (r *FileStoreRepo) List() ([]string, error) {
-	entries, err := os.ReadDir(r.storesDir)
+	entries, err := r.fs.ReadDir(r.storesDir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []string{}, nil
@@ -104,6 +164,11 @@ func (r *FileStoreRepo) Create(id string, meta *StoreMeta) error {
 
 	storePath := filepath.Join(r.storesDir, id)
 
+	// Reject invalid metadata before touching the filesystem
+	if err := meta.Validate(); err != nil {
+		return fmt.Errorf("invalid store metadata: %w", err)
+	}
+
 	// Check if store already exists
 	exists, err := r.Exists(id)
 	if err != nil {
@@ -163,6 +228,46 @@ func (r *FileStoreRepo) LoadMeta(id string) (*StoreMeta, error) {
 	return &meta, nil
 }
 
+// maxMetaLoaders bounds the meta files FileStoreRepo.LoadMetas reads at once.
+const maxMetaLoaders = 8
+
+// LoadMetas loads the metadata for several stores, reading their meta
+// files in parallel.
+func (r *FileStoreRepo) LoadMetas(ids []string) (map[string]*StoreMeta, error) {
+	metas := make(map[string]*StoreMeta, len(ids))
+	failed := make(map[string]error)
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, maxMetaLoaders)
+	)
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			meta, err := r.LoadMeta(id)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[id] = err
+				return
+			}
+			metas[id] = meta
+		}(id)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return metas, &MetaLoadError{Errors: failed}
+	}
+	return metas, nil
+}
+
 // SaveMeta saves the metadata for a store.
 func (r *FileStoreRepo) SaveMeta(id string, meta *StoreMeta) error {
 	// Validate store ID for safety
@@ -170,6 +275,10 @@ func (r *FileStoreRepo) SaveMeta(id string, meta *StoreMeta) error {
 		return fmt.Errorf("invalid store ID: %w", err)
 	}
 
+	if err := meta.Validate(); err != nil {
+		return fmt.Errorf("invalid store metadata: %w", err)
+	}
+
 	metaPath := filepath.Join(r.storesDir, id, "meta.json")
 
 	data, err := json.MarshalIndent(meta, "", "  ")
@@ -184,6 +293,29 @@ func (r *FileStoreRepo) SaveMeta(id string, meta *StoreMeta) error {
 	return nil
 }
 
+// MarkUsed sets the LastUsedAt field of a store's meta.json to at. Unlike
+// SaveMeta it does not validate the rest of the metadata, so stores written
+// under older rules can still record use.
+func (r *FileStoreRepo) MarkUsed(id string, at time.Time) error {
+	meta, err := r.LoadMeta(id)
+	if err != nil {
+		return err
+	}
+	meta.LastUsedAt = &at
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	metaPath := filepath.Join(r.storesDir, id, "meta.json")
+	if err := r.fs.AtomicWrite(metaPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write meta file: %w", err)
+	}
+
+	return nil
+}
+
 // LoadTrack loads the track file for a store.
 func (r *FileStoreRepo) LoadTrack(id string) (*TrackFile, error) {
 	// Validate store ID for safety
@@ -1,8 +1,11 @@
 package stores
 
 import (
+	"errors"
+	iofs "io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -24,6 +27,62 @@ func setupStoresDir(t *testing.T) (string, *FileStoreRepo) {
 	return tmpDir, repo
 }
 
+// memDirFS serves ReadDir from an in-memory listing; other methods are unused.
+type memDirFS struct {
+	fsops.FS
+	dirs map[string][]os.DirEntry
+}
+
+func (m *memDirFS) ReadDir(path string) ([]os.DirEntry, error) {
+	entries, ok := m.dirs[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return entries, nil
+}
+
+// memDirEntry is a directory entry without backing file info.
+type memDirEntry struct {
+	name  string
+	isDir bool
+}
+
+func (e memDirEntry) Name() string { return e.name }
+func (e memDirEntry) IsDir() bool  { return e.isDir }
+func (e memDirEntry) Type() iofs.FileMode {
+	if e.isDir {
+		return iofs.ModeDir
+	}
+	return 0
+}
+func (e memDirEntry) Info() (iofs.FileInfo, error) { return nil, os.ErrInvalid }
+
+func TestFileStoreRepo_List_MockFS(t *testing.T) {
+	fs := &memDirFS{dirs: map[string][]os.DirEntry{
+		"/stores": {
+			memDirEntry{name: "alpha", isDir: true},
+			memDirEntry{name: "notes.txt"},
+			memDirEntry{name: "beta", isDir: true},
+		},
+	}}
+
+	stores, err := NewFileStoreRepo(fs, "/stores").List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if strings.Join(stores, ",") != "alpha,beta" {
+		t.Errorf("List = %v, want [alpha beta]", stores)
+	}
+
+	stores, err = NewFileStoreRepo(fs, "/missing").List()
+	if err != nil {
+		t.Fatalf("List of missing directory failed: %v", err)
+	}
+	if len(stores) != 0 {
+		t.Errorf("List of missing directory = %v, want empty", stores)
+	}
+}
+
 func TestFileStoreRepo_List(t *testing.T) {
 	t.Run("returns empty list when directory does not exist", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "stores-test-*")
@@ -231,6 +290,24 @@ func TestFileStoreRepo_Create(t *testing.T) {
 			t.Error("Expected error for invalid store ID, got nil")
 		}
 	})
+
+	t.Run("rejects invalid metadata", func(t *testing.T) {
+		tmpDir, repo := setupStoresDir(t)
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		meta := NewStoreMeta("", "global", time.Now())
+		if err := repo.Create("bad-store", meta); err == nil {
+			t.Error("Expected error for invalid metadata, got nil")
+		}
+
+		exists, err := repo.Exists("bad-store")
+		if err != nil {
+			t.Fatalf("Exists failed: %v", err)
+		}
+		if exists {
+			t.Error("Store directory should not be created for invalid metadata")
+		}
+	})
 }
 
 func TestFileStoreRepo_LoadMeta(t *testing.T) {
@@ -288,6 +365,60 @@ func TestFileStoreRepo_LoadMeta(t *testing.T) {
 	})
 }
 
+func TestFileStoreRepo_LoadMetas(t *testing.T) {
+	t.Run("loads every store", func(t *testing.T) {
+		tmpDir, repo := setupStoresDir(t)
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		ids := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+		for _, id := range ids {
+			if err := repo.Create(id, NewStoreMeta("Store "+id, "global", time.Now())); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+		}
+
+		metas, err := repo.LoadMetas(ids)
+		if err != nil {
+			t.Fatalf("LoadMetas failed: %v", err)
+		}
+		if len(metas) != len(ids) {
+			t.Fatalf("LoadMetas returned %d metas, want %d", len(metas), len(ids))
+		}
+		for _, id := range ids {
+			if metas[id] == nil || metas[id].Name != "Store "+id {
+				t.Errorf("meta for %s = %+v, want name %q", id, metas[id], "Store "+id)
+			}
+		}
+	})
+
+	t.Run("reports per-store errors without failing others", func(t *testing.T) {
+		tmpDir, repo := setupStoresDir(t)
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		for _, id := range []string{"good", "corrupt"} {
+			if err := repo.Create(id, NewStoreMeta(id, "global", time.Now())); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, "corrupt", "meta.json"), []byte("{"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		metas, err := repo.LoadMetas([]string{"good", "corrupt", "missing"})
+
+		var loadErr *MetaLoadError
+		if !errors.As(err, &loadErr) {
+			t.Fatalf("LoadMetas error = %v, want *MetaLoadError", err)
+		}
+		if len(loadErr.Errors) != 2 || loadErr.Errors["corrupt"] == nil || loadErr.Errors["missing"] == nil {
+			t.Errorf("failed stores = %v, want corrupt and missing", loadErr.Errors)
+		}
+		if len(metas) != 1 || metas["good"] == nil {
+			t.Errorf("metas = %v, want only good", metas)
+		}
+	})
+}
+
 func TestFileStoreRepo_SaveMeta(t *testing.T) {
 	t.Run("saves metadata correctly", func(t *testing.T) {
 		tmpDir, repo := setupStoresDir(t)
@@ -338,6 +469,117 @@ func TestFileStoreRepo_SaveMeta(t *testing.T) {
 			t.Error("Expected error for invalid store ID, got nil")
 		}
 	})
+
+	t.Run("rejects invalid metadata", func(t *testing.T) {
+		tmpDir, repo := setupStoresDir(t)
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		storeID := "test-store"
+		if err := repo.Create(storeID, NewStoreMeta("Test", "global", time.Now())); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		meta := NewStoreMeta("Test", "unknown-scope", time.Now())
+		if err := repo.SaveMeta(storeID, meta); err == nil {
+			t.Error("Expected error for invalid metadata, got nil")
+		}
+	})
+
+	t.Run("loading legacy metadata skips validation", func(t *testing.T) {
+		tmpDir, repo := setupStoresDir(t)
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		storeID := "legacy-store"
+		legacyJSON := `{"name":"","scope":"","createdAt":"2024-02-01T00:00:00Z","updatedAt":"2024-01-01T00:00:00Z"}`
+		if err := os.MkdirAll(filepath.Join(tmpDir, storeID), 0755); err != nil {
+			t.Fatalf("failed to create store dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, storeID, "meta.json"), []byte(legacyJSON), 0644); err != nil {
+			t.Fatalf("failed to write legacy meta: %v", err)
+		}
+
+		meta, err := repo.LoadMeta(storeID)
+		if err != nil {
+			t.Fatalf("LoadMeta failed for legacy metadata: %v", err)
+		}
+		if meta.Validate() == nil {
+			t.Error("Expected legacy metadata to be invalid under current rules")
+		}
+	})
+}
+
+func TestFileStoreRepo_MarkUsed(t *testing.T) {
+	t.Run("sets LastUsedAt and leaves other fields intact", func(t *testing.T) {
+		tmpDir, repo := setupStoresDir(t)
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		storeID := "test-store"
+		created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		meta := NewStoreMeta("Test", "global", created)
+		meta.Description = "keep me"
+		meta.Owner = "alice"
+		meta.Tags = []string{"a", "b"}
+		if err := repo.Create(storeID, meta); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		used := created.Add(48 * time.Hour)
+		if err := repo.MarkUsed(storeID, used); err != nil {
+			t.Fatalf("MarkUsed failed: %v", err)
+		}
+
+		loaded, err := repo.LoadMeta(storeID)
+		if err != nil {
+			t.Fatalf("LoadMeta failed: %v", err)
+		}
+		if loaded.LastUsedAt == nil || !loaded.LastUsedAt.Equal(used) {
+			t.Errorf("LastUsedAt = %v, want %v", loaded.LastUsedAt, used)
+		}
+		if !loaded.CreatedAt.Equal(created) || !loaded.UpdatedAt.Equal(created) {
+			t.Errorf("timestamps changed by MarkUsed: %+v", loaded)
+		}
+		if loaded.Name != "Test" || loaded.Scope != "global" || loaded.Description != "keep me" || loaded.Owner != "alice" {
+			t.Errorf("unexpected metadata after MarkUsed: %+v", loaded)
+		}
+		if len(loaded.Tags) != 2 {
+			t.Errorf("Tags = %v, want [a b]", loaded.Tags)
+		}
+	})
+
+	t.Run("keeps the field order of SaveMeta", func(t *testing.T) {
+		tmpDir, repo := setupStoresDir(t)
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		storeID := "ordered-store"
+		meta := NewStoreMeta("Test", "global", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		meta.Description = "keep me"
+		if err := repo.Create(storeID, meta); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := repo.MarkUsed(storeID, time.Now()); err != nil {
+			t.Fatalf("MarkUsed failed: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, storeID, "meta.json"))
+		if err != nil {
+			t.Fatalf("failed to read meta: %v", err)
+		}
+		name := strings.Index(string(data), `"name"`)
+		scope := strings.Index(string(data), `"scope"`)
+		description := strings.Index(string(data), `"description"`)
+		if name < 0 || !(name < scope && scope < description) {
+			t.Errorf("meta.json fields reordered by MarkUsed:\n%s", data)
+		}
+	})
+
+	t.Run("returns error for missing store", func(t *testing.T) {
+		tmpDir, repo := setupStoresDir(t)
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		if err := repo.MarkUsed("missing", time.Now()); err == nil {
+			t.Error("Expected error for missing store, got nil")
+		}
+	})
 }
 
 func TestFileStoreRepo_LoadTrack(t *testing.T) {
@@ -465,6 +707,50 @@ func TestFileStoreRepo_SaveTrack(t *testing.T) {
 		}
 	})
 
+	t.Run("round-trips notes and changelog", func(t *testing.T) {
+		tmpDir, repo := setupStoresDir(t)
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		storeID := "test-store"
+		if err := repo.Create(storeID, NewStoreMeta("Test", "global", time.Now())); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		track := NewTrackFile()
+		track.Tracked = []TrackedPath{
+			{Path: "noted.txt", Kind: "file", Note: "needed for local TLS"},
+			{Path: "plain.txt", Kind: "file"},
+		}
+		track.AppendChangelog(at, "track noted.txt")
+
+		if err := repo.SaveTrack(storeID, track); err != nil {
+			t.Fatalf("SaveTrack failed: %v", err)
+		}
+		loaded, err := repo.LoadTrack(storeID)
+		if err != nil {
+			t.Fatalf("LoadTrack failed: %v", err)
+		}
+
+		if loaded.Tracked[0].Note != "needed for local TLS" {
+			t.Errorf("Note = %q, want %q", loaded.Tracked[0].Note, "needed for local TLS")
+		}
+		if loaded.Tracked[1].Note != "" {
+			t.Errorf("Note = %q, want empty", loaded.Tracked[1].Note)
+		}
+		if len(loaded.Changelog) != 1 || !loaded.Changelog[0].At.Equal(at) || loaded.Changelog[0].Message != "track noted.txt" {
+			t.Errorf("Changelog = %+v", loaded.Changelog)
+		}
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, storeID, "track.json"))
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if strings.Count(string(data), `"note"`) != 1 {
+			t.Errorf("empty notes should be omitted:\n%s", data)
+		}
+	})
+
 	t.Run("returns error for invalid store ID", func(t *testing.T) {
 		tmpDir, repo := setupStoresDir(t)
 		defer func() { _ = os.RemoveAll(tmpDir) }()
@@ -2,7 +2,10 @@ package stores
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
+	"unicode"
 )
 
 const (
@@ -12,6 +15,9 @@ const (
 	// ScopeComponent indicates a store stored at repo_root/.monodev/stores/
 	ScopeComponent = "component"
 
+	// ScopeProfile is a legacy scope value still accepted in store metadata
+	ScopeProfile = "profile"
+
 	// TrackedPath Role values
 	RoleScript = "script"
 	RoleDocs   = "docs"
@@ -25,6 +31,23 @@ const (
 	OriginOther = "other"
 )
 
+// ParseQualifiedID splits a scope-qualified store ID such as "global/foo" or
+// "component/foo" into its scope and bare ID. An unqualified ID is returned
+// unchanged with an empty scope.
+func ParseQualifiedID(id string) (scope, bare string, err error) {
+	prefix, rest, ok := strings.Cut(id, "/")
+	if !ok {
+		return "", id, nil
+	}
+	if prefix != ScopeGlobal && prefix != ScopeComponent {
+		return "", "", fmt.Errorf("unknown scope %q in store ID %q: must be global or component", prefix, id)
+	}
+	if rest == "" {
+		return "", "", fmt.Errorf("missing store ID after scope in %q", id)
+	}
+	return prefix, rest, nil
+}
+
 // ScopedStore wraps a store with its scope location.
 type ScopedStore struct {
 	// ID is the store identifier
@@ -35,6 +58,22 @@ type ScopedStore struct {
 
 	// Scope indicates where the store is located (ScopeGlobal or ScopeComponent)
 	Scope string
+
+	// Health is set only when the listing was asked to include it
+	Health *StoreHealth `json:",omitempty"`
+}
+
+// StoreHealth summarizes problems found while checking a store.
+type StoreHealth struct {
+	// AuditFindings is the number of audit findings in the store's overlay
+	AuditFindings int
+
+	// Orphaned is true when no workspace references the store
+	Orphaned bool
+
+	// TrackMismatch is true when the overlay holds untracked content or
+	// lacks a tracked path
+	TrackMismatch bool
 }
 
 // StoreLocation records where a store was found during scope search.
@@ -64,6 +103,10 @@ type StoreMeta struct {
 	// UpdatedAt is when the store was last modified
 	UpdatedAt time.Time `json:"updatedAt"`
 
+	// LastUsedAt is when the store was last selected or applied
+	// (pointer for proper omitempty)
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+
 	// SchemaVersion is the version of the store metadata schema
 	SchemaVersion int `json:"schemaVersion,omitempty"`
 
@@ -72,6 +115,24 @@ type StoreMeta struct {
 
 	// TaskID links the store to an external task
 	TaskID string `json:"taskId,omitempty"`
+
+	// Anchor is the repo-relative directory the store was created in.
+	// When set, relative paths passed to track are resolved against it
+	// instead of the current working directory.
+	Anchor string `json:"anchor,omitempty"`
+
+	// Requires lists the IDs of stores this store depends on
+	Requires []string `json:"requires,omitempty"`
+
+	// Tags are free-form labels used to select stores
+	Tags []string `json:"tags,omitempty"`
+
+	// Type classifies the store, such as "config" or "toolchain", so stores
+	// of one kind can be selected together
+	Type string `json:"type,omitempty"`
+
+	// Frozen marks a store whose metadata is excluded from bulk updates
+	Frozen bool `json:"frozen,omitempty"`
 }
 
 // TrackFile represents the track.json file in a store.
@@ -87,6 +148,38 @@ type TrackFile struct {
 
 	// Notes is an optional description of this store's purpose
 	Notes string `json:"notes,omitempty"`
+
+	// Normalize lists line-ending rules for text files, applied in order
+	// with later matches winning (like .gitattributes)
+	Normalize []NormalizeRule `json:"normalize,omitempty"`
+
+	// LargeFileThreshold is the size in bytes above which committed files
+	// are kept in the blob cache and stored in the overlay as pointers.
+	// Zero disables pointers.
+	LargeFileThreshold int64 `json:"largeFileThreshold,omitempty"`
+
+	// Changelog records changes to the tracked paths, oldest first
+	Changelog []ChangelogEntry `json:"changelog,omitempty"`
+}
+
+// ChangelogEntry is one recorded change to a store's tracked paths.
+type ChangelogEntry struct {
+	// At is when the change was made
+	At time.Time `json:"at"`
+
+	// Message describes the change
+	Message string `json:"message"`
+}
+
+// NormalizeRule sets the line-ending policy for overlay files matching a glob.
+type NormalizeRule struct {
+	// Pattern is a glob matched against the store-relative path, or against
+	// the base name when it contains no "/"
+	Pattern string `json:"pattern"`
+
+	// EOL is the line ending to write: "lf", "crlf", or "none" to keep
+	// content unchanged
+	EOL string `json:"eol"`
 }
 
 // TrackedPath represents a tracked file or directory.
@@ -97,9 +190,44 @@ type TrackedPath struct {
 	// Kind is the type of path ("file" or "dir")
 	Kind string `json:"kind"`
 
+	// Dest optionally installs the path at a different workspace-relative
+	// location. The overlay source stays at Path. Dest may reference the
+	// store's metadata as ${taskID} or ${owner}; see ExpandDest.
+	Dest string `json:"dest,omitempty"`
+
+	// LinkContents, for dir kind, keeps the workspace directory real and
+	// installs each top-level entry of the overlay directory individually
+	// instead of the directory as a whole, so users can add sibling files.
+	LinkContents bool `json:"linkContents,omitempty"`
+
+	// Mirror, for dir kind, makes copy-mode apply remove files in the
+	// workspace directory that are not in the overlay, so the directory
+	// matches the store exactly. It only takes effect on a directory the
+	// store already owns as a whole, and is ignored with LinkContents.
+	Mirror bool `json:"mirror,omitempty"`
+
 	// Required indicates if this path must exist when applying (default: true)
 	Required *bool `json:"required,omitempty"`
 
+	// RequiredFiles, for dir kind, lists paths relative to the directory
+	// that must exist whenever the directory is present in the overlay
+	RequiredFiles []string `json:"requiredFiles,omitempty"`
+
+	// Checksum, for file kind, pins the SHA-256 of the overlay source.
+	// Apply raises a conflict instead of installing a source that no longer
+	// matches, in symlink as well as copy mode.
+	Checksum string `json:"checksum,omitempty"`
+
+	// PreserveMtime makes copy-mode apply give placed files the overlay
+	// source's modification time instead of the current time, so incremental
+	// build tools don't rebuild them
+	PreserveMtime bool `json:"preserveMtime,omitempty"`
+
+	// When optionally limits applying the path to when a condition on the
+	// apply variables holds: "DEBUG" (set), "!CI" (not set) or "DEBUG==1"
+	// (set to a value). See Applies.
+	When string `json:"when,omitempty"`
+
 	// Deprecated: Location was the absolute path where tracking occurred.
 	// As of schema version 2, paths are repo-root-relative and Location is unused.
 	Location string `json:"location,omitempty"`
@@ -110,6 +238,9 @@ type TrackedPath struct {
 	// Description provides additional context about the tracked path
 	Description string `json:"description,omitempty"`
 
+	// Note explains why the path is in the store, for teammates
+	Note string `json:"note,omitempty"`
+
 	// CreatedAt is when the path was first tracked (pointer for proper omitempty)
 	CreatedAt *time.Time `json:"createdAt,omitempty"`
 
@@ -118,6 +249,10 @@ type TrackedPath struct {
 
 	// Origin indicates how the path was tracked (user, agent, other)
 	Origin string `json:"origin,omitempty"`
+
+	// ExcludeFromDiff hides this path from diff output unless explicitly
+	// requested (e.g. for generated lockfiles). Apply is unaffected.
+	ExcludeFromDiff bool `json:"excludeFromDiff,omitempty"`
 }
 
 // IsRequired returns whether this path is required.
@@ -128,6 +263,87 @@ func (t TrackedPath) IsRequired() bool {
 	return *t.Required
 }
 
+// Destination returns the workspace-relative path the tracked path is
+// installed at: Dest if set, otherwise Path.
+func (t TrackedPath) Destination() string {
+	if t.Dest != "" {
+		return t.Dest
+	}
+	return t.Path
+}
+
+// destVariable matches a ${name} reference in TrackedPath.Dest.
+var destVariable = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// HasDestVariables reports whether Dest references store metadata.
+func (t TrackedPath) HasDestVariables() bool {
+	return destVariable.MatchString(t.Dest)
+}
+
+// ExpandDest returns Dest with ${taskID} and ${owner} replaced by the
+// store's TaskID and Owner. Referencing an unknown variable, or one the
+// store's metadata leaves empty, is an error. The result is not validated
+// as a path.
+func (t TrackedPath) ExpandDest(meta *StoreMeta) (string, error) {
+	var expandErr error
+	expanded := destVariable.ReplaceAllStringFunc(t.Dest, func(ref string) string {
+		name := destVariable.FindStringSubmatch(ref)[1]
+		var value string
+		switch name {
+		case "taskID":
+			if meta != nil {
+				value = meta.TaskID
+			}
+		case "owner":
+			if meta != nil {
+				value = meta.Owner
+			}
+		default:
+			if expandErr == nil {
+				expandErr = fmt.Errorf("unknown variable %s in destination %q: must be ${taskID} or ${owner}", ref, t.Dest)
+			}
+			return ref
+		}
+		if value == "" && expandErr == nil {
+			expandErr = fmt.Errorf("destination %q uses %s but the store has no %s set", t.Dest, ref, name)
+		}
+		return value
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return expanded, nil
+}
+
+// conditionVariable matches a variable name in TrackedPath.When.
+var conditionVariable = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Applies reports whether the path's When condition holds for vars. An
+// empty When always holds. A bare name holds when vars has that key,
+// "!name" when it does not, and "name==value" when the key maps to value.
+// A malformed condition is an error.
+func (t TrackedPath) Applies(vars map[string]string) (bool, error) {
+	cond := strings.TrimSpace(t.When)
+	if cond == "" {
+		return true, nil
+	}
+	if name, value, ok := strings.Cut(cond, "=="); ok {
+		name = strings.TrimSpace(name)
+		if !conditionVariable.MatchString(name) {
+			return false, fmt.Errorf("invalid condition %q: %q is not a variable name", t.When, name)
+		}
+		actual, set := vars[name]
+		return set && actual == strings.TrimSpace(value), nil
+	}
+	name, negated := strings.CutPrefix(cond, "!")
+	name = strings.TrimSpace(name)
+	if !conditionVariable.MatchString(name) {
+		return false, fmt.Errorf("invalid condition %q: must be NAME, !NAME or NAME==value", t.When)
+	}
+	_, set := vars[name]
+	return set != negated, nil
+}
+
 // Paths returns a list of all tracked path strings (for backward compatibility).
 func (tf *TrackFile) Paths() []string {
 	paths := make([]string, len(tf.Tracked))
@@ -137,6 +353,11 @@ func (tf *TrackFile) Paths() []string {
 	return paths
 }
 
+// AppendChangelog records a change to the track file at the given time.
+func (tf *TrackFile) AppendChangelog(at time.Time, message string) {
+	tf.Changelog = append(tf.Changelog, ChangelogEntry{At: at, Message: message})
+}
+
 // NewStoreMeta creates a new StoreMeta with the given name and scope.
 func NewStoreMeta(name, scope string, createdAt time.Time) *StoreMeta {
 	return &StoreMeta{
@@ -149,7 +370,45 @@ func NewStoreMeta(name, scope string, createdAt time.Time) *StoreMeta {
 }
 
 // Validate checks that all fields contain valid values.
+// It is applied when metadata is written; loading never validates so that
+// metadata written by older versions remains readable.
 func (m *StoreMeta) Validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return fmt.Errorf("invalid name: must not be empty")
+	}
+	if !validScopes[m.Scope] {
+		return fmt.Errorf("invalid scope %q: must be one of global, profile, component", m.Scope)
+	}
+	if err := ValidateOwner(m.Owner); err != nil {
+		return err
+	}
+	if m.UpdatedAt.Before(m.CreatedAt) {
+		return fmt.Errorf("invalid timestamps: updatedAt %s is before createdAt %s",
+			m.UpdatedAt.Format(time.RFC3339), m.CreatedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// validScopes is the set of valid Scope values for StoreMeta.
+var validScopes = map[string]bool{
+	ScopeGlobal: true, ScopeProfile: true, ScopeComponent: true,
+}
+
+// ValidateOwner checks that an owner value is well-formed (if non-empty).
+// Owners may contain spaces (git user.name) but not surrounding whitespace
+// or control characters.
+func ValidateOwner(owner string) error {
+	if owner == "" {
+		return nil
+	}
+	if strings.TrimSpace(owner) != owner {
+		return fmt.Errorf("invalid owner %q: must not have leading or trailing whitespace", owner)
+	}
+	for _, r := range owner {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("invalid owner %q: must not contain control characters", owner)
+		}
+	}
 	return nil
 }
 
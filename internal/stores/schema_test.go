@@ -46,6 +46,74 @@ func TestTrackedPath_IsRequired(t *testing.T) {
 	})
 }
 
+func TestTrackedPath_ExpandDest(t *testing.T) {
+	meta := &StoreMeta{TaskID: "PROJ-42", Owner: "alice"}
+
+	t.Run("substitutes task ID and owner", func(t *testing.T) {
+		tp := TrackedPath{Path: "notes.md", Dest: ".tasks/${taskID}/${owner}/notes.md"}
+
+		got, err := tp.ExpandDest(meta)
+		if err != nil {
+			t.Fatalf("ExpandDest failed: %v", err)
+		}
+		if got != ".tasks/PROJ-42/alice/notes.md" {
+			t.Errorf("ExpandDest() = %q, want .tasks/PROJ-42/alice/notes.md", got)
+		}
+	})
+
+	t.Run("rejects unknown variables", func(t *testing.T) {
+		tp := TrackedPath{Path: "notes.md", Dest: "${home}/notes.md"}
+
+		if _, err := tp.ExpandDest(meta); err == nil {
+			t.Error("Expected error for unknown variable, got nil")
+		}
+	})
+
+	t.Run("rejects unset values", func(t *testing.T) {
+		tp := TrackedPath{Path: "notes.md", Dest: ".tasks/${taskID}/notes.md"}
+
+		if _, err := tp.ExpandDest(&StoreMeta{}); err == nil {
+			t.Error("Expected error for unset task ID, got nil")
+		}
+	})
+}
+
+func TestTrackedPath_Applies(t *testing.T) {
+	vars := map[string]string{"DEBUG": "1", "EMPTY": ""}
+
+	tests := []struct {
+		when string
+		want bool
+	}{
+		{"", true},
+		{"DEBUG", true},
+		{"EMPTY", true},
+		{"CI", false},
+		{"!CI", true},
+		{"!DEBUG", false},
+		{"DEBUG==1", true},
+		{" DEBUG == 1 ", true},
+		{"DEBUG==0", false},
+		{"CI==1", false},
+	}
+	for _, tt := range tests {
+		got, err := TrackedPath{When: tt.when}.Applies(vars)
+		if err != nil {
+			t.Errorf("Applies(%q) failed: %v", tt.when, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Applies(%q) = %v, want %v", tt.when, got, tt.want)
+		}
+	}
+
+	for _, when := range []string{"DEBUG=1", "!", "1DEBUG", "==1", "DEBUG && CI", "!!CI"} {
+		if _, err := (TrackedPath{When: when}).Applies(vars); err == nil {
+			t.Errorf("Applies(%q): expected error, got nil", when)
+		}
+	}
+}
+
 func TestTrackFile_Paths(t *testing.T) {
 	t.Run("returns empty slice for empty track file", func(t *testing.T) {
 		tf := NewTrackFile()
@@ -386,12 +454,45 @@ func TestNewStoreMeta_SchemaVersion(t *testing.T) {
 }
 
 func TestStoreMeta_Validate(t *testing.T) {
-	t.Run("always passes", func(t *testing.T) {
+	t.Run("valid metadata passes", func(t *testing.T) {
 		meta := NewStoreMeta("test", "global", time.Now())
+		meta.Owner = "Jane Doe"
 		if err := meta.Validate(); err != nil {
 			t.Errorf("unexpected validation error: %v", err)
 		}
 	})
+
+	t.Run("all scopes pass", func(t *testing.T) {
+		for _, scope := range []string{ScopeGlobal, ScopeProfile, ScopeComponent} {
+			meta := NewStoreMeta("test", scope, time.Now())
+			if err := meta.Validate(); err != nil {
+				t.Errorf("scope %q: unexpected validation error: %v", scope, err)
+			}
+		}
+	})
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		mutate func(m *StoreMeta)
+	}{
+		{"empty name", func(m *StoreMeta) { m.Name = "" }},
+		{"whitespace name", func(m *StoreMeta) { m.Name = "   " }},
+		{"empty scope", func(m *StoreMeta) { m.Scope = "" }},
+		{"unknown scope", func(m *StoreMeta) { m.Scope = "team" }},
+		{"owner with surrounding whitespace", func(m *StoreMeta) { m.Owner = " alice " }},
+		{"owner with control character", func(m *StoreMeta) { m.Owner = "alice\nbob" }},
+		{"updatedAt before createdAt", func(m *StoreMeta) { m.UpdatedAt = m.CreatedAt.Add(-time.Hour) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := NewStoreMeta("test", ScopeGlobal, now)
+			tt.mutate(meta)
+			if err := meta.Validate(); err == nil {
+				t.Error("expected validation error, got nil")
+			}
+		})
+	}
 }
 
 func TestStoreMeta_BackwardCompat(t *testing.T) {
@@ -586,3 +687,30 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestParseQualifiedID(t *testing.T) {
+	tests := []struct {
+		id        string
+		wantScope string
+		wantBare  string
+		wantErr   bool
+	}{
+		{id: "foo", wantScope: "", wantBare: "foo"},
+		{id: "global/foo", wantScope: ScopeGlobal, wantBare: "foo"},
+		{id: "component/foo", wantScope: ScopeComponent, wantBare: "foo"},
+		{id: "team/foo", wantErr: true},
+		{id: "global/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			scope, bare, err := ParseQualifiedID(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if scope != tt.wantScope || bare != tt.wantBare {
+				t.Errorf("ParseQualifiedID(%q) = (%q, %q), want (%q, %q)", tt.id, scope, bare, tt.wantScope, tt.wantBare)
+			}
+		})
+	}
+}
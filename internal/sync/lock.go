@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrSyncInProgress is returned when another push or pull holds the sync
+// lock for the repository and it was not released within the lock timeout.
+var ErrSyncInProgress = errors.New("another sync is in progress for this repository")
+
+// SyncLockFileName is the name of the repo-scoped sync lock file,
+// created in the repository's .monodev directory.
+const SyncLockFileName = "sync.lock"
+
+// DefaultLockTimeout is how long push and pull wait for the sync lock.
+const DefaultLockTimeout = 10 * time.Second
+
+// lockPollInterval is how often a held lock is re-checked while waiting.
+const lockPollInterval = 50 * time.Millisecond
+
+// syncLockPath returns the path of the sync lock file for a repository.
+// It lives outside .monodev/persist so it is never committed.
+func syncLockPath(repoRoot string) string {
+	return filepath.Join(repoRoot, ".monodev", SyncLockFileName)
+}
+
+// acquireLock creates the repository's sync lock, waiting up to the lock
+// timeout for a concurrent push or pull to release it. A lock left behind by
+// a process that is no longer running is removed. The returned function
+// releases the lock.
+//
+// The lock is a symlink whose target is the holder's PID: creating a symlink
+// fails if the path exists, so it is an exclusive create through fsops.FS.
+func (s *Syncer) acquireLock(ctx context.Context, repoRoot string) (func(), error) {
+	path := syncLockPath(repoRoot)
+	if err := s.fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create sync lock directory: %w", err)
+	}
+
+	pid := strconv.Itoa(os.Getpid())
+	deadline := time.Now().Add(s.lockTimeout)
+	for {
+		err := s.fs.Symlink(pid, path)
+		if err == nil {
+			return func() { _ = s.fs.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create sync lock: %w", err)
+		}
+
+		// The holder exited without releasing the lock
+		if holder, ok := s.lockHolder(path); ok && !processAlive(holder) {
+			if err := s.fs.Remove(path); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to remove stale sync lock: %w", err)
+			}
+			continue
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("%w (lock file %s; remove it if no sync is running)", ErrSyncInProgress, path)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// lockHolder returns the PID recorded in the sync lock at path, and false
+// if it cannot be read.
+func (s *Syncer) lockHolder(path string) (int, bool) {
+	recorded, err := s.fs.Readlink(path)
+	if err != nil {
+		// Locks written as regular files hold the PID as their content
+		data, err := s.fs.ReadFile(path)
+		if err != nil {
+			return 0, false
+		}
+		recorded = string(data)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(recorded))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, true
+}
+
+// processAlive reports whether a process with the given PID is running.
+// It reports true whenever it cannot tell, so a live lock is never broken.
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = proc.Signal(syscall.Signal(0))
+	return !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH)
+}
@@ -3,14 +3,29 @@ package sync
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/danieljhkim/monodev/internal/hash"
+	"github.com/danieljhkim/monodev/internal/persist"
+	"github.com/danieljhkim/monodev/internal/remote"
 )
 
+// fetchedRef names the branch tip the last fetch retrieved. The local
+// branch checked out after a fetch is not moved to it, so stores added on
+// the remote are read from here.
+const fetchedRef = "FETCH_HEAD"
+
 // pullStore implements the pull operation for stores.
 func (s *Syncer) pullStore(ctx context.Context, req *PullRequest) (*PullResult, error) {
 	// Validate request
 	if req.RepoRoot == "" {
 		return nil, fmt.Errorf("repo root is required")
 	}
+	if req.Merge && len(req.PathFilters) > 0 {
+		return nil, fmt.Errorf("path filters cannot be combined with merge")
+	}
 
 	// Load remote config
 	config, err := s.configStore.Load(req.RepoRoot)
@@ -24,39 +39,54 @@ func (s *Syncer) pullStore(ctx context.Context, req *PullRequest) (*PullResult,
 		remoteName = req.Remote
 	}
 
+	git, err := config.Persistence(s.git, s.fs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Ensure persistence repo exists
-	if err := s.git.EnsureRepo(req.RepoRoot, config.Branch); err != nil {
+	if err := git.EnsureRepo(req.RepoRoot, config.Branch); err != nil {
 		return nil, fmt.Errorf("failed to ensure persistence repo: %w", err)
 	}
 
 	// Get the remote URL from the main repository
-	remoteURL, err := s.git.GetRemoteURL(req.RepoRoot, remoteName)
+	remoteURL, err := git.GetRemoteURL(req.RepoRoot, remoteName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get remote URL: %w", err)
 	}
 
 	// Configure the remote in the persistence repository
-	if err := s.git.SetRemote(req.RepoRoot, remoteName, remoteURL); err != nil {
+	if err := git.SetRemote(req.RepoRoot, remoteName, remoteURL); err != nil {
 		return nil, fmt.Errorf("failed to set remote: %w", err)
 	}
 
 	// Fetch the persistence branch
-	if err := s.git.Fetch(req.RepoRoot, remoteName, config.Branch); err != nil {
+	if err := git.Fetch(req.RepoRoot, remoteName, config.Branch); err != nil {
 		return nil, fmt.Errorf("failed to fetch: %w", err)
 	}
 
 	// Checkout to work tree
-	if err := s.git.Checkout(req.RepoRoot, config.Branch); err != nil {
+	if err := git.Checkout(req.RepoRoot, config.Branch); err != nil {
 		return nil, fmt.Errorf("failed to checkout: %w", err)
 	}
 
 	// If no store IDs specified, pull all stores from the persist directory
+	// along with any the fetched branch has that it lacks
 	storeIDs := req.StoreIDs
+	var discovered map[string]bool
 	if len(storeIDs) == 0 {
 		persistedStores, err := s.snapshotMgr.ListPersistedStores(req.RepoRoot)
 		if err != nil {
 			return nil, fmt.Errorf("failed to list persisted stores: %w", err)
 		}
+		discovered, err = s.discoverRemoteStores(git, req.RepoRoot, persistedStores)
+		if err != nil {
+			return nil, err
+		}
+		for storeID := range discovered {
+			persistedStores = append(persistedStores, storeID)
+		}
+		sort.Strings(persistedStores)
 		if len(persistedStores) == 0 {
 			return &PullResult{
 				PulledStores:    []string{},
@@ -69,17 +99,53 @@ func (s *Syncer) pullStore(ctx context.Context, req *PullRequest) (*PullResult,
 		storeIDs = persistedStores
 	}
 
+	// Restrict "pull all" to the allowlist; warn on explicit stores outside it
+	storeIDs, warnings := applySyncAllowlist(config, storeIDs, len(req.StoreIDs) > 0)
+
 	// Dematerialize stores from .monodev/persist/stores/ to ~/.monodev/stores/
 	var pulledStores []string
+	var conflicts []string
 	for _, storeID := range storeIDs {
-		if err := s.snapshotMgr.Dematerialize(storeID, req.RepoRoot, s.storeRepo); err != nil {
-			return nil, fmt.Errorf("failed to dematerialize store %q: %w", storeID, err)
+		if err := ctx.Err(); err != nil {
+			return &PullResult{
+				PulledStores: pulledStores,
+				Verified:     req.Verify,
+				Remote:       remoteName,
+				Branch:       config.Branch,
+				Conflicts:    conflicts,
+				Warnings:     warnings,
+			}, err
+		}
+		if discovered[storeID] {
+			if err := git.CheckoutPathAt(req.RepoRoot, fetchedRef, persist.PersistedStorePath(storeID), persistedStoreDir(req.RepoRoot, storeID)); err != nil {
+				return nil, fmt.Errorf("failed to materialize remote store %q: %w", storeID, err)
+			}
+		}
+		var prefixes, keptLocal []string
+		if req.Merge {
+			mergeResult, err := s.snapshotMgr.Merge(storeID, req.RepoRoot, s.storeRepo, s.hasher)
+			if err != nil {
+				return nil, fmt.Errorf("failed to merge store %q: %w", storeID, err)
+			}
+			for _, rel := range mergeResult.Conflicts {
+				conflicts = append(conflicts, storeID+"/"+rel)
+			}
+			keptLocal = slices.Concat(mergeResult.Kept, mergeResult.Conflicts)
+		} else {
+			prefixes = req.PathFilters[storeID]
+			if err := s.snapshotMgr.DematerializePaths(storeID, req.RepoRoot, s.storeRepo, prefixes); err != nil {
+				return nil, fmt.Errorf("failed to dematerialize store %q: %w", storeID, err)
+			}
+			// Paths left out by a filter keep their previous baseline
+			if err := s.snapshotMgr.RecordBaselinePaths(storeID, req.RepoRoot, s.storeRepo, s.hasher, prefixes); err != nil {
+				return nil, fmt.Errorf("failed to record baseline for store %q: %w", storeID, err)
+			}
 		}
 		pulledStores = append(pulledStores, storeID)
 
 		// Optionally verify checksums
 		if req.Verify {
-			if err := s.snapshotMgr.Verify(storeID, req.RepoRoot, s.hasher); err != nil {
+			if err := s.verifyStore(storeID, req.RepoRoot, prefixes, keptLocal); err != nil {
 				return nil, fmt.Errorf("verification failed for store %q: %w", storeID, err)
 			}
 		}
@@ -91,5 +157,50 @@ func (s *Syncer) pullStore(ctx context.Context, req *PullRequest) (*PullResult,
 		Verified:        req.Verify,
 		Remote:          remoteName,
 		Branch:          config.Branch,
+		Conflicts:       conflicts,
+		Warnings:        warnings,
 	}, nil
 }
+
+// discoverRemoteStores returns the stores present on the fetched branch but
+// missing from the persist directory, which lists persisted.
+func (s *Syncer) discoverRemoteStores(git remote.GitPersistence, repoRoot string, persisted []string) (map[string]bool, error) {
+	remoteStores, err := git.ListDirsAt(repoRoot, fetchedRef, persist.PersistedStoresPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote stores: %w", err)
+	}
+	discovered := make(map[string]bool)
+	for _, storeID := range remoteStores {
+		if slices.Contains(persisted, storeID) {
+			continue
+		}
+		if err := s.fs.ValidateIdentifier(storeID); err != nil {
+			return nil, fmt.Errorf("invalid remote store ID %q: %w", storeID, err)
+		}
+		discovered[storeID] = true
+	}
+	return discovered, nil
+}
+
+// persistedStoreDir returns where storeID is materialized in the .monodev
+// work tree of the repo at repoRoot.
+func persistedStoreDir(repoRoot, storeID string) string {
+	return filepath.Join(repoRoot, ".monodev", filepath.FromSlash(persist.PersistedStorePath(storeID)))
+}
+
+// verifyStore checks a pulled store against its persisted snapshot. With the
+// hash cache enabled, hashes of unchanged files are reused across pulls.
+func (s *Syncer) verifyStore(storeID, repoRoot string, prefixes, keptLocal []string) error {
+	if s.hashCache {
+		storeDir := filepath.Dir(s.storeRepo.OverlayRoot(storeID))
+		cache := hash.NewCachingHasher(s.hasher, s.fs, storeDir, filepath.Join(storeDir, hash.CacheFileName))
+		if err := s.snapshotMgr.Verify(storeID, repoRoot, s.storeRepo, cache, prefixes, keptLocal); err != nil {
+			return err
+		}
+		if err := cache.Save(); err != nil {
+			return fmt.Errorf("failed to save hash cache for store %s: %w", storeID, err)
+		}
+		return nil
+	}
+	return s.snapshotMgr.Verify(storeID, repoRoot, s.storeRepo, s.hasher, prefixes, keptLocal)
+}
@@ -2,11 +2,14 @@ package sync
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/danieljhkim/monodev/internal/persist"
 	"github.com/danieljhkim/monodev/internal/remote"
 )
 
@@ -36,59 +39,144 @@ func (s *Syncer) pushStore(ctx context.Context, req *PushRequest) (*PushResult,
 		return nil, err
 	}
 
+	git, err := config.Persistence(s.git, s.fs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restrict "push all" to the allowlist; warn on explicit stores outside it
+	storeIDs, warnings := applySyncAllowlist(config, storeIDs, len(req.StoreIDs) > 0)
+	if len(req.StoreIDs) == 0 && !req.WithWorkspace && len(storeIDs) == 0 {
+		return nil, fmt.Errorf("no allowlisted stores found to push")
+	}
+
 	// Ensure persistence repo exists
+	actions := []GitAction{
+		{Kind: GitActionEnsureRepo, Branch: config.Branch},
+		{Kind: GitActionSetRemote, Remote: config.Remote},
+	}
 	if !req.DryRun {
-		if err := s.git.EnsureRepo(req.RepoRoot, config.Branch); err != nil {
+		if err := git.EnsureRepo(req.RepoRoot, config.Branch); err != nil {
 			return nil, fmt.Errorf("failed to ensure persistence repo: %w", err)
 		}
 
 		// Get the remote URL from the main repository
-		remoteURL, err := s.git.GetRemoteURL(req.RepoRoot, config.Remote)
+		remoteURL, err := git.GetRemoteURL(req.RepoRoot, config.Remote)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get remote URL: %w", err)
 		}
 
 		// Configure the remote in the persistence repository
-		if err := s.git.SetRemote(req.RepoRoot, config.Remote, remoteURL); err != nil {
+		if err := git.SetRemote(req.RepoRoot, config.Remote, remoteURL); err != nil {
 			return nil, fmt.Errorf("failed to set remote: %w", err)
 		}
 	}
 
 	// Materialize stores to .monodev/persist/stores/
 	var pushedStores []string
+	failures := make(map[string]error)
 	for _, storeID := range storeIDs {
+		if err := ctx.Err(); err != nil {
+			return &PushResult{
+				PushedStores:      pushedStores,
+				Remote:            config.Remote,
+				Branch:            config.Branch,
+				DryRun:            req.DryRun,
+				Warnings:          warnings,
+				Failures:          failureMessages(failures),
+				PlannedGitActions: actions,
+			}, err
+		}
 		if !req.DryRun {
+			// A failing store is recorded and skipped so the rest still push
 			if err := s.snapshotMgr.Materialize(storeID, s.storeRepo, req.RepoRoot); err != nil {
-				return nil, fmt.Errorf("failed to materialize store %q: %w", storeID, err)
+				failures[storeID] = fmt.Errorf("failed to materialize store %q: %w", storeID, err)
+				continue
+			}
+			if err := s.snapshotMgr.RecordBaseline(storeID, req.RepoRoot, s.hasher); err != nil {
+				failures[storeID] = fmt.Errorf("failed to record baseline for store %q: %w", storeID, err)
+				continue
 			}
 		}
 		pushedStores = append(pushedStores, storeID)
 	}
 
+	result := &PushResult{
+		PushedStores:      pushedStores,
+		PushedWorkspace:   req.WithWorkspace,
+		Remote:            config.Remote,
+		Branch:            config.Branch,
+		DryRun:            req.DryRun,
+		Warnings:          warnings,
+		Failures:          failureMessages(failures),
+		PlannedGitActions: actions,
+	}
+
+	// Nothing left to commit if every store failed
+	if len(pushedStores) == 0 && !req.WithWorkspace && len(failures) > 0 {
+		return result, failuresError(failures, len(storeIDs))
+	}
+
 	// Build commit message
 	commitMessage := s.buildPushCommitMessage(pushedStores, req.WithWorkspace)
-
-	// Stage and commit changes
+	result.CommitMessage = commitMessage
+	result.PlannedGitActions = append(result.PlannedGitActions,
+		GitAction{Kind: GitActionCommit, Branch: config.Branch, Message: commitMessage},
+		GitAction{Kind: GitActionPush, Remote: config.Remote, Branch: config.Branch, Force: req.Force},
+	)
+
+	// Stage and commit only the stores that materialized, so a failed
+	// store's partial copy is never pushed
 	if !req.DryRun {
-		persistDir := filepath.Join(req.RepoRoot, ".monodev", "persist")
-		if err := s.git.Commit(req.RepoRoot, commitMessage, []string{persistDir}); err != nil {
-			return nil, fmt.Errorf("failed to commit: %w", err)
+		commitPaths := make([]string, 0, len(pushedStores))
+		for _, storeID := range pushedStores {
+			commitPaths = append(commitPaths, filepath.Join(req.RepoRoot, ".monodev", filepath.FromSlash(persist.PersistedStorePath(storeID))))
+		}
+		if len(commitPaths) > 0 {
+			if err := git.Commit(req.RepoRoot, commitMessage, commitPaths); err != nil {
+				return nil, fmt.Errorf("failed to commit: %w", err)
+			}
 		}
 
 		// Push to remote
-		if err := s.git.Push(req.RepoRoot, config.Remote, config.Branch, req.Force); err != nil {
+		if err := git.Push(req.RepoRoot, config.Remote, config.Branch, req.Force); err != nil {
 			return nil, fmt.Errorf("failed to push: %w", err)
 		}
 	}
 
-	return &PushResult{
-		PushedStores:    pushedStores,
-		PushedWorkspace: req.WithWorkspace,
-		CommitMessage:   commitMessage,
-		Remote:          config.Remote,
-		Branch:          config.Branch,
-		DryRun:          req.DryRun,
-	}, nil
+	if len(failures) > 0 {
+		return result, failuresError(failures, len(storeIDs))
+	}
+	return result, nil
+}
+
+// failureMessages returns the messages of per-store push failures, keyed by
+// store ID, or nil if there are none.
+func failureMessages(failures map[string]error) map[string]string {
+	if len(failures) == 0 {
+		return nil
+	}
+	messages := make(map[string]string, len(failures))
+	for id, err := range failures {
+		messages[id] = err.Error()
+	}
+	return messages
+}
+
+// failuresError aggregates per-store push failures into a single error,
+// ordered by store ID.
+func failuresError(failures map[string]error, total int) error {
+	ids := make([]string, 0, len(failures))
+	for id := range failures {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	errs := make([]error, 0, len(ids))
+	for _, id := range ids {
+		errs = append(errs, failures[id])
+	}
+	return fmt.Errorf("failed to push %d of %d stores: %w", len(failures), total, errors.Join(errs...))
 }
 
 // loadOrCreateConfig loads the remote config, or creates a default one if it doesn't exist.
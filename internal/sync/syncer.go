@@ -2,6 +2,8 @@ package sync
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/danieljhkim/monodev/internal/clock"
 	"github.com/danieljhkim/monodev/internal/fsops"
@@ -22,6 +24,25 @@ type Syncer struct {
 	fs          fsops.FS
 	hasher      hash.Hasher
 	clock       clock.Clock
+
+	// lockTimeout bounds the wait for the repository sync lock
+	lockTimeout time.Duration
+
+	// hashCache enables the per-store hash cache used by pull verification
+	hashCache bool
+}
+
+// Option configures a Syncer.
+type Option func(*Syncer)
+
+// WithHashCache enables the per-store hash cache. When enabled, verifying a
+// pulled store records file hashes in the store directory (see
+// hash.CacheFileName) and skips rehashing files whose size and modification
+// time are unchanged.
+func WithHashCache(enabled bool) Option {
+	return func(s *Syncer) {
+		s.hashCache = enabled
+	}
 }
 
 // New creates a new Syncer with the specified dependencies.
@@ -34,8 +55,9 @@ func New(
 	fs fsops.FS,
 	hasher hash.Hasher,
 	clock clock.Clock,
+	opts ...Option,
 ) *Syncer {
-	return &Syncer{
+	s := &Syncer{
 		git:         git,
 		storeRepo:   storeRepo,
 		stateStore:  stateStore,
@@ -44,15 +66,88 @@ func New(
 		fs:          fs,
 		hasher:      hasher,
 		clock:       clock,
+		lockTimeout: DefaultLockTimeout,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SetLockTimeout sets how long PushStore and PullStore wait for another sync
+// on the same repository to finish. Zero or less fails immediately.
+func (s *Syncer) SetLockTimeout(timeout time.Duration) {
+	s.lockTimeout = timeout
 }
 
 // PushStore pushes stores to the remote persistence repository.
+// Only one push or pull may run per repository at a time; dry runs change
+// nothing and do not take the lock.
 func (s *Syncer) PushStore(ctx context.Context, req *PushRequest) (*PushResult, error) {
+	if req.RepoRoot == "" {
+		return nil, fmt.Errorf("repo root is required")
+	}
+	if req.DryRun {
+		return s.pushStore(ctx, req)
+	}
+	unlock, err := s.acquireLock(ctx, req.RepoRoot)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	return s.pushStore(ctx, req)
 }
 
 // PullStore pulls stores from the remote persistence repository.
+// Only one push or pull may run per repository at a time.
 func (s *Syncer) PullStore(ctx context.Context, req *PullRequest) (*PullResult, error) {
+	if req.RepoRoot == "" {
+		return nil, fmt.Errorf("repo root is required")
+	}
+	unlock, err := s.acquireLock(ctx, req.RepoRoot)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	return s.pullStore(ctx, req)
 }
+
+// GC compacts the sync repository under repoRoot, pruning history objects
+// that are no longer reachable. It is a maintenance operation separate from
+// push and pull, but waits for them via the same sync lock.
+func (s *Syncer) GC(ctx context.Context, repoRoot string, aggressive bool) error {
+	if repoRoot == "" {
+		return fmt.Errorf("repo root is required")
+	}
+	unlock, err := s.acquireLock(ctx, repoRoot)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.git.GC(repoRoot, aggressive); err != nil {
+		return fmt.Errorf("failed to gc sync repository: %w", err)
+	}
+	return nil
+}
+
+// applySyncAllowlist filters storeIDs by the config's SyncStores allowlist.
+// When explicit is true the IDs are kept as requested, and a warning is
+// returned for each one that is not allowlisted.
+func applySyncAllowlist(config *remote.RemoteConfig, storeIDs []string, explicit bool) ([]string, []string) {
+	var kept, warnings []string
+	for _, id := range storeIDs {
+		if config.AllowsStore(id) {
+			kept = append(kept, id)
+			continue
+		}
+		if explicit {
+			kept = append(kept, id)
+			warnings = append(warnings, fmt.Sprintf("store %s is not in the remote sync allowlist", id))
+		}
+	}
+	return kept, warnings
+}
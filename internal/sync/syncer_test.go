@@ -2,9 +2,16 @@ package sync
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -62,11 +69,24 @@ func (r *fakeStoreRepo) LoadMeta(id string) (*stores.StoreMeta, error) {
 	return meta, nil
 }
 
+func (r *fakeStoreRepo) LoadMetas(ids []string) (map[string]*stores.StoreMeta, error) {
+	return stores.LoadMetasSequential(r, ids)
+}
+
 func (r *fakeStoreRepo) SaveMeta(id string, meta *stores.StoreMeta) error {
 	r.stores[id] = meta
 	return nil
 }
 
+func (r *fakeStoreRepo) MarkUsed(id string, at time.Time) error {
+	meta, exists := r.stores[id]
+	if !exists {
+		return fmt.Errorf("store not found")
+	}
+	meta.LastUsedAt = &at
+	return nil
+}
+
 func (r *fakeStoreRepo) LoadTrack(id string) (*stores.TrackFile, error) {
 	track, exists := r.tracks[id]
 	if !exists {
@@ -187,10 +207,18 @@ func (s *fakeStateStore) SaveWorkspace(workspaceID string, st *state.WorkspaceSt
 	return fmt.Errorf("not implemented")
 }
 
+func (s *fakeStateStore) CheckWorkspace(workspaceID string, st *state.WorkspaceState) error {
+	return fmt.Errorf("not implemented")
+}
+
 func (s *fakeStateStore) DeleteWorkspace(workspaceID string) error {
 	return fmt.Errorf("not implemented")
 }
 
+func (s *fakeStateStore) LoadWorkspaceIfChanged(workspaceID string, since time.Time) (*state.WorkspaceState, bool, error) {
+	return nil, false, fmt.Errorf("not implemented")
+}
+
 func TestSyncer_PushStore(t *testing.T) {
 	t.Run("pushes single store successfully", func(t *testing.T) {
 		repoRoot, _, syncer, git, storeRepo, configStore, cleanup := setupSyncerTest(t)
@@ -504,6 +532,56 @@ func TestSyncer_PullStore(t *testing.T) {
 		}
 	})
 
+	t.Run("pulls stores the remote introduced", func(t *testing.T) {
+		repoRoot, _, syncer, git, storeRepo, configStore, cleanup := setupSyncerTest(t)
+		defer cleanup()
+
+		config := remote.DefaultRemoteConfig()
+		if err := configStore.Save(repoRoot, config); err != nil {
+			t.Fatalf("failed to save config: %v", err)
+		}
+
+		// One store is persisted locally; the fetched branch also has a new one
+		fs := fsops.NewRealFS()
+		snapshotMgr := persist.NewSnapshotManager(fs)
+		if err := storeRepo.Create("known", stores.NewStoreMeta("Known", "global", time.Now())); err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+		if err := os.MkdirAll(storeRepo.OverlayRoot("known"), 0755); err != nil {
+			t.Fatalf("failed to create overlay dir: %v", err)
+		}
+		if err := snapshotMgr.Materialize("known", storeRepo, repoRoot); err != nil {
+			t.Fatalf("failed to materialize: %v", err)
+		}
+		git.RefTrees = map[string]map[string]string{
+			"FETCH_HEAD": {
+				"persist/stores/known/meta.json":         "{}",
+				"persist/stores/fresh/meta.json":         `{"name":"Fresh","scope":"global"}`,
+				"persist/stores/fresh/overlay/setup.sh":  "echo hi\n",
+				"persist/stores/fresh/overlay/README.md": "fresh\n",
+			},
+		}
+
+		result, err := syncer.PullStore(context.Background(), &PullRequest{RepoRoot: repoRoot})
+		if err != nil {
+			t.Fatalf("PullStore failed: %v", err)
+		}
+
+		if strings.Join(result.PulledStores, ",") != "fresh,known" {
+			t.Errorf("PulledStores = %v, want [fresh known]", result.PulledStores)
+		}
+		data, err := os.ReadFile(filepath.Join(storeRepo.OverlayRoot("fresh"), "setup.sh"))
+		if err != nil {
+			t.Fatalf("new store was not pulled locally: %v", err)
+		}
+		if string(data) != "echo hi\n" {
+			t.Errorf("setup.sh = %q, want %q", data, "echo hi\n")
+		}
+		if len(git.CheckoutPathAtCalls) != 1 || git.CheckoutPathAtCalls[0].Path != "persist/stores/fresh" {
+			t.Errorf("expected only the new store to be materialized, got %+v", git.CheckoutPathAtCalls)
+		}
+	})
+
 	t.Run("returns empty result when no persisted stores exist", func(t *testing.T) {
 		repoRoot, _, syncer, _, _, configStore, cleanup := setupSyncerTest(t)
 		defer cleanup()
@@ -610,3 +688,729 @@ func TestBuildPushCommitMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncer_PullStore_PathFilters(t *testing.T) {
+	repoRoot, _, syncer, _, storeRepo, configStore, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	if err := configStore.Save(repoRoot, remote.DefaultRemoteConfig()); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	storeID := "big-store"
+	if err := storeRepo.Create(storeID, stores.NewStoreMeta("Big Store", "global", time.Now())); err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	overlayDir := storeRepo.OverlayRoot(storeID)
+	for rel, content := range map[string]string{
+		"scripts/build.sh":  "build",
+		"scripts/lib/a.sh":  "lib",
+		"scriptsextra/x.sh": "not a match",
+		"docs/big.md":       "docs",
+	} {
+		path := filepath.Join(overlayDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := persist.NewSnapshotManager(fsops.NewRealFS()).Materialize(storeID, storeRepo, repoRoot); err != nil {
+		t.Fatalf("failed to materialize: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Dir(overlayDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := syncer.PullStore(context.Background(), &PullRequest{
+		RepoRoot:    repoRoot,
+		StoreIDs:    []string{storeID},
+		PathFilters: map[string][]string{storeID: {"scripts"}},
+	})
+	if err != nil {
+		t.Fatalf("PullStore failed: %v", err)
+	}
+	if len(result.PulledStores) != 1 {
+		t.Fatalf("PulledStores = %v, want [%s]", result.PulledStores, storeID)
+	}
+
+	for _, rel := range []string{"scripts/build.sh", "scripts/lib/a.sh"} {
+		if _, err := os.Stat(filepath.Join(overlayDir, rel)); err != nil {
+			t.Errorf("expected %s to be pulled: %v", rel, err)
+		}
+	}
+	for _, rel := range []string{"scriptsextra/x.sh", "docs/big.md"} {
+		if _, err := os.Stat(filepath.Join(overlayDir, rel)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be left unpulled, got %v", rel, err)
+		}
+	}
+
+	// Store metadata still comes in full
+	if exists, err := storeRepo.Exists(storeID); err != nil || !exists {
+		t.Errorf("expected store metadata to be pulled, got %v, %v", exists, err)
+	}
+
+	_, err = syncer.PullStore(context.Background(), &PullRequest{
+		RepoRoot:    repoRoot,
+		StoreIDs:    []string{storeID},
+		PathFilters: map[string][]string{storeID: {"scripts"}},
+		Merge:       true,
+	})
+	if err == nil {
+		t.Error("expected path filters with merge to be rejected")
+	}
+}
+
+func TestSyncer_PullStore_VerifyWithHashCache(t *testing.T) {
+	repoRoot, _, syncer, _, storeRepo, configStore, cleanup := setupSyncerTest(t)
+	defer cleanup()
+	syncer.hasher = hash.NewContentFakeHasher()
+	WithHashCache(true)(syncer)
+
+	if err := configStore.Save(repoRoot, remote.DefaultRemoteConfig()); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	storeID := "test-store"
+	if err := storeRepo.Create(storeID, stores.NewStoreMeta("Test Store", "global", time.Now())); err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	overlayDir := storeRepo.OverlayRoot(storeID)
+	if err := os.MkdirAll(overlayDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(overlayDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := persist.NewSnapshotManager(fsops.NewRealFS()).Materialize(storeID, storeRepo, repoRoot); err != nil {
+		t.Fatalf("failed to materialize: %v", err)
+	}
+
+	result, err := syncer.PullStore(context.Background(), &PullRequest{
+		RepoRoot: repoRoot,
+		StoreIDs: []string{storeID},
+		Verify:   true,
+	})
+	if err != nil {
+		t.Fatalf("PullStore failed: %v", err)
+	}
+	if !result.Verified {
+		t.Error("expected the pull to be verified")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(overlayDir), hash.CacheFileName)); err != nil {
+		t.Errorf("expected verification to write the hash cache: %v", err)
+	}
+}
+
+func TestSyncer_PullStore_Merge(t *testing.T) {
+	repoRoot, _, syncer, _, storeRepo, configStore, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	// Merge relies on content checksums
+	syncer.hasher = hash.NewSHA256Hasher()
+
+	if err := configStore.Save(repoRoot, remote.DefaultRemoteConfig()); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	storeID := "shared-store"
+	if err := storeRepo.Create(storeID, stores.NewStoreMeta("Shared", "global", time.Now())); err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	overlayDir := storeRepo.OverlayRoot(storeID)
+	persistOverlay := filepath.Join(repoRoot, ".monodev", "persist", "stores", storeID, "overlay")
+
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readFile := func(path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", path, err)
+		}
+		return string(data)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		writeFile(filepath.Join(overlayDir, name), "base "+name)
+	}
+
+	// Push records the baseline
+	if _, err := syncer.PushStore(context.Background(), &PushRequest{
+		RepoRoot: repoRoot,
+		StoreIDs: []string{storeID},
+	}); err != nil {
+		t.Fatalf("PushStore failed: %v", err)
+	}
+
+	// Remote changes a.txt and c.txt; local changes b.txt and c.txt
+	writeFile(filepath.Join(persistOverlay, "a.txt"), "remote a")
+	writeFile(filepath.Join(persistOverlay, "c.txt"), "remote c")
+	writeFile(filepath.Join(overlayDir, "b.txt"), "local b")
+	writeFile(filepath.Join(overlayDir, "c.txt"), "local c")
+
+	result, err := syncer.PullStore(context.Background(), &PullRequest{
+		RepoRoot: repoRoot,
+		StoreIDs: []string{storeID},
+		Merge:    true,
+	})
+	if err != nil {
+		t.Fatalf("PullStore failed: %v", err)
+	}
+
+	if got := readFile(filepath.Join(overlayDir, "a.txt")); got != "remote a" {
+		t.Errorf("a.txt = %q, want remote change", got)
+	}
+	if got := readFile(filepath.Join(overlayDir, "b.txt")); got != "local b" {
+		t.Errorf("b.txt = %q, want local change preserved", got)
+	}
+	if got := readFile(filepath.Join(overlayDir, "c.txt")); got != "local c" {
+		t.Errorf("c.txt = %q, want local version kept on conflict", got)
+	}
+
+	want := storeID + "/overlay/c.txt"
+	if len(result.Conflicts) != 1 || result.Conflicts[0] != want {
+		t.Errorf("Conflicts = %v, want [%s]", result.Conflicts, want)
+	}
+}
+
+func TestSyncer_PullStore_MergeWithoutLocalStore(t *testing.T) {
+	repoRoot, _, syncer, _, storeRepo, configStore, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	syncer.hasher = hash.NewSHA256Hasher()
+
+	if err := configStore.Save(repoRoot, remote.DefaultRemoteConfig()); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	storeID := "remote-only"
+	persistOverlay := filepath.Join(repoRoot, ".monodev", "persist", "stores", storeID, "overlay")
+	if err := os.MkdirAll(persistOverlay, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(persistOverlay, "x.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := syncer.PullStore(context.Background(), &PullRequest{
+		RepoRoot: repoRoot,
+		StoreIDs: []string{storeID},
+		Merge:    true,
+	})
+	if err != nil {
+		t.Fatalf("PullStore failed: %v", err)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("Conflicts = %v, want none", result.Conflicts)
+	}
+	if _, err := os.Stat(filepath.Join(storeRepo.OverlayRoot(storeID), "x.txt")); err != nil {
+		t.Errorf("expected store to be restored: %v", err)
+	}
+}
+
+func TestSyncer_PushStore_CancelledContext(t *testing.T) {
+	repoRoot, _, syncer, _, storeRepo, _, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	storeID := "test-store"
+	if err := storeRepo.Create(storeID, stores.NewStoreMeta("Test Store", "global", time.Now())); err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := syncer.PushStore(ctx, &PushRequest{
+		RepoRoot: repoRoot,
+		StoreIDs: []string{storeID},
+		Remote:   "origin",
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if result == nil || len(result.PushedStores) != 0 {
+		t.Errorf("expected no pushed stores, got %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, ".monodev", "persist", "stores", storeID)); !os.IsNotExist(err) {
+		t.Errorf("expected store not to be materialized, got err=%v", err)
+	}
+}
+
+func TestSyncer_PushStore_DryRunPlansGitActions(t *testing.T) {
+	repoRoot, _, syncer, git, storeRepo, _, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	for _, id := range []string{"alpha", "beta"} {
+		if err := storeRepo.Create(id, stores.NewStoreMeta(id, "global", time.Now())); err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+	}
+
+	result, err := syncer.PushStore(context.Background(), &PushRequest{
+		RepoRoot: repoRoot,
+		StoreIDs: []string{"alpha", "beta"},
+		Remote:   "origin",
+		DryRun:   true,
+		Force:    true,
+	})
+	if err != nil {
+		t.Fatalf("PushStore failed: %v", err)
+	}
+
+	want := []GitAction{
+		{Kind: GitActionEnsureRepo, Branch: result.Branch},
+		{Kind: GitActionSetRemote, Remote: "origin"},
+		{Kind: GitActionCommit, Branch: result.Branch, Message: "push: 2 stores"},
+		{Kind: GitActionPush, Remote: "origin", Branch: result.Branch, Force: true},
+	}
+	if !reflect.DeepEqual(result.PlannedGitActions, want) {
+		t.Errorf("PlannedGitActions = %+v, want %+v", result.PlannedGitActions, want)
+	}
+	if len(git.EnsureRepoCalls) > 0 || len(git.CommitCalls) > 0 {
+		t.Error("dry run should not run git")
+	}
+}
+
+func TestSyncer_PushStore_SyncAllowlist(t *testing.T) {
+	repoRoot, _, syncer, _, storeRepo, configStore, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	for _, id := range []string{"alpha", "beta", "gamma"} {
+		if err := storeRepo.Create(id, stores.NewStoreMeta(id, "global", time.Now())); err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+	}
+
+	config := remote.DefaultRemoteConfig()
+	config.SyncStores = []string{"alpha", "gamma"}
+	if err := configStore.Save(repoRoot, config); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	t.Run("push all only pushes allowlisted stores", func(t *testing.T) {
+		result, err := syncer.PushStore(context.Background(), &PushRequest{RepoRoot: repoRoot, DryRun: true})
+		if err != nil {
+			t.Fatalf("PushStore failed: %v", err)
+		}
+		pushed := append([]string{}, result.PushedStores...)
+		sort.Strings(pushed)
+		if len(pushed) != 2 || pushed[0] != "alpha" || pushed[1] != "gamma" {
+			t.Errorf("PushedStores = %v, want [alpha gamma]", pushed)
+		}
+		if len(result.Warnings) != 0 {
+			t.Errorf("unexpected warnings: %v", result.Warnings)
+		}
+	})
+
+	t.Run("explicit store outside allowlist is pushed with a warning", func(t *testing.T) {
+		result, err := syncer.PushStore(context.Background(), &PushRequest{
+			RepoRoot: repoRoot,
+			StoreIDs: []string{"beta"},
+			DryRun:   true,
+		})
+		if err != nil {
+			t.Fatalf("PushStore failed: %v", err)
+		}
+		if len(result.PushedStores) != 1 || result.PushedStores[0] != "beta" {
+			t.Errorf("PushedStores = %v, want [beta]", result.PushedStores)
+		}
+		if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "beta") {
+			t.Errorf("expected allowlist warning for beta, got %v", result.Warnings)
+		}
+	})
+}
+
+func TestSyncer_PushStore_IsolatesStoreFailures(t *testing.T) {
+	repoRoot, _, syncer, git, storeRepo, _, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	for _, id := range []string{"alpha", "gamma"} {
+		if err := storeRepo.Create(id, stores.NewStoreMeta(id, "global", time.Now())); err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+		if err := os.MkdirAll(storeRepo.OverlayRoot(id), 0755); err != nil {
+			t.Fatalf("failed to create overlay dir: %v", err)
+		}
+	}
+
+	// "beta" does not exist, so materializing it fails
+	result, err := syncer.PushStore(context.Background(), &PushRequest{
+		RepoRoot: repoRoot,
+		StoreIDs: []string{"alpha", "beta", "gamma"},
+		Remote:   "origin",
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 3") {
+		t.Fatalf("expected aggregate error for 1 of 3 stores, got %v", err)
+	}
+	if result == nil {
+		t.Fatal("expected a result alongside the aggregate error")
+	}
+	if len(result.PushedStores) != 2 || result.PushedStores[0] != "alpha" || result.PushedStores[1] != "gamma" {
+		t.Errorf("PushedStores = %v, want [alpha gamma]", result.PushedStores)
+	}
+	if len(result.Failures) != 1 || result.Failures["beta"] == "" {
+		t.Errorf("Failures = %v, want only beta", result.Failures)
+	}
+	if len(git.CommitCalls) != 1 || len(git.PushCalls) != 1 {
+		t.Fatalf("expected remaining stores to be committed and pushed, got %d commits and %d pushes",
+			len(git.CommitCalls), len(git.PushCalls))
+	}
+	wantPaths := []string{
+		filepath.Join(repoRoot, ".monodev", "persist", "stores", "alpha"),
+		filepath.Join(repoRoot, ".monodev", "persist", "stores", "gamma"),
+	}
+	if !reflect.DeepEqual(git.CommitCalls[0].Paths, wantPaths) {
+		t.Errorf("committed paths = %v, want only the pushed stores %v", git.CommitCalls[0].Paths, wantPaths)
+	}
+
+	// Failures carry their messages into JSON output
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct{ Failures map[string]string }
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if msg := decoded.Failures["beta"]; !strings.Contains(msg, `store "beta"`) {
+		t.Errorf("JSON failure message for beta = %q, want the error text", msg)
+	}
+	for _, id := range []string{"alpha", "gamma"} {
+		if _, err := os.Stat(filepath.Join(repoRoot, ".monodev", "persist", "stores", id)); err != nil {
+			t.Errorf("expected %s to be materialized: %v", id, err)
+		}
+	}
+}
+
+func TestSyncer_PushStore_AllStoresFail(t *testing.T) {
+	repoRoot, _, syncer, git, _, _, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	result, err := syncer.PushStore(context.Background(), &PushRequest{
+		RepoRoot: repoRoot,
+		StoreIDs: []string{"missing"},
+		Remote:   "origin",
+	})
+	if err == nil {
+		t.Fatal("expected error when every store fails")
+	}
+	if result == nil || len(result.PushedStores) != 0 || result.Failures["missing"] == "" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(git.CommitCalls) != 0 || len(git.PushCalls) != 0 {
+		t.Error("nothing should be committed or pushed when every store fails")
+	}
+}
+
+// blockingGitPersistence holds EnsureRepo open until release is closed,
+// keeping a push in progress.
+type blockingGitPersistence struct {
+	*remote.FakeGitPersistence
+	started chan struct{}
+	release chan struct{}
+}
+
+func (g *blockingGitPersistence) EnsureRepo(repoRoot, branch string) error {
+	close(g.started)
+	<-g.release
+	return g.FakeGitPersistence.EnsureRepo(repoRoot, branch)
+}
+
+func TestSyncer_PushStore_ConcurrentPushFailsFast(t *testing.T) {
+	repoRoot, _, syncer, git, storeRepo, _, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	storeID := "test-store"
+	if err := storeRepo.Create(storeID, stores.NewStoreMeta("Test Store", "global", time.Now())); err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if err := os.MkdirAll(storeRepo.OverlayRoot(storeID), 0755); err != nil {
+		t.Fatalf("failed to create overlay dir: %v", err)
+	}
+
+	blocking := &blockingGitPersistence{
+		FakeGitPersistence: git,
+		started:            make(chan struct{}),
+		release:            make(chan struct{}),
+	}
+	syncer.git = blocking
+	syncer.SetLockTimeout(0)
+
+	req := &PushRequest{RepoRoot: repoRoot, StoreIDs: []string{storeID}, Remote: "origin"}
+
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := syncer.PushStore(context.Background(), req)
+		firstErr <- err
+	}()
+	<-blocking.started
+
+	// The first push holds the lock, so a second one fails immediately
+	start := time.Now()
+	_, err := syncer.PushStore(context.Background(), req)
+	if !errors.Is(err, ErrSyncInProgress) {
+		t.Fatalf("expected ErrSyncInProgress, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("second push took %v, expected it to fail fast", elapsed)
+	}
+
+	close(blocking.release)
+	if err := <-firstErr; err != nil {
+		t.Fatalf("first push failed: %v", err)
+	}
+
+	// The lock is released once the first push completes
+	if _, err := os.Lstat(syncLockPath(repoRoot)); !os.IsNotExist(err) {
+		t.Errorf("expected sync lock to be removed, got %v", err)
+	}
+	syncer.git = git
+	if _, err := syncer.PushStore(context.Background(), req); err != nil {
+		t.Errorf("push after lock release failed: %v", err)
+	}
+}
+
+func TestSyncer_PullStore_WaitsForHeldLock(t *testing.T) {
+	repoRoot, _, syncer, _, _, _, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	// Simulate another running process holding the lock
+	holdSyncLock(t, repoRoot, os.Getpid())
+	syncer.SetLockTimeout(100 * time.Millisecond)
+
+	_, err := syncer.PullStore(context.Background(), &PullRequest{RepoRoot: repoRoot})
+	if !errors.Is(err, ErrSyncInProgress) {
+		t.Fatalf("expected ErrSyncInProgress, got %v", err)
+	}
+	if _, err := os.Lstat(syncLockPath(repoRoot)); err != nil {
+		t.Errorf("a lock held by someone else must not be removed: %v", err)
+	}
+}
+
+func TestSyncer_GC_BreaksStaleLock(t *testing.T) {
+	repoRoot, _, syncer, _, _, _, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	// A lock left behind by a process that no longer exists
+	holdSyncLock(t, repoRoot, math.MaxInt32)
+	syncer.SetLockTimeout(0)
+
+	if err := syncer.GC(context.Background(), repoRoot, false); err != nil {
+		t.Fatalf("GC should break a stale lock, got %v", err)
+	}
+	if _, err := os.Lstat(syncLockPath(repoRoot)); !os.IsNotExist(err) {
+		t.Errorf("expected sync lock to be released, got %v", err)
+	}
+}
+
+func TestSyncer_PushStore_DryRunSkipsLock(t *testing.T) {
+	repoRoot, _, syncer, _, storeRepo, _, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	storeID := "test-store"
+	if err := storeRepo.Create(storeID, stores.NewStoreMeta("Test Store", "global", time.Now())); err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	holdSyncLock(t, repoRoot, os.Getpid())
+	syncer.SetLockTimeout(0)
+
+	req := &PushRequest{RepoRoot: repoRoot, StoreIDs: []string{storeID}, Remote: "origin", DryRun: true}
+	if _, err := syncer.PushStore(context.Background(), req); err != nil {
+		t.Fatalf("dry run should not wait for the sync lock, got %v", err)
+	}
+	if _, err := os.Lstat(syncLockPath(repoRoot)); err != nil {
+		t.Errorf("a dry run must not touch the held lock: %v", err)
+	}
+}
+
+// holdSyncLock creates the sync lock of repoRoot on behalf of pid.
+func holdSyncLock(t *testing.T, repoRoot string, pid int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(syncLockPath(repoRoot)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(strconv.Itoa(pid), syncLockPath(repoRoot)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncer_GC_ReachesGitLayer(t *testing.T) {
+	repoRoot, _, syncer, git, _, _, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	if err := syncer.GC(context.Background(), repoRoot, false); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	if err := syncer.GC(context.Background(), repoRoot, true); err != nil {
+		t.Fatalf("aggressive GC failed: %v", err)
+	}
+
+	want := []remote.GCCall{
+		{RepoRoot: repoRoot, Aggressive: false},
+		{RepoRoot: repoRoot, Aggressive: true},
+	}
+	if len(git.GCCalls) != len(want) {
+		t.Fatalf("expected %d GC calls, got %+v", len(want), git.GCCalls)
+	}
+	for i, call := range git.GCCalls {
+		if call != want[i] {
+			t.Errorf("GC call %d = %+v, want %+v", i, call, want[i])
+		}
+	}
+	if len(git.PushCalls) != 0 || len(git.FetchCalls) != 0 {
+		t.Error("GC must not push or fetch")
+	}
+}
+
+func TestSyncer_GC_PropagatesGitError(t *testing.T) {
+	repoRoot, _, syncer, git, _, _, cleanup := setupSyncerTest(t)
+	defer cleanup()
+
+	git.GCErr = errors.New("gc exploded")
+	err := syncer.GC(context.Background(), repoRoot, false)
+	if err == nil || !strings.Contains(err.Error(), "gc exploded") {
+		t.Fatalf("expected git error to propagate, got %v", err)
+	}
+	if _, err := os.Lstat(syncLockPath(repoRoot)); !os.IsNotExist(err) {
+		t.Errorf("expected sync lock to be released, got %v", err)
+	}
+}
+
+func TestSyncer_DirRemoteRoundTrip(t *testing.T) {
+	remoteDir := t.TempDir()
+	dirConfig := func() *remote.RemoteConfig {
+		config := remote.DefaultRemoteConfig()
+		config.Kind = remote.RemoteKindDir
+		config.Dir = remoteDir
+		return config
+	}
+
+	// Push from one machine
+	srcRoot, _, srcSyncer, srcGit, srcRepo, srcConfigs, srcCleanup := setupSyncerTest(t)
+	defer srcCleanup()
+	if err := srcConfigs.Save(srcRoot, dirConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if err := srcRepo.Create("shared", stores.NewStoreMeta("Shared", "global", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	overlayDir := srcRepo.OverlayRoot("shared")
+	if err := os.MkdirAll(filepath.Join(overlayDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(overlayDir, "sub", "a.txt"), []byte("shared content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := srcSyncer.PushStore(context.Background(), &PushRequest{RepoRoot: srcRoot, StoreIDs: []string{"shared"}}); err != nil {
+		t.Fatalf("PushStore failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(remoteDir, "persist", "stores", "shared")); err != nil {
+		t.Fatalf("expected store in directory remote: %v", err)
+	}
+	if len(srcGit.PushCalls) != 0 || len(srcGit.CommitCalls) != 0 {
+		t.Errorf("git layer used for a directory remote: %d pushes, %d commits", len(srcGit.PushCalls), len(srcGit.CommitCalls))
+	}
+
+	// Pull on another machine
+	dstRoot, _, dstSyncer, dstGit, dstRepo, dstConfigs, dstCleanup := setupSyncerTest(t)
+	defer dstCleanup()
+	if err := dstConfigs.Save(dstRoot, dirConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := dstSyncer.PullStore(context.Background(), &PullRequest{RepoRoot: dstRoot})
+	if err != nil {
+		t.Fatalf("PullStore failed: %v", err)
+	}
+	if strings.Join(result.PulledStores, ",") != "shared" {
+		t.Errorf("PulledStores = %v, want [shared]", result.PulledStores)
+	}
+	data, err := os.ReadFile(filepath.Join(dstRepo.OverlayRoot("shared"), "sub", "a.txt"))
+	if err != nil {
+		t.Fatalf("pulled file missing: %v", err)
+	}
+	if string(data) != "shared content" {
+		t.Errorf("pulled content = %q, want original", data)
+	}
+	if len(dstGit.FetchCalls) != 0 {
+		t.Errorf("git layer used for a directory remote: %d fetches", len(dstGit.FetchCalls))
+	}
+}
+
+func TestSyncer_DirRemotePushKeepsOtherClonesStores(t *testing.T) {
+	remoteDir := t.TempDir()
+	config := remote.DefaultRemoteConfig()
+	config.Kind = remote.RemoteKindDir
+	config.Dir = remoteDir
+
+	// setupClone returns a syncer for a clone holding one store with one file
+	setupClone := func(storeID, file string) (string, *Syncer, func()) {
+		root, _, syncer, _, repo, configs, cleanup := setupSyncerTest(t)
+		if err := configs.Save(root, config); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Create(storeID, stores.NewStoreMeta(storeID, "global", time.Now())); err != nil {
+			t.Fatal(err)
+		}
+		overlayDir := repo.OverlayRoot(storeID)
+		if err := os.MkdirAll(overlayDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(overlayDir, file), []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return root, syncer, cleanup
+	}
+	remoteStore := func(storeID string) string {
+		return filepath.Join(remoteDir, "persist", "stores", storeID)
+	}
+	ctx := context.Background()
+
+	rootA, syncerA, cleanupA := setupClone("alpha", "a.txt")
+	defer cleanupA()
+	rootB, syncerB, cleanupB := setupClone("beta", "b.txt")
+	defer cleanupB()
+
+	if _, err := syncerA.PushStore(ctx, &PushRequest{RepoRoot: rootA}); err != nil {
+		t.Fatalf("push from clone A failed: %v", err)
+	}
+	if _, err := syncerB.PushStore(ctx, &PushRequest{RepoRoot: rootB}); err != nil {
+		t.Fatalf("push from clone B failed: %v", err)
+	}
+	for _, id := range []string{"alpha", "beta"} {
+		if _, err := os.Stat(remoteStore(id)); err != nil {
+			t.Errorf("expected store %s in the remote after both pushes: %v", id, err)
+		}
+	}
+
+	// Clone C has its own "alpha" without a.txt, so pushing it would drop
+	// remote content it never pulled
+	rootC, syncerC, cleanupC := setupClone("alpha", "c.txt")
+	defer cleanupC()
+	_, err := syncerC.PushStore(ctx, &PushRequest{RepoRoot: rootC})
+	if !errors.Is(err, remote.ErrRemoteAhead) {
+		t.Fatalf("expected ErrRemoteAhead, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(remoteStore("alpha"), "overlay", "a.txt")); err != nil {
+		t.Errorf("refused push should leave the remote store intact: %v", err)
+	}
+
+	if _, err := syncerC.PushStore(ctx, &PushRequest{RepoRoot: rootC, Force: true}); err != nil {
+		t.Fatalf("forced push failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(remoteStore("alpha"), "overlay", "c.txt")); err != nil {
+		t.Errorf("forced push should replace the remote store: %v", err)
+	}
+	if _, err := os.Stat(remoteStore("beta")); err != nil {
+		t.Errorf("forced push of alpha should leave beta alone: %v", err)
+	}
+}
@@ -43,6 +43,44 @@ type PushResult struct {
 
 	// DryRun indicates whether this was a dry run
 	DryRun bool
+
+	// Warnings contains non-fatal issues, such as explicitly requested
+	// stores that are not in the remote sync allowlist
+	Warnings []string
+
+	// Failures maps the IDs of stores that could not be pushed to their
+	// error messages. The remaining stores are still pushed.
+	Failures map[string]string
+
+	// PlannedGitActions lists, in order, the git operations the push ran
+	// or, in a dry run, would have run
+	PlannedGitActions []GitAction
+}
+
+// Git action kinds reported in PushResult.PlannedGitActions.
+const (
+	GitActionEnsureRepo = "ensure-repo"
+	GitActionSetRemote  = "set-remote"
+	GitActionCommit     = "commit"
+	GitActionPush       = "push"
+)
+
+// GitAction describes one git operation on the persistence repository.
+type GitAction struct {
+	// Kind is one of the GitAction* constants
+	Kind string
+
+	// Remote is the remote the action configures or pushes to
+	Remote string `json:",omitempty"`
+
+	// Branch is the persistence branch the action works on
+	Branch string `json:",omitempty"`
+
+	// Message is the commit message of a commit action
+	Message string `json:",omitempty"`
+
+	// Force is set on a push that overwrites the remote branch
+	Force bool `json:",omitempty"`
 }
 
 // PullRequest contains parameters for pulling stores and workspaces from a remote.
@@ -50,7 +88,8 @@ type PullRequest struct {
 	// RepoRoot is the root directory of the repository
 	RepoRoot string
 
-	// StoreIDs is the list of store IDs to pull
+	// StoreIDs is the list of store IDs to pull. Empty pulls every store
+	// on the fetched branch, including stores not yet persisted locally.
 	StoreIDs []string
 
 	// WorkspaceID is the ID of the workspace to pull (optional)
@@ -67,6 +106,16 @@ type PullRequest struct {
 
 	// Verify indicates whether to verify checksums after pulling
 	Verify bool
+
+	// Merge performs a per-file three-way merge against the last-synced baseline
+	// instead of overwriting local stores. Files changed on both sides are
+	// reported in PullResult.Conflicts and keep their local version.
+	Merge bool
+
+	// PathFilters maps store IDs to overlay-relative path prefixes. Only
+	// matching overlay files of those stores are restored; their meta and
+	// track files are still pulled in full. Not supported with Merge.
+	PathFilters map[string][]string
 }
 
 // PullResult contains the result of a pull operation.
@@ -85,4 +134,12 @@ type PullResult struct {
 
 	// Branch is the branch that was pulled
 	Branch string
+
+	// Conflicts lists files changed both locally and remotely, as "<store-id>/<path>".
+	// Only populated in merge mode.
+	Conflicts []string
+
+	// Warnings contains non-fatal issues, such as explicitly requested
+	// stores that are not in the remote sync allowlist
+	Warnings []string
 }
@@ -2,8 +2,11 @@ package integration
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/danieljhkim/monodev/internal/engine"
 	"github.com/danieljhkim/monodev/internal/state"
@@ -432,3 +435,343 @@ func TestApply_MultipleStores(t *testing.T) {
 		t.Errorf("expected Store=%q (store2 takes precedence), got %q", store2, ownership.Store)
 	}
 }
+
+func TestApplyAll_ActiveStoreWinsOverStack(t *testing.T) {
+	eng, fs, stateStore, storeRepo, _ := setupTestEngine(t)
+	ctx := context.Background()
+
+	overlayRoot1 := "/stores/store1/overlay"
+	overlayRoot2 := "/stores/store2/overlay"
+	storeRepo.setOverlayRoot("store1", overlayRoot1)
+	storeRepo.setOverlayRoot("store2", overlayRoot2)
+
+	// store1 (stacked) tracks Makefile and .envrc; store2 (active) tracks Makefile
+	fs.files[filepath.Join(overlayRoot1, "Makefile")] = []byte("all: build\n")
+	fs.files[filepath.Join(overlayRoot1, ".envrc")] = []byte("export A=1\n")
+	fs.dirs[overlayRoot1] = true
+	fs.files[filepath.Join(overlayRoot2, "Makefile")] = []byte("all: test\n")
+	fs.dirs[overlayRoot2] = true
+
+	track1 := stores.NewTrackFile()
+	track1.Tracked = []stores.TrackedPath{
+		{Path: "Makefile", Kind: "file"},
+		{Path: ".envrc", Kind: "file"},
+	}
+	storeRepo.setTrack("store1", track1)
+	track2 := stores.NewTrackFile()
+	track2.Tracked = []stores.TrackedPath{
+		{Path: "Makefile", Kind: "file"},
+	}
+	storeRepo.setTrack("store2", track2)
+
+	workspaceID := state.ComputeWorkspaceID("repo-fingerprint-123", "workspace")
+	setupWS := state.NewWorkspaceState("repo-fingerprint-123", "workspace", "symlink")
+	setupWS.Stack = []string{"store1"}
+	setupWS.ActiveStore = "store2"
+	_ = stateStore.SaveWorkspace(workspaceID, setupWS)
+
+	result, err := eng.ApplyAll(ctx, &engine.ApplyAllRequest{CWD: "/repo/workspace", Mode: "symlink"})
+	if err != nil {
+		t.Fatalf("ApplyAll() error = %v", err)
+	}
+
+	if len(result.Plan.Stores) != 2 || result.Plan.Stores[1] != "store2" {
+		t.Errorf("expected the active store last in the plan, got %v", result.Plan.Stores)
+	}
+
+	if target := fs.symlinks["/repo/workspace/Makefile"]; target != filepath.Join(overlayRoot2, "Makefile") {
+		t.Errorf("expected Makefile from the active store, got symlink to %q", target)
+	}
+	if target := fs.symlinks["/repo/workspace/.envrc"]; target != filepath.Join(overlayRoot1, ".envrc") {
+		t.Errorf("expected .envrc from the stacked store, got symlink to %q", target)
+	}
+
+	ws, err := stateStore.LoadWorkspace(workspaceID)
+	if err != nil {
+		t.Fatalf("failed to load workspace state: %v", err)
+	}
+	if !ws.Applied {
+		t.Error("expected workspace to be marked as applied")
+	}
+	if got := ws.Paths["Makefile"].Store; got != "store2" {
+		t.Errorf("expected Makefile owned by store2, got %q", got)
+	}
+	if got := ws.Paths[".envrc"].Store; got != "store1" {
+		t.Errorf("expected .envrc owned by store1, got %q", got)
+	}
+	if ws.GetAppliedStore("store1") == nil || ws.GetAppliedStore("store2") == nil {
+		t.Errorf("expected both stores recorded as applied, got %+v", ws.AppliedStores)
+	}
+}
+
+func TestApplyAll_ConflictAppliesNothing(t *testing.T) {
+	eng, fs, stateStore, storeRepo, _ := setupTestEngine(t)
+	ctx := context.Background()
+
+	overlayRoot1 := "/stores/store1/overlay"
+	overlayRoot2 := "/stores/store2/overlay"
+	storeRepo.setOverlayRoot("store1", overlayRoot1)
+	storeRepo.setOverlayRoot("store2", overlayRoot2)
+
+	fs.files[filepath.Join(overlayRoot1, ".envrc")] = []byte("export A=1\n")
+	fs.dirs[overlayRoot1] = true
+	fs.files[filepath.Join(overlayRoot2, "Makefile")] = []byte("all: test\n")
+	fs.dirs[overlayRoot2] = true
+
+	track1 := stores.NewTrackFile()
+	track1.Tracked = []stores.TrackedPath{{Path: ".envrc", Kind: "file"}}
+	storeRepo.setTrack("store1", track1)
+	track2 := stores.NewTrackFile()
+	track2.Tracked = []stores.TrackedPath{{Path: "Makefile", Kind: "file"}}
+	storeRepo.setTrack("store2", track2)
+
+	// An unmanaged file blocks the active store's path
+	fs.files["/repo/workspace/Makefile"] = []byte("local\n")
+
+	workspaceID := state.ComputeWorkspaceID("repo-fingerprint-123", "workspace")
+	setupWS := state.NewWorkspaceState("repo-fingerprint-123", "workspace", "symlink")
+	setupWS.Stack = []string{"store1"}
+	setupWS.ActiveStore = "store2"
+	_ = stateStore.SaveWorkspace(workspaceID, setupWS)
+
+	result, err := eng.ApplyAll(ctx, &engine.ApplyAllRequest{CWD: "/repo/workspace", Mode: "symlink"})
+	if !errors.Is(err, engine.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if len(result.Applied) != 0 {
+		t.Errorf("expected nothing applied, got %d operations", len(result.Applied))
+	}
+	if _, ok := fs.symlinks["/repo/workspace/.envrc"]; ok {
+		t.Error("stacked store path should not be applied when the active store conflicts")
+	}
+}
+
+func TestApply_CopyModeStampsMtimes(t *testing.T) {
+	eng, fs, stateStore, storeRepo, _ := setupTestEngine(t)
+	ctx := context.Background()
+
+	storeID := "test-store"
+	overlayRoot := "/stores/test-store/overlay"
+	storeRepo.setOverlayRoot(storeID, overlayRoot)
+	fs.dirs[overlayRoot] = true
+	sourceMtime := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	keptSource := filepath.Join(overlayRoot, "kept.txt")
+	fs.files[keptSource] = []byte("kept")
+	fs.fileInfo[keptSource] = &mockFileInfo{name: "kept.txt", modTime: sourceMtime}
+	fs.files[filepath.Join(overlayRoot, "fresh.txt")] = []byte("fresh")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "kept.txt", Kind: "file", PreserveMtime: true},
+		{Path: "fresh.txt", Kind: "file"},
+	}
+	storeRepo.setTrack(storeID, track)
+
+	ws := state.NewWorkspaceState("repo-fingerprint-123", "workspace", "copy")
+	ws.ActiveStore = storeID
+	_ = stateStore.SaveWorkspace(state.ComputeWorkspaceID("repo-fingerprint-123", "workspace"), ws)
+
+	if _, err := eng.Apply(ctx, &engine.ApplyRequest{CWD: "/repo/workspace", Mode: "copy"}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if got := fs.chtimes["/repo/workspace/kept.txt"]; !got.Equal(sourceMtime) {
+		t.Errorf("kept.txt mtime = %v, want source mtime %v", got, sourceMtime)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got := fs.chtimes["/repo/workspace/fresh.txt"]; !got.Equal(now) {
+		t.Errorf("fresh.txt mtime = %v, want current time %v", got, now)
+	}
+}
+
+// setupStagedStore tracks a.txt and b.txt in a store that is the
+// workspace's active store.
+func setupStagedStore(t *testing.T) (*engine.Engine, *testFS, *testStateStore, string) {
+	t.Helper()
+	eng, fs, stateStore, storeRepo, _ := setupTestEngine(t)
+
+	storeID := "test-store"
+	overlayRoot := "/stores/test-store/overlay"
+	storeRepo.setOverlayRoot(storeID, overlayRoot)
+	fs.dirs[overlayRoot] = true
+	fs.files[filepath.Join(overlayRoot, "a.txt")] = []byte("a")
+	fs.files[filepath.Join(overlayRoot, "b.txt")] = []byte("b")
+
+	track := stores.NewTrackFile()
+	track.Tracked = []stores.TrackedPath{
+		{Path: "a.txt", Kind: "file"},
+		{Path: "b.txt", Kind: "file"},
+	}
+	storeRepo.setTrack(storeID, track)
+
+	ws := state.NewWorkspaceState("repo-fingerprint-123", "workspace", "copy")
+	ws.ActiveStore = storeID
+	_ = stateStore.SaveWorkspace(state.ComputeWorkspaceID("repo-fingerprint-123", "workspace"), ws)
+	return eng, fs, stateStore, overlayRoot
+}
+
+func TestApply_StagedInstall(t *testing.T) {
+	for _, mode := range []string{"copy", "symlink"} {
+		t.Run(mode, func(t *testing.T) {
+			eng, fs, stateStore, overlayRoot := setupStagedStore(t)
+
+			result, err := eng.Apply(context.Background(), &engine.ApplyRequest{CWD: "/repo/workspace", Mode: mode, StagedInstall: true})
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+
+			for _, name := range []string{"a.txt", "b.txt"} {
+				dest := filepath.Join("/repo/workspace", name)
+				if mode == "copy" {
+					if string(fs.files[dest]) != name[:1] {
+						t.Errorf("%s = %q, want copied content", name, fs.files[dest])
+					}
+				} else if fs.symlinks[dest] != filepath.Join(overlayRoot, name) {
+					t.Errorf("%s links to %q, want the overlay", name, fs.symlinks[dest])
+				}
+			}
+			if exists, _ := fs.Exists("/repo/workspace/.monodev-staging"); exists {
+				t.Error("staging directory should be removed after install")
+			}
+
+			ws, err := stateStore.LoadWorkspace(result.WorkspaceID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ws.Applied || len(ws.Paths) != 2 {
+				t.Errorf("expected 2 applied paths recorded, got applied=%v paths=%v", ws.Applied, ws.Paths)
+			}
+		})
+	}
+}
+
+func TestApply_StagedInstallFailureLeavesWorkspaceUnchanged(t *testing.T) {
+	eng, fs, stateStore, overlayRoot := setupStagedStore(t)
+	fs.files["/repo/workspace/keep.txt"] = []byte("user file")
+	fs.copyErrs[filepath.Join(overlayRoot, "b.txt")] = errors.New("disk full")
+
+	_, err := eng.Apply(context.Background(), &engine.ApplyRequest{CWD: "/repo/workspace", Mode: "copy", StagedInstall: true})
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("Apply() error = %v, want staging failure", err)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if exists, _ := fs.Exists(filepath.Join("/repo/workspace", name)); exists {
+			t.Errorf("%s should not be installed after a failed staged apply", name)
+		}
+	}
+	if string(fs.files["/repo/workspace/keep.txt"]) != "user file" {
+		t.Error("existing workspace files must be untouched")
+	}
+	if exists, _ := fs.Exists("/repo/workspace/.monodev-staging"); exists {
+		t.Error("staging directory should be rolled back")
+	}
+	ws, err := stateStore.LoadWorkspace(state.ComputeWorkspaceID("repo-fingerprint-123", "workspace"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Applied || len(ws.Paths) != 0 {
+		t.Errorf("workspace state should be unchanged, got applied=%v paths=%v", ws.Applied, ws.Paths)
+	}
+
+	// Without staging the same failure leaves a.txt behind
+	if _, err := eng.Apply(context.Background(), &engine.ApplyRequest{CWD: "/repo/workspace", Mode: "copy"}); err == nil {
+		t.Fatal("expected unstaged apply to fail too")
+	}
+	if exists, _ := fs.Exists("/repo/workspace/a.txt"); !exists {
+		t.Error("expected unstaged apply to have copied a.txt before failing")
+	}
+}
+
+func TestApply_ResumeCompletesInterruptedApply(t *testing.T) {
+	for _, mode := range []string{"copy", "symlink"} {
+		t.Run(mode, func(t *testing.T) {
+			eng, fs, stateStore, overlayRoot := setupStagedStore(t)
+
+			// An earlier apply placed a.txt, then stopped before b.txt and
+			// before recording any state
+			placed := "/repo/workspace/a.txt"
+			if mode == "copy" {
+				_ = fs.Copy(filepath.Join(overlayRoot, "a.txt"), placed)
+			} else {
+				_ = fs.Symlink(filepath.Join(overlayRoot, "a.txt"), placed)
+			}
+
+			// A plain apply sees the placed file as unmanaged
+			if _, err := eng.Apply(context.Background(), &engine.ApplyRequest{CWD: "/repo/workspace", Mode: mode}); !errors.Is(err, engine.ErrConflict) {
+				t.Fatalf("Apply() error = %v, want conflict on the half-applied path", err)
+			}
+
+			result, err := eng.Apply(context.Background(), &engine.ApplyRequest{CWD: "/repo/workspace", Mode: mode, Resume: true})
+			if err != nil {
+				t.Fatalf("Apply(Resume) error = %v", err)
+			}
+
+			if len(result.Resumed) != 1 || result.Resumed[0] != "a.txt" {
+				t.Errorf("Resumed = %v, want [a.txt]", result.Resumed)
+			}
+			if len(result.Applied) != 1 || result.Applied[0].RelPath != "b.txt" {
+				t.Errorf("Applied = %+v, want only the b.txt operation", result.Applied)
+			}
+			if exists, _ := fs.Exists("/repo/workspace/b.txt"); !exists {
+				t.Error("b.txt should be installed by the resumed apply")
+			}
+
+			ws, err := stateStore.LoadWorkspace(result.WorkspaceID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"a.txt", "b.txt"} {
+				if owner, ok := ws.Paths[name]; !ok || owner.Store != "test-store" || owner.Type != mode {
+					t.Errorf("%s ownership = %+v (recorded %v), want test-store/%s", name, owner, ok, mode)
+				}
+			}
+		})
+	}
+}
+
+func TestApply_ResumeLeavesPreexistingAndOwnedPaths(t *testing.T) {
+	t.Run("matching file after a missing path is not adopted", func(t *testing.T) {
+		eng, fs, stateStore, _ := setupStagedStore(t)
+		// b.txt matches the store but a.txt, planned first, was never
+		// placed, so the interrupted apply cannot have written b.txt
+		fs.files["/repo/workspace/b.txt"] = []byte("b")
+
+		result, err := eng.Apply(context.Background(), &engine.ApplyRequest{CWD: "/repo/workspace", Mode: "copy", Resume: true})
+		if !errors.Is(err, engine.ErrConflict) {
+			t.Fatalf("Apply(Resume) error = %v, want conflict on the user's b.txt", err)
+		}
+		if result != nil && len(result.Resumed) != 0 {
+			t.Errorf("Resumed = %v, want none", result.Resumed)
+		}
+		ws, err := stateStore.LoadWorkspace(state.ComputeWorkspaceID("repo-fingerprint-123", "workspace"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := ws.Paths["b.txt"]; ok {
+			t.Error("a pre-existing file must not be adopted")
+		}
+	})
+
+	t.Run("path owned by another store is not reassigned", func(t *testing.T) {
+		eng, fs, stateStore, _ := setupStagedStore(t)
+		workspaceID := state.ComputeWorkspaceID("repo-fingerprint-123", "workspace")
+		ws, err := stateStore.LoadWorkspace(workspaceID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ws.Paths["a.txt"] = state.PathOwnership{Store: "other-store", Type: "copy"}
+		_ = stateStore.SaveWorkspace(workspaceID, ws)
+		fs.files["/repo/workspace/a.txt"] = []byte("a")
+
+		result, _ := eng.Apply(context.Background(), &engine.ApplyRequest{CWD: "/repo/workspace", Mode: "copy", Resume: true})
+		if result == nil {
+			return
+		}
+		for _, relPath := range result.Resumed {
+			if relPath == "a.txt" {
+				t.Error("a.txt is owned by another store and must not be adopted")
+			}
+		}
+	})
+}
@@ -2,8 +2,10 @@ package integration
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -23,6 +25,12 @@ type testFS struct {
 	dirs     map[string]bool
 	symlinks map[string]string
 	fileInfo map[string]os.FileInfo
+
+	// chtimes records the mtime set by each Chtimes call, by path
+	chtimes map[string]time.Time
+
+	// copyErrs makes Copy fail for the given source paths
+	copyErrs map[string]error
 }
 
 func newTestFS() *testFS {
@@ -31,6 +39,8 @@ func newTestFS() *testFS {
 		dirs:     make(map[string]bool),
 		symlinks: make(map[string]string),
 		fileInfo: make(map[string]os.FileInfo),
+		chtimes:  make(map[string]time.Time),
+		copyErrs: make(map[string]error),
 	}
 }
 
@@ -46,7 +56,7 @@ func (fs *testFS) Lstat(path string) (os.FileInfo, error) {
 		return info, nil
 	}
 	if _, ok := fs.symlinks[path]; ok {
-		return &mockFileInfo{name: filepath.Base(path), isDir: false}, nil
+		return &mockFileInfo{name: filepath.Base(path), isDir: false, mode: os.ModeSymlink}, nil
 	}
 	if _, ok := fs.dirs[path]; ok {
 		return &mockFileInfo{name: filepath.Base(path), isDir: true}, nil
@@ -112,11 +122,14 @@ func (fs *testFS) RemoveAll(path string) error {
 
 func (fs *testFS) Symlink(oldname, newname string) error {
 	fs.symlinks[newname] = oldname
-	fs.fileInfo[newname] = &mockFileInfo{name: filepath.Base(newname), isDir: false}
+	fs.fileInfo[newname] = &mockFileInfo{name: filepath.Base(newname), isDir: false, mode: os.ModeSymlink}
 	return nil
 }
 
 func (fs *testFS) Copy(src, dst string) error {
+	if err, ok := fs.copyErrs[src]; ok {
+		return err
+	}
 	// Copy file content
 	if content, ok := fs.files[src]; ok {
 		fs.files[dst] = append([]byte(nil), content...)
@@ -181,18 +194,158 @@ func (fs *testFS) ValidateIdentifier(id string) error {
 	return nil
 }
 
+func (fs *testFS) Rename(oldpath, newpath string) error {
+	exists, _ := fs.Exists(oldpath)
+	if !exists {
+		return os.ErrNotExist
+	}
+	prefix := oldpath + string(filepath.Separator)
+	move := func(p string) (string, bool) {
+		if p == oldpath {
+			return newpath, true
+		}
+		if strings.HasPrefix(p, prefix) {
+			return filepath.Join(newpath, strings.TrimPrefix(p, prefix)), true
+		}
+		return "", false
+	}
+	for p, v := range fs.files {
+		if np, ok := move(p); ok {
+			delete(fs.files, p)
+			fs.files[np] = v
+		}
+	}
+	for p, v := range fs.dirs {
+		if np, ok := move(p); ok {
+			delete(fs.dirs, p)
+			fs.dirs[np] = v
+		}
+	}
+	for p, v := range fs.symlinks {
+		if np, ok := move(p); ok {
+			delete(fs.symlinks, p)
+			fs.symlinks[np] = v
+		}
+	}
+	for p, v := range fs.fileInfo {
+		if np, ok := move(p); ok {
+			delete(fs.fileInfo, p)
+			fs.fileInfo[np] = v
+		}
+	}
+	return nil
+}
+
+func (fs *testFS) Chtimes(path string, atime, mtime time.Time) error {
+	fs.chtimes[path] = mtime
+	return nil
+}
+
+func (fs *testFS) ReadDir(path string) ([]os.DirEntry, error) {
+	if !fs.dirs[path] {
+		return nil, os.ErrNotExist
+	}
+	// Collect the immediate children of path in lexical order
+	prefix := path + string(filepath.Separator)
+	seen := make(map[string]bool)
+	var children []string
+	for _, m := range []map[string]bool{fs.dirs, keysOf(fs.files), keysOf(fs.symlinks)} {
+		for p := range m {
+			rest, ok := strings.CutPrefix(p, prefix)
+			if ok && rest != "" && !strings.Contains(rest, string(filepath.Separator)) && !seen[p] {
+				seen[p] = true
+				children = append(children, p)
+			}
+		}
+	}
+	sort.Strings(children)
+
+	entries := make([]os.DirEntry, 0, len(children))
+	for _, p := range children {
+		info, err := fs.Lstat(p)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, dirEntryFromInfo(info))
+	}
+	return entries, nil
+}
+
+func (fs *testFS) WalkDir(root string, fn fs.WalkDirFunc) error {
+	// Collect all known paths under root in lexical order
+	prefix := root + string(filepath.Separator)
+	seen := make(map[string]bool)
+	var paths []string
+	for _, m := range []map[string]bool{fs.dirs, keysOf(fs.files), keysOf(fs.symlinks)} {
+		for p := range m {
+			if (p == root || strings.HasPrefix(p, prefix)) && !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	var skipped []string
+	for _, p := range paths {
+		if hasAnyPrefix(p, skipped) {
+			continue
+		}
+		info, err := fs.Lstat(p)
+		if err != nil {
+			return err
+		}
+		if err := fn(p, dirEntryFromInfo(info), nil); err != nil {
+			if err == filepath.SkipDir && info.IsDir() {
+				skipped = append(skipped, p+string(filepath.Separator))
+				continue
+			}
+			if err == filepath.SkipDir || err == filepath.SkipAll {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// hasAnyPrefix reports whether p starts with any of the given prefixes.
+func hasAnyPrefix(p string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// keysOf returns the key set of a map.
+func keysOf[V any](m map[string]V) map[string]bool {
+	keys := make(map[string]bool, len(m))
+	for k := range m {
+		keys[k] = true
+	}
+	return keys
+}
+
+// dirEntryFromInfo adapts an os.FileInfo for WalkDir callbacks.
+func dirEntryFromInfo(info os.FileInfo) fs.DirEntry {
+	return fs.FileInfoToDirEntry(info)
+}
+
 // mockFileInfo implements os.FileInfo
 type mockFileInfo struct {
-	name  string
-	size  int64
-	mode  os.FileMode
-	isDir bool
+	name    string
+	size    int64
+	mode    os.FileMode
+	isDir   bool
+	modTime time.Time
 }
 
 func (m *mockFileInfo) Name() string       { return m.name }
 func (m *mockFileInfo) Size() int64        { return m.size }
 func (m *mockFileInfo) Mode() os.FileMode  { return m.mode }
-func (m *mockFileInfo) ModTime() time.Time { return time.Time{} }
+func (m *mockFileInfo) ModTime() time.Time { return m.modTime }
 func (m *mockFileInfo) IsDir() bool        { return m.isDir }
 func (m *mockFileInfo) Sys() interface{}   { return nil }
 
@@ -233,11 +386,25 @@ func (s *testStateStore) SaveWorkspace(id string, ws *state.WorkspaceState) erro
 	return nil
 }
 
+// CheckWorkspace never conflicts since saves store a copy.
+func (s *testStateStore) CheckWorkspace(id string, ws *state.WorkspaceState) error {
+	return nil
+}
+
 func (s *testStateStore) DeleteWorkspace(id string) error {
 	delete(s.workspaces, id)
 	return nil
 }
 
+// LoadWorkspaceIfChanged always reloads since in-memory state has no mtime.
+func (s *testStateStore) LoadWorkspaceIfChanged(id string, since time.Time) (*state.WorkspaceState, bool, error) {
+	ws, err := s.LoadWorkspace(id)
+	if err != nil {
+		return nil, false, err
+	}
+	return ws, true, nil
+}
+
 // testStoreRepo is a mock store repository for testing
 type testStoreRepo struct {
 	tracks       map[string]*stores.TrackFile
@@ -278,9 +445,14 @@ func (r *testStoreRepo) Exists(id string) (bool, error)                     { re
 func (r *testStoreRepo) Create(id string, meta *stores.StoreMeta) error     { return nil }
 func (r *testStoreRepo) LoadMeta(id string) (*stores.StoreMeta, error)      { return nil, nil }
 func (r *testStoreRepo) SaveMeta(id string, meta *stores.StoreMeta) error   { return nil }
+func (r *testStoreRepo) MarkUsed(id string, at time.Time) error             { return nil }
 func (r *testStoreRepo) SaveTrack(id string, track *stores.TrackFile) error { return nil }
 func (r *testStoreRepo) Delete(id string) error                             { return nil }
 
+func (r *testStoreRepo) LoadMetas(ids []string) (map[string]*stores.StoreMeta, error) {
+	return stores.LoadMetasSequential(r, ids)
+}
+
 func setupTestEngine(t *testing.T) (*engine.Engine, *testFS, *testStateStore, *testStoreRepo, *hash.FakeHasher) {
 	fs := newTestFS()
 	stateStore := newTestStateStore()